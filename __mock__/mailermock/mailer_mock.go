@@ -0,0 +1,16 @@
+package mailermock
+
+import (
+	"context"
+	"final-project-enigma-clean/util/mailer"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type MailerMock struct {
+	mock.Mock
+}
+
+func (m *MailerMock) Send(ctx context.Context, msg mailer.Message) error {
+	return m.Called(ctx, msg).Error(0)
+}
@@ -11,13 +11,8 @@ type AssetRepoMock struct {
 	mock.Mock
 }
 
-// UpdateAvailable implements repository.AssetRepository.
-func (a *AssetRepoMock) UpdateAvailable(id string, amount int) error {
-	return a.Called(id, amount).Error(0)
-}
-
 // Paging implements repository.AssetRepository.
-func (a *AssetRepoMock) Paging(payload dto.PageRequest) ([]model.Asset, dto.Paging, error) {
+func (a *AssetRepoMock) Paging(payload dto.AssetQuery) ([]model.Asset, dto.Paging, error) {
 	args := a.Called(payload)
 	if args.Get(2) != nil {
 		return nil, dto.Paging{}, args.Error(2)
@@ -37,8 +32,8 @@ func (a *AssetRepoMock) FindByName(name string) ([]model.Asset, error) {
 }
 
 // Delete implements AssetRepoMock.
-func (a *AssetRepoMock) Delete(id string) error {
-	return a.Called(id).Error(0)
+func (a *AssetRepoMock) Delete(id string, audit model.AuditLog) error {
+	return a.Called(id, audit).Error(0)
 }
 
 // FindAll implements AssetRepoMock.
@@ -60,11 +55,30 @@ func (a *AssetRepoMock) FindById(id string) (model.Asset, error) {
 }
 
 // Save implements AssetRepoMock.
-func (a *AssetRepoMock) Save(asset model.AssetRequest) error {
-	return a.Called(asset).Error(0)
+func (a *AssetRepoMock) Save(asset model.AssetRequest, audit model.AuditLog) error {
+	return a.Called(asset, audit).Error(0)
 }
 
 // Update implements AssetRepoMock.
-func (a *AssetRepoMock) Update(asset model.AssetRequest) error {
-	return a.Called(asset).Error(0)
+func (a *AssetRepoMock) Update(asset model.AssetRequest, audit model.AuditLog) error {
+	return a.Called(asset, audit).Error(0)
+}
+
+func (a *AssetRepoMock) Restore(id string, audit model.AuditLog) error {
+	return a.Called(id, audit).Error(0)
+}
+
+// PagingCursor implements repository.AssetRepository.
+func (a *AssetRepoMock) PagingCursor(query dto.AssetQuery, payload dto.CursorRequest) ([]model.Asset, dto.CursorPaging, error) {
+	args := a.Called(query, payload)
+	if args.Get(2) != nil {
+		return nil, dto.CursorPaging{}, args.Error(2)
+	}
+
+	return args.Get(0).([]model.Asset), args.Get(1).(dto.CursorPaging), nil
+}
+
+// SaveAll implements repository.AssetRepository.
+func (a *AssetRepoMock) SaveAll(assets []model.AssetRequest, audits []model.AuditLog) error {
+	return a.Called(assets, audits).Error(0)
 }
@@ -0,0 +1,39 @@
+package repomock
+
+import (
+	"final-project-enigma-clean/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AssetUnitRepoMock struct {
+	mock.Mock
+}
+
+func (a *AssetUnitRepoMock) Save(unit model.AssetUnit) error {
+	return a.Called(unit).Error(0)
+}
+
+func (a *AssetUnitRepoMock) Update(unit model.AssetUnit) error {
+	return a.Called(unit).Error(0)
+}
+
+func (a *AssetUnitRepoMock) FindById(id string) (model.AssetUnit, error) {
+	args := a.Called(id)
+	return args.Get(0).(model.AssetUnit), args.Error(1)
+}
+
+func (a *AssetUnitRepoMock) FindBySerial(serial string) (model.AssetUnit, error) {
+	args := a.Called(serial)
+	return args.Get(0).(model.AssetUnit), args.Error(1)
+}
+
+func (a *AssetUnitRepoMock) FindByAsset(assetId string) ([]model.AssetUnit, error) {
+	args := a.Called(assetId)
+	return args.Get(0).([]model.AssetUnit), args.Error(1)
+}
+
+func (a *AssetUnitRepoMock) FindByTransaction(idManageAsset string) ([]model.AssetUnit, error) {
+	args := a.Called(idManageAsset)
+	return args.Get(0).([]model.AssetUnit), args.Error(1)
+}
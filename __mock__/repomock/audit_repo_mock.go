@@ -0,0 +1,17 @@
+package repomock
+
+import (
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditRepoMock struct {
+	mock.Mock
+}
+
+func (a *AuditRepoMock) FindAll(payload dto.AuditRequest) ([]model.AuditLog, dto.Paging, error) {
+	args := a.Called(payload)
+	return args.Get(0).([]model.AuditLog), args.Get(1).(dto.Paging), args.Error(2)
+}
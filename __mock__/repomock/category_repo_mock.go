@@ -20,8 +20,8 @@ func (c *CategoryRepoMock) FindById(id string) (model.Category, error) {
 }
 
 // Delete implements categoryRepository.
-func (c *CategoryRepoMock) Delete(id string) error {
-	return c.Called(id).Error(0)
+func (c *CategoryRepoMock) Delete(id string, audit model.AuditLog) error {
+	return c.Called(id, audit).Error(0)
 }
 
 // FindAll implements categoryRepository.
@@ -34,11 +34,20 @@ func (c *CategoryRepoMock) FindAll() ([]model.Category, error) {
 }
 
 // Save implements categoryRepository.
-func (c *CategoryRepoMock) Save(category model.Category) error {
-	return c.Called(category).Error(0)
+func (c *CategoryRepoMock) Save(category model.Category, audit model.AuditLog) error {
+	return c.Called(category, audit).Error(0)
 }
 
 // Update implements categoryRepository.
-func (c *CategoryRepoMock) Update(category model.Category) error {
-	return c.Called(category).Error(0)
+func (c *CategoryRepoMock) Update(category model.Category, audit model.AuditLog) error {
+	return c.Called(category, audit).Error(0)
+}
+
+func (c *CategoryRepoMock) Restore(id string, audit model.AuditLog) error {
+	return c.Called(id, audit).Error(0)
+}
+
+func (c *CategoryRepoMock) FindAllWithDeleted() ([]model.Category, error) {
+	args := c.Called()
+	return args.Get(0).([]model.Category), args.Error(1)
 }
@@ -0,0 +1,43 @@
+package repomock
+
+import (
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type EmailOutboxRepoMock struct {
+	mock.Mock
+}
+
+func (e *EmailOutboxRepoMock) Enqueue(email model.EmailOutbox) error {
+	return e.Called(email).Error(0)
+}
+
+func (e *EmailOutboxRepoMock) ClaimDue(limit int, lease time.Duration) ([]model.EmailOutbox, error) {
+	args := e.Called(limit, lease)
+	return args.Get(0).([]model.EmailOutbox), args.Error(1)
+}
+
+func (e *EmailOutboxRepoMock) MarkSent(id string) error {
+	return e.Called(id).Error(0)
+}
+
+func (e *EmailOutboxRepoMock) MarkRetry(id string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	return e.Called(id, attempts, nextAttemptAt, lastError).Error(0)
+}
+
+func (e *EmailOutboxRepoMock) MarkFailed(id string, attempts int, lastError string) error {
+	return e.Called(id, attempts, lastError).Error(0)
+}
+
+func (e *EmailOutboxRepoMock) FindFailed(payload dto.PageRequest) ([]model.EmailOutbox, dto.Paging, error) {
+	args := e.Called(payload)
+	return args.Get(0).([]model.EmailOutbox), args.Get(1).(dto.Paging), args.Error(2)
+}
+
+func (e *EmailOutboxRepoMock) Requeue(id string) error {
+	return e.Called(id).Error(0)
+}
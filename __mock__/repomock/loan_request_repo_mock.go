@@ -0,0 +1,61 @@
+package repomock
+
+import (
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LoanRequestRepoMock struct {
+	mock.Mock
+}
+
+func (l *LoanRequestRepoMock) Save(request model.LoanRequest, audit model.AuditLog) error {
+	return l.Called(request, audit).Error(0)
+}
+
+func (l *LoanRequestRepoMock) Update(request model.LoanRequest, audit model.AuditLog) error {
+	return l.Called(request, audit).Error(0)
+}
+
+func (l *LoanRequestRepoMock) FindById(id string) (model.LoanRequest, error) {
+	args := l.Called(id)
+	return args.Get(0).(model.LoanRequest), args.Error(1)
+}
+
+func (l *LoanRequestRepoMock) FindAll(query dto.LoanRequestQuery) ([]model.LoanRequest, error) {
+	args := l.Called(query)
+	return args.Get(0).([]model.LoanRequest), args.Error(1)
+}
+
+func (l *LoanRequestRepoMock) FindApprovers(roles []string) ([]model.UserCredentials, error) {
+	args := l.Called(roles)
+	return args.Get(0).([]model.UserCredentials), args.Error(1)
+}
+
+func (l *LoanRequestRepoMock) Submit(id string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	return l.Called(id, now, emails, audit).Error(0)
+}
+
+func (l *LoanRequestRepoMock) Approve(id, approverId, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	return l.Called(id, approverId, comment, now, emails, audit).Error(0)
+}
+
+func (l *LoanRequestRepoMock) Reject(id, approverId, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	return l.Called(id, approverId, comment, now, emails, audit).Error(0)
+}
+
+func (l *LoanRequestRepoMock) Issue(id, issuerId string, loan dto.ManageAssetRequest, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	return l.Called(id, issuerId, loan, now, emails, audit).Error(0)
+}
+
+func (l *LoanRequestRepoMock) MarkReturned(id string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) (bool, error) {
+	args := l.Called(id, now, emails, audit)
+	return args.Bool(0), args.Error(1)
+}
+
+func (l *LoanRequestRepoMock) Close(id, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	return l.Called(id, comment, now, emails, audit).Error(0)
+}
@@ -3,6 +3,7 @@ package repomock
 import (
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -38,6 +39,47 @@ func (m *ManageAssetRepoMock) FindAllTransaction() ([]model.ManageAsset, error)
 }
 
 // CreateTransaksi implements ManageAssetRepository.
-func (m *ManageAssetRepoMock) CreateTransaction(payload dto.ManageAssetRequest) error {
-	return m.Called(payload).Error(0)
+func (m *ManageAssetRepoMock) CreateTransaction(payload dto.ManageAssetRequest, audit model.AuditLog) error {
+	return m.Called(payload, audit).Error(0)
+}
+
+// ReturnTransaction implements ManageAssetRepository.
+func (m *ManageAssetRepoMock) ReturnTransaction(payload dto.ReturnAssetRequest, audit model.AuditLog) error {
+	return m.Called(payload, audit).Error(0)
+}
+
+func (m *ManageAssetRepoMock) FindOverdue(now time.Time) ([]model.OverdueTransaction, []model.ManageDetailAsset, error) {
+	args := m.Called(now)
+	if args.Get(2) != nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).([]model.OverdueTransaction), args.Get(1).([]model.ManageDetailAsset), nil
+}
+
+func (m *ManageAssetRepoMock) MarkOverdue(id string, now, remindedBefore time.Time, emails []model.EmailOutbox) (bool, error) {
+	args := m.Called(id, now, remindedBefore, emails)
+	return args.Bool(0), args.Error(1)
+}
+
+// ExportDetails implements repository.ManageAssetRepository.
+// the rows given to Return are handed to each in order
+func (m *ManageAssetRepoMock) ExportDetails(filter dto.TransactionExportRequest, each func(model.TransactionDetailRow) error) error {
+	args := m.Called(filter, each)
+	if rows, ok := args.Get(0).([]model.TransactionDetailRow); ok {
+		for _, row := range rows {
+			if err := each(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// FindOpenDetails implements repository.ManageAssetRepository.
+func (m *ManageAssetRepoMock) FindOpenDetails(filter dto.OpenDetailRequest) ([]model.ManageDetailAsset, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ManageDetailAsset), args.Error(1)
 }
@@ -0,0 +1,19 @@
+package repomock
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type OTPStoreMock struct {
+	mock.Mock
+}
+
+func (o *OTPStoreMock) Save(purpose, email string, code int, expiresAt time.Time) error {
+	return o.Called(purpose, email, code, expiresAt).Error(0)
+}
+
+func (o *OTPStoreMock) Verify(purpose, email string, code int) error {
+	return o.Called(purpose, email, code).Error(0)
+}
@@ -12,8 +12,8 @@ type StaffRepoMock struct {
 }
 
 // Save implements repository.StaffRepository.
-func (s *StaffRepoMock) Save(payload model.Staff) error {
-	return s.Called(payload).Error(0)
+func (s *StaffRepoMock) Save(payload model.Staff, audit model.AuditLog) error {
+	return s.Called(payload, audit).Error(0)
 }
 
 // FindByName implements repository.StaffRepository.
@@ -47,8 +47,8 @@ func (s *StaffRepoMock) FindById(id string) (model.Staff, error) {
 }
 
 // Delete implements StaffRepository.
-func (s *StaffRepoMock) Delete(id string) error {
-	return s.Called(id).Error(0)
+func (s *StaffRepoMock) Delete(id string, audit model.AuditLog) error {
+	return s.Called(id, audit).Error(0)
 }
 
 // FindAll implements StaffRepository.
@@ -63,6 +63,35 @@ func (s *StaffRepoMock) FindByAll() ([]model.Staff, error) {
 // Save implements StaffRepository.
 
 // Update implements StaffRepository.
-func (s *StaffRepoMock) Update(payload model.Staff) error {
-	return s.Called(payload).Error(0)
+func (s *StaffRepoMock) Update(payload model.Staff, audit model.AuditLog) error {
+	return s.Called(payload, audit).Error(0)
+}
+
+func (s *StaffRepoMock) Restore(id string, audit model.AuditLog) error {
+	return s.Called(id, audit).Error(0)
+}
+
+// PagingCursor implements repository.StaffRepository.
+func (s *StaffRepoMock) PagingCursor(payload dto.CursorRequest) ([]model.Staff, dto.CursorPaging, error) {
+	args := s.Called(payload)
+	if args.Get(2) != nil {
+		return nil, dto.CursorPaging{}, args.Error(2)
+	}
+
+	return args.Get(0).([]model.Staff), args.Get(1).(dto.CursorPaging), nil
+}
+
+// FindByNiks implements repository.StaffRepository.
+func (s *StaffRepoMock) FindByNiks(niks []string) ([]model.Staff, error) {
+	args := s.Called(niks)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]model.Staff), nil
+}
+
+// Upsert implements repository.StaffRepository.
+func (s *StaffRepoMock) Upsert(staffs []model.Staff, audits []model.AuditLog) error {
+	return s.Called(staffs, audits).Error(0)
 }
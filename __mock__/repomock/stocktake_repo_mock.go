@@ -0,0 +1,44 @@
+package repomock
+
+import (
+	"final-project-enigma-clean/model"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type StocktakeRepoMock struct {
+	mock.Mock
+}
+
+func (s *StocktakeRepoMock) Save(stocktake model.Stocktake, audit model.AuditLog) error {
+	return s.Called(stocktake, audit).Error(0)
+}
+
+func (s *StocktakeRepoMock) FindById(id string) (model.Stocktake, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.Stocktake), args.Error(1)
+}
+
+func (s *StocktakeRepoMock) FindAll(status string) ([]model.Stocktake, error) {
+	args := s.Called(status)
+	return args.Get(0).([]model.Stocktake), args.Error(1)
+}
+
+func (s *StocktakeRepoMock) SaveCount(count model.StocktakeCount) error {
+	return s.Called(count).Error(0)
+}
+
+func (s *StocktakeRepoMock) FindCounts(id string) ([]model.StocktakeCount, error) {
+	args := s.Called(id)
+	return args.Get(0).([]model.StocktakeCount), args.Error(1)
+}
+
+func (s *StocktakeRepoMock) Lines(stocktake model.Stocktake) ([]model.StocktakeLine, error) {
+	args := s.Called(stocktake)
+	return args.Get(0).([]model.StocktakeLine), args.Error(1)
+}
+
+func (s *StocktakeRepoMock) Close(id, closedBy string, closedAt time.Time, audit model.AuditLog, adjustments []model.StocktakeAdjustment) error {
+	return s.Called(id, closedBy, closedAt, audit, adjustments).Error(0)
+}
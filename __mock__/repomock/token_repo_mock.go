@@ -0,0 +1,33 @@
+package repomock
+
+import (
+	"final-project-enigma-clean/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type TokenRepoMock struct {
+	mock.Mock
+}
+
+func (t *TokenRepoMock) Save(token model.RefreshToken) error {
+	return t.Called(token).Error(0)
+}
+
+func (t *TokenRepoMock) Rotate(oldHash string, next model.RefreshToken) (model.RefreshToken, error) {
+	args := t.Called(oldHash, next)
+	return args.Get(0).(model.RefreshToken), args.Error(1)
+}
+
+func (t *TokenRepoMock) RevokeByHash(tokenHash string) error {
+	return t.Called(tokenHash).Error(0)
+}
+
+func (t *TokenRepoMock) RevokeAllForUser(userID string) error {
+	return t.Called(userID).Error(0)
+}
+
+func (t *TokenRepoMock) IsSessionActive(userID, familyID string) (bool, error) {
+	args := t.Called(userID, familyID)
+	return args.Bool(0), args.Error(1)
+}
@@ -42,8 +42,8 @@ func (t *TypeAssetRepoMock) FindById(id string) (model.TypeAsset, error) {
 }
 
 // Delete implements categoryRepository.
-func (t *TypeAssetRepoMock) Delete(id string) error {
-	return t.Called(id).Error(0)
+func (t *TypeAssetRepoMock) Delete(id string, audit model.AuditLog) error {
+	return t.Called(id, audit).Error(0)
 }
 
 // FindAll implements categoryRepository.
@@ -56,11 +56,25 @@ func (t *TypeAssetRepoMock) FindAll() ([]model.TypeAsset, error) {
 }
 
 // Save implements categoryRepository.
-func (t *TypeAssetRepoMock) Save(typeAsset model.TypeAsset) error {
-	return t.Called(typeAsset).Error(0)
+func (t *TypeAssetRepoMock) Save(typeAsset model.TypeAsset, audit model.AuditLog) error {
+	return t.Called(typeAsset, audit).Error(0)
 }
 
 // Update implements categoryRepository.
-func (t *TypeAssetRepoMock) Update(payload model.TypeAsset) error {
-	return t.Called(payload).Error(0)
+func (t *TypeAssetRepoMock) Update(payload model.TypeAsset, audit model.AuditLog) error {
+	return t.Called(payload, audit).Error(0)
+}
+
+func (t *TypeAssetRepoMock) Restore(id string, audit model.AuditLog) error {
+	return t.Called(id, audit).Error(0)
+}
+
+// PagingCursor implements repository.TypeAssetRepository.
+func (t *TypeAssetRepoMock) PagingCursor(payload dto.CursorRequest) ([]model.TypeAsset, dto.CursorPaging, error) {
+	args := t.Called(payload)
+	if args.Get(2) != nil {
+		return nil, dto.CursorPaging{}, args.Error(2)
+	}
+
+	return args.Get(0).([]model.TypeAsset), args.Get(1).(dto.CursorPaging), nil
 }
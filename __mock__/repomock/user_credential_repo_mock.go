@@ -21,8 +21,7 @@ func (m *MockUserCredentialsRepository) FindUserEmailPass(email string) (userPas
 }
 
 func (m *MockUserCredentialsRepository) ChangePassword(email, newpass string) error {
-	//TODO implement me
-	panic("implement me")
+	return m.Called(email, newpass).Error(0)
 }
 
 func (m *MockUserCredentialsRepository) GetUserPassword(email string) (string, error) {
@@ -45,7 +44,29 @@ func (m *MockUserCredentialsRepository) FindUserEmail(email string) (user model.
 	return args.Get(0).(model.UserLoginRequest), args.Error(1)
 }
 
-func (m *MockUserCredentialsRepository) UserRegister(user model.UserRegisterRequest) error {
-	args := m.Called(user)
+func (m *MockUserCredentialsRepository) UserRegister(user model.UserRegisterRequest, welcome model.EmailOutbox) error {
+	args := m.Called(user, welcome)
 	return args.Error(0)
 }
+
+func (m *MockUserCredentialsRepository) FindUserByEmail(email string) (model.UserCredentials, error) {
+	args := m.Called(email)
+	return args.Get(0).(model.UserCredentials), args.Error(1)
+}
+
+func (m *MockUserCredentialsRepository) UpdateRole(id, role string) error {
+	return m.Called(id, role).Error(0)
+}
+
+func (m *MockUserCredentialsRepository) FindUserByID(id string) (model.UserCredentials, error) {
+	args := m.Called(id)
+	return args.Get(0).(model.UserCredentials), args.Error(1)
+}
+
+func (m *MockUserCredentialsRepository) UpdateStatus(id string, isActive bool) error {
+	return m.Called(id, isActive).Error(0)
+}
+
+func (m *MockUserCredentialsRepository) UpdateLocale(id, locale string) error {
+	return m.Called(id, locale).Error(0)
+}
@@ -0,0 +1,39 @@
+package usecasemock
+
+import (
+	"final-project-enigma-clean/model"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AssetUnitUsecaseMock struct {
+	mock.Mock
+}
+
+func (a *AssetUnitUsecaseMock) Create(payload model.AssetUnit) error {
+	return a.Called(payload).Error(0)
+}
+
+func (a *AssetUnitUsecaseMock) Update(payload model.AssetUnit) error {
+	return a.Called(payload).Error(0)
+}
+
+func (a *AssetUnitUsecaseMock) FindById(id string) (model.AssetUnit, error) {
+	args := a.Called(id)
+	return args.Get(0).(model.AssetUnit), args.Error(1)
+}
+
+func (a *AssetUnitUsecaseMock) FindBySerial(serial string) (model.AssetUnit, error) {
+	args := a.Called(serial)
+	return args.Get(0).(model.AssetUnit), args.Error(1)
+}
+
+func (a *AssetUnitUsecaseMock) FindByAsset(assetId string) ([]model.AssetUnit, error) {
+	args := a.Called(assetId)
+	return args.Get(0).([]model.AssetUnit), args.Error(1)
+}
+
+func (a *AssetUnitUsecaseMock) FindByTransaction(idManageAsset string) ([]model.AssetUnit, error) {
+	args := a.Called(idManageAsset)
+	return args.Get(0).([]model.AssetUnit), args.Error(1)
+}
@@ -12,7 +12,7 @@ type AssetUsecaseMock struct {
 }
 
 // Paging implements usecase.AssetUsecase.
-func (a *AssetUsecaseMock) Paging(payload dto.PageRequest) ([]model.Asset, dto.Paging, error) {
+func (a *AssetUsecaseMock) Paging(payload dto.AssetQuery) ([]model.Asset, dto.Paging, error) {
 	args := a.Called(payload)
 	if args.Get(2) != nil {
 		return nil, dto.Paging{}, args.Error(2)
@@ -21,11 +21,6 @@ func (a *AssetUsecaseMock) Paging(payload dto.PageRequest) ([]model.Asset, dto.P
 	return args.Get(0).([]model.Asset), args.Get(1).(dto.Paging), nil
 }
 
-// UpdateAvailable implements usecase.AssetUsecase.
-func (a *AssetUsecaseMock) UpdateAvailable(id string, amount int) error {
-	return a.Called(id, amount).Error(0)
-}
-
 // FindByName implements usecase.AssetUsecase.
 func (a *AssetUsecaseMock) FindByName(name string) ([]model.Asset, error) {
 	args := a.Called(name)
@@ -37,13 +32,13 @@ func (a *AssetUsecaseMock) FindByName(name string) ([]model.Asset, error) {
 }
 
 // Create implements AssetUsecase.
-func (a *AssetUsecaseMock) Create(payload model.AssetRequest) error {
-	return a.Called(payload).Error(0)
+func (a *AssetUsecaseMock) Create(actorId string, payload model.AssetRequest) error {
+	return a.Called(actorId, payload).Error(0)
 }
 
 // Delete implements AssetUsecase.
-func (a *AssetUsecaseMock) Delete(id string) error {
-	return a.Called(id).Error(0)
+func (a *AssetUsecaseMock) Delete(actorId, id string) error {
+	return a.Called(actorId, id).Error(0)
 }
 
 // FindAll implements AssetUsecase.
@@ -66,6 +61,53 @@ func (a *AssetUsecaseMock) FindById(id string) (model.Asset, error) {
 }
 
 // Update implements AssetUsecase.
-func (a *AssetUsecaseMock) Update(payload model.AssetRequest) error {
-	return a.Called(payload).Error(0)
+func (a *AssetUsecaseMock) Update(actorId string, payload model.AssetRequest) error {
+	return a.Called(actorId, payload).Error(0)
+}
+
+func (a *AssetUsecaseMock) Restore(actorId, id string) error {
+	return a.Called(actorId, id).Error(0)
+}
+
+// Report implements usecase.AssetUsecase.
+func (a *AssetUsecaseMock) Report(query dto.AssetQuery) ([]model.Asset, error) {
+	args := a.Called(query)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Asset), nil
+}
+
+// PagingCursor implements usecase.AssetUsecase.
+func (a *AssetUsecaseMock) PagingCursor(query dto.AssetQuery, payload dto.CursorRequest) ([]model.Asset, dto.CursorPaging, error) {
+	args := a.Called(query, payload)
+	if args.Get(2) != nil {
+		return nil, dto.CursorPaging{}, args.Error(2)
+	}
+
+	return args.Get(0).([]model.Asset), args.Get(1).(dto.CursorPaging), nil
+}
+
+// Import implements usecase.AssetUsecase.
+func (a *AssetUsecaseMock) Import(actorId string, rows [][]string, dryRun bool) (dto.ImportResult, error) {
+	args := a.Called(actorId, rows, dryRun)
+	return args.Get(0).(dto.ImportResult), args.Error(1)
+}
+
+// Label implements usecase.AssetUsecase.
+func (a *AssetUsecaseMock) Label(id string, payload dto.LabelRequest) ([]byte, error) {
+	args := a.Called(id, payload)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+// LabelSheet implements usecase.AssetUsecase.
+func (a *AssetUsecaseMock) LabelSheet(query dto.AssetQuery) ([]byte, error) {
+	args := a.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
 }
@@ -0,0 +1,22 @@
+package usecasemock
+
+import (
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditUsecaseMock struct {
+	mock.Mock
+}
+
+func (a *AuditUsecaseMock) Entry(actorId, entity, entityId, action string, before, after any) (model.AuditLog, error) {
+	args := a.Called(actorId, entity, entityId, action, before, after)
+	return args.Get(0).(model.AuditLog), args.Error(1)
+}
+
+func (a *AuditUsecaseMock) List(payload dto.AuditRequest) ([]model.AuditLog, dto.Paging, error) {
+	args := a.Called(payload)
+	return args.Get(0).([]model.AuditLog), args.Get(1).(dto.Paging), args.Error(2)
+}
@@ -21,14 +21,14 @@ func (c *CategoryUsecaseMock) FindById(id string) (model.Category, error) {
 }
 
 // CreateNew implements CategoryUseCase.
-func (c *CategoryUsecaseMock) CreateNew(payload model.Category) error {
-	return c.Called(payload).Error(0)
+func (c *CategoryUsecaseMock) CreateNew(actorId string, payload model.Category) error {
+	return c.Called(actorId, payload).Error(0)
 }
 
 // Delete implements CategoryUseCase.
-func (c *CategoryUsecaseMock) Delete(id string) error {
+func (c *CategoryUsecaseMock) Delete(actorId, id string) error {
 	// panic("implement me")
-	return c.Called(id).Error(0)
+	return c.Called(actorId, id).Error(0)
 }
 
 // FindAll implements CategoryUseCase.
@@ -42,7 +42,16 @@ func (c *CategoryUsecaseMock) FindAll() ([]model.Category, error) {
 }
 
 // Update implements CategoryUseCase.
-func (c *CategoryUsecaseMock) Update(payload model.Category) error {
+func (c *CategoryUsecaseMock) Update(actorId string, payload model.Category) error {
 	// panic("implement me")
-	return c.Called(payload).Error(0)
+	return c.Called(actorId, payload).Error(0)
+}
+
+func (c *CategoryUsecaseMock) Restore(actorId, id string) error {
+	return c.Called(actorId, id).Error(0)
+}
+
+func (c *CategoryUsecaseMock) FindAllWithDeleted() ([]model.Category, error) {
+	args := c.Called()
+	return args.Get(0).([]model.Category), args.Error(1)
 }
@@ -0,0 +1,26 @@
+package usecasemock
+
+import (
+	"context"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type EmailOutboxUsecaseMock struct {
+	mock.Mock
+}
+
+func (e *EmailOutboxUsecaseMock) ProcessDue(ctx context.Context) error {
+	return e.Called(ctx).Error(0)
+}
+
+func (e *EmailOutboxUsecaseMock) ListFailed(payload dto.PageRequest) ([]model.EmailOutbox, dto.Paging, error) {
+	args := e.Called(payload)
+	return args.Get(0).([]model.EmailOutbox), args.Get(1).(dto.Paging), args.Error(2)
+}
+
+func (e *EmailOutboxUsecaseMock) Resend(id string) error {
+	return e.Called(id).Error(0)
+}
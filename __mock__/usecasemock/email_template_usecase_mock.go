@@ -0,0 +1,20 @@
+package usecasemock
+
+import (
+	"final-project-enigma-clean/util/mailer"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type EmailTemplateUsecaseMock struct {
+	mock.Mock
+}
+
+func (e *EmailTemplateUsecaseMock) Events() []string {
+	return e.Called().Get(0).([]string)
+}
+
+func (e *EmailTemplateUsecaseMock) Preview(event, locale string) (mailer.Message, error) {
+	args := e.Called(event, locale)
+	return args.Get(0).(mailer.Message), args.Error(1)
+}
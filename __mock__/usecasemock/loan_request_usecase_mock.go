@@ -0,0 +1,56 @@
+package usecasemock
+
+import (
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LoanRequestUsecaseMock struct {
+	mock.Mock
+}
+
+func (l *LoanRequestUsecaseMock) Create(actorId string, payload dto.LoanRequestRequest) (model.LoanRequest, error) {
+	args := l.Called(actorId, payload)
+	return args.Get(0).(model.LoanRequest), args.Error(1)
+}
+
+func (l *LoanRequestUsecaseMock) Update(actorId, id string, payload dto.LoanRequestRequest) error {
+	return l.Called(actorId, id, payload).Error(0)
+}
+
+func (l *LoanRequestUsecaseMock) FindAll(actorId, role string, query dto.LoanRequestQuery) ([]model.LoanRequest, error) {
+	args := l.Called(actorId, role, query)
+	return args.Get(0).([]model.LoanRequest), args.Error(1)
+}
+
+func (l *LoanRequestUsecaseMock) FindById(actorId, role, id string) (model.LoanRequest, error) {
+	args := l.Called(actorId, role, id)
+	return args.Get(0).(model.LoanRequest), args.Error(1)
+}
+
+func (l *LoanRequestUsecaseMock) Submit(actorId, id string) error {
+	return l.Called(actorId, id).Error(0)
+}
+
+func (l *LoanRequestUsecaseMock) Approve(actorId, id string, payload dto.LoanDecisionRequest) error {
+	return l.Called(actorId, id, payload).Error(0)
+}
+
+func (l *LoanRequestUsecaseMock) Reject(actorId, id string, payload dto.LoanDecisionRequest) error {
+	return l.Called(actorId, id, payload).Error(0)
+}
+
+func (l *LoanRequestUsecaseMock) Issue(actorId, id string, payload dto.LoanIssueRequest) (model.LoanRequest, error) {
+	args := l.Called(actorId, id, payload)
+	return args.Get(0).(model.LoanRequest), args.Error(1)
+}
+
+func (l *LoanRequestUsecaseMock) Return(actorId, id string, payload dto.LoanReturnRequest) error {
+	return l.Called(actorId, id, payload).Error(0)
+}
+
+func (l *LoanRequestUsecaseMock) Close(actorId, role, id string, payload dto.LoanDecisionRequest) error {
+	return l.Called(actorId, role, id, payload).Error(0)
+}
@@ -1,6 +1,7 @@
 package usecasemock
 
 import (
+	"context"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 
@@ -35,11 +36,6 @@ func (m *ManageAssetsMock) Update(payload model.AssetRequest) error {
 	panic("implement me")
 }
 
-func (m *ManageAssetsMock) UpdateAvailable(id string, amount int) error {
-	//TODO implement me
-	panic("implement me")
-}
-
 func (m *ManageAssetsMock) Delete(id string) error {
 	//TODO implement me
 	panic("implement me")
@@ -94,3 +90,50 @@ func (m *ManageAssetsMock) DownloadAssets() ([]byte, error) {
 	//TODO implement me
 	panic("implement me")
 }
+
+func (m *ManageAssetsMock) Receipt(id string) ([]byte, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *ManageAssetsMock) ReturnTransaction(payload dto.ReturnAssetRequest) error {
+	return m.Called(payload).Error(0)
+}
+
+func (m *ManageAssetsMock) FindOverdue() ([]model.OverdueTransaction, error) {
+	args := m.Called()
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.OverdueTransaction), nil
+}
+
+func (m *ManageAssetsMock) RemindOverdue(ctx context.Context) error {
+	return m.Called(ctx).Error(0)
+}
+
+// ExportDetails implements usecase.ManageAssetUsecase.
+// the rows given to Return are handed to each in order
+func (m *ManageAssetsMock) ExportDetails(filter dto.TransactionExportRequest, each func(model.TransactionDetailRow) error) error {
+	args := m.Called(filter, each)
+	if rows, ok := args.Get(0).([]model.TransactionDetailRow); ok {
+		for _, row := range rows {
+			if err := each(row); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// FindOpenDetails implements usecase.ManageAssetUsecase.
+func (m *ManageAssetsMock) FindOpenDetails(filter dto.OpenDetailRequest) ([]model.ManageDetailAsset, error) {
+	args := m.Called(filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ManageDetailAsset), args.Error(1)
+}
@@ -0,0 +1,16 @@
+package usecasemock
+
+import (
+	"final-project-enigma-clean/model/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ScanUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *ScanUsecaseMock) Scan(actorId string, payload dto.ScanRequest) (dto.ScanResponse, error) {
+	args := s.Called(actorId, payload)
+	return args.Get(0).(dto.ScanResponse), args.Error(1)
+}
@@ -49,14 +49,14 @@ func (s *StaffUsecaseMock) FindById(id string) (model.Staff, error) {
 }
 
 // CreateNew implements StaffUseCase.
-func (s *StaffUsecaseMock) CreateNew(payload model.Staff) error {
-	return s.Called(payload).Error(0)
+func (s *StaffUsecaseMock) CreateNew(actorId string, payload model.Staff) error {
+	return s.Called(actorId, payload).Error(0)
 }
 
 // Delete implements StaffUseCase.
-func (s *StaffUsecaseMock) Delete(id string) error {
+func (s *StaffUsecaseMock) Delete(actorId, id string) error {
 	// panic("implement me")
-	return s.Called(id).Error(0)
+	return s.Called(actorId, id).Error(0)
 }
 
 // FindAll implements StaffUseCase.
@@ -70,7 +70,36 @@ func (s *StaffUsecaseMock) FindByAll() ([]model.Staff, error) {
 }
 
 // Update implements StaffUseCase.
-func (s *StaffUsecaseMock) Update(payload model.Staff) error {
+func (s *StaffUsecaseMock) Update(actorId string, payload model.Staff) error {
 	// panic("implement me")
-	return s.Called(payload).Error(0)
+	return s.Called(actorId, payload).Error(0)
+}
+
+func (s *StaffUsecaseMock) Restore(actorId, id string) error {
+	return s.Called(actorId, id).Error(0)
+}
+
+// Report implements usecase.StaffUseCase.
+func (s *StaffUsecaseMock) Report(includeDeleted bool) ([]model.Staff, error) {
+	args := s.Called(includeDeleted)
+	if args.Get(1) != nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Staff), nil
+}
+
+// PagingCursor implements usecase.StaffUseCase.
+func (s *StaffUsecaseMock) PagingCursor(payload dto.CursorRequest) ([]model.Staff, dto.CursorPaging, error) {
+	args := s.Called(payload)
+	if args.Get(2) != nil {
+		return nil, dto.CursorPaging{}, args.Error(2)
+	}
+
+	return args.Get(0).([]model.Staff), args.Get(1).(dto.CursorPaging), nil
+}
+
+// Import implements usecase.StaffUseCase.
+func (s *StaffUsecaseMock) Import(actorId string, rows [][]string, dryRun bool) (dto.ImportResult, error) {
+	args := s.Called(actorId, rows, dryRun)
+	return args.Get(0).(dto.ImportResult), args.Error(1)
 }
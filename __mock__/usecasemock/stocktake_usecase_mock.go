@@ -0,0 +1,41 @@
+package usecasemock
+
+import (
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type StocktakeUsecaseMock struct {
+	mock.Mock
+}
+
+func (s *StocktakeUsecaseMock) Open(actorId string, payload dto.StocktakeRequest) (model.Stocktake, error) {
+	args := s.Called(actorId, payload)
+	return args.Get(0).(model.Stocktake), args.Error(1)
+}
+
+func (s *StocktakeUsecaseMock) FindAll(status string) ([]model.Stocktake, error) {
+	args := s.Called(status)
+	return args.Get(0).([]model.Stocktake), args.Error(1)
+}
+
+func (s *StocktakeUsecaseMock) FindById(id string) (model.Stocktake, error) {
+	args := s.Called(id)
+	return args.Get(0).(model.Stocktake), args.Error(1)
+}
+
+func (s *StocktakeUsecaseMock) RecordCount(actorId, id string, payload dto.StocktakeCountRequest) error {
+	return s.Called(actorId, id, payload).Error(0)
+}
+
+func (s *StocktakeUsecaseMock) Report(id string) (dto.StocktakeReport, error) {
+	args := s.Called(id)
+	return args.Get(0).(dto.StocktakeReport), args.Error(1)
+}
+
+func (s *StocktakeUsecaseMock) Close(actorId, id string, payload dto.StocktakeCloseRequest) (dto.StocktakeReport, error) {
+	args := s.Called(actorId, id, payload)
+	return args.Get(0).(dto.StocktakeReport), args.Error(1)
+}
@@ -44,14 +44,14 @@ func (t *TypeAssetUsecaseMock) FindById(id string) (model.TypeAsset, error) {
 }
 
 // CreateNew implements CategoryUseCase.
-func (t *TypeAssetUsecaseMock) CreateNew(payload model.TypeAsset) error {
-	return t.Called(payload).Error(0)
+func (t *TypeAssetUsecaseMock) CreateNew(actorId string, payload model.TypeAsset) error {
+	return t.Called(actorId, payload).Error(0)
 }
 
 // Delete implements CategoryUseCase.
-func (t *TypeAssetUsecaseMock) Delete(id string) error {
+func (t *TypeAssetUsecaseMock) Delete(actorId, id string) error {
 	// panic("implement me")
-	return t.Called(id).Error(0)
+	return t.Called(actorId, id).Error(0)
 }
 
 // FindAll implements CategoryUseCase.
@@ -65,7 +65,21 @@ func (t *TypeAssetUsecaseMock) FindAll() ([]model.TypeAsset, error) {
 }
 
 // Update implements CategoryUseCase.
-func (t *TypeAssetUsecaseMock) Update(payload model.TypeAsset) error {
+func (t *TypeAssetUsecaseMock) Update(actorId string, payload model.TypeAsset) error {
 	// panic("implement me")
-	return t.Called(payload).Error(0)
+	return t.Called(actorId, payload).Error(0)
+}
+
+func (t *TypeAssetUsecaseMock) Restore(actorId, id string) error {
+	return t.Called(actorId, id).Error(0)
+}
+
+// PagingCursor implements usecase.TypeAssetUseCase.
+func (t *TypeAssetUsecaseMock) PagingCursor(payload dto.CursorRequest) ([]model.TypeAsset, dto.CursorPaging, error) {
+	args := t.Called(payload)
+	if args.Get(2) != nil {
+		return nil, dto.CursorPaging{}, args.Error(2)
+	}
+
+	return args.Get(0).([]model.TypeAsset), args.Get(1).(dto.CursorPaging), nil
 }
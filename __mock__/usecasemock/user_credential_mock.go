@@ -47,8 +47,8 @@ func (u *UserCredentialsMock) LoginUserForgotPass(user model.ChangePasswordReque
 }
 
 func (u *UserCredentialsMock) FindingUserEmail(email string) (userlogin model.UserLoginRequest, err error) {
-	//TODO implement me
-	panic("implement me")
+	args := u.Called(email)
+	return args.Get(0).(model.UserLoginRequest), args.Error(1)
 }
 
 func (u *UserCredentialsMock) FindingUserEmailPass(email string) (userlogin model.ChangePasswordRequest, err error) {
@@ -71,3 +71,41 @@ func (u *UserCredentialsMock) EmailExist(email string) bool {
 	args := u.Called(email)
 	return args.Bool(0)
 }
+
+func (u *UserCredentialsMock) IssueToken(email string) (model.TokenPair, error) {
+	args := u.Called(email)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (u *UserCredentialsMock) UpdateRole(payload model.UpdateRoleRequest) error {
+	return u.Called(payload).Error(0)
+}
+
+func (u *UserCredentialsMock) VerifyOTP(purpose, email string, otp int) error {
+	return u.Called(purpose, email, otp).Error(0)
+}
+
+func (u *UserCredentialsMock) RefreshToken(refreshToken string) (model.TokenPair, error) {
+	args := u.Called(refreshToken)
+	return args.Get(0).(model.TokenPair), args.Error(1)
+}
+
+func (u *UserCredentialsMock) Logout(refreshToken string) error {
+	return u.Called(refreshToken).Error(0)
+}
+
+func (u *UserCredentialsMock) CheckSession(userID, familyID string) error {
+	return u.Called(userID, familyID).Error(0)
+}
+
+func (u *UserCredentialsMock) UpdateStatus(payload model.UpdateStatusRequest) error {
+	return u.Called(payload).Error(0)
+}
+
+func (u *UserCredentialsMock) UpdateLocale(userID, locale string) error {
+	return u.Called(userID, locale).Error(0)
+}
+
+func (u *UserCredentialsMock) ResetPassword(email, newPassword string) error {
+	return u.Called(email, newPassword).Error(0)
+}
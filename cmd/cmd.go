@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"database/sql"
+	"final-project-enigma-clean/config"
+	"final-project-enigma-clean/manager"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// App is shared by every command, the config and database are only loaded
+// when a command ask for them so "help" works without a .env
+type App struct {
+	In  io.Reader
+	Out io.Writer
+
+	im manager.InfraManager
+	um manager.UsecaseManager
+}
+
+// Actor is recorded in the audit log for the changes made by a command
+const Actor = "cli"
+
+type Command struct {
+	Name  string
+	Usage string
+	Run   func(app *App, args []string) error
+}
+
+func commands() []Command {
+	return []Command{
+		serveCommand,
+		migrateCommand,
+		seedCommand,
+		createAdminCommand,
+		resetPasswordCommand,
+		exportCommand,
+		importCommand,
+	}
+}
+
+// Execute run the command named by the first argument, the server is started without one
+func Execute(app *App, args []string) error {
+	if len(args) == 0 {
+		args = []string{serveCommand.Name}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		app.usage()
+		return nil
+	}
+
+	for _, command := range commands() {
+		if command.Name == args[0] {
+			return command.Run(app, args[1:])
+		}
+	}
+	app.usage()
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func (a *App) usage() {
+	fmt.Fprintln(a.Out, "usage: <command> [arguments]")
+	fmt.Fprintln(a.Out)
+	for _, command := range commands() {
+		fmt.Fprintf(a.Out, "  %-16s %s\n", command.Name, command.Usage)
+	}
+}
+
+func (a *App) infra() (manager.InfraManager, error) {
+	if a.im != nil {
+		return a.im, nil
+	}
+	cfg, err := config.NewDbConfig()
+	if err != nil {
+		return nil, err
+	}
+	im, err := manager.NewInfraManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	a.im = im
+	return im, nil
+}
+
+// DB is the connection of the configured database
+func (a *App) DB() (*sql.DB, error) {
+	im, err := a.infra()
+	if err != nil {
+		return nil, err
+	}
+	return im.Connect(), nil
+}
+
+// Usecases build the same usecase manager the server use
+func (a *App) Usecases() (manager.UsecaseManager, error) {
+	if a.um != nil {
+		return a.um, nil
+	}
+	im, err := a.infra()
+	if err != nil {
+		return nil, err
+	}
+	a.um = manager.NewUsecaseManager(manager.NewRepoManager(im), im.Mailer())
+	return a.um, nil
+}
+
+func (a *App) Close() {
+	if a.im != nil {
+		a.im.Connect().Close()
+	}
+}
+
+// readSecret take a secret from the flag or, when empty, from the first line of stdin,
+// so passwords do not have to end up in the shell history
+func (a *App) readSecret(value, name string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	fmt.Fprintf(a.Out, "%s: ", name)
+	var line string
+	if _, err := fmt.Fscanln(a.In, &line); err != nil {
+		return "", fmt.Errorf("%s is required", name)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func NewApp(in io.Reader, out io.Writer) *App {
+	return &App{
+		In:  in,
+		Out: out,
+	}
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/manager"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/usecase"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeUsecases hand out the mocks, the methods a command does not use are left nil
+type fakeUsecases struct {
+	manager.UsecaseManager
+	user      *usecasemock.UserCredentialsMock
+	category  *usecasemock.CategoryUsecaseMock
+	assetType *usecasemock.TypeAssetUsecaseMock
+	staff     *usecasemock.StaffUsecaseMock
+}
+
+func (f *fakeUsecases) UserUsecase() usecase.UserCredentialUsecase { return f.user }
+func (f *fakeUsecases) CategoryUsecase() usecase.CategoryUsecase   { return f.category }
+func (f *fakeUsecases) TypeAssetUseCase() usecase.TypeAssetUseCase { return f.assetType }
+func (f *fakeUsecases) StaffUseCase() usecase.StaffUseCase         { return f.staff }
+
+type CmdSuite struct {
+	suite.Suite
+	um  *fakeUsecases
+	in  *bytes.Buffer
+	out *bytes.Buffer
+	app *App
+}
+
+func (suite *CmdSuite) SetupTest() {
+	suite.um = &fakeUsecases{
+		user:      new(usecasemock.UserCredentialsMock),
+		category:  new(usecasemock.CategoryUsecaseMock),
+		assetType: new(usecasemock.TypeAssetUsecaseMock),
+		staff:     new(usecasemock.StaffUsecaseMock),
+	}
+	suite.in = new(bytes.Buffer)
+	suite.out = new(bytes.Buffer)
+	suite.app = NewApp(suite.in, suite.out)
+	suite.app.um = suite.um
+}
+
+func TestCmdSuite(t *testing.T) {
+	suite.Run(t, new(CmdSuite))
+}
+
+func (suite *CmdSuite) TestHelp() {
+	assert.NoError(suite.T(), Execute(suite.app, []string{"help"}))
+	for _, command := range commands() {
+		assert.Contains(suite.T(), suite.out.String(), command.Name)
+	}
+}
+
+func (suite *CmdSuite) TestUnknownCommand() {
+	err := Execute(suite.app, []string{"launch"})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CmdSuite) TestSeed_SkipExisting() {
+	suite.um.category.On("FindAll").Return([]model.Category{{Id: "1", Name: "elektronik"}}, nil)
+	suite.um.category.On("CreateNew", Actor, mock.Anything).Return(nil)
+	suite.um.assetType.On("FindAll").Return([]model.TypeAsset{}, nil)
+	suite.um.assetType.On("CreateNew", Actor, mock.Anything).Return(nil)
+
+	assert.NoError(suite.T(), Execute(suite.app, []string{"seed"}))
+	suite.um.category.AssertNotCalled(suite.T(), "CreateNew", Actor, model.Category{Name: "Elektronik"})
+	suite.um.category.AssertNumberOfCalls(suite.T(), "CreateNew", len(seedCategories)-1)
+	suite.um.assetType.AssertNumberOfCalls(suite.T(), "CreateNew", len(seedAssetTypes))
+}
+
+func (suite *CmdSuite) TestCreateAdmin_NewUser() {
+	suite.um.user.On("FindingUserEmail", "admin@example.com").Return(model.UserLoginRequest{}, errors.New("not found")).Once()
+	suite.um.user.On("RegisterUser", model.UserRegisterRequest{Email: "admin@example.com", Name: "Administrator", Password: "Secret1!"}).Return(nil)
+	suite.um.user.On("FindingUserEmail", "admin@example.com").Return(model.UserLoginRequest{ID: "1", Email: "admin@example.com"}, nil)
+	suite.um.user.On("UpdateRole", model.UpdateRoleRequest{ID: "1", Role: model.RoleAdmin}).Return(nil)
+	suite.um.user.On("UpdateStatus", model.UpdateStatusRequest{ID: "1", IsActive: true}).Return(nil)
+
+	//the password is read from stdin
+	suite.in.WriteString("Secret1!\n")
+	err := Execute(suite.app, []string{"create-admin", "-email", "admin@example.com"})
+	assert.NoError(suite.T(), err)
+	suite.um.user.AssertExpectations(suite.T())
+}
+
+func (suite *CmdSuite) TestCreateAdmin_PromoteExisting() {
+	suite.um.user.On("FindingUserEmail", "admin@example.com").Return(model.UserLoginRequest{ID: "1", Email: "admin@example.com"}, nil)
+	suite.um.user.On("UpdateRole", model.UpdateRoleRequest{ID: "1", Role: model.RoleAdmin}).Return(nil)
+	suite.um.user.On("UpdateStatus", model.UpdateStatusRequest{ID: "1", IsActive: true}).Return(nil)
+
+	err := Execute(suite.app, []string{"create-admin", "-email", "admin@example.com"})
+	assert.NoError(suite.T(), err)
+	suite.um.user.AssertNotCalled(suite.T(), "RegisterUser", mock.Anything)
+}
+
+func (suite *CmdSuite) TestCreateAdmin_MissingEmail() {
+	err := Execute(suite.app, []string{"create-admin"})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CmdSuite) TestResetPassword() {
+	suite.um.user.On("ResetPassword", "user@example.com", "NewPass1!").Return(nil)
+
+	err := Execute(suite.app, []string{"reset-password", "-email", "user@example.com", "-password", "NewPass1!"})
+	assert.NoError(suite.T(), err)
+	suite.um.user.AssertExpectations(suite.T())
+}
+
+func (suite *CmdSuite) TestExport_CategoriesJson() {
+	suite.um.category.On("FindAll").Return([]model.Category{{Id: "1", Name: "Elektronik"}}, nil)
+
+	err := Execute(suite.app, []string{"export", "categories", "-format", "json"})
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), suite.out.String(), `"name": "Elektronik"`)
+}
+
+func (suite *CmdSuite) TestExport_CategoriesCsv() {
+	suite.um.category.On("FindAll").Return([]model.Category{{Id: "1", Name: "Elektronik"}}, nil)
+
+	err := Execute(suite.app, []string{"export", "categories"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "Id,Name\n1,Elektronik\n", suite.out.String())
+}
+
+func (suite *CmdSuite) TestExport_UnknownEntity() {
+	err := Execute(suite.app, []string{"export", "planets"})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *CmdSuite) TestImport_ReportFailedRows() {
+	suite.um.staff.On("CreateNew", Actor, mock.MatchedBy(func(staff model.Staff) bool { return staff.Nik_Staff == "1" })).Return(nil)
+	suite.um.staff.On("CreateNew", Actor, mock.MatchedBy(func(staff model.Staff) bool { return staff.Nik_Staff == "2" })).
+		Return(errors.New("phone number must be between 10 and 15 characters"))
+
+	err := importRows(suite.out, suite.um, "staff", strings.NewReader(`[{"nik_staff":"1"},{"nik_staff":"2"}]`))
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), suite.out.String(), "row 2: phone number")
+	assert.Contains(suite.T(), suite.out.String(), "imported 1 of 2 staff")
+}
+
+func (suite *CmdSuite) TestImport_NotAnArray() {
+	err := importRows(suite.out, suite.um, "categories", strings.NewReader(`{"name":"x"}`))
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"final-project-enigma-clean/model"
+	"fmt"
+	"strings"
+)
+
+// reference data a fresh environment need before assets can be created
+var (
+	seedCategories = []string{"Elektronik", "Furniture", "Kendaraan", "Peralatan Kantor"}
+	seedAssetTypes = []string{"Laptop", "Monitor", "Proyektor", "Printer", "Meja", "Kursi"}
+)
+
+var seedCommand = Command{
+	Name:  "seed",
+	Usage: "create the default categories and asset types, existing names are skipped",
+	Run: func(app *App, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("seed does not take arguments")
+		}
+		um, err := app.Usecases()
+		if err != nil {
+			return err
+		}
+
+		categories, err := um.CategoryUsecase().FindAll()
+		if err != nil {
+			return err
+		}
+		existing := make(map[string]bool)
+		for _, category := range categories {
+			existing[strings.ToLower(category.Name)] = true
+		}
+		for _, name := range seedCategories {
+			if existing[strings.ToLower(name)] {
+				continue
+			}
+			if err = um.CategoryUsecase().CreateNew(Actor, model.Category{Name: name}); err != nil {
+				return err
+			}
+			fmt.Fprintf(app.Out, "created category %s\n", name)
+		}
+
+		assetTypes, err := um.TypeAssetUseCase().FindAll()
+		if err != nil {
+			return err
+		}
+		existing = make(map[string]bool)
+		for _, assetType := range assetTypes {
+			existing[strings.ToLower(assetType.Name)] = true
+		}
+		for _, name := range seedAssetTypes {
+			if existing[strings.ToLower(name)] {
+				continue
+			}
+			if err = um.TypeAssetUseCase().CreateNew(Actor, model.TypeAsset{Name: name}); err != nil {
+				return err
+			}
+			fmt.Fprintf(app.Out, "created asset type %s\n", name)
+		}
+		return nil
+	},
+}
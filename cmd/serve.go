@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"final-project-enigma-clean/delivery"
+	"final-project-enigma-clean/util/migration"
+	"fmt"
+)
+
+var serveCommand = Command{
+	Name:  "serve",
+	Usage: "start the http server and background jobs",
+	Run: func(app *App, args []string) error {
+		if len(args) > 0 {
+			return fmt.Errorf("serve does not take arguments")
+		}
+		delivery.NewServer().Run()
+		return nil
+	},
+}
+
+var migrateCommand = Command{
+	Name:  "migrate",
+	Usage: "up | down [steps] | status, manage the database schema",
+	Run: func(app *App, args []string) error {
+		db, err := app.DB()
+		if err != nil {
+			return err
+		}
+		m, err := migration.New(db)
+		if err != nil {
+			return err
+		}
+		return m.Run(args, app.Out)
+	},
+}
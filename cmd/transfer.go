@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"final-project-enigma-clean/manager"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/util/helper"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+var exportCommand = Command{
+	Name:  "export",
+	Usage: "<categories|asset-types|assets|staff|transactions> [-format csv|json] [-out file]",
+	Run: func(app *App, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("usage: export %s", "<categories|asset-types|assets|staff|transactions> [-format csv|json] [-out file]")
+		}
+		entity := args[0]
+		flags := flag.NewFlagSet("export", flag.ContinueOnError)
+		flags.SetOutput(app.Out)
+		format := flags.String("format", "csv", "csv or json")
+		out := flags.String("out", "", "output file, stdout when empty")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *format != "csv" && *format != "json" {
+			return fmt.Errorf("unknown format %q", *format)
+		}
+
+		um, err := app.Usecases()
+		if err != nil {
+			return err
+		}
+		data, err := export(um, entity, *format)
+		if err != nil {
+			return err
+		}
+
+		if *out == "" {
+			_, err = app.Out.Write(data)
+			return err
+		}
+		if err = os.WriteFile(*out, data, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(app.Out, "exported %s to %s\n", entity, *out)
+		return nil
+	},
+}
+
+func export(um manager.UsecaseManager, entity, format string) ([]byte, error) {
+	var rows any
+	var records [][]string
+	switch entity {
+	case "categories":
+		categories, err := um.CategoryUsecase().FindAll()
+		if err != nil {
+			return nil, err
+		}
+		rows = categories
+		records = append(records, []string{"Id", "Name"})
+		for _, category := range categories {
+			records = append(records, []string{category.Id, category.Name})
+		}
+	case "asset-types":
+		assetTypes, err := um.TypeAssetUseCase().FindAll()
+		if err != nil {
+			return nil, err
+		}
+		rows = assetTypes
+		records = append(records, []string{"Id", "Name"})
+		for _, assetType := range assetTypes {
+			records = append(records, []string{assetType.Id, assetType.Name})
+		}
+	case "assets":
+		assets, err := um.AssetUsecase().FindAll()
+		if err != nil {
+			return nil, err
+		}
+		rows = assets
+		records = append(records, []string{"Id", "Name", "Category", "Asset Type", "Total", "Available", "Status", "Entry Date"})
+		for _, asset := range assets {
+			records = append(records, []string{asset.Id, asset.Name, asset.Category.Name, asset.AssetType.Name,
+				strconv.Itoa(asset.Total), strconv.Itoa(asset.Available), asset.Status, asset.EntryDate.Format("2006-01-02")})
+		}
+	case "staff":
+		staffs, err := um.StaffUseCase().FindByAll()
+		if err != nil {
+			return nil, err
+		}
+		if format == "csv" {
+			return helper.ConvertToCSVForStaff(staffs)
+		}
+		rows = staffs
+	case "transactions":
+		if format == "csv" {
+			records = append(records, helper.TransactionDetailColumns)
+			err := um.ManageAssetUsecase().ExportDetails(dto.TransactionExportRequest{}, func(row model.TransactionDetailRow) error {
+				records = append(records, helper.TransactionDetailRecord(row))
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		transactions, err := um.ManageAssetUsecase().ShowAllAsset()
+		if err != nil {
+			return nil, err
+		}
+		rows = transactions
+	default:
+		return nil, fmt.Errorf("unknown entity %q", entity)
+	}
+
+	if format == "json" {
+		return json.MarshalIndent(rows, "", "  ")
+	}
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var importCommand = Command{
+	Name:  "import",
+	Usage: "<categories|asset-types|assets|staff> <file.json>, create every row of a json array",
+	Run: func(app *App, args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: import <categories|asset-types|assets|staff> <file.json>")
+		}
+		file, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		um, err := app.Usecases()
+		if err != nil {
+			return err
+		}
+		return importRows(app.Out, um, args[0], file)
+	},
+}
+
+// importRows run every row through the same usecase as the http endpoint,
+// a failed row is reported and the rest are still imported
+func importRows(w io.Writer, um manager.UsecaseManager, entity string, r io.Reader) error {
+	var create func(raw json.RawMessage) error
+	switch entity {
+	case "categories":
+		create = func(raw json.RawMessage) error {
+			var category model.Category
+			if err := json.Unmarshal(raw, &category); err != nil {
+				return err
+			}
+			return um.CategoryUsecase().CreateNew(Actor, category)
+		}
+	case "asset-types":
+		create = func(raw json.RawMessage) error {
+			var assetType model.TypeAsset
+			if err := json.Unmarshal(raw, &assetType); err != nil {
+				return err
+			}
+			return um.TypeAssetUseCase().CreateNew(Actor, assetType)
+		}
+	case "assets":
+		create = func(raw json.RawMessage) error {
+			var asset model.AssetRequest
+			if err := json.Unmarshal(raw, &asset); err != nil {
+				return err
+			}
+			return um.AssetUsecase().Create(Actor, asset)
+		}
+	case "staff":
+		create = func(raw json.RawMessage) error {
+			var staff model.Staff
+			if err := json.Unmarshal(raw, &staff); err != nil {
+				return err
+			}
+			return um.StaffUseCase().CreateNew(Actor, staff)
+		}
+	default:
+		return fmt.Errorf("unknown entity %q", entity)
+	}
+
+	var rows []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return fmt.Errorf("file must be a json array: %v", err)
+	}
+
+	failed := 0
+	for i, raw := range rows {
+		if err := create(raw); err != nil {
+			failed++
+			fmt.Fprintf(w, "row %d: %v\n", i+1, err)
+		}
+	}
+	fmt.Fprintf(w, "imported %d of %d %s\n", len(rows)-failed, len(rows), entity)
+	if failed > 0 {
+		return fmt.Errorf("%d rows failed", failed)
+	}
+	return nil
+}
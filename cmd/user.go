@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"final-project-enigma-clean/model"
+	"flag"
+	"fmt"
+)
+
+var createAdminCommand = Command{
+	Name:  "create-admin",
+	Usage: "-email <email> [-name <name>] [-password <password>], create or promote an active admin",
+	Run: func(app *App, args []string) error {
+		flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+		flags.SetOutput(app.Out)
+		email := flags.String("email", "", "admin email")
+		name := flags.String("name", "Administrator", "admin name, used for a new account")
+		password := flags.String("password", "", "password of a new account, read from stdin when empty")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *email == "" {
+			return fmt.Errorf("email is required")
+		}
+
+		um, err := app.Usecases()
+		if err != nil {
+			return err
+		}
+		userUC := um.UserUsecase()
+
+		//an existing account is promoted, its password is left alone
+		user, err := userUC.FindingUserEmail(*email)
+		if err != nil {
+			secret, err := app.readSecret(*password, "password")
+			if err != nil {
+				return err
+			}
+			err = userUC.RegisterUser(model.UserRegisterRequest{Email: *email, Name: *name, Password: secret})
+			if err != nil {
+				return err
+			}
+			if user, err = userUC.FindingUserEmail(*email); err != nil {
+				return err
+			}
+			fmt.Fprintf(app.Out, "registered %s\n", *email)
+		}
+
+		if err = userUC.UpdateRole(model.UpdateRoleRequest{ID: user.ID, Role: model.RoleAdmin}); err != nil {
+			return err
+		}
+		if err = userUC.UpdateStatus(model.UpdateStatusRequest{ID: user.ID, IsActive: true}); err != nil {
+			return err
+		}
+		fmt.Fprintf(app.Out, "%s is an active admin\n", *email)
+		return nil
+	},
+}
+
+var resetPasswordCommand = Command{
+	Name:  "reset-password",
+	Usage: "-email <email> [-password <password>], set a new password and log the user out",
+	Run: func(app *App, args []string) error {
+		flags := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+		flags.SetOutput(app.Out)
+		email := flags.String("email", "", "user email")
+		password := flags.String("password", "", "new password, read from stdin when empty")
+		if err := flags.Parse(args); err != nil {
+			return err
+		}
+		if *email == "" {
+			return fmt.Errorf("email is required")
+		}
+
+		um, err := app.Usecases()
+		if err != nil {
+			return err
+		}
+		secret, err := app.readSecret(*password, "new password")
+		if err != nil {
+			return err
+		}
+		if err = um.UserUsecase().ResetPassword(*email, secret); err != nil {
+			return err
+		}
+		fmt.Fprintf(app.Out, "password of %s was reset, every session is revoked\n", *email)
+		return nil
+	},
+}
@@ -15,16 +15,40 @@ type DbConfig struct {
 	Password string
 	DbName   string
 	DbDriver string
+	// apply pending migrations when the server start
+	Migrate bool
 }
 
 type LoggerPath struct {
 	FilePath string
 }
 
+// OtpConfig select where otp codes are kept, "postgres" (default) or "memory",
+// Secret key the hash of the stored codes
+type OtpConfig struct {
+	Store  string
+	Secret string
+}
+
+// MailerConfig select the mail driver, "brevo", "smtp" or "log"
+type MailerConfig struct {
+	Driver       string
+	FromName     string
+	FromEmail    string
+	ApiKey       string
+	SmtpHost     string
+	SmtpPort     string
+	SmtpUser     string
+	SmtpPassword string
+	LogDir       string
+}
+
 type Config struct {
 	*DbConfig
 	*LoggerPath
 	ApiConfig
+	OtpConfig
+	MailerConfig
 }
 type ApiConfig struct {
 	ApiHost string
@@ -43,12 +67,50 @@ func (c *Config) ReadConfig() error {
 		Password: os.Getenv("DB_PASSWORD"),
 		DbName:   os.Getenv("DB_NAME"),
 		DbDriver: os.Getenv("DB_DRIVER"),
+		Migrate:  os.Getenv("DB_MIGRATE") == "true",
 	}
 	c.ApiConfig = ApiConfig{
 		ApiHost: os.Getenv("API_HOST"),
 		ApiPort: os.Getenv("API_PORT"),
 	}
 
+	c.OtpConfig = OtpConfig{
+		Store:  os.Getenv("OTP_STORE"),
+		Secret: os.Getenv("OTP_SECRET"),
+	}
+	if c.OtpConfig.Store == "" {
+		c.OtpConfig.Store = "postgres"
+	}
+	//keep working with only the jwt secret set, a dedicated one is preferred
+	if c.OtpConfig.Secret == "" {
+		c.OtpConfig.Secret = os.Getenv("JWT_SECRET")
+	}
+
+	c.MailerConfig = MailerConfig{
+		Driver:       os.Getenv("MAILER_DRIVER"),
+		FromName:     os.Getenv("MAILER_FROM_NAME"),
+		FromEmail:    os.Getenv("MAILER_FROM_EMAIL"),
+		ApiKey:       os.Getenv("MAILER_API_KEY"),
+		SmtpHost:     os.Getenv("SMTP_HOST"),
+		SmtpPort:     os.Getenv("SMTP_PORT"),
+		SmtpUser:     os.Getenv("SMTP_USER"),
+		SmtpPassword: os.Getenv("SMTP_PASSWORD"),
+		LogDir:       os.Getenv("MAILER_LOG_DIR"),
+	}
+	//keep the old behaviour of sending through brevo when only the api key is set
+	if c.MailerConfig.Driver == "" {
+		c.MailerConfig.Driver = "log"
+		if c.MailerConfig.ApiKey != "" {
+			c.MailerConfig.Driver = "brevo"
+		}
+	}
+	if c.MailerConfig.FromName == "" {
+		c.MailerConfig.FromName = "Stephanie Project"
+	}
+	if c.MailerConfig.FromEmail == "" {
+		c.MailerConfig.FromEmail = "Stephanie@stephanieproject.my.id"
+	}
+
 	//file config
 	c.LoggerPath = &LoggerPath{
 		FilePath: os.Getenv("LOGGER_FILE"),
@@ -59,6 +121,9 @@ func (c *Config) ReadConfig() error {
 		c.DbConfig.User == "" || c.DbConfig.Password == "" || c.DbConfig.DbDriver == "" || c.ApiConfig.ApiHost == "" || c.ApiConfig.ApiPort == "" {
 		return fmt.Errorf("missing required environment variable")
 	}
+	if c.OtpConfig.Secret == "" {
+		return fmt.Errorf("missing required environment variable OTP_SECRET or JWT_SECRET")
+	}
 
 	slog.Infof("Connected to database %v", c.DbConfig.Host)
 	fmt.Println("-------------------------------------")
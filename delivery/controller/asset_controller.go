@@ -2,10 +2,14 @@ package controller
 
 import (
 	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"final-project-enigma-clean/usecase"
+	"final-project-enigma-clean/util/helper"
+	"final-project-enigma-clean/util/label"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,7 +28,7 @@ func (a *AssetController) createAssetHandler(c *gin.Context) {
 		return
 	}
 
-	err = a.usecase.Create(assetRequest)
+	err = a.usecase.Create(c.GetString("user_id"), assetRequest)
 	if err != nil {
 		c.Error(err)
 		return
@@ -34,29 +38,50 @@ func (a *AssetController) createAssetHandler(c *gin.Context) {
 	// c.JSON(201, assetRequest)
 }
 
+// ListAssetHandler combine search, filters, sort and paging of the assets in one request,
+// a cursor or limit switch the listing to cursor paging and format=xlsx send every filtered asset as a report
 func (a *AssetController) ListAssetHandler(c *gin.Context) {
-	name := c.Query("name")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	size, _ := strconv.Atoi(c.DefaultQuery("size", "5"))
-
-	if name != "" {
-		assets, err := a.usecase.FindByName(name)
+	asReport, err := wantsReport(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	query, err := assetQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	//a report cover the whole filtered list, not the requested page
+	if asReport {
+		assets, err := a.usecase.Report(query)
 		if err != nil {
 			c.Error(err)
 			return
 		}
+		sendReport(c, "assets", helper.AssetSheet(assets))
+		return
+	}
 
+	cursor, ok, err := cursorRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if ok {
+		assets, paging, err := a.usecase.PagingCursor(query, cursor)
+		if err != nil {
+			c.Error(err)
+			return
+		}
 		c.JSON(200, gin.H{
 			"status": "OK",
 			"assets": assets,
+			"paging": paging,
 		})
 		return
 	}
 
-	assets, paging, err := a.usecase.Paging(dto.PageRequest{
-		Page: page,
-		Size: size,
-	})
+	assets, paging, err := a.usecase.Paging(query)
 	if err != nil {
 		c.Error(err)
 		return
@@ -68,6 +93,54 @@ func (a *AssetController) ListAssetHandler(c *gin.Context) {
 	})
 }
 
+// assetQuery read the listing query string, a malformed value is a bad request
+func assetQuery(c *gin.Context) (dto.AssetQuery, error) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "5"))
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		return dto.AssetQuery{}, err
+	}
+
+	query := dto.AssetQuery{
+		PageRequest: dto.PageRequest{
+			Page:           page,
+			Size:           size,
+			IncludeDeleted: withDeleted,
+		},
+		Name:        c.Query("name"),
+		CategoryId:  c.Query("category_id"),
+		AssetTypeId: c.Query("asset_type_id"),
+		Status:      c.Query("status"),
+		Sort:        c.Query("sort"),
+		Direction:   c.Query("direction"),
+	}
+	if available := c.Query("available"); available != "" {
+		if query.OnlyAvailable, err = strconv.ParseBool(available); err != nil {
+			return dto.AssetQuery{}, exception.BadRequestErr("available must be true or false")
+		}
+	}
+	if query.EntryFrom, err = queryDate(c, "entry_from"); err != nil {
+		return dto.AssetQuery{}, err
+	}
+	if query.EntryTo, err = queryDate(c, "entry_to"); err != nil {
+		return dto.AssetQuery{}, err
+	}
+	return query, nil
+}
+
+func queryDate(c *gin.Context, key string) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, exception.BadRequestErr(key + " must be formatted as YYYY-MM-DD")
+	}
+	return date, nil
+}
+
 func (a *AssetController) findByIdHandler(c *gin.Context) {
 
 	id := c.Param("id")
@@ -93,7 +166,7 @@ func (a *AssetController) updateHandler(c *gin.Context) {
 		return
 	}
 
-	err = a.usecase.Update(assetRequest)
+	err = a.usecase.Update(c.GetString("user_id"), assetRequest)
 	if err != nil {
 		c.Error(err)
 		return
@@ -106,7 +179,7 @@ func (a *AssetController) deleteHandler(c *gin.Context) {
 
 	id := c.Param("id")
 
-	err := a.usecase.Delete(id)
+	err := a.usecase.Delete(c.GetString("user_id"), id)
 	if err != nil {
 		c.Error(err)
 		return
@@ -115,12 +188,90 @@ func (a *AssetController) deleteHandler(c *gin.Context) {
 	c.JSON(200, gin.H{"status": "OK", "message": "successfully delete asset"})
 }
 
+func (a *AssetController) restoreHandler(c *gin.Context) {
+	err := a.usecase.Restore(c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "OK", "message": "successfully restore asset"})
+}
+
+// importHandler create the assets of an uploaded csv or xlsx file, dry_run=true only validate the rows
+func (a *AssetController) importHandler(c *gin.Context) {
+	rows, err := uploadedRows(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	result, err := a.usecase.Import(c.GetString("user_id"), rows, dryRun)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	code := 201
+	if dryRun {
+		code = 200
+	}
+	c.JSON(code, gin.H{"status": "OK", "result": result})
+}
+
+// labelHandler send a qr code or code128 barcode of the asset id as png or svg
+func (a *AssetController) labelHandler(c *gin.Context) {
+	payload := dto.LabelRequest{
+		Type:   c.Query("type"),
+		Format: c.Query("format"),
+	}
+	if size := c.Query("size"); size != "" {
+		var err error
+		if payload.Size, err = strconv.Atoi(size); err != nil {
+			c.Error(exception.BadRequestErr("size must be a number"))
+			return
+		}
+	}
+
+	image, err := a.usecase.Label(c.Param("id"), payload)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	contentType := "image/png"
+	if payload.Format == label.SVG {
+		contentType = "image/svg+xml"
+	}
+	c.Data(200, contentType, image)
+}
+
+// labelSheetHandler send a printable pdf of the labels of the assets matching the listing filters
+func (a *AssetController) labelSheetHandler(c *gin.Context) {
+	query, err := assetQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	pdf, err := a.usecase.LabelSheet(query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="asset-labels.pdf"`)
+	c.Data(200, "application/pdf", pdf)
+}
+
 func (a *AssetController) Route() {
-	a.rg.POST("/assets", middleware.AuthMiddleware(), a.createAssetHandler)
-	a.rg.GET("/assets", middleware.AuthMiddleware(), a.ListAssetHandler)
-	a.rg.GET("/assets/:id", middleware.AuthMiddleware(), a.findByIdHandler)
-	a.rg.PUT("/assets", middleware.AuthMiddleware(), a.updateHandler)
-	a.rg.DELETE("/assets/:id", middleware.AuthMiddleware(), a.deleteHandler)
+	a.rg.POST("/assets", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), a.createAssetHandler)
+	a.rg.POST("/assets/import", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), a.importHandler)
+	a.rg.GET("/assets", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), a.ListAssetHandler)
+	a.rg.GET("/assets/labels.pdf", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), a.labelSheetHandler)
+	a.rg.GET("/assets/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), a.findByIdHandler)
+	a.rg.GET("/assets/:id/label", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), a.labelHandler)
+	a.rg.PUT("/assets", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), a.updateHandler)
+	a.rg.DELETE("/assets/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), a.deleteHandler)
+	a.rg.POST("/assets/:id/restore", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermRestore), a.restoreHandler)
 }
 
 func NewAssetController(usecase usecase.AssetUsecase, rg *gin.RouterGroup) *AssetController {
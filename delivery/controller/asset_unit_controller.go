@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AssetUnitController struct {
+	usecase usecase.AssetUnitUsecase
+	rg      *gin.RouterGroup
+}
+
+// register a serial numbered unit under the asset
+func (a *AssetUnitController) createHandler(c *gin.Context) {
+	var unit model.AssetUnit
+	if err := c.ShouldBindJSON(&unit); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"status": "Error", "message": err.Error()})
+		return
+	}
+	unit.AssetId = c.Param("id")
+
+	if err := a.usecase.Create(unit); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(201, gin.H{"status": "OK", "message": "successfully created asset unit"})
+}
+
+func (a *AssetUnitController) listByAssetHandler(c *gin.Context) {
+	units, err := a.usecase.FindByAsset(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "OK", "units": units})
+}
+
+func (a *AssetUnitController) findByIdHandler(c *gin.Context) {
+	unit, err := a.usecase.FindById(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "OK", "unit": unit})
+}
+
+// change serial, condition or status, e.g. send a unit to maintenance or retire it
+func (a *AssetUnitController) updateHandler(c *gin.Context) {
+	var unit model.AssetUnit
+	if err := c.ShouldBindJSON(&unit); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"status": "Error", "message": err.Error()})
+		return
+	}
+	unit.Id = c.Param("id")
+
+	if err := a.usecase.Update(unit); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"status": "OK", "message": "successfully update asset unit"})
+}
+
+func (a *AssetUnitController) Route() {
+	a.rg.POST("/assets/:id/units", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), a.createHandler)
+	a.rg.GET("/assets/:id/units", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), a.listByAssetHandler)
+	a.rg.GET("/asset-units/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), a.findByIdHandler)
+	a.rg.PUT("/asset-units/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), a.updateHandler)
+}
+
+func NewAssetUnitController(usecase usecase.AssetUnitUsecase, rg *gin.RouterGroup) *AssetUnitController {
+	return &AssetUnitController{
+		usecase: usecase,
+		rg:      rg,
+	}
+}
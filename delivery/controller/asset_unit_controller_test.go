@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"bytes"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AssetUnitControllerSuite struct {
+	suite.Suite
+	usecase *usecasemock.AssetUnitUsecaseMock
+	router  *gin.Engine
+}
+
+func (suite *AssetUnitControllerSuite) SetupTest() {
+	suite.usecase = new(usecasemock.AssetUnitUsecaseMock)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+	NewAssetUnitController(suite.usecase, suite.router.Group("/api/v1")).Route()
+}
+
+func TestAssetUnitControllerSuite(t *testing.T) {
+	suite.Run(t, new(AssetUnitControllerSuite))
+}
+
+func (suite *AssetUnitControllerSuite) TestCreate_Success() {
+	suite.usecase.On("Create", model.AssetUnit{AssetId: "a1", SerialNumber: "SN-1"}).Return(nil)
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/assets/a1/units", bytes.NewBufferString(`{"serial_number":"SN-1"}`))
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusCreated, record.Code)
+	suite.usecase.AssertExpectations(suite.T())
+}
+
+func (suite *AssetUnitControllerSuite) TestCreate_Forbidden() {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/assets/a1/units", bytes.NewBufferString(`{"serial_number":"SN-1"}`))
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleViewer))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "Create", mock.Anything)
+}
+
+func (suite *AssetUnitControllerSuite) TestListByAsset_Success() {
+	suite.usecase.On("FindByAsset", "a1").Return([]model.AssetUnit{{Id: "u1", SerialNumber: "SN-1"}}, nil)
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/assets/a1/units", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleViewer))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	assert.Contains(suite.T(), record.Body.String(), "SN-1")
+}
+
+func (suite *AssetUnitControllerSuite) TestFindById_NotFound() {
+	suite.usecase.On("FindById", "u9").Return(model.AssetUnit{}, exception.NotFoundErr("asset unit not found"))
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/asset-units/u9", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleViewer))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusNotFound, record.Code)
+}
+
+func (suite *AssetUnitControllerSuite) TestUpdate_BadJson() {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPut, "/api/v1/asset-units/u1", bytes.NewBufferString(`{`))
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusBadRequest, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
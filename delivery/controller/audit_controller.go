@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/usecase"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditController struct {
+	auditUC usecase.AuditUsecase
+	rg      *gin.RouterGroup
+}
+
+// list the history of changes, filtered by entity, entity id and actor
+func (a *AuditController) listHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+	entries, paging, err := a.auditUC.List(dto.AuditRequest{
+		Entity:   c.Query("entity"),
+		EntityId: c.Query("id"),
+		ActorId:  c.Query("actor"),
+		Page:     page,
+		Size:     size,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully get audit log",
+		"data":    entries,
+		"paging":  paging,
+	})
+}
+
+func (a *AuditController) Route() {
+	a.rg.GET("/audit", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAuditRead), a.listHandler)
+}
+
+func NewAuditController(auditUC usecase.AuditUsecase, rg *gin.RouterGroup) *AuditController {
+	return &AuditController{
+		auditUC: auditUC,
+		rg:      rg,
+	}
+}
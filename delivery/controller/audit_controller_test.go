@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditControllerSuite struct {
+	suite.Suite
+	usecase *usecasemock.AuditUsecaseMock
+	router  *gin.Engine
+}
+
+func (suite *AuditControllerSuite) SetupTest() {
+	suite.usecase = new(usecasemock.AuditUsecaseMock)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+	NewAuditController(suite.usecase, suite.router.Group("/api/v1")).Route()
+}
+
+func TestAuditControllerSuite(t *testing.T) {
+	suite.Run(t, new(AuditControllerSuite))
+}
+
+func (suite *AuditControllerSuite) TestList_Success() {
+	suite.usecase.On("List", dto.AuditRequest{Entity: model.AuditEntityAsset, EntityId: "a-1", Page: 2, Size: 5}).
+		Return([]model.AuditLog{{Id: "1", Entity: model.AuditEntityAsset, EntityId: "a-1", Action: model.AuditActionUpdate}},
+			dto.Paging{Page: 2, Size: 5, TotalRows: 6, TotalPages: 2}, nil)
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/audit?entity=asset&id=a-1&page=2&size=5", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	assert.Contains(suite.T(), record.Body.String(), `"entity_id":"a-1"`)
+}
+
+func (suite *AuditControllerSuite) TestList_BadEntity() {
+	suite.usecase.On("List", dto.AuditRequest{Entity: "planet", Page: 1, Size: 10}).
+		Return([]model.AuditLog(nil), dto.Paging{}, exception.BadRequestErr("unknown entity planet"))
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/audit?entity=planet", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusBadRequest, record.Code)
+}
+
+func (suite *AuditControllerSuite) TestList_Forbidden() {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/audit", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAssetManager))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "List")
+}
@@ -0,0 +1,21 @@
+package controller
+
+import (
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/util/helper"
+)
+
+const testUserID = "test-user"
+
+// bearerToken sign a token for the test user with the given role
+func bearerToken(role string) string {
+	token, err := helper.GenerateJWT(model.UserCredentials{
+		ID:    testUserID,
+		Email: "tester@mail.com",
+		Role:  role,
+	}, "")
+	if err != nil {
+		panic(err)
+	}
+	return "Bearer " + token
+}
@@ -4,6 +4,7 @@ import (
 	"final-project-enigma-clean/delivery/middleware"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/usecase"
+	"final-project-enigma-clean/util/helper"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
@@ -23,7 +24,7 @@ func (cc *CategoryController) createHandlerCategory(c *gin.Context) {
 		return
 	}
 	// category.Id = helper.GenerateUUID()
-	err := cc.categoryUC.CreateNew(category)
+	err := cc.categoryUC.CreateNew(c.GetString("user_id"), category)
 	if err != nil {
 		c.Error(err)
 		return
@@ -34,12 +35,31 @@ func (cc *CategoryController) createHandlerCategory(c *gin.Context) {
 	c.JSON(201, response)
 }
 func (cc *CategoryController) listHandlerCategory(c *gin.Context) {
+	asReport, err := wantsReport(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
-	category, err := cc.categoryUC.FindAll()
+	var category []model.Category
+	if withDeleted {
+		category, err = cc.categoryUC.FindAllWithDeleted()
+	} else {
+		category, err = cc.categoryUC.FindAll()
+	}
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	if asReport {
+		sendReport(c, "categories", helper.CategorySheet(category))
+		return
+	}
 	response := gin.H{
 		"message": "successfully get category",
 		"data":    category,
@@ -69,7 +89,7 @@ func (cc *CategoryController) updateHandlerCategory(c *gin.Context) {
 		})
 		return
 	}
-	err := cc.categoryUC.Update(category)
+	err := cc.categoryUC.Update(c.GetString("user_id"), category)
 	if err != nil {
 		c.Error(err)
 		return
@@ -80,7 +100,7 @@ func (cc *CategoryController) updateHandlerCategory(c *gin.Context) {
 }
 func (cc *CategoryController) deleteHandlerCategory(c *gin.Context) {
 	id := c.Param("id")
-	if err := cc.categoryUC.Delete(id); err != nil {
+	if err := cc.categoryUC.Delete(c.GetString("user_id"), id); err != nil {
 		c.Error(err)
 		return
 	}
@@ -89,12 +109,24 @@ func (cc *CategoryController) deleteHandlerCategory(c *gin.Context) {
 		"message": message,
 	})
 }
+func (cc *CategoryController) restoreHandlerCategory(c *gin.Context) {
+	if err := cc.categoryUC.Restore(c.GetString("user_id"), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	response := gin.H{
+		"message": "successfully restore category",
+	}
+	c.JSON(200, response)
+}
+
 func (cc *CategoryController) Route() {
-	cc.rg.POST("/categories", middleware.AuthMiddleware(), cc.createHandlerCategory)
-	cc.rg.GET("/categories", middleware.AuthMiddleware(), cc.listHandlerCategory)
-	cc.rg.GET("/categories/:id", middleware.AuthMiddleware(), cc.getByIdteHandlerCategory)
-	cc.rg.PUT("/categories", middleware.AuthMiddleware(), cc.updateHandlerCategory)
-	cc.rg.DELETE("/categories/:id", middleware.AuthMiddleware(), cc.deleteHandlerCategory)
+	cc.rg.POST("/categories", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermCategoryWrite), cc.createHandlerCategory)
+	cc.rg.GET("/categories", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermCategoryRead), cc.listHandlerCategory)
+	cc.rg.GET("/categories/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermCategoryRead), cc.getByIdteHandlerCategory)
+	cc.rg.PUT("/categories", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermCategoryWrite), cc.updateHandlerCategory)
+	cc.rg.DELETE("/categories/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermCategoryWrite), cc.deleteHandlerCategory)
+	cc.rg.POST("/categories/:id/restore", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermRestore), cc.restoreHandlerCategory)
 }
 
 func NewCategoryController(categoryUC usecase.CategoryUsecase, rg *gin.RouterGroup) *CategoryController {
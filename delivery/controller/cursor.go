@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model/dto"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cursorRequest read the cursor and limit of a listing,
+// ok is false when neither is given and the listing keep its page and size
+func cursorRequest(c *gin.Context) (request dto.CursorRequest, ok bool, err error) {
+	cursor, hasCursor := c.GetQuery("cursor")
+	limit, hasLimit := c.GetQuery("limit")
+	if !hasCursor && !hasLimit {
+		return dto.CursorRequest{}, false, nil
+	}
+
+	request.Cursor = cursor
+	if limit != "" {
+		if request.Limit, err = strconv.Atoi(limit); err != nil {
+			return dto.CursorRequest{}, true, exception.BadRequestErr("limit must be a number")
+		}
+	}
+	if request.IncludeDeleted, err = includeDeleted(c); err != nil {
+		return dto.CursorRequest{}, true, err
+	}
+	return request, true, nil
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/usecase"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailOutboxController struct {
+	outboxUC usecase.EmailOutboxUsecase
+	rg       *gin.RouterGroup
+}
+
+// list emails that used every attempt
+func (e *EmailOutboxController) listFailedHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "5"))
+	emails, paging, err := e.outboxUC.ListFailed(dto.PageRequest{
+		Page: page,
+		Size: size,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully get failed emails",
+		"data":    emails,
+		"paging":  paging,
+	})
+}
+
+func (e *EmailOutboxController) resendHandler(c *gin.Context) {
+	if err := e.outboxUC.Resend(c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "email queued for resend",
+	})
+}
+
+func (e *EmailOutboxController) Route() {
+	e.rg.GET("/email-outbox/failed", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermEmailManage), e.listFailedHandler)
+	e.rg.POST("/email-outbox/:id/resend", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermEmailManage), e.resendHandler)
+}
+
+func NewEmailOutboxController(outboxUC usecase.EmailOutboxUsecase, rg *gin.RouterGroup) *EmailOutboxController {
+	return &EmailOutboxController{
+		outboxUC: outboxUC,
+		rg:       rg,
+	}
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EmailOutboxControllerSuite struct {
+	suite.Suite
+	usecase *usecasemock.EmailOutboxUsecaseMock
+	router  *gin.Engine
+}
+
+func (suite *EmailOutboxControllerSuite) SetupTest() {
+	suite.usecase = new(usecasemock.EmailOutboxUsecaseMock)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+	NewEmailOutboxController(suite.usecase, suite.router.Group("/api/v1")).Route()
+}
+
+func TestEmailOutboxControllerSuite(t *testing.T) {
+	suite.Run(t, new(EmailOutboxControllerSuite))
+}
+
+func (suite *EmailOutboxControllerSuite) TestListFailed_Success() {
+	suite.usecase.On("ListFailed", dto.PageRequest{Page: 1, Size: 5}).
+		Return([]model.EmailOutbox{{Id: "mail-1", Status: model.OutboxStatusFailed}}, dto.Paging{Page: 1, Size: 5, TotalRows: 1, TotalPages: 1}, nil)
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/email-outbox/failed", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	assert.Contains(suite.T(), record.Body.String(), "mail-1")
+	//the rendered body is not exposed in the listing
+	assert.NotContains(suite.T(), record.Body.String(), "html")
+}
+
+func (suite *EmailOutboxControllerSuite) TestResend_Success() {
+	suite.usecase.On("Resend", "mail-1").Return(nil)
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/email-outbox/mail-1/resend", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	suite.usecase.AssertExpectations(suite.T())
+}
+
+func (suite *EmailOutboxControllerSuite) TestResend_NotFound() {
+	suite.usecase.On("Resend", "mail-2").Return(exception.NotFoundErr("failed email not found"))
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/email-outbox/mail-2/resend", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusNotFound, record.Code)
+}
+
+func (suite *EmailOutboxControllerSuite) TestResend_Forbidden() {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/email-outbox/mail-1/resend", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAssetManager))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "Resend", "mail-1")
+}
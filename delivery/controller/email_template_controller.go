@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailTemplateController struct {
+	templateUC usecase.EmailTemplateUsecase
+	rg         *gin.RouterGroup
+}
+
+func (e *EmailTemplateController) listHandler(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"message": "successfully get email templates",
+		"data":    e.templateUC.Events(),
+	})
+}
+
+// render the template as html so admin can open it in the browser
+func (e *EmailTemplateController) previewHandler(c *gin.Context) {
+	msg, err := e.templateUC.Preview(c.Param("event"), c.Query("locale"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("X-Email-Subject", msg.Subject)
+	c.Data(200, "text/html; charset=utf-8", []byte(msg.HTML))
+}
+
+func (e *EmailTemplateController) Route() {
+	e.rg.GET("/email-templates", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermEmailManage), e.listHandler)
+	e.rg.GET("/email-templates/:event/preview", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermEmailManage), e.previewHandler)
+}
+
+func NewEmailTemplateController(templateUC usecase.EmailTemplateUsecase, rg *gin.RouterGroup) *EmailTemplateController {
+	return &EmailTemplateController{
+		templateUC: templateUC,
+		rg:         rg,
+	}
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/util/mailer"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EmailTemplateControllerSuite struct {
+	suite.Suite
+	usecase *usecasemock.EmailTemplateUsecaseMock
+	router  *gin.Engine
+}
+
+func (suite *EmailTemplateControllerSuite) SetupTest() {
+	suite.usecase = new(usecasemock.EmailTemplateUsecaseMock)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+	NewEmailTemplateController(suite.usecase, suite.router.Group("/api/v1")).Route()
+}
+
+func TestEmailTemplateControllerSuite(t *testing.T) {
+	suite.Run(t, new(EmailTemplateControllerSuite))
+}
+
+func (suite *EmailTemplateControllerSuite) TestPreview_Success() {
+	suite.usecase.On("Preview", "login_otp", "id").
+		Return(mailer.Message{Subject: "Masuk ke Stephanie Project", HTML: "<html>123456</html>"}, nil)
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/email-templates/login_otp/preview?locale=id", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	assert.Equal(suite.T(), "<html>123456</html>", record.Body.String())
+	assert.Contains(suite.T(), record.Header().Get("Content-Type"), "text/html")
+}
+
+func (suite *EmailTemplateControllerSuite) TestPreview_UnknownEvent() {
+	suite.usecase.On("Preview", "birthday", "").
+		Return(mailer.Message{}, exception.BadRequestErr("event birthday is not valid"))
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/email-templates/birthday/preview", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusBadRequest, record.Code)
+}
+
+func (suite *EmailTemplateControllerSuite) TestPreview_Forbidden() {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/email-templates/login_otp/preview", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAssetManager))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoanRequestController struct {
+	loanRequestUC usecase.LoanRequestUsecase
+	rg            *gin.RouterGroup
+}
+
+// create a draft loan request, it is only seen by the approvers once submitted
+func (l *LoanRequestController) createHandler(c *gin.Context) {
+	var payload dto.LoanRequestRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.Error(exception.BadRequestErr(err.Error()))
+		return
+	}
+	request, err := l.loanRequestUC.Create(c.GetString("user_id"), payload)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(201, gin.H{
+		"message": "successfully create loan request",
+		"data":    request,
+	})
+}
+
+func (l *LoanRequestController) updateHandler(c *gin.Context) {
+	var payload dto.LoanRequestRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.Error(exception.BadRequestErr(err.Error()))
+		return
+	}
+	if err := l.loanRequestUC.Update(c.GetString("user_id"), c.Param("id"), payload); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully update loan request",
+	})
+}
+
+func (l *LoanRequestController) listHandler(c *gin.Context) {
+	requests, err := l.loanRequestUC.FindAll(c.GetString("user_id"), c.GetString("role"), dto.LoanRequestQuery{
+		Status: c.Query("status"),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully get loan requests",
+		"data":    requests,
+	})
+}
+
+func (l *LoanRequestController) findHandler(c *gin.Context) {
+	request, err := l.loanRequestUC.FindById(c.GetString("user_id"), c.GetString("role"), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully get loan request",
+		"data":    request,
+	})
+}
+
+func (l *LoanRequestController) submitHandler(c *gin.Context) {
+	if err := l.loanRequestUC.Submit(c.GetString("user_id"), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully submit loan request",
+	})
+}
+
+func (l *LoanRequestController) approveHandler(c *gin.Context) {
+	payload, ok := bindDecision(c)
+	if !ok {
+		return
+	}
+	if err := l.loanRequestUC.Approve(c.GetString("user_id"), c.Param("id"), payload); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully approve loan request",
+	})
+}
+
+func (l *LoanRequestController) rejectHandler(c *gin.Context) {
+	payload, ok := bindDecision(c)
+	if !ok {
+		return
+	}
+	if err := l.loanRequestUC.Reject(c.GetString("user_id"), c.Param("id"), payload); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully reject loan request",
+	})
+}
+
+// hand the approved items over, the loan is created from the request
+func (l *LoanRequestController) issueHandler(c *gin.Context) {
+	var payload dto.LoanIssueRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.Error(exception.BadRequestErr(err.Error()))
+			return
+		}
+	}
+	request, err := l.loanRequestUC.Issue(c.GetString("user_id"), c.Param("id"), payload)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully issue loan request",
+		"data":    request,
+	})
+}
+
+func (l *LoanRequestController) returnHandler(c *gin.Context) {
+	var payload dto.LoanReturnRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.Error(exception.BadRequestErr(err.Error()))
+			return
+		}
+	}
+	if err := l.loanRequestUC.Return(c.GetString("user_id"), c.Param("id"), payload); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully return loan request",
+	})
+}
+
+func (l *LoanRequestController) closeHandler(c *gin.Context) {
+	payload, ok := bindDecision(c)
+	if !ok {
+		return
+	}
+	if err := l.loanRequestUC.Close(c.GetString("user_id"), c.GetString("role"), c.Param("id"), payload); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully close loan request",
+	})
+}
+
+// bindDecision read the optional comment of a decision
+func bindDecision(c *gin.Context) (dto.LoanDecisionRequest, bool) {
+	var payload dto.LoanDecisionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.Error(exception.BadRequestErr(err.Error()))
+			return payload, false
+		}
+	}
+	return payload, true
+}
+
+func (l *LoanRequestController) Route() {
+	l.rg.POST("/loan-requests", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanRequest), l.createHandler)
+	l.rg.GET("/loan-requests", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanRequest), l.listHandler)
+	l.rg.GET("/loan-requests/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanRequest), l.findHandler)
+	l.rg.PUT("/loan-requests/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanRequest), l.updateHandler)
+	l.rg.POST("/loan-requests/:id/submit", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanRequest), l.submitHandler)
+	l.rg.POST("/loan-requests/:id/close", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanRequest), l.closeHandler)
+	l.rg.POST("/loan-requests/:id/approve", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanApprove), l.approveHandler)
+	l.rg.POST("/loan-requests/:id/reject", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanApprove), l.rejectHandler)
+	l.rg.POST("/loan-requests/:id/issue", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetWrite), l.issueHandler)
+	l.rg.POST("/loan-requests/:id/return", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetWrite), l.returnHandler)
+}
+
+func NewLoanRequestController(loanRequestUC usecase.LoanRequestUsecase, rg *gin.RouterGroup) *LoanRequestController {
+	return &LoanRequestController{
+		loanRequestUC: loanRequestUC,
+		rg:            rg,
+	}
+}
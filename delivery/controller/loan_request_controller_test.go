@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoanRequestControllerSuite struct {
+	suite.Suite
+	usecase *usecasemock.LoanRequestUsecaseMock
+	router  *gin.Engine
+}
+
+func (suite *LoanRequestControllerSuite) SetupTest() {
+	suite.usecase = new(usecasemock.LoanRequestUsecaseMock)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+	NewLoanRequestController(suite.usecase, suite.router.Group("/api/v1")).Route()
+}
+
+func TestLoanRequestControllerSuite(t *testing.T) {
+	suite.Run(t, new(LoanRequestControllerSuite))
+}
+
+func (suite *LoanRequestControllerSuite) serve(method, path, role string, body io.Reader) *httptest.ResponseRecorder {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(method, "/api/v1"+path, body)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(role))
+	suite.router.ServeHTTP(record, request)
+	return record
+}
+
+func (suite *LoanRequestControllerSuite) TestCreate_Success() {
+	payload := dto.LoanRequestRequest{NikStaff: "1001", Duration: 3, Detail: []dto.LoanRequestDetailRequest{{IdAsset: "a1", TotalItem: 2}}}
+	suite.usecase.On("Create", testUserID, payload).Return(model.LoanRequest{Id: "r1", Status: model.LoanRequestDraft}, nil)
+
+	record := suite.serve(http.MethodPost, "/loan-requests", model.RoleViewer,
+		strings.NewReader(`{"nik_staff":"1001","duration":3,"detail":[{"id_asset":"a1","total_item":2}]}`))
+	assert.Equal(suite.T(), http.StatusCreated, record.Code)
+}
+
+func (suite *LoanRequestControllerSuite) TestList_Role() {
+	suite.usecase.On("FindAll", testUserID, model.RoleViewer, dto.LoanRequestQuery{Status: model.LoanRequestSubmitted}).Return([]model.LoanRequest{}, nil)
+
+	record := suite.serve(http.MethodGet, "/loan-requests?status=submitted", model.RoleViewer, nil)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
+
+func (suite *LoanRequestControllerSuite) TestApprove_Forbidden() {
+	record := suite.serve(http.MethodPost, "/loan-requests/r1/approve", model.RoleViewer, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "Approve", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *LoanRequestControllerSuite) TestApprove_WithoutBody() {
+	suite.usecase.On("Approve", testUserID, "r1", dto.LoanDecisionRequest{}).Return(nil)
+
+	record := suite.serve(http.MethodPost, "/loan-requests/r1/approve", model.RoleAssetManager, nil)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
+
+func (suite *LoanRequestControllerSuite) TestReject_Failed() {
+	suite.usecase.On("Reject", testUserID, "r1", dto.LoanDecisionRequest{}).Return(exception.BadRequestErr("comment is required to reject"))
+
+	record := suite.serve(http.MethodPost, "/loan-requests/r1/reject", model.RoleAdmin, strings.NewReader(`{}`))
+	assert.Equal(suite.T(), http.StatusBadRequest, record.Code)
+}
+
+func (suite *LoanRequestControllerSuite) TestClose_Role() {
+	suite.usecase.On("Close", testUserID, model.RoleViewer, "r1", dto.LoanDecisionRequest{Comment: "not needed"}).Return(nil)
+
+	record := suite.serve(http.MethodPost, "/loan-requests/r1/close", model.RoleViewer, strings.NewReader(`{"comment":"not needed"}`))
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
+
+func (suite *LoanRequestControllerSuite) TestIssue_Forbidden() {
+	record := suite.serve(http.MethodPost, "/loan-requests/r1/issue", model.RoleViewer, nil)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "Issue", mock.Anything, mock.Anything, mock.Anything)
+}
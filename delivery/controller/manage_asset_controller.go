@@ -1,12 +1,17 @@
 package controller
 
 import (
+	"final-project-enigma-clean/delivery/middleware"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"final-project-enigma-clean/usecase"
+	"final-project-enigma-clean/util/helper"
+	"final-project-enigma-clean/util/spreadsheet"
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gookit/slog"
 )
 
 type ManageAssetController struct {
@@ -16,25 +21,37 @@ type ManageAssetController struct {
 
 // show assets handler
 func (m *ManageAssetController) ShowAllAssetHandler(c *gin.Context) {
+	asReport, err := wantsReport(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
 	mAssets, err := m.manageAssetUC.ShowAllAsset()
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	//a report put the loans and their detail lines on two sheets
+	if asReport {
+		sendReport(c, "transactions", helper.TransactionSheets(mAssets)...)
+		return
+	}
 	c.JSON(200, gin.H{"Message": "Success", "Data": mAssets})
 }
 
-//create a new asset handler
-
+// create a new asset handler
+// the loan is committed at once without approval, this is the admin override of the loan request workflow
 func (m *ManageAssetController) CreateNewAssetHandler(c *gin.Context) {
-
 	var manageAssetReq dto.ManageAssetRequest
 	if err := c.ShouldBindJSON(&manageAssetReq); err != nil {
 		c.AbortWithStatusJSON(400, gin.H{"Error": "Bad JSON Format", "error": err.Error()})
 		return
 	}
 
+	//the issuing user always come from the token, not from the body
+	manageAssetReq.IdUser = c.GetString("user_id")
+
 	if err := m.manageAssetUC.CreateTransaction(manageAssetReq); err != nil {
 		c.Error(err)
 		return
@@ -44,6 +61,25 @@ func (m *ManageAssetController) CreateNewAssetHandler(c *gin.Context) {
 
 }
 
+// return (check-in) handler
+func (m *ManageAssetController) ReturnAssetHandler(c *gin.Context) {
+
+	var returnReq dto.ReturnAssetRequest
+	if err := c.ShouldBindJSON(&returnReq); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"Error": "Bad JSON Format", "error": err.Error()})
+		return
+	}
+	returnReq.IdManageAsset = c.Param("id")
+	returnReq.IdUser = c.GetString("user_id")
+
+	if err := m.manageAssetUC.ReturnTransaction(returnReq); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"Message": "Success"})
+}
+
 func (m *ManageAssetController) FindByIdTransaction(c *gin.Context) {
 	id := c.Param("id")
 
@@ -85,12 +121,79 @@ func (m *ManageAssetController) DownloadAssetsHandler(c *gin.Context) {
 	}
 	c.Data(http.StatusOK, "text/csv", csvData)
 }
+
+// ReceiptHandler send the printable handover form of a loan
+func (m *ManageAssetController) ReceiptHandler(c *gin.Context) {
+	pdf, err := m.manageAssetUC.Receipt(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%s.pdf"`, c.Param("id")))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// ExportHandler stream one row per detail line of the loans as csv or xlsx,
+// filtered by the submission date from and to and by nik_staff
+func (m *ManageAssetController) ExportHandler(c *gin.Context) {
+	format, err := exportFormat(c, "csv", "xlsx")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	filter := dto.TransactionExportRequest{NikStaff: c.Query("nik_staff")}
+	if filter.From, err = queryDate(c, "from"); err != nil {
+		c.Error(err)
+		return
+	}
+	if filter.To, err = queryDate(c, "to"); err != nil {
+		c.Error(err)
+		return
+	}
+
+	setAttachment(c, "transactions", format)
+	writer, err := spreadsheet.NewRowWriter(c.Writer, format, "transactions")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer writer.Discard()
+	if err = writer.Write(helper.TransactionDetailColumns); err != nil {
+		c.Error(err)
+		return
+	}
+	err = m.manageAssetUC.ExportDetails(filter, func(row model.TransactionDetailRow) error {
+		return writer.Write(helper.TransactionDetailRecord(row))
+	})
+	if err != nil {
+		exportFailed(c, "transaction", err)
+		return
+	}
+	if err = writer.Close(); err != nil {
+		slog.Errorf("failed to finish transaction export: %v", err)
+	}
+}
+
+// loans past the return date with the days overdue
+func (m *ManageAssetController) OverdueHandler(c *gin.Context) {
+	overdue, err := m.manageAssetUC.FindOverdue()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{"Message": "Success", "Data": overdue})
+}
+
 func (m *ManageAssetController) Route() {
-	m.g.GET("/manage-assets/show-all", m.ShowAllAssetHandler)
-	m.g.POST("/manage-assets/create-new", m.CreateNewAssetHandler)
-	m.g.GET("/manage-assets/find/:id", m.FindByIdTransaction)
-	m.g.POST("/manage-assets/find-asset", m.FindByName)
-	m.g.GET("/manage-assets/download/list-assets", m.DownloadAssetsHandler)
+	m.g.GET("/manage-assets/show-all", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetRead), m.ShowAllAssetHandler)
+	m.g.POST("/manage-assets/create-new", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermLoanDirect), m.CreateNewAssetHandler)
+	m.g.GET("/manage-assets/find/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetRead), m.FindByIdTransaction)
+	m.g.POST("/manage-assets/find-asset", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetRead), m.FindByName)
+	m.g.GET("/manage-assets/download/list-assets", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetRead), m.DownloadAssetsHandler)
+	m.g.GET("/manage-assets/export", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetRead), m.ExportHandler)
+	m.g.GET("/manage-assets/overdue", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetRead), m.OverdueHandler)
+	m.g.GET("/manage-assets/:id/receipt.pdf", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetRead), m.ReceiptHandler)
+	m.g.POST("/manage-assets/:id/return", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermManageAssetWrite), m.ReturnAssetHandler)
 }
 
 func NewManageAssetController(maUC usecase.ManageAssetUsecase, g *gin.RouterGroup) *ManageAssetController {
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -15,6 +18,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/suite"
+	"github.com/xuri/excelize/v2"
 )
 
 type ManageAssetsControllerSuite struct {
@@ -67,6 +71,7 @@ func (suite *ManageAssetsControllerSuite) TestShowAllAssetsSuccess() {
 	record := httptest.NewRecorder()
 	request, err := http.NewRequest("GET", "/api/v1/manage-assets/show-all", nil)
 	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
 
 	suite.r.ServeHTTP(record, request)
 	assert.Equal(suite.T(), http.StatusOK, record.Code)
@@ -113,7 +118,7 @@ func (suite *ManageAssetsControllerSuite) TestShowAllAssetsSuccess() {
 func (suite *ManageAssetsControllerSuite) TestCreateNewManageAssetsSuccess() {
 	mockData := dto.ManageAssetRequest{
 		Id:                   "123213",
-		IdUser:               "124214",
+		IdUser:               testUserID,
 		NikStaff:             "12312312",
 		SubmisstionDate:      time.Time{},
 		ReturnDate:           time.Time{},
@@ -132,6 +137,7 @@ func (suite *ManageAssetsControllerSuite) TestCreateNewManageAssetsSuccess() {
 
 	request, err := http.NewRequest("POST", "/api/v1/manage-assets/create-new", bytes.NewBuffer(marshal))
 	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
 
 	suite.r.ServeHTTP(record, request)
 	response := record.Body.Bytes()
@@ -146,6 +152,7 @@ func (suite *ManageAssetsControllerSuite) TestCreate_ErrorJSON() {
 
 	record := httptest.NewRecorder()
 	request, err := http.NewRequest(http.MethodPost, "/api/v1/manage-assets/create-new", nil)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
 	suite.r.ServeHTTP(record, request)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), http.StatusBadRequest, record.Code)
@@ -155,7 +162,7 @@ func (suite *ManageAssetsControllerSuite) TestCreate_ErrorJSON() {
 func (suite *ManageAssetsControllerSuite) TestCreate_Failed() {
 	mockData := dto.ManageAssetRequest{
 		Id:                   "123213",
-		IdUser:               "124214",
+		IdUser:               testUserID,
 		NikStaff:             "12312312",
 		SubmisstionDate:      time.Time{},
 		ReturnDate:           time.Time{},
@@ -173,6 +180,7 @@ func (suite *ManageAssetsControllerSuite) TestCreate_Failed() {
 
 	req, err := http.NewRequest("POST", "/api/v1/manage-assets/create-new", bytes.NewBuffer(marshal))
 	assert.NoError(suite.T(), err)
+	req.Header.Set("Authorization", bearerToken(model.RoleAdmin))
 
 	//serve http
 	suite.r.ServeHTTP(record, req)
@@ -239,6 +247,7 @@ func (suite *ManageAssetsControllerSuite) TestFindById_Success() {
 
 	req, err := http.NewRequest("GET", "/api/v1/manage-assets/find/13", nil)
 	assert.NoError(suite.T(), err)
+	req.Header.Set("Authorization", bearerToken(model.RoleAdmin))
 
 	suite.r.ServeHTTP(record, req)
 	assert.Equal(suite.T(), 200, record.Code)
@@ -254,6 +263,7 @@ func (suite *ManageAssetsControllerSuite) TestFindTXById_Fail() {
 
 	request, err := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/find/13", nil)
 	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
 
 	suite.r.ServeHTTP(record, request)
 	assert.Equal(suite.T(), http.StatusInternalServerError, record.Code)
@@ -285,6 +295,7 @@ func (suite *ManageAssetsControllerSuite) TestFindTXByName_Success() {
 
 	req, err := http.NewRequest("POST", "/api/v1/manage-assets/find-asset", bytes.NewBuffer(marshal))
 	assert.NoError(suite.T(), err)
+	req.Header.Set("Authorization", bearerToken(model.RoleAdmin))
 
 	suite.r.ServeHTTP(recorder, req)
 	resp := recorder.Body.Bytes()
@@ -296,3 +307,225 @@ func (suite *ManageAssetsControllerSuite) TestFindTXByName_Success() {
 	assert.Equal(suite.T(), 200, recorder.Code)
 	assert.Equal(suite.T(), mockData, response["Data"])
 }
+
+// return transaction success
+func (suite *ManageAssetsControllerSuite) TestReturn_Success() {
+	mockData := dto.ReturnAssetRequest{
+		IdManageAsset: "13",
+		//not part of the body, the controller take it from the token
+		IdUser: testUserID,
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{
+			IdDetail:  "1",
+			TotalItem: 1,
+		}},
+	}
+
+	suite.usecase.On("ReturnTransaction", mockData).Return(nil)
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+
+	marshal, err := json.Marshal(mockData)
+	assert.NoError(suite.T(), err)
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/manage-assets/13/return", bytes.NewBuffer(marshal))
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.r.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	suite.usecase.AssertExpectations(suite.T())
+}
+
+// return transaction with invalid json
+func (suite *ManageAssetsControllerSuite) TestReturn_ErrorJSON() {
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/manage-assets/13/return", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.r.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusBadRequest, record.Code)
+}
+
+// request without token is rejected
+func (suite *ManageAssetsControllerSuite) TestShowAll_Unauthorized() {
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/show-all", nil)
+	assert.NoError(suite.T(), err)
+
+	suite.r.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusUnauthorized, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "ShowAllAsset")
+}
+
+// only an admin can create a transaction without a loan request
+func (suite *ManageAssetsControllerSuite) TestCreate_Forbidden() {
+	suite.controller.Route()
+
+	for _, role := range []string{model.RoleViewer, model.RoleAssetManager} {
+		record := httptest.NewRecorder()
+		marshal, err := json.Marshal(dto.ManageAssetRequest{NikStaff: "12312312", Duration: 2})
+		assert.NoError(suite.T(), err)
+
+		request, err := http.NewRequest(http.MethodPost, "/api/v1/manage-assets/create-new", bytes.NewBuffer(marshal))
+		assert.NoError(suite.T(), err)
+		request.Header.Set("Authorization", bearerToken(role))
+
+		suite.r.ServeHTTP(record, request)
+		assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	}
+	suite.usecase.AssertNotCalled(suite.T(), "CreateTransaction", mock.Anything)
+}
+
+// viewer can still read transaction
+func (suite *ManageAssetsControllerSuite) TestShowAll_Viewer() {
+	suite.usecase.On("ShowAllAsset").Return([]model.ManageAsset{}, nil)
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/show-all", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleViewer))
+
+	suite.r.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
+
+// revoked login is rejected even though the token signature is valid
+func (suite *ManageAssetsControllerSuite) TestShowAll_RevokedToken() {
+	middleware.UseTokenGuard(func(userID, familyID string) error {
+		return errors.New("token has been revoked")
+	})
+	defer middleware.UseTokenGuard(nil)
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/show-all", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.r.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusUnauthorized, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "ShowAllAsset")
+}
+
+func (suite *ManageAssetsControllerSuite) TestOverdue_Success() {
+	mockData := []model.OverdueTransaction{{ManageAsset: model.ManageAsset{Id: "1"}, DaysOverdue: 3}}
+	suite.usecase.On("FindOverdue").Return(mockData, nil)
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/overdue", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleViewer))
+
+	suite.r.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	assert.Contains(suite.T(), record.Body.String(), `"days_overdue":3`)
+}
+
+func (suite *ManageAssetsControllerSuite) TestOverdue_Failed() {
+	suite.usecase.On("FindOverdue").Return(nil, errors.New("failed"))
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	NewManageAssetController(suite.usecase, r.Group("/api/v1")).Route()
+
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/overdue", nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleViewer))
+
+	r.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusInternalServerError, record.Code)
+}
+
+func (suite *ManageAssetsControllerSuite) TestExportHandler_Csv() {
+	submitted := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	filter := dto.TransactionExportRequest{From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), NikStaff: "1"}
+	suite.usecase.On("ExportDetails", filter, mock.Anything).Return([]model.TransactionDetailRow{{
+		TransactionId: "t1", SubmissionDate: submitted, ReturnDate: submitted.AddDate(0, 0, 7), NikStaff: "1", StaffName: "Budi",
+		UserName: "Admin", AssetId: "a1", AssetName: "Laptop", TotalItem: 2, Status: "ready",
+	}}, nil)
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/export?from=2023-01-01&nik_staff=1", nil)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+	suite.r.ServeHTTP(record, request)
+
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	assert.Equal(suite.T(), "text/csv", record.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "transaction_id,submission_date,return_date,actual_return_date,nik_staff,staff_name,user_name,asset_id,asset_name,total_item,total_returned,status,returned_at\n"+
+		"t1,2023-01-10,2023-01-17,,1,Budi,Admin,a1,Laptop,2,0,ready,\n", record.Body.String())
+}
+
+func (suite *ManageAssetsControllerSuite) TestExportHandler_Failed() {
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	NewManageAssetController(suite.usecase, router.Group("/api/v1")).Route()
+	suite.usecase.On("ExportDetails", dto.TransactionExportRequest{}, mock.Anything).Return(nil, errors.New("db down"))
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/export?format=xlsx", nil)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+	router.ServeHTTP(record, request)
+
+	assert.Equal(suite.T(), http.StatusInternalServerError, record.Code)
+	assert.Empty(suite.T(), record.Header().Get("Content-Disposition"))
+}
+
+func (suite *ManageAssetsControllerSuite) TestShowAllAssetHandler_XlsxReport() {
+	suite.usecase.On("ShowAllAsset").Return([]model.ManageAsset{{
+		Id:     "t1",
+		Staff:  model.Staff{Nik_Staff: "1", Name: "Budi"},
+		Detail: []model.ManageDetailAsset{{Asset: model.Asset{Id: "a1", Name: "Laptop"}, TotalItem: 2}, {Asset: model.Asset{Id: "a2", Name: "Monitor"}, TotalItem: 1}},
+	}}, nil)
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/show-all?format=xlsx", nil)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+	suite.r.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+
+	file, err := excelize.OpenReader(record.Body)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"transactions", "details"}, file.GetSheetList())
+	details, _ := file.GetRows("details")
+	assert.Len(suite.T(), details, 3)
+	total, _ := file.GetCellValue("transactions", "H2")
+	assert.Equal(suite.T(), "3", total)
+}
+
+func (suite *ManageAssetsControllerSuite) TestReceiptHandler_Success() {
+	suite.usecase.On("Receipt", "1").Return([]byte("%PDF-1.3"), nil)
+	suite.controller.Route()
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/1/receipt.pdf", nil)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+	suite.r.ServeHTTP(record, request)
+
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	assert.Equal(suite.T(), "application/pdf", record.Header().Get("Content-Type"))
+	assert.Equal(suite.T(), "%PDF-1.3", record.Body.String())
+}
+
+func (suite *ManageAssetsControllerSuite) TestReceiptHandler_NotFound() {
+	router := gin.New()
+	router.Use(middleware.ErrorHandler())
+	NewManageAssetController(suite.usecase, router.Group("/api/v1")).Route()
+	suite.usecase.On("Receipt", "1").Return(nil, exception.NotFoundErr("transaction not found"))
+
+	record := httptest.NewRecorder()
+	request, _ := http.NewRequest(http.MethodGet, "/api/v1/manage-assets/1/receipt.pdf", nil)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+	router.ServeHTTP(record, request)
+
+	assert.Equal(suite.T(), http.StatusNotFound, record.Code)
+}
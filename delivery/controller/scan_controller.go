@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/usecase"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scanPermissions is the permission each intent need on top of reading assets,
+// a check-out is a direct loan so it skip the loan request approval like create-new
+var scanPermissions = map[string]string{
+	dto.ScanCheckOut:  model.PermLoanDirect,
+	dto.ScanCheckIn:   model.PermManageAssetWrite,
+	dto.ScanStocktake: model.PermAssetWrite,
+}
+
+type ScanController struct {
+	scanUC usecase.ScanUsecase
+	rg     *gin.RouterGroup
+}
+
+// scanHandler resolve a scanned code and carry out the intent
+func (s *ScanController) scanHandler(c *gin.Context) {
+	var payload dto.ScanRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.Error(exception.BadRequestErr(err.Error()))
+		return
+	}
+	if permission, ok := scanPermissions[payload.Intent]; ok && !model.HasPermission(c.GetString("role"), permission) {
+		c.Error(exception.ForbiddenErr(payload.Intent + " is not allowed for your role"))
+		return
+	}
+
+	response, err := s.scanUC.Scan(c.GetString("user_id"), payload)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, response)
+}
+
+func (s *ScanController) Route() {
+	s.rg.POST("/scan", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), s.scanHandler)
+}
+
+func NewScanController(scanUC usecase.ScanUsecase, rg *gin.RouterGroup) *ScanController {
+	return &ScanController{
+		scanUC: scanUC,
+		rg:     rg,
+	}
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"encoding/json"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ScanControllerSuite struct {
+	suite.Suite
+	usecase *usecasemock.ScanUsecaseMock
+	router  *gin.Engine
+}
+
+func (suite *ScanControllerSuite) SetupTest() {
+	suite.usecase = new(usecasemock.ScanUsecaseMock)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+	NewScanController(suite.usecase, suite.router.Group("/api/v1")).Route()
+}
+
+func TestScanControllerSuite(t *testing.T) {
+	suite.Run(t, new(ScanControllerSuite))
+}
+
+func (suite *ScanControllerSuite) scan(role, body string) *httptest.ResponseRecorder {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/scan", strings.NewReader(body))
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(role))
+	suite.router.ServeHTTP(record, request)
+	return record
+}
+
+func (suite *ScanControllerSuite) TestScan_Lookup() {
+	suite.usecase.On("Scan", testUserID, dto.ScanRequest{Code: "a1", Intent: dto.ScanLookup}).
+		Return(dto.ScanResponse{Intent: dto.ScanLookup, AssetId: "a1", Name: "Kursi", Message: "found"}, nil)
+
+	record := suite.scan(model.RoleViewer, `{"code":"a1","intent":"lookup"}`)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+
+	var response dto.ScanResponse
+	assert.NoError(suite.T(), json.Unmarshal(record.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "Kursi", response.Name)
+}
+
+func (suite *ScanControllerSuite) TestScan_IntentForbidden() {
+	record := suite.scan(model.RoleViewer, `{"code":"a1","intent":"check_out","nik_staff":"1001"}`)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "Scan", mock.Anything, mock.Anything)
+}
+
+func (suite *ScanControllerSuite) TestScan_CheckOutOverride() {
+	record := suite.scan(model.RoleAssetManager, `{"code":"a1","intent":"check_out","nik_staff":"1001"}`)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "Scan", mock.Anything, mock.Anything)
+
+	payload := dto.ScanRequest{Code: "a1", Intent: dto.ScanCheckOut, NikStaff: "1001"}
+	suite.usecase.On("Scan", testUserID, payload).Return(dto.ScanResponse{Intent: dto.ScanCheckOut, AssetId: "a1"}, nil)
+	record = suite.scan(model.RoleAdmin, `{"code":"a1","intent":"check_out","nik_staff":"1001"}`)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
+
+func (suite *ScanControllerSuite) TestScan_BindingError() {
+	record := suite.scan(model.RoleAssetManager, `{"code":`)
+	assert.Equal(suite.T(), http.StatusBadRequest, record.Code)
+}
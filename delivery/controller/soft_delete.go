@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// includeDeleted read the include_deleted filter of a listing,
+// only a role allowed to restore may see the deleted rows
+func includeDeleted(c *gin.Context) (bool, error) {
+	if c.Query("include_deleted") != "true" {
+		return false, nil
+	}
+	if !model.HasPermission(c.GetString("role"), model.PermRestore) {
+		return false, exception.ForbiddenErr("include_deleted is only allowed for admin")
+	}
+	return true, nil
+}
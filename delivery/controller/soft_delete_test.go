@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type SoftDeleteControllerSuite struct {
+	suite.Suite
+	category *usecasemock.CategoryUsecaseMock
+	staff    *usecasemock.StaffUsecaseMock
+	router   *gin.Engine
+}
+
+func (suite *SoftDeleteControllerSuite) SetupTest() {
+	suite.category = new(usecasemock.CategoryUsecaseMock)
+	suite.staff = new(usecasemock.StaffUsecaseMock)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+	rg := suite.router.Group("/api/v1")
+	NewCategoryController(suite.category, rg).Route()
+	NewStaffController(suite.staff, rg).Route()
+}
+
+func TestSoftDeleteControllerSuite(t *testing.T) {
+	suite.Run(t, new(SoftDeleteControllerSuite))
+}
+
+func (suite *SoftDeleteControllerSuite) serve(method, path, role string) *httptest.ResponseRecorder {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(method, path, nil)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(role))
+	suite.router.ServeHTTP(record, request)
+	return record
+}
+
+func (suite *SoftDeleteControllerSuite) TestRestore_Success() {
+	suite.category.On("Restore", testUserID, "1").Return(nil)
+
+	record := suite.serve(http.MethodPost, "/api/v1/categories/1/restore", model.RoleAdmin)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	suite.category.AssertExpectations(suite.T())
+}
+
+func (suite *SoftDeleteControllerSuite) TestRestore_NotDeleted() {
+	suite.staff.On("Restore", testUserID, "1").Return(exception.NotFoundErr("deleted staff not found"))
+
+	record := suite.serve(http.MethodPost, "/api/v1/staffs/1/restore", model.RoleAdmin)
+	assert.Equal(suite.T(), http.StatusNotFound, record.Code)
+}
+
+func (suite *SoftDeleteControllerSuite) TestRestore_Forbidden() {
+	record := suite.serve(http.MethodPost, "/api/v1/categories/1/restore", model.RoleAssetManager)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.category.AssertNotCalled(suite.T(), "Restore", testUserID, "1")
+}
+
+func (suite *SoftDeleteControllerSuite) TestList_IncludeDeleted() {
+	suite.category.On("FindAllWithDeleted").Return([]model.Category{{Id: "1", Name: "Kendaraan"}}, nil)
+
+	record := suite.serve(http.MethodGet, "/api/v1/categories?include_deleted=true", model.RoleAdmin)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	suite.category.AssertNotCalled(suite.T(), "FindAll")
+}
+
+func (suite *SoftDeleteControllerSuite) TestList_IncludeDeletedPaging() {
+	suite.staff.On("Paging", dto.PageRequest{Page: 1, Size: 5, IncludeDeleted: true}).Return([]model.Staff{}, dto.Paging{}, nil)
+
+	record := suite.serve(http.MethodGet, "/api/v1/staffs?include_deleted=true", model.RoleAdmin)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	suite.staff.AssertExpectations(suite.T())
+}
+
+func (suite *SoftDeleteControllerSuite) TestList_IncludeDeletedForbidden() {
+	record := suite.serve(http.MethodGet, "/api/v1/staffs?include_deleted=true", model.RoleViewer)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.staff.AssertNotCalled(suite.T(), "Paging", mock.Anything)
+}
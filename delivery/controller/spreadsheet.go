@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"bytes"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/util/report"
+	"final-project-enigma-clean/util/spreadsheet"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gookit/slog"
+)
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// uploadedRows read the rows of the csv or xlsx file sent in the file field
+func uploadedRows(c *gin.Context) ([][]string, error) {
+	upload, err := c.FormFile("file")
+	if err != nil {
+		return nil, exception.BadRequestErr("file is required")
+	}
+	file, err := upload.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rows, err := spreadsheet.ReadRows(upload.Filename, file)
+	if err != nil {
+		return nil, exception.BadRequestErr(err.Error())
+	}
+	return rows, nil
+}
+
+// exportFormat read the format of a download, csv when it is not given
+func exportFormat(c *gin.Context, formats ...string) (string, error) {
+	format := c.DefaultQuery("format", "csv")
+	for _, allowed := range formats {
+		if format == allowed {
+			return format, nil
+		}
+	}
+	return "", exception.BadRequestErr("format must be one of " + strings.Join(formats, ", "))
+}
+
+// sendRows send the rows as a csv or xlsx attachment named after the sheet
+func sendRows(c *gin.Context, sheet, format string, rows [][]string) {
+	setAttachment(c, sheet, format)
+	c.Status(http.StatusOK)
+	if err := spreadsheet.WriteRows(c.Writer, format, sheet, rows); err != nil {
+		exportFailed(c, sheet, err)
+	}
+}
+
+// exportFailed report a failed download, nothing is sent before the first rows are flushed
+// so an early error still get its status, a later one can only cut the attachment short
+func exportFailed(c *gin.Context, name string, err error) {
+	if !c.Writer.Written() {
+		c.Header("Content-Disposition", "")
+		c.Header("Content-Type", "")
+		c.Error(err)
+		return
+	}
+	slog.Errorf("%s export stopped after the first rows: %v", name, err)
+	c.Abort()
+}
+
+func setAttachment(c *gin.Context, name, format string) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	switch format {
+	case "xlsx":
+		c.Header("Content-Type", xlsxContentType)
+	case "csv":
+		c.Header("Content-Type", "text/csv")
+	}
+}
+
+// wantsReport tell whether a listing is asked as a xlsx report, by ?format=xlsx
+// or by an Accept header naming the xlsx content type when the format is not given
+func wantsReport(c *gin.Context) (bool, error) {
+	switch c.Query("format") {
+	case "xlsx":
+		return true, nil
+	case "json":
+		return false, nil
+	case "":
+		return strings.Contains(c.GetHeader("Accept"), xlsxContentType), nil
+	}
+	return false, exception.BadRequestErr("format must be one of json, xlsx")
+}
+
+// sendReport render the sheets as a xlsx attachment, the workbook is built
+// before anything is sent so a failure is still reported as an error
+func sendReport(c *gin.Context, name string, sheets ...report.Sheet) {
+	var buf bytes.Buffer
+	if err := report.Write(&buf, sheets...); err != nil {
+		c.Error(err)
+		return
+	}
+	setAttachment(c, name, "xlsx")
+	c.Data(http.StatusOK, xlsxContentType, buf.Bytes())
+}
@@ -5,6 +5,7 @@ import (
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"final-project-enigma-clean/usecase"
+	"final-project-enigma-clean/util/helper"
 	"fmt"
 	"strconv"
 
@@ -24,7 +25,7 @@ func (s *StaffController) createHandlerStaff(c *gin.Context) {
 		})
 		return
 	}
-	err := s.staffUC.CreateNew(staff)
+	err := s.staffUC.CreateNew(c.GetString("user_id"), staff)
 	if err != nil {
 		c.Error(err)
 		return
@@ -37,9 +38,49 @@ func (s *StaffController) createHandlerStaff(c *gin.Context) {
 func (s *StaffController) listHandlerStaff(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("size", "5"))
+	asReport, err := wantsReport(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	//a report cover every staff, not the requested page
+	if asReport {
+		staff, err := s.staffUC.Report(withDeleted)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		sendReport(c, "staff", helper.StaffSheet(staff))
+		return
+	}
+	cursor, ok, err := cursorRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if ok {
+		staff, paging, err := s.staffUC.PagingCursor(cursor)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(200, gin.H{
+			"message": "successfully get staff",
+			"data":    staff,
+			"paging":  paging,
+		})
+		return
+	}
+
 	staff, paging, err := s.staffUC.Paging(dto.PageRequest{
-		Page: page,
-		Size: size,
+		Page:           page,
+		Size:           size,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{
@@ -89,7 +130,7 @@ func (s *StaffController) updateHandlerStaff(c *gin.Context) {
 		})
 		return
 	}
-	err := s.staffUC.Update(staff)
+	err := s.staffUC.Update(c.GetString("user_id"), staff)
 	if err != nil {
 		c.Error(err)
 		return
@@ -100,7 +141,7 @@ func (s *StaffController) updateHandlerStaff(c *gin.Context) {
 }
 func (s *StaffController) deleteHandlerStaff(c *gin.Context) {
 	nik_staff := c.Param("nik_staff")
-	if err := s.staffUC.Delete(nik_staff); err != nil {
+	if err := s.staffUC.Delete(c.GetString("user_id"), nik_staff); err != nil {
 		c.AbortWithStatusJSON(500, gin.H{
 			"message": err.Error(),
 		})
@@ -112,13 +153,73 @@ func (s *StaffController) deleteHandlerStaff(c *gin.Context) {
 	})
 }
 
+func (s *StaffController) restoreHandlerStaff(c *gin.Context) {
+	if err := s.staffUC.Restore(c.GetString("user_id"), c.Param("nik_staff")); err != nil {
+		c.Error(err)
+		return
+	}
+	response := gin.H{
+		"message": "successfully restore staff",
+	}
+	c.JSON(200, response)
+}
+
+// importHandlerStaff create or update the staff of an uploaded csv or xlsx file, dry_run=true only validate the rows
+func (s *StaffController) importHandlerStaff(c *gin.Context) {
+	rows, err := uploadedRows(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	result, err := s.staffUC.Import(c.GetString("user_id"), rows, dryRun)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	code := 201
+	if dryRun {
+		code = 200
+	}
+	c.JSON(code, gin.H{
+		"message": "successfully import staff",
+		"data":    result,
+	})
+}
+
+// exportHandlerStaff stream the current staff as csv, xlsx or json, the csv and xlsx can be imported back
+func (s *StaffController) exportHandlerStaff(c *gin.Context) {
+	format, err := exportFormat(c, "csv", "xlsx", "json")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	staffs, err := s.staffUC.FindByAll()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if format == "json" {
+		c.Header("Content-Disposition", `attachment; filename="staff.json"`)
+		c.JSON(200, staffs)
+		return
+	}
+	sendRows(c, "staff", format, helper.StaffRows(staffs))
+}
+
 func (s *StaffController) Route() {
-	s.rg.POST("/staffs", middleware.AuthMiddleware(), s.createHandlerStaff)
-	s.rg.GET("/staffs", middleware.AuthMiddleware(), s.listHandlerStaff)
-	s.rg.GET("/staffs/:nik_staff", middleware.AuthMiddleware(), s.getByIdteHandlerStaff)
-	s.rg.GET("/staffs/name/:name", middleware.AuthMiddleware(), s.getByNameteHandlerStaff)
-	s.rg.PUT("/staffs", middleware.AuthMiddleware(), s.updateHandlerStaff)
-	s.rg.DELETE("/staffs/:nik_staff", middleware.AuthMiddleware(), s.deleteHandlerStaff)
+	s.rg.POST("/staffs", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermStaffWrite), s.createHandlerStaff)
+	s.rg.GET("/staffs", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermStaffRead), s.listHandlerStaff)
+	s.rg.POST("/staffs/import", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermStaffWrite), s.importHandlerStaff)
+	s.rg.GET("/staffs/export", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermStaffRead), s.exportHandlerStaff)
+	s.rg.GET("/staffs/:nik_staff", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermStaffRead), s.getByIdteHandlerStaff)
+	s.rg.GET("/staffs/name/:name", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermStaffRead), s.getByNameteHandlerStaff)
+	s.rg.PUT("/staffs", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermStaffWrite), s.updateHandlerStaff)
+	s.rg.DELETE("/staffs/:nik_staff", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermStaffWrite), s.deleteHandlerStaff)
+	s.rg.POST("/staffs/:nik_staff/restore", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermRestore), s.restoreHandlerStaff)
 }
 
 func NewStaffController(staffUC usecase.StaffUseCase, rg *gin.RouterGroup) *StaffController {
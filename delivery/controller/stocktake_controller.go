@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/usecase"
+	"final-project-enigma-clean/util/helper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StocktakeController struct {
+	stocktakeUC usecase.StocktakeUsecase
+	rg          *gin.RouterGroup
+}
+
+// open a stocktake session, counts can be recorded until it is closed
+func (s *StocktakeController) openHandler(c *gin.Context) {
+	var payload dto.StocktakeRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.Error(exception.BadRequestErr(err.Error()))
+		return
+	}
+	stocktake, err := s.stocktakeUC.Open(c.GetString("user_id"), payload)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(201, gin.H{
+		"message": "successfully open stocktake",
+		"data":    stocktake,
+	})
+}
+
+func (s *StocktakeController) listHandler(c *gin.Context) {
+	stocktakes, err := s.stocktakeUC.FindAll(c.Query("status"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully get stocktakes",
+		"data":    stocktakes,
+	})
+}
+
+func (s *StocktakeController) findHandler(c *gin.Context) {
+	stocktake, err := s.stocktakeUC.FindById(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully get stocktake",
+		"data":    stocktake,
+	})
+}
+
+// record what was found of an asset, counting it again at the same location replace the count
+func (s *StocktakeController) countHandler(c *gin.Context) {
+	var payload dto.StocktakeCountRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.Error(exception.BadRequestErr(err.Error()))
+		return
+	}
+	if err := s.stocktakeUC.RecordCount(c.GetString("user_id"), c.Param("id"), payload); err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully record count",
+	})
+}
+
+// the discrepancy report as json, or as a xlsx workbook
+func (s *StocktakeController) reportHandler(c *gin.Context) {
+	xlsx, err := wantsReport(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	report, err := s.stocktakeUC.Report(c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if xlsx {
+		sendReport(c, "stocktake", helper.StocktakeSheet(report.Lines))
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully get stocktake report",
+		"data":    report,
+	})
+}
+
+// close the session, with adjust the counted assets are corrected
+func (s *StocktakeController) closeHandler(c *gin.Context) {
+	var payload dto.StocktakeCloseRequest
+	//the body is optional, closing without it does not adjust
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.Error(exception.BadRequestErr(err.Error()))
+			return
+		}
+	}
+	report, err := s.stocktakeUC.Close(c.GetString("user_id"), c.Param("id"), payload)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "successfully close stocktake",
+		"data":    report,
+	})
+}
+
+func (s *StocktakeController) Route() {
+	s.rg.POST("/stocktakes", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), s.openHandler)
+	s.rg.GET("/stocktakes", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), s.listHandler)
+	s.rg.GET("/stocktakes/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), s.findHandler)
+	s.rg.POST("/stocktakes/:id/counts", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), s.countHandler)
+	s.rg.GET("/stocktakes/:id/report", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetRead), s.reportHandler)
+	s.rg.POST("/stocktakes/:id/close", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermAssetWrite), s.closeHandler)
+}
+
+func NewStocktakeController(stocktakeUC usecase.StocktakeUsecase, rg *gin.RouterGroup) *StocktakeController {
+	return &StocktakeController{
+		stocktakeUC: stocktakeUC,
+		rg:          rg,
+	}
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type StocktakeControllerSuite struct {
+	suite.Suite
+	usecase *usecasemock.StocktakeUsecaseMock
+	router  *gin.Engine
+}
+
+func (suite *StocktakeControllerSuite) SetupTest() {
+	suite.usecase = new(usecasemock.StocktakeUsecaseMock)
+	suite.router = gin.New()
+	suite.router.Use(middleware.ErrorHandler())
+	NewStocktakeController(suite.usecase, suite.router.Group("/api/v1")).Route()
+}
+
+func TestStocktakeControllerSuite(t *testing.T) {
+	suite.Run(t, new(StocktakeControllerSuite))
+}
+
+func (suite *StocktakeControllerSuite) serve(method, path, role string, body io.Reader) *httptest.ResponseRecorder {
+	record := httptest.NewRecorder()
+	request, err := http.NewRequest(method, "/api/v1"+path, body)
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(role))
+	suite.router.ServeHTTP(record, request)
+	return record
+}
+
+func (suite *StocktakeControllerSuite) TestOpen_Success() {
+	suite.usecase.On("Open", testUserID, dto.StocktakeRequest{Name: "Q3 count"}).
+		Return(model.Stocktake{Id: "s1", Name: "Q3 count", Status: model.StocktakeOpen}, nil)
+
+	record := suite.serve(http.MethodPost, "/stocktakes", model.RoleAssetManager, strings.NewReader(`{"name":"Q3 count"}`))
+	assert.Equal(suite.T(), http.StatusCreated, record.Code)
+}
+
+func (suite *StocktakeControllerSuite) TestOpen_Forbidden() {
+	record := suite.serve(http.MethodPost, "/stocktakes", model.RoleViewer, strings.NewReader(`{"name":"Q3 count"}`))
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "Open", mock.Anything, mock.Anything)
+}
+
+func (suite *StocktakeControllerSuite) TestRecordCount_Success() {
+	suite.usecase.On("RecordCount", testUserID, "s1", mock.MatchedBy(func(payload dto.StocktakeCountRequest) bool {
+		return payload.AssetId == "a1" && payload.Location == "Gudang" && *payload.Counted == 0
+	})).Return(nil)
+
+	record := suite.serve(http.MethodPost, "/stocktakes/s1/counts", model.RoleAssetManager,
+		strings.NewReader(`{"asset_id":"a1","location":"Gudang","counted":0}`))
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
+
+func (suite *StocktakeControllerSuite) TestReport_Json() {
+	suite.usecase.On("Report", "s1").Return(dto.StocktakeReport{Summary: dto.StocktakeSummary{Assets: 1}}, nil)
+
+	record := suite.serve(http.MethodGet, "/stocktakes/s1/report", model.RoleViewer, nil)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+
+	var response struct {
+		Data dto.StocktakeReport `json:"data"`
+	}
+	assert.NoError(suite.T(), json.Unmarshal(record.Body.Bytes(), &response))
+	assert.Equal(suite.T(), 1, response.Data.Summary.Assets)
+}
+
+func (suite *StocktakeControllerSuite) TestReport_Xlsx() {
+	difference := -1
+	suite.usecase.On("Report", "s1").Return(dto.StocktakeReport{Lines: []model.StocktakeLine{
+		{AssetId: "a1", AssetName: "Kursi", Total: 10, Expected: 8, Counted: new(int), Difference: &difference},
+		{AssetId: "a2", AssetName: "Meja", Total: 4, Expected: 4},
+	}}, nil)
+
+	record := suite.serve(http.MethodGet, "/stocktakes/s1/report?format=xlsx", model.RoleViewer, nil)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	assert.Equal(suite.T(), xlsxContentType, record.Header().Get("Content-Type"))
+	assert.Contains(suite.T(), record.Header().Get("Content-Disposition"), "stocktake.xlsx")
+}
+
+func (suite *StocktakeControllerSuite) TestClose_WithoutBody() {
+	suite.usecase.On("Close", testUserID, "s1", dto.StocktakeCloseRequest{}).Return(dto.StocktakeReport{}, nil)
+
+	record := suite.serve(http.MethodPost, "/stocktakes/s1/close", model.RoleAssetManager, nil)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
+
+func (suite *StocktakeControllerSuite) TestClose_Failed() {
+	payload := dto.StocktakeCloseRequest{Adjust: true}
+	suite.usecase.On("Close", testUserID, "s1", payload).
+		Return(dto.StocktakeReport{}, exception.BadRequestErr("reason is required to adjust the assets"))
+
+	record := suite.serve(http.MethodPost, "/stocktakes/s1/close", model.RoleAssetManager, strings.NewReader(`{"adjust":true}`))
+	assert.Equal(suite.T(), http.StatusBadRequest, record.Code)
+}
@@ -25,7 +25,7 @@ func (t *TypeAssetController) createHandlerTypeAsset(c *gin.Context) {
 		return
 	}
 	// typeAsset.Id = helper.GenerateUUID()
-	err := t.typeAssetUC.CreateNew(typeAsset)
+	err := t.typeAssetUC.CreateNew(c.GetString("user_id"), typeAsset)
 	if err != nil {
 		c.Error(err)
 		return
@@ -38,9 +38,34 @@ func (t *TypeAssetController) createHandlerTypeAsset(c *gin.Context) {
 func (t *TypeAssetController) listHandlerTypeAsset(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("size", "5"))
+	cursor, ok, err := cursorRequest(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if ok {
+		typeAsset, paging, err := t.typeAssetUC.PagingCursor(cursor)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(200, gin.H{
+			"message": "successfully get type asset",
+			"data":    typeAsset,
+			"paging":  paging,
+		})
+		return
+	}
+
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 	typeAsset, paging, err := t.typeAssetUC.Paging(dto.PageRequest{
-		Page: page,
-		Size: size,
+		Page:           page,
+		Size:           size,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
 		c.AbortWithStatusJSON(500, gin.H{
@@ -90,7 +115,7 @@ func (t *TypeAssetController) updateHandlerTypeAsset(c *gin.Context) {
 		})
 		return
 	}
-	err := t.typeAssetUC.Update(typeAsset)
+	err := t.typeAssetUC.Update(c.GetString("user_id"), typeAsset)
 	if err != nil {
 		c.Error(err)
 		return
@@ -101,7 +126,7 @@ func (t *TypeAssetController) updateHandlerTypeAsset(c *gin.Context) {
 }
 func (t *TypeAssetController) deleteHandlerTypeAsset(c *gin.Context) {
 	id := c.Param("id")
-	if err := t.typeAssetUC.Delete(id); err != nil {
+	if err := t.typeAssetUC.Delete(c.GetString("user_id"), id); err != nil {
 		c.AbortWithStatusJSON(500, gin.H{
 			"message": err.Error(),
 		})
@@ -112,13 +137,25 @@ func (t *TypeAssetController) deleteHandlerTypeAsset(c *gin.Context) {
 		"message": message,
 	})
 }
+func (t *TypeAssetController) restoreHandlerTypeAsset(c *gin.Context) {
+	if err := t.typeAssetUC.Restore(c.GetString("user_id"), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+	response := gin.H{
+		"message": "successfully restore type asset",
+	}
+	c.JSON(200, response)
+}
+
 func (t *TypeAssetController) Route() {
-	t.rg.POST("/typeAsset", middleware.AuthMiddleware(), t.createHandlerTypeAsset)
-	t.rg.GET("/typeAsset", middleware.AuthMiddleware(), t.listHandlerTypeAsset)
-	t.rg.GET("/typeAsset/:id", middleware.AuthMiddleware(), t.getByIdteHandlerTypeAsset)
-	t.rg.GET("/typeAsset/name/:name", middleware.AuthMiddleware(), t.getByNameteHandlerTypeAsset)
-	t.rg.PUT("/typeAsset", middleware.AuthMiddleware(), t.updateHandlerTypeAsset)
-	t.rg.DELETE("/typeAsset/:id", middleware.AuthMiddleware(), t.deleteHandlerTypeAsset)
+	t.rg.POST("/typeAsset", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermTypeAssetWrite), t.createHandlerTypeAsset)
+	t.rg.GET("/typeAsset", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermTypeAssetRead), t.listHandlerTypeAsset)
+	t.rg.GET("/typeAsset/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermTypeAssetRead), t.getByIdteHandlerTypeAsset)
+	t.rg.GET("/typeAsset/name/:name", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermTypeAssetRead), t.getByNameteHandlerTypeAsset)
+	t.rg.PUT("/typeAsset", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermTypeAssetWrite), t.updateHandlerTypeAsset)
+	t.rg.DELETE("/typeAsset/:id", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermTypeAssetWrite), t.deleteHandlerTypeAsset)
+	t.rg.POST("/typeAsset/:id/restore", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermRestore), t.restoreHandlerTypeAsset)
 }
 
 func NewTypeAssetController(typeAssetUC usecase.TypeAssetUseCase, rg *gin.RouterGroup) *TypeAssetController {
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"final-project-enigma-clean/delivery/middleware"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/usecase"
 	"final-project-enigma-clean/util/helper"
@@ -69,24 +70,19 @@ func (u *UserController) LoginOTPHandler(c *gin.Context) {
 		return
 	}
 
-	//store otp
-	storedOTP, exists := usecase.OTPMap[request.Email]
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"Error": "OTP not found or expired"})
+	//verify otp and then we need to generate jwt
+	if err := u.userUC.VerifyOTP(model.OTPPurposeLogin, request.Email, request.OTP); err != nil {
+		c.Error(err)
 		return
 	}
 
-	//stored otp and then we need to generate jwt
-	if request.OTP == storedOTP {
-		token, err := helper.GenerateJWT(request.Email)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create token"})
-			return
-		}
-		delete(usecase.OTPMap, request.Email)
-
-		c.JSON(200, gin.H{"Message": "Login successfully", "Data": token})
+	token, err := u.userUC.IssueToken(request.Email)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"Error": err.Error()})
+		return
 	}
+
+	c.JSON(200, gin.H{"Message": "Login successfully", "Data": token})
 }
 
 func (u *UserController) ChangePasswordHandler(c *gin.Context) {
@@ -126,44 +122,38 @@ func (u *UserController) ChangePassOTPHandler(c *gin.Context) {
 	//is email exist?
 	u.userUC.EmailExist(request.Email)
 
-	//store otp
-	storedOTP, exists := usecase.OTPMap[request.Email]
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"Error": "OTP not found or expired"})
+	//verify otp
+	if err := u.userUC.VerifyOTP(model.OTPPurposeChangePassword, request.Email, request.OTP); err != nil {
+		c.Error(err)
 		return
 	}
 
-	//stored otp
-	if request.OTP == storedOTP {
-		delete(usecase.OTPMap, request.Email)
-
-		//get user password
-		hashedPass, err := u.userUC.GetUserPassword(request.Email)
-		if err != nil {
-			c.AbortWithStatusJSON(500, gin.H{"Error to get password": err.Error()})
-			return
-		}
-
-		//compare
-		if err = bcrypt.CompareHashAndPassword([]byte(hashedPass), []byte(request.OldPassword)); err != nil {
-			c.AbortWithStatusJSON(500, gin.H{"Error": "Invalid credentials"})
-			return
-		}
+	//get user password
+	hashedPass, err := u.userUC.GetUserPassword(request.Email)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"Error to get password": err.Error()})
+		return
+	}
 
-		//if compare successfully,then weneed to hash new password
-		newHashPassword, err := helper.HashPasswordForgotPass(request.NewPassword)
-		if err != nil {
-			c.AbortWithStatusJSON(500, gin.H{"Error": "something is wrong"})
-			return
-		}
+	//compare
+	if err = bcrypt.CompareHashAndPassword([]byte(hashedPass), []byte(request.OldPassword)); err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"Error": "Invalid credentials"})
+		return
+	}
 
-		if err = u.userUC.ChangePassword(request.Email, newHashPassword); err != nil {
-			c.AbortWithStatusJSON(500, gin.H{"Error": "Invalid Password"})
-			return
-		}
+	//if compare successfully,then weneed to hash new password
+	newHashPassword, err := helper.HashPasswordForgotPass(request.NewPassword)
+	if err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"Error": "something is wrong"})
+		return
+	}
 
-		c.JSON(200, gin.H{"Message": "Successfully change password"})
+	if err = u.userUC.ChangePassword(request.Email, newHashPassword); err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"Error": "Invalid Password"})
+		return
 	}
+
+	c.JSON(200, gin.H{"Message": "Successfully change password"})
 }
 
 func (u *UserController) ForgotPassHandler(c *gin.Context) {
@@ -181,7 +171,10 @@ func (u *UserController) ForgotPassHandler(c *gin.Context) {
 		return
 	}
 
-	u.userUC.ForgotPass(request.Email)
+	if err = u.userUC.ForgotPass(request.Email); err != nil {
+		c.AbortWithStatusJSON(500, gin.H{"Error": err.Error()})
+		return
+	}
 	c.JSON(200, gin.H{"Success": "Check your email for verification and follow the instruction"})
 }
 
@@ -197,25 +190,105 @@ func (u *UserController) ForgotPassOTPHandler(c *gin.Context) {
 	u.userUC.EmailExist(request.Email)
 
 	//now validate otp
-	storedOTP, exists := usecase.OTPMap[request.Email]
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"Error": "OTP not found or expired"})
+	if err := u.userUC.VerifyOTP(model.OTPPurposeForgotPassword, request.Email, request.OTP); err != nil {
+		c.Error(err)
 		return
 	}
 
-	if request.OTP == storedOTP {
-		//confirm new password
-		if err := u.userUC.ForgotPassRequest(request.Email, request.NewPassword, request.ConfirmNewPassword); err != nil {
-			c.AbortWithStatusJSON(400, gin.H{"Error": err.Error()})
-			return
-		}
-
-		//delete otp
-		delete(usecase.OTPMap, request.Email)
+	//confirm new password
+	if err := u.userUC.ForgotPassRequest(request.Email, request.NewPassword, request.ConfirmNewPassword); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"Error": err.Error()})
+		return
 	}
 	c.JSON(200, gin.H{"Message": "Success"})
 }
 
+// admin grant role to user
+func (u *UserController) UpdateRoleHandler(c *gin.Context) {
+	var request model.UpdateRoleRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"Error": "Bad JSON Format"})
+		return
+	}
+
+	if err := u.userUC.UpdateRole(request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"Message": "Successfully update role"})
+}
+
+// exchange refresh token for a new token pair
+func (u *UserController) RefreshTokenHandler(c *gin.Context) {
+	var request model.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"Error": "Bad JSON Format"})
+		return
+	}
+
+	tokens, err := u.userUC.RefreshToken(request.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"Message": "Successfully refresh token", "Data": tokens})
+}
+
+// logout revoke the refresh token family of the current login
+func (u *UserController) LogoutHandler(c *gin.Context) {
+	var request model.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"Error": "Bad JSON Format"})
+		return
+	}
+
+	if err := u.userUC.Logout(request.RefreshToken); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"Message": "Successfully logout"})
+}
+
+// admin activate or deactivate user
+func (u *UserController) UpdateStatusHandler(c *gin.Context) {
+	var request model.UpdateStatusRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"Error": "Bad JSON Format"})
+		return
+	}
+
+	if err := u.userUC.UpdateStatus(request); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"Message": "Successfully update status"})
+}
+
+// user choose the language of their email
+func (u *UserController) UpdateLocaleHandler(c *gin.Context) {
+	var request model.UpdateLocaleRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.AbortWithStatusJSON(400, gin.H{"Error": "Bad JSON Format"})
+		return
+	}
+
+	if err := u.userUC.UpdateLocale(c.GetString("user_id"), request.Locale); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(200, gin.H{"Message": "Successfully update locale"})
+}
+
 // init route
 func (u *UserController) Route() {
 	{
@@ -226,6 +299,11 @@ func (u *UserController) Route() {
 		u.rg.POST("/change-password/start", u.ChangePassOTPHandler)
 		u.rg.POST("/forgot-password", u.ForgotPassHandler)
 		u.rg.POST("/forgot-password/start", u.ForgotPassOTPHandler)
+		u.rg.POST("/token/refresh", u.RefreshTokenHandler)
+		u.rg.POST("/logout", u.LogoutHandler)
+		u.rg.PUT("/users/role", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermUserManage), u.UpdateRoleHandler)
+		u.rg.PUT("/users/locale", middleware.AuthMiddleware(), u.UpdateLocaleHandler)
+		u.rg.PUT("/users/status", middleware.AuthMiddleware(), middleware.RequirePermission(model.PermUserManage), u.UpdateStatusHandler)
 	}
 }
 
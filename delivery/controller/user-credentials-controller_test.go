@@ -5,8 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/delivery/middleware"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
-	"final-project-enigma-clean/usecase"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -26,6 +27,7 @@ type RegisterControllerTestSuite struct {
 func (suite *RegisterControllerTestSuite) SetupTest() {
 	suite.usecase = new(usecasemock.UserCredentialsMock)
 	suite.router = gin.Default()
+	suite.router.Use(middleware.ErrorHandler())
 }
 
 func TestRegisterUserTestSuite(t *testing.T) {
@@ -153,10 +155,11 @@ func (suite *RegisterControllerTestSuite) TestLoginUserOTP_Success() {
 		OTP:   287303,
 	}
 
-	// Set up OTP map with a valid OTP value
-	usecase.OTPMap["ellizavad@gmail.com"] = 287303
+	// otp is valid for login
+	suite.usecase.On("VerifyOTP", model.OTPPurposeLogin, mockData.Email, mockData.OTP).Return(nil)
 
 	suite.usecase.On("LoginUser", mockData).Return(nil)
+	suite.usecase.On("IssueToken", mockData.Email).Return(model.TokenPair{AccessToken: "access", RefreshToken: "refresh"}, nil)
 	mockRg := suite.router.Group("/api/v1")
 	NewUserController(suite.usecase, mockRg).Route()
 
@@ -187,7 +190,8 @@ func (suite *RegisterControllerTestSuite) TestLoginUserOTP_Fail() {
 		OTP:   875502,
 	}
 
-	suite.usecase.On("LoginUser", mockData).Return(errors.New("otp is invalid / expired"))
+	suite.usecase.On("VerifyOTP", model.OTPPurposeLogin, mockData.Email, mockData.OTP).
+		Return(exception.UnauthorizedErr("OTP is invalid"))
 	mockRg := suite.router.Group("/api/v1")
 	NewUserController(suite.usecase, mockRg).Route()
 
@@ -283,3 +287,154 @@ func (suite *RegisterControllerTestSuite) TestForgotPassOTP_InvalidJSONFormat()
 	assert.Equal(suite.T(), http.StatusBadRequest, recorder.Code)
 
 }
+
+// update role
+func (suite *RegisterControllerTestSuite) TestUpdateRole_Success() {
+	mockData := model.UpdateRoleRequest{
+		ID:   "1",
+		Role: model.RoleAssetManager,
+	}
+
+	suite.usecase.On("UpdateRole", mockData).Return(nil)
+	mockRg := suite.router.Group("/api/v1")
+	NewUserController(suite.usecase, mockRg).Route()
+
+	record := httptest.NewRecorder()
+
+	marshal, err := json.Marshal(mockData)
+	assert.NoError(suite.T(), err)
+
+	request, err := http.NewRequest(http.MethodPut, "/api/v1/users/role", bytes.NewBuffer(marshal))
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
+
+func (suite *RegisterControllerTestSuite) TestUpdateRole_Forbidden() {
+	mockRg := suite.router.Group("/api/v1")
+	NewUserController(suite.usecase, mockRg).Route()
+
+	record := httptest.NewRecorder()
+
+	marshal, err := json.Marshal(model.UpdateRoleRequest{ID: "1", Role: model.RoleAdmin})
+	assert.NoError(suite.T(), err)
+
+	request, err := http.NewRequest(http.MethodPut, "/api/v1/users/role", bytes.NewBuffer(marshal))
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAssetManager))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusForbidden, record.Code)
+}
+
+func (suite *RegisterControllerTestSuite) TestForgotPassOTP_InvalidOTP() {
+	mockData := model.ForgotPassRequest{
+		Email:              "ellizavad@gmail.com",
+		OTP:                123456,
+		NewPassword:        "N@ufa282",
+		ConfirmNewPassword: "N@ufa282",
+	}
+
+	//a login otp can not be used to reset password
+	suite.usecase.On("VerifyOTP", model.OTPPurposeForgotPassword, mockData.Email, mockData.OTP).
+		Return(exception.UnauthorizedErr("OTP not found or expired"))
+	suite.usecase.On("EmailExist", mockData.Email).Return(true)
+	mockRg := suite.router.Group("/api/v1")
+	NewUserController(suite.usecase, mockRg).Route()
+
+	record := httptest.NewRecorder()
+
+	marshal, err := json.Marshal(mockData)
+	assert.NoError(suite.T(), err)
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/forgot-password/start", bytes.NewBuffer(marshal))
+	assert.NoError(suite.T(), err)
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusUnauthorized, record.Code)
+	suite.usecase.AssertNotCalled(suite.T(), "ForgotPassRequest", mockData.Email, mockData.NewPassword, mockData.ConfirmNewPassword)
+}
+
+func (suite *RegisterControllerTestSuite) TestRefreshToken_Success() {
+	mockData := model.RefreshTokenRequest{RefreshToken: "refresh"}
+	suite.usecase.On("RefreshToken", mockData.RefreshToken).
+		Return(model.TokenPair{AccessToken: "access", RefreshToken: "next-refresh"}, nil)
+	mockRg := suite.router.Group("/api/v1")
+	NewUserController(suite.usecase, mockRg).Route()
+
+	record := httptest.NewRecorder()
+
+	marshal, err := json.Marshal(mockData)
+	assert.NoError(suite.T(), err)
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/token/refresh", bytes.NewBuffer(marshal))
+	assert.NoError(suite.T(), err)
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+
+	var response struct {
+		Data model.TokenPair
+	}
+	assert.NoError(suite.T(), json.Unmarshal(record.Body.Bytes(), &response))
+	assert.Equal(suite.T(), "next-refresh", response.Data.RefreshToken)
+}
+
+func (suite *RegisterControllerTestSuite) TestRefreshToken_Revoked() {
+	mockData := model.RefreshTokenRequest{RefreshToken: "refresh"}
+	suite.usecase.On("RefreshToken", mockData.RefreshToken).
+		Return(model.TokenPair{}, exception.UnauthorizedErr("Refresh token has been revoked"))
+	mockRg := suite.router.Group("/api/v1")
+	NewUserController(suite.usecase, mockRg).Route()
+
+	record := httptest.NewRecorder()
+
+	marshal, err := json.Marshal(mockData)
+	assert.NoError(suite.T(), err)
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/token/refresh", bytes.NewBuffer(marshal))
+	assert.NoError(suite.T(), err)
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusUnauthorized, record.Code)
+}
+
+func (suite *RegisterControllerTestSuite) TestLogout_Success() {
+	mockData := model.RefreshTokenRequest{RefreshToken: "refresh"}
+	suite.usecase.On("Logout", mockData.RefreshToken).Return(nil)
+	mockRg := suite.router.Group("/api/v1")
+	NewUserController(suite.usecase, mockRg).Route()
+
+	record := httptest.NewRecorder()
+
+	marshal, err := json.Marshal(mockData)
+	assert.NoError(suite.T(), err)
+
+	request, err := http.NewRequest(http.MethodPost, "/api/v1/logout", bytes.NewBuffer(marshal))
+	assert.NoError(suite.T(), err)
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+	suite.usecase.AssertExpectations(suite.T())
+}
+
+func (suite *RegisterControllerTestSuite) TestUpdateStatus_Success() {
+	mockData := model.UpdateStatusRequest{ID: "1", IsActive: false}
+	suite.usecase.On("UpdateStatus", mockData).Return(nil)
+	mockRg := suite.router.Group("/api/v1")
+	NewUserController(suite.usecase, mockRg).Route()
+
+	record := httptest.NewRecorder()
+
+	marshal, err := json.Marshal(mockData)
+	assert.NoError(suite.T(), err)
+
+	request, err := http.NewRequest(http.MethodPut, "/api/v1/users/status", bytes.NewBuffer(marshal))
+	assert.NoError(suite.T(), err)
+	request.Header.Set("Authorization", bearerToken(model.RoleAdmin))
+
+	suite.router.ServeHTTP(record, request)
+	assert.Equal(suite.T(), http.StatusOK, record.Code)
+}
@@ -1,12 +1,23 @@
 package middleware
 
 import (
+	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/util/helper"
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v4"
 	"strings"
+
+	"github.com/gin-gonic/gin"
 )
 
+// TokenGuard reject access token whose user was deactivated or whose login was revoked
+type TokenGuard func(userID, familyID string) error
+
+var tokenGuard TokenGuard
+
+// UseTokenGuard install the guard consulted by AuthMiddleware, without it only the signature is checked
+func UseTokenGuard(guard TokenGuard) {
+	tokenGuard = guard
+}
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenHeader := c.GetHeader("Authorization")
@@ -21,18 +32,37 @@ func AuthMiddleware() gin.HandlerFunc {
 		tokenHeader = strings.Replace(tokenHeader, "Bearer ", "", 1)
 
 		//do parse jwt
-		token, err := helper.ParseJWT(tokenHeader)
+		claims, err := helper.ParseJWT(tokenHeader)
 		if err != nil {
-			c.AbortWithStatusJSON(500, gin.H{"Error": "Failed to parse jwt"})
+			c.AbortWithStatusJSON(401, gin.H{"Error": "Invalid token"})
 			return
 		}
 
+		//token may be revoked before it expires
+		if tokenGuard != nil {
+			if err = tokenGuard(claims.UserID, claims.FamilyID); err != nil {
+				c.AbortWithStatusJSON(401, gin.H{"Error": "Token has been revoked"})
+				return
+			}
+		}
+
 		//claim token nya
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("user_id", claims["user_id"])
+		c.Set("user_id", claims.UserID)
+		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+
+		//next
+		c.Next()
+	}
+}
+
+// RequirePermission must run after AuthMiddleware, it reject role without the permission
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !model.HasPermission(c.GetString("role"), permission) {
+			c.AbortWithStatusJSON(403, gin.H{"Error": "Forbidden"})
 			return
 		}
-		//next
 		c.Next()
 	}
 }
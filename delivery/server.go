@@ -1,22 +1,42 @@
 package delivery
 
 import (
+	"context"
+	"database/sql"
 	"final-project-enigma-clean/config"
 	"final-project-enigma-clean/delivery/controller"
 	"final-project-enigma-clean/delivery/middleware"
 	"final-project-enigma-clean/manager"
+	"final-project-enigma-clean/util/migration"
+	"final-project-enigma-clean/util/scheduler"
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"go.uber.org/zap"
 )
 
+const (
+	// how often the outbox worker look for due emails
+	outboxInterval = 5 * time.Second
+	// how often loans are checked for overdue, each loan is reminded at most once a day
+	overdueInterval = time.Hour
+	// how long the requests in flight are given to finish when the server stop
+	shutdownTimeout = 10 * time.Second
+)
+
 type Server struct {
 	um   manager.UsecaseManager
 	gin  *gin.Engine
 	host string
 	log  *logrus.Logger
+
+	scheduler *scheduler.Scheduler
 }
 
 func (s *Server) initMiddlewares() {
@@ -31,22 +51,80 @@ func (s *Server) initMiddlewares() {
 }
 
 func (s *Server) initControllers() {
+	middleware.UseTokenGuard(s.um.UserUsecase().CheckSession)
+
 	rg := s.gin.Group("/api/v1")
 	controller.NewUserController(s.um.UserUsecase(), rg).Route()
 	controller.NewTypeAssetController(s.um.TypeAssetUseCase(), rg).Route()
 	controller.NewStaffController(s.um.StaffUseCase(), rg).Route()
 	controller.NewAssetController(s.um.AssetUsecase(), rg).Route()
+	controller.NewAssetUnitController(s.um.AssetUnitUsecase(), rg).Route()
 	controller.NewCategoryController(s.um.CategoryUsecase(), rg).Route()
 	controller.NewManageAssetController(s.um.ManageAssetUsecase(), rg).Route()
+	controller.NewEmailTemplateController(s.um.EmailTemplateUsecase(), rg).Route()
+	controller.NewEmailOutboxController(s.um.EmailOutboxUsecase(), rg).Route()
+	controller.NewAuditController(s.um.AuditUsecase(), rg).Route()
+	controller.NewScanController(s.um.ScanUsecase(), rg).Route()
+	controller.NewStocktakeController(s.um.StocktakeUsecase(), rg).Route()
+	controller.NewLoanRequestController(s.um.LoanRequestUsecase(), rg).Route()
+}
+
+// background jobs share the lifetime of the server, they stop when ctx is cancelled
+func (s *Server) initScheduler(ctx context.Context) {
+	s.scheduler.Add(scheduler.Job{
+		Name:     "email-outbox",
+		Interval: outboxInterval,
+		Run:      s.um.EmailOutboxUsecase().ProcessDue,
+	})
+	s.scheduler.Add(scheduler.Job{
+		Name:     "overdue-reminder",
+		Interval: overdueInterval,
+		Run:      s.um.ManageAssetUsecase().RemindOverdue,
+	})
+	s.scheduler.Start(ctx)
 }
 
+// Run serve until an interrupt or terminate signal, then let the requests in flight
+// and the running jobs finish before returning
 func (s *Server) Run() {
 	s.initMiddlewares()
 	s.initControllers()
-	err := s.gin.Run(s.host)
-	if err != nil {
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s.initScheduler(ctx)
+
+	srv := &http.Server{Addr: s.host, Handler: s.gin}
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		stop()
+		s.scheduler.Wait()
 		panic(err)
+	case <-ctx.Done():
 	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		s.log.Errorf("failed to shut down the server: %v", err)
+	}
+	s.scheduler.Wait()
+}
+
+// bring the schema up to date before serving
+func migrate(db *sql.DB) error {
+	m, err := migration.New(db)
+	if err != nil {
+		return err
+	}
+	_, err = m.Up()
+	return err
 }
 
 func NewServer() *Server {
@@ -61,8 +139,14 @@ func NewServer() *Server {
 		fmt.Printf("Failed on infra constructor %v", err.Error())
 	}
 
+	if cfg.DbConfig.Migrate {
+		if err := migrate(im.Connect()); err != nil {
+			panic(err)
+		}
+	}
+
 	rm := manager.NewRepoManager(im)
-	um := manager.NewUsecaseManager(rm)
+	um := manager.NewUsecaseManager(rm, im.Mailer())
 
 	//untuk host
 	host := fmt.Sprintf("%s:%s", cfg.ApiHost, cfg.ApiPort)
@@ -76,5 +160,7 @@ func NewServer() *Server {
 		gin:  g,
 		host: host,
 		log:  log,
+
+		scheduler: scheduler.New(),
 	}
 }
@@ -24,4 +24,16 @@ func NewHttpError(description string, statusCode int) *Http {
 
 func BadRequestErr(description string) error  {
     return NewHttpError(description, http.StatusBadRequest)
-}
\ No newline at end of file
+}
+
+func UnauthorizedErr(description string) error {
+    return NewHttpError(description, http.StatusUnauthorized)
+}
+
+func NotFoundErr(description string) error {
+    return NewHttpError(description, http.StatusNotFound)
+}
+
+func ForbiddenErr(description string) error {
+    return NewHttpError(description, http.StatusForbidden)
+}
@@ -1,8 +1,17 @@
 package main
 
-import "final-project-enigma-clean/delivery"
+import (
+	"final-project-enigma-clean/cmd"
+	"fmt"
+	"os"
+)
 
 func main() {
-	delivery.NewServer().Run()
-
-}
\ No newline at end of file
+	app := cmd.NewApp(os.Stdin, os.Stdout)
+	err := cmd.Execute(app, os.Args[1:])
+	app.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -3,6 +3,7 @@ package manager
 import (
 	"database/sql"
 	"final-project-enigma-clean/config"
+	"final-project-enigma-clean/util/mailer"
 	"fmt"
 
 	_ "github.com/lib/pq"
@@ -10,17 +11,47 @@ import (
 
 type InfraManager interface {
 	Connect() *sql.DB
+	Config() *config.Config
+	Mailer() mailer.Mailer
 }
 
 type infraManager struct {
-	db  *sql.DB
-	cfg *config.Config
+	db     *sql.DB
+	cfg    *config.Config
+	mailer mailer.Mailer
 }
 
 func (i *infraManager) Connect() *sql.DB {
 	return i.db
 }
 
+func (i *infraManager) Config() *config.Config {
+	return i.cfg
+}
+
+func (i *infraManager) Mailer() mailer.Mailer {
+	return i.mailer
+}
+
+func (i *infraManager) initMailer() error {
+	m, err := mailer.New(mailer.Config{
+		Driver:       i.cfg.MailerConfig.Driver,
+		FromName:     i.cfg.MailerConfig.FromName,
+		FromEmail:    i.cfg.MailerConfig.FromEmail,
+		BrevoAPIKey:  i.cfg.MailerConfig.ApiKey,
+		SMTPHost:     i.cfg.MailerConfig.SmtpHost,
+		SMTPPort:     i.cfg.MailerConfig.SmtpPort,
+		SMTPUser:     i.cfg.MailerConfig.SmtpUser,
+		SMTPPassword: i.cfg.MailerConfig.SmtpPassword,
+		LogDir:       i.cfg.MailerConfig.LogDir,
+	})
+	if err != nil {
+		return err
+	}
+	i.mailer = m
+	return nil
+}
+
 func (i *infraManager) initdb() error {
 	//init dsn in here
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
@@ -49,5 +80,8 @@ func NewInfraManager(cfg *config.Config) (InfraManager, error) {
 	if err := connect.initdb(); err != nil {
 		return nil, fmt.Errorf("failed init db %v", err.Error())
 	}
+	if err := connect.initMailer(); err != nil {
+		return nil, fmt.Errorf("failed init mailer %v", err.Error())
+	}
 	return connect, nil
 }
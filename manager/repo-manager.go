@@ -1,6 +1,9 @@
 package manager
 
-import "final-project-enigma-clean/repository"
+import (
+	"final-project-enigma-clean/repository"
+	"sync"
+)
 
 type RepoManager interface {
 	UserRepo() repository.UserCredentialsRepository
@@ -9,10 +12,64 @@ type RepoManager interface {
 	AssetRepo() repository.AssetRepository
 	CategoryRepo() repository.CategoryRepository
 	ManageAssetRepo() repository.ManageAssetRepository
+	OTPStore() repository.OTPStore
+	TokenRepo() repository.TokenRepository
+	EmailOutboxRepo() repository.EmailOutboxRepository
+	AssetUnitRepo() repository.AssetUnitRepository
+	AuditRepo() repository.AuditRepository
+	StocktakeRepo() repository.StocktakeRepository
+	LoanRequestRepo() repository.LoanRequestRepository
 }
 
 type repoManager struct {
 	im InfraManager
+
+	//otp store is shared, the memory store would lose codes if recreated per usecase
+	otpOnce  sync.Once
+	otpStore repository.OTPStore
+}
+
+// LoanRequestRepo implements RepoManager.
+func (r *repoManager) LoanRequestRepo() repository.LoanRequestRepository {
+	return repository.NewLoanRequestRepository(r.im.Connect())
+}
+
+// StocktakeRepo implements RepoManager.
+func (r *repoManager) StocktakeRepo() repository.StocktakeRepository {
+	return repository.NewStocktakeRepository(r.im.Connect())
+}
+
+// AuditRepo implements RepoManager.
+func (r *repoManager) AuditRepo() repository.AuditRepository {
+	return repository.NewAuditRepository(r.im.Connect())
+}
+
+// AssetUnitRepo implements RepoManager.
+func (r *repoManager) AssetUnitRepo() repository.AssetUnitRepository {
+	return repository.NewAssetUnitRepository(r.im.Connect())
+}
+
+// EmailOutboxRepo implements RepoManager.
+func (r *repoManager) EmailOutboxRepo() repository.EmailOutboxRepository {
+	return repository.NewEmailOutboxRepository(r.im.Connect())
+}
+
+// TokenRepo implements RepoManager.
+func (r *repoManager) TokenRepo() repository.TokenRepository {
+	return repository.NewTokenRepository(r.im.Connect())
+}
+
+// OTPStore implements RepoManager.
+func (r *repoManager) OTPStore() repository.OTPStore {
+	r.otpOnce.Do(func() {
+		secret := []byte(r.im.Config().OtpConfig.Secret)
+		if r.im.Config().OtpConfig.Store == "memory" {
+			r.otpStore = repository.NewMemoryOTPStore(secret)
+			return
+		}
+		r.otpStore = repository.NewOTPRepository(r.im.Connect(), secret)
+	})
+	return r.otpStore
 }
 
 // ManageAssetRepo implements RepoManager.
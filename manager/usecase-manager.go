@@ -1,6 +1,9 @@
 package manager
 
-import "final-project-enigma-clean/usecase"
+import (
+	"final-project-enigma-clean/usecase"
+	"final-project-enigma-clean/util/mailer"
+)
 
 type UsecaseManager interface {
 	UserUsecase() usecase.UserCredentialUsecase
@@ -9,44 +12,89 @@ type UsecaseManager interface {
 	AssetUsecase() usecase.AssetUsecase
 	CategoryUsecase() usecase.CategoryUsecase
 	ManageAssetUsecase() usecase.ManageAssetUsecase
+	EmailTemplateUsecase() usecase.EmailTemplateUsecase
+	EmailOutboxUsecase() usecase.EmailOutboxUsecase
+	AssetUnitUsecase() usecase.AssetUnitUsecase
+	AuditUsecase() usecase.AuditUsecase
+	ScanUsecase() usecase.ScanUsecase
+	StocktakeUsecase() usecase.StocktakeUsecase
+	LoanRequestUsecase() usecase.LoanRequestUsecase
 }
 
 type usecaseManager struct {
-	rm RepoManager
+	rm     RepoManager
+	mailer mailer.Mailer
+}
+
+// LoanRequestUsecase implements UsecaseManager.
+func (u *usecaseManager) LoanRequestUsecase() usecase.LoanRequestUsecase {
+	return usecase.NewLoanRequestUsecase(u.rm.LoanRequestRepo(), u.StaffUseCase(), u.AssetUsecase(), u.AssetUnitUsecase(),
+		u.ManageAssetUsecase(), u.AuditUsecase())
+}
+
+// StocktakeUsecase implements UsecaseManager.
+func (u *usecaseManager) StocktakeUsecase() usecase.StocktakeUsecase {
+	return usecase.NewStocktakeUsecase(u.rm.StocktakeRepo(), u.AssetUsecase(), u.CategoryUsecase(), u.AuditUsecase())
+}
+
+// ScanUsecase implements UsecaseManager.
+func (u *usecaseManager) ScanUsecase() usecase.ScanUsecase {
+	return usecase.NewScanUsecase(u.AssetUsecase(), u.AssetUnitUsecase(), u.ManageAssetUsecase())
+}
+
+// AuditUsecase implements UsecaseManager.
+func (u *usecaseManager) AuditUsecase() usecase.AuditUsecase {
+	return usecase.NewAuditUsecase(u.rm.AuditRepo())
+}
+
+// AssetUnitUsecase implements UsecaseManager.
+func (u *usecaseManager) AssetUnitUsecase() usecase.AssetUnitUsecase {
+	return usecase.NewAssetUnitUsecase(u.rm.AssetUnitRepo(), u.AssetUsecase())
+}
+
+// EmailOutboxUsecase implements UsecaseManager.
+func (u *usecaseManager) EmailOutboxUsecase() usecase.EmailOutboxUsecase {
+	return usecase.NewEmailOutboxUsecase(u.rm.EmailOutboxRepo(), u.mailer)
+}
+
+// EmailTemplateUsecase implements UsecaseManager.
+func (u *usecaseManager) EmailTemplateUsecase() usecase.EmailTemplateUsecase {
+	return usecase.NewEmailTemplateUsecase()
 }
 
 // ManageAssetUsecase implements UsecaseManager.
 func (u *usecaseManager) ManageAssetUsecase() usecase.ManageAssetUsecase {
-	return usecase.NewManageAssetUsecase(u.rm.ManageAssetRepo(), u.StaffUseCase(), u.AssetUsecase())
+	return usecase.NewManageAssetUsecase(u.rm.ManageAssetRepo(), u.StaffUseCase(), u.AssetUsecase(), u.AssetUnitUsecase(), u.AuditUsecase())
 }
 
 // StaffUseCase implements UsecaseManager.
 func (u *usecaseManager) StaffUseCase() usecase.StaffUseCase {
-	return usecase.NewStaffUseCase(u.rm.StaffRepo())
+	return usecase.NewStaffUseCase(u.rm.StaffRepo(), u.AuditUsecase())
 }
 
 // CategoryUsecase implements UsecaseManager.
 func (u *usecaseManager) CategoryUsecase() usecase.CategoryUsecase {
-	return usecase.NewCategoryUseCase(u.rm.CategoryRepo())
+	return usecase.NewCategoryUseCase(u.rm.CategoryRepo(), u.AuditUsecase())
 }
 
 // AssetUsecase implements UsecaseManager.
 func (u *usecaseManager) AssetUsecase() usecase.AssetUsecase {
-	return usecase.NewAssetUsecase(u.rm.AssetRepo(), u.TypeAssetUseCase(), u.CategoryUsecase())
+	return usecase.NewAssetUsecase(u.rm.AssetRepo(), u.TypeAssetUseCase(), u.CategoryUsecase(), u.AuditUsecase())
 }
 
 // TypeAssetUseCase implements UsecaseManager.
 func (u *usecaseManager) TypeAssetUseCase() usecase.TypeAssetUseCase {
-	return usecase.NewTypeAssetUseCase(u.rm.TypeAssetRepo())
+	return usecase.NewTypeAssetUseCase(u.rm.TypeAssetRepo(), u.AuditUsecase())
 }
 
 func (u *usecaseManager) UserUsecase() usecase.UserCredentialUsecase {
 	//TODO implement me
-	return usecase.NewUserCredentialUsecase(u.rm.UserRepo())
+	return usecase.NewUserCredentialUsecase(u.rm.UserRepo(), u.rm.OTPStore(), u.rm.TokenRepo(), u.rm.EmailOutboxRepo())
 }
 
-func NewUsecaseManager(rm RepoManager) UsecaseManager {
+func NewUsecaseManager(rm RepoManager, mailer mailer.Mailer) UsecaseManager {
 	return &usecaseManager{
-		rm: rm,
+		rm:     rm,
+		mailer: mailer,
 	}
 }
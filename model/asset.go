@@ -12,6 +12,8 @@ type Asset struct {
 	Status      string `json:"status,omitempty"`
 	EntryDate   time.Time `json:"entryDate,omitempty"`
 	ImgUrl		string `json:"imgUrl,omitempty"`
+	// DeletedAt is only set on a deleted asset listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type AssetRequest struct {
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// state of a single unit, borrowed is only set by a loan
+const (
+	UnitStatusAvailable   = "available"
+	UnitStatusBorrowed    = "borrowed"
+	UnitStatusMaintenance = "maintenance"
+	UnitStatusRetired     = "retired"
+)
+
+const (
+	UnitConditionGood    = "good"
+	UnitConditionFair    = "fair"
+	UnitConditionDamaged = "damaged"
+)
+
+// AssetUnit is one physical item of an asset identified by its serial number,
+// once an asset has units its total and available are counted from them
+type AssetUnit struct {
+	Id           string     `json:"id"`
+	AssetId      string     `json:"asset_id"`
+	SerialNumber string     `json:"serial_number"`
+	Condition    string     `json:"condition"`
+	Status       string     `json:"status"`
+	PurchaseDate *time.Time `json:"purchase_date,omitempty"`
+	HolderNik    string     `json:"holder_nik,omitempty"`
+
+	// set when the unit is listed as part of a loan
+	DetailId   string     `json:"id_detail,omitempty"`
+	ReturnedAt *time.Time `json:"returned_at,omitempty"`
+}
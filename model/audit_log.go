@@ -0,0 +1,47 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// entity of an audit log entry
+const (
+	AuditEntityAsset       = "asset"
+	AuditEntityCategory    = "category"
+	AuditEntityTypeAsset   = "type-asset"
+	AuditEntityStaff       = "staff"
+	AuditEntityTransaction = "transaction"
+	AuditEntityStocktake   = "stocktake"
+	AuditEntityLoanRequest = "loan-request"
+)
+
+// action of an audit log entry
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionReturn  = "return"
+	AuditActionRestore = "restore"
+	AuditActionClose   = "close"
+	// the transitions of a loan request
+	AuditActionSubmit  = "submit"
+	AuditActionApprove = "approve"
+	AuditActionReject  = "reject"
+	AuditActionIssue   = "issue"
+	// AuditActionAdjust is a stock correction posted by a stocktake, the entry hold its reason
+	AuditActionAdjust = "adjust"
+)
+
+// AuditLog is one change of an entity, before is empty on create and after is empty on delete.
+// entries are append only, the table reject update and delete
+type AuditLog struct {
+	Id        string          `json:"id"`
+	ActorId   string          `json:"actor_id"`
+	Entity    string          `json:"entity"`
+	EntityId  string          `json:"entity_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -1,6 +1,10 @@
 package model
 
+import "time"
+
 type Category struct {
 	Id   string `json:"id,omitempty"`
 	Name string `json:"name,omitempty"`
-}
\ No newline at end of file
+	// DeletedAt is only set on a deleted category listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+// AssetQuery filter, sort and page the asset listing, an empty field is not filtered
+type AssetQuery struct {
+	PageRequest
+	// Name is matched case insensitive anywhere in the asset name
+	Name        string
+	CategoryId  string
+	AssetTypeId string
+	Status      string
+	// OnlyAvailable keep the assets with at least one available unit
+	OnlyAvailable bool
+	// EntryFrom and EntryTo are inclusive dates
+	EntryFrom time.Time
+	EntryTo   time.Time
+	// Sort is one of AssetSortFields, Direction is asc or desc
+	Sort      string
+	Direction string
+}
+
+// AssetSortFields are the fields an asset listing can be sorted by
+var AssetSortFields = []string{"name", "entry_date", "total", "available", "status"}
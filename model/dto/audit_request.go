@@ -0,0 +1,10 @@
+package dto
+
+// AuditRequest filter the audit log, an empty field is not filtered
+type AuditRequest struct {
+	Entity   string
+	EntityId string
+	ActorId  string
+	Page     int
+	Size     int
+}
@@ -0,0 +1,18 @@
+package dto
+
+// ImportResult report a bulk import, a dry run validate every row without saving
+type ImportResult struct {
+	DryRun    bool `json:"dry_run"`
+	TotalRows int  `json:"total_rows"`
+	ValidRows int  `json:"valid_rows"`
+	Imported  int  `json:"imported"`
+	// Updated is the part of Imported that changed an existing row
+	Updated int              `json:"updated,omitempty"`
+	Errors  []ImportRowError `json:"errors"`
+}
+
+// ImportRowError is the reason a row was rejected, Row is its line in the file
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,8 @@
+package dto
+
+// LabelRequest pick how the label of a single asset is drawn, Size is the width in pixel of a png
+type LabelRequest struct {
+	Type   string
+	Format string
+	Size   int
+}
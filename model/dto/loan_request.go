@@ -0,0 +1,41 @@
+package dto
+
+// LoanRequestRequest create or edit a draft loan request
+type LoanRequestRequest struct {
+	NikStaff string `json:"nik_staff"`
+	// Duration of the loan in days, counted from the day it is issued
+	Duration int                        `json:"duration"`
+	Note     string                     `json:"note"`
+	Detail   []LoanRequestDetailRequest `json:"detail"`
+}
+
+type LoanRequestDetailRequest struct {
+	IdAsset   string `json:"id_asset"`
+	TotalItem int    `json:"total_item"`
+}
+
+// LoanDecisionRequest carry the comment of an approval, a rejection or a close
+type LoanDecisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// LoanIssueRequest name the units handed over for the lines of an asset tracked per unit
+type LoanIssueRequest struct {
+	Detail []LoanIssueDetailRequest `json:"detail"`
+}
+
+type LoanIssueDetailRequest struct {
+	IdDetail string   `json:"id_detail"`
+	UnitIds  []string `json:"unit_ids"`
+}
+
+// LoanReturnRequest return the items of an issued request, the lines are the detail lines of its loan
+type LoanReturnRequest struct {
+	ReturnDetailReq []ReturnAssetDetailRequest `json:"return_detail"`
+}
+
+// LoanRequestQuery filter the loan requests, an empty field is not filtered
+type LoanRequestQuery struct {
+	Status      string
+	RequesterId string
+}
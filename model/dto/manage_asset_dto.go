@@ -18,4 +18,37 @@ type ManageAssetDetailRequest struct {
 	IdAsset       string
 	TotalItem     int
 	Status        string
+	// UnitIds name the borrowed units of an asset tracked per unit
+	UnitIds []string `json:"unit_ids"`
+}
+
+type ReturnAssetRequest struct {
+	IdManageAsset   string                     `json:"id_manage_asset"`
+	ReturnDate      time.Time                  `json:"return_date"`
+	ReturnDetailReq []ReturnAssetDetailRequest `json:"return_detail"`
+	// IdUser is the user taking the return, it is set from the token
+	IdUser string `json:"-"`
+}
+
+type ReturnAssetDetailRequest struct {
+	IdDetail  string `json:"id_detail"`
+	TotalItem int    `json:"total_item"`
+	// UnitIds name the returned units of an asset tracked per unit
+	UnitIds []string `json:"unit_ids"`
+}
+
+// OpenDetailRequest find the borrowed detail lines of an asset, NikStaff and UnitId narrow
+// it to a staff and to the line holding a unit when they are set
+type OpenDetailRequest struct {
+	AssetId  string
+	NikStaff string
+	UnitId   string
+}
+
+// TransactionExportRequest filter the exported loans, an empty field is not filtered
+type TransactionExportRequest struct {
+	// From and To bound the submission date, both are inclusive dates
+	From     time.Time
+	To       time.Time
+	NikStaff string
 }
@@ -3,6 +3,8 @@ package dto
 type PageRequest struct {
 	Page int
 	Size int
+	// IncludeDeleted list the soft deleted rows too
+	IncludeDeleted bool
 }
 
 type Paging struct {
@@ -11,3 +13,19 @@ type Paging struct {
 	TotalRows  int `json:"totalrows"`
 	TotalPages int `json:"totalpages"`
 }
+
+// CursorRequest page a listing by key, Cursor is the next_cursor of the previous page
+type CursorRequest struct {
+	Cursor string
+	Limit  int
+	// IncludeDeleted list the soft deleted rows too
+	IncludeDeleted bool
+	// After is the last key of the previous page, decoded from Cursor
+	After string
+}
+
+type CursorPaging struct {
+	Limit int `json:"limit"`
+	// NextCursor is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+// the actions a handheld scanner can take on a scanned code
+const (
+	ScanLookup    = "lookup"
+	ScanCheckOut  = "check_out"
+	ScanCheckIn   = "check_in"
+	ScanStocktake = "stocktake"
+)
+
+// ScanRequest is a scanned code, the id of an asset or the serial number of a unit, and what to do with it
+type ScanRequest struct {
+	Code   string `json:"code"`
+	Intent string `json:"intent"`
+	// NikStaff is the borrower of a check out, on a check in it pick the loan of that staff
+	NikStaff string `json:"nik_staff"`
+	// Quantity of a bulk asset to check out or in, 1 when it is not given
+	Quantity int `json:"quantity"`
+	// Duration of a check out in days
+	Duration int `json:"duration"`
+	// Counted is the number of item found by a stocktake, a scanned unit count as one
+	Counted *int `json:"counted"`
+}
+
+// ScanResponse is kept flat and short for the small screen of a scanner
+type ScanResponse struct {
+	Intent       string `json:"intent"`
+	AssetId      string `json:"asset_id"`
+	Name         string `json:"name"`
+	Status       string `json:"status,omitempty"`
+	Available    int    `json:"available"`
+	Total        int    `json:"total"`
+	UnitId       string `json:"unit_id,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	UnitStatus   string `json:"unit_status,omitempty"`
+	HolderNik    string `json:"holder_nik,omitempty"`
+	// TransactionId is the loan a check in was returned to
+	TransactionId string `json:"transaction_id,omitempty"`
+	// Expected, Counted and Difference are only set by a stocktake
+	Expected   *int   `json:"expected,omitempty"`
+	Counted    *int   `json:"counted,omitempty"`
+	Difference *int   `json:"difference,omitempty"`
+	Message    string `json:"message"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+import "final-project-enigma-clean/model"
+
+// StocktakeRequest open a session, CategoryId limit it to the assets of a category
+type StocktakeRequest struct {
+	Name       string `json:"name"`
+	CategoryId string `json:"category_id"`
+	Note       string `json:"note"`
+}
+
+// StocktakeCountRequest record what was found of an asset at a location
+type StocktakeCountRequest struct {
+	AssetId  string `json:"asset_id"`
+	Location string `json:"location"`
+	Counted  *int   `json:"counted"`
+}
+
+// StocktakeCloseRequest close a session, Adjust post the differences to the assets and need a Reason
+type StocktakeCloseRequest struct {
+	Adjust bool   `json:"adjust"`
+	Reason string `json:"reason"`
+}
+
+// StocktakeSummary count the lines of a report
+type StocktakeSummary struct {
+	Assets        int `json:"assets"`
+	Counted       int `json:"counted"`
+	Uncounted     int `json:"uncounted"`
+	Matched       int `json:"matched"`
+	Discrepancies int `json:"discrepancies"`
+}
+
+// StocktakeReport compare every asset in the scope of a session with its counts
+type StocktakeReport struct {
+	Stocktake model.Stocktake       `json:"stocktake"`
+	Summary   StocktakeSummary      `json:"summary"`
+	Lines     []model.StocktakeLine `json:"lines"`
+	// Adjusted list the assets corrected when the session was closed
+	Adjusted []string `json:"adjusted,omitempty"`
+}
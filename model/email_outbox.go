@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// status of an email in the outbox
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusFailed  = "failed"
+)
+
+// EmailOutbox is an email waiting to be delivered by the outbox worker,
+// failed means every attempt was used and the email is dead-lettered. A Sensitive email carry a secret,
+// its html is cleared once it is sent or dead-lettered and it cannot be resent
+type EmailOutbox struct {
+	Id            string     `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Html          string     `json:"-"`
+	Sensitive     bool       `json:"sensitive"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// state of a loan request. A draft is submitted to the approvers, who approve or reject it, an approved
+// request hold its stock until it is issued as a loan, and it is returned with the loan. A request that
+// was not issued can be closed instead
+const (
+	LoanRequestDraft     = "draft"
+	LoanRequestSubmitted = "submitted"
+	LoanRequestApproved  = "approved"
+	LoanRequestRejected  = "rejected"
+	LoanRequestIssued    = "issued"
+	LoanRequestReturned  = "returned"
+	LoanRequestClosed    = "closed"
+)
+
+// LoanRequest is a request of a staff to borrow assets, Requester is the user who filed it
+type LoanRequest struct {
+	Id        string          `json:"id"`
+	Requester UserCredentials `json:"requester"`
+	Staff     Staff           `json:"staff"`
+	// Duration of the loan in days, counted from the day it is issued
+	Duration      int                 `json:"duration"`
+	Status        string              `json:"status"`
+	Note          string              `json:"note,omitempty"`
+	Comment       string              `json:"comment,omitempty"`
+	DecidedBy     string              `json:"decided_by,omitempty"`
+	DecidedAt     *time.Time          `json:"decided_at,omitempty"`
+	IssuedBy      string              `json:"issued_by,omitempty"`
+	IssuedAt      *time.Time          `json:"issued_at,omitempty"`
+	ManageAssetId string              `json:"id_manage_asset,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	Detail        []LoanRequestDetail `json:"detail,omitempty"`
+}
+
+type LoanRequestDetail struct {
+	Id            string `json:"id"`
+	LoanRequestId string `json:"-"`
+	Asset         Asset  `json:"asset"`
+	TotalItem     int    `json:"total_item"`
+}
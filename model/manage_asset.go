@@ -4,19 +4,55 @@ import (
 	"time"
 )
 
+const (
+	DetailStatusBorrowed          = "borrowed"
+	DetailStatusReturned          = "returned"
+	DetailStatusPartiallyReturned = "partially returned"
+)
+
 type ManageAsset struct {
-	Id             string
-	User         UserCredentials `json:"user,omitempty"`
-	Staff			Staff	`json:"staff,omitempty"`
-	SubmissionDate time.Time `json:"submission_date,omitempty"`
-	ReturnDate     time.Time `json:"return_date"`
-	Detail	[]ManageDetailAsset `json:"detail,omitempty"`
+	Id               string
+	User             UserCredentials     `json:"user,omitempty"`
+	Staff            Staff               `json:"staff,omitempty"`
+	SubmissionDate   time.Time           `json:"submission_date,omitempty"`
+	ReturnDate       time.Time           `json:"return_date"`
+	ActualReturnDate *time.Time          `json:"actual_return_date,omitempty"`
+	Detail           []ManageDetailAsset `json:"detail,omitempty"`
 }
 
 type ManageDetailAsset struct {
-	Id 				string	`json:"id,omitempty"`
-	ManageAssetId 	string	`json:"id_manage_asset,omitempty"`
-	Asset 			Asset	`json:"asset,omitempty"`
-	TotalItem 		int		`json:"total_item,omitempty"`
-	Status 			string	`json:"status,omitempty"`
+	Id            string      `json:"id,omitempty"`
+	ManageAssetId string      `json:"id_manage_asset,omitempty"`
+	Asset         Asset       `json:"asset,omitempty"`
+	TotalItem     int         `json:"total_item,omitempty"`
+	TotalReturned int         `json:"total_returned"`
+	Status        string      `json:"status,omitempty"`
+	ReturnedAt    *time.Time  `json:"returned_at,omitempty"`
+	Units         []AssetUnit `json:"units,omitempty"`
+}
+
+// OverdueTransaction is a loan past its return date with item still borrowed,
+// only the unreturned details are listed
+type OverdueTransaction struct {
+	ManageAsset
+	DaysOverdue int        `json:"days_overdue"`
+	OverdueAt   *time.Time `json:"overdue_at,omitempty"`
+	RemindedAt  *time.Time `json:"reminded_at,omitempty"`
+}
+
+// TransactionDetailRow is one detail line of a loan with its transaction, as exported
+type TransactionDetailRow struct {
+	TransactionId    string
+	SubmissionDate   time.Time
+	ReturnDate       time.Time
+	ActualReturnDate *time.Time
+	NikStaff         string
+	StaffName        string
+	UserName         string
+	AssetId          string
+	AssetName        string
+	TotalItem        int
+	TotalReturned    int
+	Status           string
+	ReturnedAt       *time.Time
 }
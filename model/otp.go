@@ -0,0 +1,8 @@
+package model
+
+// purpose of an otp, a code issued for one purpose can not be used for another
+const (
+	OTPPurposeLogin          = "login"
+	OTPPurposeChangePassword = "change-password"
+	OTPPurposeForgotPassword = "forgot-password"
+)
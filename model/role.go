@@ -0,0 +1,81 @@
+package model
+
+// role of user credential
+const (
+	RoleAdmin        = "admin"
+	RoleAssetManager = "asset-manager"
+	RoleViewer       = "viewer"
+)
+
+// permission required by each route
+const (
+	PermAssetRead        = "asset:read"
+	PermAssetWrite       = "asset:write"
+	PermCategoryRead     = "category:read"
+	PermCategoryWrite    = "category:write"
+	PermTypeAssetRead    = "type-asset:read"
+	PermTypeAssetWrite   = "type-asset:write"
+	PermStaffRead        = "staff:read"
+	PermStaffWrite       = "staff:write"
+	PermManageAssetRead  = "manage-asset:read"
+	PermManageAssetWrite = "manage-asset:write"
+	PermUserManage       = "user:manage"
+	PermEmailManage      = "email:manage"
+	PermAuditRead        = "audit:read"
+	PermRestore          = "master-data:restore"
+	// every role can request a loan, only the approvers decide on it
+	PermLoanRequest = "loan-request:submit"
+	PermLoanApprove = "loan-request:approve"
+	// a direct loan skip the loan request approval, it is kept as an admin override
+	PermLoanDirect = "manage-asset:direct-loan"
+)
+
+var readPermissions = []string{
+	PermAssetRead,
+	PermCategoryRead,
+	PermTypeAssetRead,
+	PermStaffRead,
+	PermManageAssetRead,
+}
+
+var writePermissions = []string{
+	PermAssetWrite,
+	PermCategoryWrite,
+	PermTypeAssetWrite,
+	PermStaffWrite,
+	PermManageAssetWrite,
+}
+
+var rolePermissions = map[string][]string{
+	RoleAdmin: append(append(append([]string{}, readPermissions...), writePermissions...), PermUserManage, PermEmailManage, PermAuditRead, PermRestore,
+		PermLoanRequest, PermLoanApprove, PermLoanDirect),
+	RoleAssetManager: append(append(append([]string{}, readPermissions...), writePermissions...), PermLoanRequest, PermLoanApprove),
+	RoleViewer:       append(append([]string{}, readPermissions...), PermLoanRequest),
+}
+
+// IsValidRole check role is one of the known role
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolesWithPermission list the roles granted the permission
+func RolesWithPermission(permission string) []string {
+	var roles []string
+	for _, role := range []string{RoleAdmin, RoleAssetManager, RoleViewer} {
+		if HasPermission(role, permission) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// HasPermission check role is granted the permission
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -10,4 +10,8 @@ type Staff struct {
 	Birth_date   time.Time `json:"birth_date,omitempty"`
 	Img_url      string    `json:"img_url,omitempty"`
 	Divisi       string    `json:"divisi,omitempty"`
+	// Email receive the overdue reminder of the staff loans
+	Email string `json:"email,omitempty"`
+	// DeletedAt is only set on a deleted staff listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// state of a stocktake session, counts are only recorded while it is open
+const (
+	StocktakeOpen   = "open"
+	StocktakeClosed = "closed"
+)
+
+// Stocktake is a session of physical counting, the counts are compared with the database when it is reported
+type Stocktake struct {
+	Id         string           `json:"id"`
+	Name       string           `json:"name"`
+	CategoryId string           `json:"category_id,omitempty"`
+	Status     string           `json:"status"`
+	Note       string           `json:"note,omitempty"`
+	OpenedBy   string           `json:"opened_by"`
+	OpenedAt   time.Time        `json:"opened_at"`
+	ClosedBy   string           `json:"closed_by,omitempty"`
+	ClosedAt   *time.Time       `json:"closed_at,omitempty"`
+	Counts     []StocktakeCount `json:"counts,omitempty"`
+}
+
+// StocktakeCount is the number of item of an asset found at a location, the location may be empty
+type StocktakeCount struct {
+	StocktakeId string    `json:"-"`
+	AssetId     string    `json:"asset_id"`
+	AssetName   string    `json:"asset_name,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Counted     int       `json:"counted"`
+	CountedBy   string    `json:"counted_by"`
+	CountedAt   time.Time `json:"counted_at"`
+}
+
+// StocktakeLine is one asset of a discrepancy report. The item not on loan are expected on site,
+// Difference compare the count with them. The item reserved by an approved loan request are still on site
+// but not available, AvailableDrift compare the stored available with the expected item less the reserved ones
+type StocktakeLine struct {
+	AssetId   string `json:"asset_id"`
+	AssetName string `json:"asset_name"`
+	Category  string `json:"category"`
+	Total     int    `json:"total"`
+	Available int    `json:"available"`
+	OnLoan    int    `json:"on_loan"`
+	Reserved  int    `json:"reserved"`
+	// TrackedByUnit assets are counted from their units, they are not adjusted by a stocktake
+	TrackedByUnit  bool `json:"tracked_by_unit"`
+	Expected       int  `json:"expected"`
+	Counted        *int `json:"counted"`
+	Difference     *int `json:"difference"`
+	AvailableDrift int  `json:"available_drift"`
+}
+
+// StocktakeAdjustment is the difference of a counted asset posted on close, with the audit entry of the
+// asset update and the adjust entry of the stocktake
+type StocktakeAdjustment struct {
+	Line       StocktakeLine
+	AssetAudit AuditLog
+	Audit      AuditLog
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// refresh token is stored hashed, every rotation keep the family id of the login it came from
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type UpdateStatusRequest struct {
+	ID       string `json:"id"`
+	IsActive bool   `json:"is_active"`
+}
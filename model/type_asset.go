@@ -1,6 +1,10 @@
 package model
 
+import "time"
+
 type TypeAsset struct {
 	Id   string `json:"id,omitempty"`
 	Name string `json:"name,omitempty"`
+	// DeletedAt is only set on a deleted type listed with include_deleted
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
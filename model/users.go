@@ -7,6 +7,8 @@ type UserCredentials struct {
 	Password string `json:"password,omitempty" validate:"required"`
 	Name     string `json:"name" validate:"required"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"role,omitempty"`
+	Locale   string `json:"locale,omitempty"`
 }
 
 type UserLoginRequest struct {
@@ -21,6 +23,17 @@ type UserRegisterRequest struct {
 	Password string `json:"password" validate:"required"`
 	Name     string `json:"name" validate:"required"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"-"`
+	Locale   string `json:"locale"`
+}
+
+type UpdateRoleRequest struct {
+	ID   string `json:"id"`
+	Role string `json:"role"`
+}
+
+type UpdateLocaleRequest struct {
+	Locale string `json:"locale"`
 }
 
 type UserLoginOTPRequest struct {
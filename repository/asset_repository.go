@@ -4,41 +4,102 @@ import (
 	"database/sql"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
+	"fmt"
 	"math"
+	"strings"
 )
 
 type AssetRepository interface {
-	Save(asset model.AssetRequest) error
+	Save(asset model.AssetRequest, audit model.AuditLog) error
+	SaveAll(assets []model.AssetRequest, audits []model.AuditLog) error
 	FindAll() ([]model.Asset, error)
 	FindById(id string) (model.Asset, error)
 	FindByName(name string) ([]model.Asset, error)
-	Update(asset model.AssetRequest) error
-	UpdateAvailable(id string, amount int) error
-	Delete(id string) error
-	Paging(payload dto.PageRequest) ([]model.Asset, dto.Paging, error)
+	Update(asset model.AssetRequest, audit model.AuditLog) error
+	Delete(id string, audit model.AuditLog) error
+	Paging(payload dto.AssetQuery) ([]model.Asset, dto.Paging, error)
+	PagingCursor(query dto.AssetQuery, payload dto.CursorRequest) ([]model.Asset, dto.CursorPaging, error)
+	Restore(id string, audit model.AuditLog) error
 }
 
 type assetRepository struct {
 	db *sql.DB
 }
 
+// assetSortColumns map a sort field of the listing to its column, nothing else reach the order by
+var assetSortColumns = map[string]string{
+	"name":       "a.name",
+	"entry_date": "a.entry_date",
+	"total":      "a.total",
+	"available":  "a.available",
+	"status":     "a.status",
+}
+
+// assetConditions turn the filled fields of the query into the where clause, every value is passed as a parameter
+func assetConditions(payload dto.AssetQuery) (string, []any) {
+	var conditions []string
+	var args []any
+	filters := []struct {
+		condition string
+		value     any
+		ok        bool
+	}{
+		{"a.name ilike $%d", "%" + payload.Name + "%", payload.Name != ""},
+		{"a.id_category = $%d", payload.CategoryId, payload.CategoryId != ""},
+		{"a.id_asset_type = $%d", payload.AssetTypeId, payload.AssetTypeId != ""},
+		{"a.status = $%d", payload.Status, payload.Status != ""},
+		{"a.entry_date >= $%d", payload.EntryFrom, !payload.EntryFrom.IsZero()},
+		//the end date is inclusive so the day after is the bound
+		{"a.entry_date < $%d", payload.EntryTo.AddDate(0, 0, 1), !payload.EntryTo.IsZero()},
+	}
+	for _, filter := range filters {
+		if !filter.ok {
+			continue
+		}
+		args = append(args, filter.value)
+		conditions = append(conditions, fmt.Sprintf(filter.condition, len(args)))
+	}
+	if payload.OnlyAvailable {
+		conditions = append(conditions, "a.available > 0")
+	}
+	if !payload.IncludeDeleted {
+		conditions = append(conditions, "a.deleted_at is null")
+	}
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " where " + strings.Join(conditions, " and "), args
+}
+
 // Paging implements AssetRepository.
-func (a *assetRepository) Paging(payload dto.PageRequest) ([]model.Asset, dto.Paging, error) {
-	q := `select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name
+func (a *assetRepository) Paging(payload dto.AssetQuery) ([]model.Asset, dto.Paging, error) {
+	where, args := assetConditions(payload)
+
+	column, ok := assetSortColumns[payload.Sort]
+	if !ok {
+		column = "a.name"
+	}
+	direction := "asc"
+	if strings.EqualFold(payload.Direction, "desc") {
+		direction = "desc"
+	}
+
+	q := `select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name, a.deleted_at
 	from asset as a 
 	left join category as c on c.id = a.id_category
-	left join asset_type as at on at.id = a.id_asset_type
-	limit $2 offset $1`
+	left join asset_type as at on at.id = a.id_asset_type` + where +
+		fmt.Sprintf(" order by %s %s, a.id limit $%d offset $%d", column, direction, len(args)+1, len(args)+2)
 
-	rows, err := a.db.Query(q, (payload.Page-1)*payload.Size, payload.Size)
+	rows, err := a.db.Query(q, append(args, payload.Size, (payload.Page-1)*payload.Size)...)
 	if err != nil {
 		return nil, dto.Paging{}, err
 	}
+	defer rows.Close()
 
 	var assets []model.Asset
 	for rows.Next() {
 		var asset model.Asset
-		rows.Scan(&asset.Id, &asset.Name, &asset.Available, &asset.Status, &asset.EntryDate, &asset.ImgUrl, &asset.Total, &asset.Category.Id, &asset.Category.Name, &asset.AssetType.Id, &asset.AssetType.Name)
+		rows.Scan(&asset.Id, &asset.Name, &asset.Available, &asset.Status, &asset.EntryDate, &asset.ImgUrl, &asset.Total, &asset.Category.Id, &asset.Category.Name, &asset.AssetType.Id, &asset.AssetType.Name, &asset.DeletedAt)
 		assets = append(assets, asset)
 	}
 	if rows.Err() != nil {
@@ -46,7 +107,7 @@ func (a *assetRepository) Paging(payload dto.PageRequest) ([]model.Asset, dto.Pa
 	}
 
 	var count int
-	row := a.db.QueryRow("select count(id) from asset")
+	row := a.db.QueryRow("select count(a.id) from asset as a"+where, args...)
 	if err := row.Scan(&count); err != nil {
 		return nil, dto.Paging{}, err
 	}
@@ -61,16 +122,46 @@ func (a *assetRepository) Paging(payload dto.PageRequest) ([]model.Asset, dto.Pa
 	return assets, paging, nil
 }
 
-// UpdateAmount implements AssetRepository.
-func (a *assetRepository) UpdateAvailable(id string, amount int) error {
-	query := "update asset set available = $2 where id = $1"
+// PagingCursor implements AssetRepository.
+// the filters of the query apply but the rows are read in id order after the last key,
+// an asset inserted meanwhile never shift a page
+func (a *assetRepository) PagingCursor(query dto.AssetQuery, payload dto.CursorRequest) ([]model.Asset, dto.CursorPaging, error) {
+	query.IncludeDeleted = payload.IncludeDeleted
+	where, args := assetConditions(query)
+	args = append(args, payload.After)
+	if where == "" {
+		where = " where "
+	} else {
+		where += " and "
+	}
+	where += fmt.Sprintf("a.id > $%d", len(args))
 
-	_, err := a.db.Exec(query, id, amount)
+	q := `select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name, a.deleted_at
+	from asset as a 
+	left join category as c on c.id = a.id_category
+	left join asset_type as at on at.id = a.id_asset_type` + where + fmt.Sprintf(" order by a.id limit $%d", len(args)+1)
+
+	rows, err := a.db.Query(q, append(args, payload.Limit+1)...)
 	if err != nil {
-		return err
+		return nil, dto.CursorPaging{}, err
+	}
+	defer rows.Close()
+
+	var assets []model.Asset
+	for rows.Next() {
+		var asset model.Asset
+		err = rows.Scan(&asset.Id, &asset.Name, &asset.Available, &asset.Status, &asset.EntryDate, &asset.ImgUrl, &asset.Total, &asset.Category.Id, &asset.Category.Name, &asset.AssetType.Id, &asset.AssetType.Name, &asset.DeletedAt)
+		if err != nil {
+			return nil, dto.CursorPaging{}, err
+		}
+		assets = append(assets, asset)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dto.CursorPaging{}, err
 	}
 
-	return nil
+	assets, paging := cursorPage(assets, payload.Limit, func(asset model.Asset) string { return asset.Id })
+	return assets, paging, nil
 }
 
 // FindByName implements AssetRepository.
@@ -79,7 +170,7 @@ func (a *assetRepository) FindByName(name string) ([]model.Asset, error) {
 			from asset as a 
 			left join category as c on c.id = a.id_category
 			left join asset_type as at on at.id = a.id_asset_type
-			where a.name ilike $1`
+			where a.name ilike $1 and a.deleted_at is null`
 
 	rows, err := a.db.Query(query, "%"+name+"%")
 	if err != nil {
@@ -101,16 +192,18 @@ func (a *assetRepository) FindByName(name string) ([]model.Asset, error) {
 }
 
 // Delete implements AssetRepository.
-func (a *assetRepository) Delete(id string) error {
-
-	query := "delete from asset where id = $1"
-
-	_, err := a.db.Exec(query, id)
-	if err != nil {
-		return err
-	}
-
-	return nil
+// the row is only marked deleted, the loans of the asset keep pointing to it
+func (a *assetRepository) Delete(id string, audit model.AuditLog) error {
+
+	query := "update asset set deleted_at = now() where id = $1 and deleted_at is null"
+
+	return inTx(a.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(query, id)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 // FindAll implements AssetRepository.
@@ -120,7 +213,8 @@ func (a *assetRepository) FindAll() ([]model.Asset, error) {
 	query := `select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name
 			from asset as a 
 			left join category as c on c.id = a.id_category
-			left join asset_type as at on at.id = a.id_asset_type`
+			left join asset_type as at on at.id = a.id_asset_type
+			where a.deleted_at is null`
 
 	rows, err := a.db.Query(query)
 	if err != nil {
@@ -142,13 +236,18 @@ func (a *assetRepository) FindAll() ([]model.Asset, error) {
 
 // FindById implements AssetRepository.
 func (a *assetRepository) FindById(id string) (model.Asset, error) {
-	query := `select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name
+	return scanAsset(a.db.QueryRow(queryFindAsset, id))
+}
+
+// queryFindAsset read one asset with its category and type
+const queryFindAsset = `select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name
 			from asset as a 
 			left join category as c on c.id = a.id_category
 			left join asset_type as at on at.id = a.id_asset_type
-			where a.id = $1`
+			where a.id = $1 and a.deleted_at is null`
 
-	row := a.db.QueryRow(query, id)
+// scanAsset scan the row of queryFindAsset, a change read the asset back through its own transaction
+func scanAsset(row *sql.Row) (model.Asset, error) {
 	var asset model.Asset
 	err := row.Scan(&asset.Id, &asset.Name, &asset.Available, &asset.Status, &asset.EntryDate, &asset.ImgUrl, &asset.Total, &asset.Category.Id, &asset.Category.Name, &asset.AssetType.Id, &asset.AssetType.Name)
 	if err != nil {
@@ -159,27 +258,82 @@ func (a *assetRepository) FindById(id string) (model.Asset, error) {
 }
 
 // Save implements AssetRepository.
-func (a *assetRepository) Save(asset model.AssetRequest) error {
+func (a *assetRepository) Save(asset model.AssetRequest, audit model.AuditLog) error {
+	query := "insert into asset(id, id_category, id_asset_type, name, available, status, entry_date, img_url, total) values($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+
+	return inTx(a.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(query, asset.Id, asset.CategoryId, asset.AssetTypeId, asset.Name, asset.Available, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
+}
+
+// SaveAll implements AssetRepository.
+// the assets and their audit entries are saved in one transaction, a failed insert save none of them
+func (a *assetRepository) SaveAll(assets []model.AssetRequest, audits []model.AuditLog) error {
 	query := "insert into asset(id, id_category, id_asset_type, name, available, status, entry_date, img_url, total) values($1, $2, $3, $4, $5, $6, $7, $8, $9)"
 
-	_, err := a.db.Exec(query, asset.Id, asset.CategoryId, asset.AssetTypeId, asset.Name, asset.Available, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total)
+	tx, err := a.db.Begin()
 	if err != nil {
 		return err
 	}
-
-	return nil
+	for _, asset := range assets {
+		_, err = tx.Exec(query, asset.Id, asset.CategoryId, asset.AssetTypeId, asset.Name, asset.Available, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = insertAudit(tx, audits...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
 // Update implements AssetRepository.
-func (a *assetRepository) Update(asset model.AssetRequest) error {
-	query := `update asset set id_category = $2, id_asset_type = $3, name = $4, available = $5, status = $6, img_url = $7, total = $8 where id = $1`
+// the row is read back for the after state of the audit entry
+func (a *assetRepository) Update(asset model.AssetRequest, audit model.AuditLog) error {
+	//the counts of an asset tracked per unit are moved by its units only
+	query := `update asset set id_category = $2, id_asset_type = $3, name = $4,
+	available = case when exists (select 1 from asset_unit where id_asset = $1) then available else $5 end,
+	status = $6, img_url = $7,
+	total = case when exists (select 1 from asset_unit where id_asset = $1) then total else $8 end
+	where id = $1 and deleted_at is null`
+
+	return inTx(a.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(query, asset.Id, asset.CategoryId, asset.AssetTypeId, asset.Name, asset.Available, asset.Status, asset.ImgUrl, asset.Total)
+		if err != nil {
+			return err
+		}
+		return insertAssetAudit(tx, audit, asset.Id)
+	})
+}
 
-	_, err := a.db.Exec(query, asset.Id, asset.CategoryId, asset.AssetTypeId, asset.Name, asset.Available, asset.Status, asset.ImgUrl, asset.Total)
+// Restore implements AssetRepository.
+func (a *assetRepository) Restore(id string, audit model.AuditLog) error {
+	return inTx(a.db, func(tx *sql.Tx) error {
+		if err := restore(tx, "update asset set deleted_at = null where id = $1 and deleted_at is not null", id); err != nil {
+			return err
+		}
+		return insertAssetAudit(tx, audit, id)
+	})
+}
+
+// insertAssetAudit write the audit entry with the asset as it is saved,
+// the counts of an asset tracked per unit are kept by the update so the request alone can't tell them
+func insertAssetAudit(tx *sql.Tx, audit model.AuditLog, id string) error {
+	after, err := scanAsset(tx.QueryRow(queryFindAsset, id))
 	if err != nil {
 		return err
 	}
-
-	return nil
+	audit, err = auditAfter(audit, after)
+	if err != nil {
+		return err
+	}
+	return insertAudit(tx, audit)
 }
 
 func NewAssetRepository(db *sql.DB) AssetRepository {
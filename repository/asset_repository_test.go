@@ -49,6 +49,7 @@ func (suite *AssetRepositoryTestSuite) TestCreate_Success() {
 	}
 
 
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("insert into asset").WithArgs(
 		asset.Id, 
 		asset.CategoryId, 
@@ -59,8 +60,9 @@ func (suite *AssetRepositoryTestSuite) TestCreate_Success() {
 		asset.EntryDate,  
 		asset.ImgUrl,
 		asset.Total,).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
 
-	got := suite.repository.Save(asset)
+	got := suite.repository.Save(asset, testAudit)
 	assert.NoError(suite.T(), got)
 	assert.Nil(suite.T(), got)
 }
@@ -78,6 +80,7 @@ func (suite *AssetRepositoryTestSuite) TestCreate_Failed() {
 	}
 
 
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("insert into asset").WithArgs(
 		asset.Id, 
 		asset.CategoryId, 
@@ -87,8 +90,9 @@ func (suite *AssetRepositoryTestSuite) TestCreate_Failed() {
 		asset.Status, 
 		asset.EntryDate, 
 		asset.ImgUrl,).WillReturnError(errors.New("failed save asset"))
+	suite.mockSQL.ExpectRollback()
 
-	got := suite.repository.Save(asset)
+	got := suite.repository.Save(asset, testAudit)
 	assert.Error(suite.T(), got)
 	assert.NotNil(suite.T(), got)
 }
@@ -260,6 +264,7 @@ func (suite *AssetRepositoryTestSuite) TestUpdate_Success()  {
 		Total:     5,
 	}
 
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("update asset").WithArgs(
 		asset.Id,
 		asset.CategoryId, 
@@ -269,11 +274,14 @@ func (suite *AssetRepositoryTestSuite) TestUpdate_Success()  {
 		asset.Status,
 		asset.ImgUrl,
 		asset.Total).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectQuery("select a.id").WithArgs(asset.Id).WillReturnRows(savedAssetRow(asset.Id))
+	expectAudit(suite.mockSQL)
 
-	gotError := suite.repository.Update(asset)
+	gotError := suite.repository.Update(asset, testAudit)
 
 	assert.NoError(suite.T(), gotError)
 	assert.Nil(suite.T(), gotError)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
 }
 
 func (suite *AssetRepositoryTestSuite) TestUpdate_Failed()  {
@@ -287,6 +295,7 @@ func (suite *AssetRepositoryTestSuite) TestUpdate_Failed()  {
 		ImgUrl:     "nothing",
 	}
 
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("update asset").WithArgs(
 		asset.Id,
 		asset.CategoryId, 
@@ -295,8 +304,9 @@ func (suite *AssetRepositoryTestSuite) TestUpdate_Failed()  {
 		asset.Total, 
 		asset.Status,
 		asset.ImgUrl,).WillReturnError(errors.New("failed to update"))
+	suite.mockSQL.ExpectRollback()
 
-	gotError := suite.repository.Update(asset)
+	gotError := suite.repository.Update(asset, testAudit)
 
 	assert.Error(suite.T(), gotError)
 	assert.NotNil(suite.T(), gotError)
@@ -304,24 +314,27 @@ func (suite *AssetRepositoryTestSuite) TestUpdate_Failed()  {
 
 func (suite *AssetRepositoryTestSuite) TestDelete_Success()  {
 	
-	suite.mockSQL.ExpectExec("delete from asset").WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
-	gotError := suite.repository.Delete("1")
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("update asset set deleted_at").WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+	gotError := suite.repository.Delete("1", testAudit)
 	assert.NoError(suite.T(), gotError)
 	assert.Nil(suite.T(), gotError)
 }
 
 func (suite *AssetRepositoryTestSuite) TestDelete_Failed()  {
 	
-	suite.mockSQL.ExpectExec("delete from asset").WithArgs("1").WillReturnError(errors.New("failed to delete"))
-	gotError := suite.repository.Delete("1")
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("update asset set deleted_at").WithArgs("1").WillReturnError(errors.New("failed to delete"))
+	suite.mockSQL.ExpectRollback()
+	gotError := suite.repository.Delete("1", testAudit)
 	assert.Error(suite.T(), gotError)
 	assert.NotNil(suite.T(), gotError)
 }
 
 func (suite *AssetRepositoryTestSuite) TestPaging_Success() {
-	mockPaging := dto.PageRequest{
-		Page: 1,
-		Size: 5,
+	mockPaging := dto.AssetQuery{
+		PageRequest: dto.PageRequest{Page: 1, Size: 5},
 	}
 
 	asset := model.Asset{
@@ -336,15 +349,15 @@ func (suite *AssetRepositoryTestSuite) TestPaging_Success() {
 		ImgUrl:    "qwerty",
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name"}).
-	AddRow(asset.Id, asset.Name, asset.Total, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total , asset.Category.Id, asset.Category.Name, asset.AssetType.Id, asset.AssetType.Name)
+	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name", "deleted_at"}).
+	AddRow(asset.Id, asset.Name, asset.Total, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total , asset.Category.Id, asset.Category.Name, asset.AssetType.Id, asset.AssetType.Name, nil)
 
-	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name from asset as a").
-	WithArgs((mockPaging.Page-1)*mockPaging.Size, mockPaging.Size).WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name, a.deleted_at from asset as a").
+	WithArgs(mockPaging.Size, (mockPaging.Page-1)*mockPaging.Size).WillReturnRows(rows)
 
 	rowCount := sqlmock.NewRows([]string{"count"})
 	rowCount.AddRow(1)
-	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("select count(id) from asset")).WillReturnRows(rowCount)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("select count(a.id) from asset as a")).WillReturnRows(rowCount)
 
 	actualAsset, actualPaging, actualErr := suite.repository.Paging(mockPaging)
 	assert.Nil(suite.T(), actualErr)
@@ -353,13 +366,12 @@ func (suite *AssetRepositoryTestSuite) TestPaging_Success() {
 }
 
 func (suite *AssetRepositoryTestSuite) TestPaging_Failed() {
-	mockPaging := dto.PageRequest{
-		Page: 1,
-		Size: 5,
+	mockPaging := dto.AssetQuery{
+		PageRequest: dto.PageRequest{Page: 1, Size: 5},
 	}
 
-	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name from asset as a").
-	WithArgs((mockPaging.Page-1)*mockPaging.Size, mockPaging.Size).WillReturnError(errors.New("failed get assets"))
+	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name, a.deleted_at from asset as a").
+	WithArgs(mockPaging.Size, (mockPaging.Page-1)*mockPaging.Size).WillReturnError(errors.New("failed get assets"))
 
 	actualAsset, actualPaging, actualErr := suite.repository.Paging(mockPaging)
 	assert.Error(suite.T(), actualErr)
@@ -368,9 +380,8 @@ func (suite *AssetRepositoryTestSuite) TestPaging_Failed() {
 }
 
 func (suite *AssetRepositoryTestSuite) TestPaging_RowsError() {
-	mockPaging := dto.PageRequest{
-		Page: 1,
-		Size: 5,
+	mockPaging := dto.AssetQuery{
+		PageRequest: dto.PageRequest{Page: 1, Size: 5},
 	}
 
 	asset := model.Asset{
@@ -385,12 +396,12 @@ func (suite *AssetRepositoryTestSuite) TestPaging_RowsError() {
 		ImgUrl:    "qwerty",
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name"}).
-	AddRow(asset.Id, asset.Name, asset.Total, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total , asset.Category.Id, asset.Category.Name, asset.AssetType.Id, asset.AssetType.Name).
+	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name", "deleted_at"}).
+	AddRow(asset.Id, asset.Name, asset.Total, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total , asset.Category.Id, asset.Category.Name, asset.AssetType.Id, asset.AssetType.Name, nil).
 	RowError(0, errors.New("error scan"))
 
-	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name from asset as a").
-	WithArgs((mockPaging.Page-1)*mockPaging.Size, mockPaging.Size).WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name, a.deleted_at from asset as a").
+	WithArgs(mockPaging.Size, (mockPaging.Page-1)*mockPaging.Size).WillReturnRows(rows)
 
 	actualAsset, actualPaging, actualErr := suite.repository.Paging(mockPaging)
 	assert.Error(suite.T(), actualErr)
@@ -399,9 +410,8 @@ func (suite *AssetRepositoryTestSuite) TestPaging_RowsError() {
 }
 
 func (suite *AssetRepositoryTestSuite) TestPaging_RowCountErr() {
-	mockPaging := dto.PageRequest{
-		Page: 1,
-		Size: 5,
+	mockPaging := dto.AssetQuery{
+		PageRequest: dto.PageRequest{Page: 1, Size: 5},
 	}
 
 	asset := model.Asset{
@@ -416,14 +426,14 @@ func (suite *AssetRepositoryTestSuite) TestPaging_RowCountErr() {
 		ImgUrl:    "qwerty",
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name"}).
-	AddRow(asset.Id, asset.Name, asset.Total, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total , asset.Category.Id, asset.Category.Name, asset.AssetType.Id, asset.AssetType.Name).
+	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name", "deleted_at"}).
+	AddRow(asset.Id, asset.Name, asset.Total, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total , asset.Category.Id, asset.Category.Name, asset.AssetType.Id, asset.AssetType.Name, nil).
 	RowError(0, errors.New("error scan"))
 
-	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name from asset as a").
-	WithArgs((mockPaging.Page-1)*mockPaging.Size, mockPaging.Size).WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name, a.deleted_at from asset as a").
+	WithArgs(mockPaging.Size, (mockPaging.Page-1)*mockPaging.Size).WillReturnRows(rows)
 
-	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("select count(id) from asset")).WillReturnError(errors.New("failed get count"))
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("select count(a.id) from asset as a")).WillReturnError(errors.New("failed get count"))
 
 	actualAsset, actualPaging, actualErr := suite.repository.Paging(mockPaging)
 	assert.Error(suite.T(), actualErr)
@@ -432,9 +442,8 @@ func (suite *AssetRepositoryTestSuite) TestPaging_RowCountErr() {
 }
 
 func (suite *AssetRepositoryTestSuite) TestPaging_RowCountError() {
-	mockPaging := dto.PageRequest{
-		Page: 1,
-		Size: 5,
+	mockPaging := dto.AssetQuery{
+		PageRequest: dto.PageRequest{Page: 1, Size: 5},
 	}
 
 	asset := model.Asset{
@@ -449,15 +458,15 @@ func (suite *AssetRepositoryTestSuite) TestPaging_RowCountError() {
 		ImgUrl:    "qwerty",
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name"}).
-	AddRow(asset.Id, asset.Name, asset.Total, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total , asset.Category.Id, asset.Category.Name, asset.AssetType.Id, asset.AssetType.Name)
+	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name", "deleted_at"}).
+	AddRow(asset.Id, asset.Name, asset.Total, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total , asset.Category.Id, asset.Category.Name, asset.AssetType.Id, asset.AssetType.Name, nil)
 
-	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name from asset as a").
-	WithArgs((mockPaging.Page-1)*mockPaging.Size, mockPaging.Size).WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("select a.id, a.name, a.available, a.status, a.entry_date, a.img_url, a.total, c.id, c.name, at.id, at.name, a.deleted_at from asset as a").
+	WithArgs(mockPaging.Size, (mockPaging.Page-1)*mockPaging.Size).WillReturnRows(rows)
 
 	rowCount := sqlmock.NewRows([]string{"count"})
 	rowCount.AddRow(1).RowError(0, errors.New("failed row count"))
-	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("select count(id) from asset")).WillReturnRows(rowCount)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("select count(a.id) from asset as a")).WillReturnRows(rowCount)
 
 	actualAsset, actualPaging, actualErr := suite.repository.Paging(mockPaging)
 	assert.Error(suite.T(), actualErr)
@@ -465,22 +474,6 @@ func (suite *AssetRepositoryTestSuite) TestPaging_RowCountError() {
 	assert.Equal(suite.T(), 0, actualPaging.TotalRows)
 }
 
-func (suite *AssetRepositoryTestSuite) TestUpdateAvailable_Success() {
-	
-	suite.mockSQL.ExpectExec("update asset set available").WithArgs("1", 5).WillReturnResult(sqlmock.NewResult(1, 1))
-
-	gotErr := suite.repository.UpdateAvailable("1", 5)
-	assert.NoError(suite.T(), gotErr)
-}
-
-func (suite *AssetRepositoryTestSuite) TestUpdateAvailable_Failed() {
-	
-	suite.mockSQL.ExpectExec("update asset set available").WithArgs("1", 5).WillReturnError(errors.New("failed update"))
-
-	gotErr := suite.repository.UpdateAvailable("1", 5)
-	assert.Error(suite.T(), gotErr)
-}
-
 func (suite *AssetRepositoryTestSuite) TestFindByName_Success()  {
 	
 	assetMock := []model.Asset{{
@@ -543,4 +536,115 @@ func (suite *AssetRepositoryTestSuite) TestFindByName_FailedRowScan()  {
 	assets, err := suite.repository.FindByName("mobil")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), assets)
-}
\ No newline at end of file
+}
+func (suite *AssetRepositoryTestSuite) TestRestore_Success() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("update asset set deleted_at = null").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectQuery("select a.id").WithArgs("1").WillReturnRows(savedAssetRow("1"))
+	expectAudit(suite.mockSQL)
+	assert.NoError(suite.T(), suite.repository.Restore("1", testAudit))
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *AssetRepositoryTestSuite) TestRestore_NotDeleted() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("update asset set deleted_at = null").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSQL.ExpectRollback()
+	assert.ErrorIs(suite.T(), suite.repository.Restore("1", testAudit), ErrNotDeleted)
+}
+
+func (suite *AssetRepositoryTestSuite) TestRestore_Failed() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("update asset set deleted_at = null").WithArgs("1").WillReturnError(errors.New("db down"))
+	suite.mockSQL.ExpectRollback()
+	assert.Error(suite.T(), suite.repository.Restore("1", testAudit))
+}
+
+// savedAssetRow is the asset read back after a change for its audit entry
+func savedAssetRow(id string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id", "name", "id", "name"}).
+		AddRow(id, "Laptop", 5, "ready", time.Now(), "", 5, "1", "Elektronik", "1", "Laptop")
+}
+
+func (suite *AssetRepositoryTestSuite) TestPaging_Filtered() {
+	entryFrom := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	entryTo := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	query := dto.AssetQuery{
+		PageRequest:   dto.PageRequest{Page: 2, Size: 5},
+		Name:          "laptop",
+		CategoryId:    "1",
+		Status:        "ready",
+		OnlyAvailable: true,
+		EntryFrom:     entryFrom,
+		EntryTo:       entryTo,
+		Sort:          "entry_date",
+		Direction:     "desc",
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name", "deleted_at"}).
+		AddRow("1", "Laptop", 2, "ready", entryFrom, "", 5, "1", "Elektronik", "2", "Laptop", nil)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`where a.name ilike $1 and a.id_category = $2 and a.status = $3 and a.entry_date >= $4 and a.entry_date < $5 and a.available > 0 and a.deleted_at is null order by a.entry_date desc, a.id limit $6 offset $7`)).
+		WithArgs("%laptop%", "1", "ready", entryFrom, entryTo.AddDate(0, 0, 1), 5, 5).WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`select count(a.id) from asset as a where a.name ilike $1 and a.id_category = $2 and a.status = $3 and a.entry_date >= $4 and a.entry_date < $5 and a.available > 0 and a.deleted_at is null`)).
+		WithArgs("%laptop%", "1", "ready", entryFrom, entryTo.AddDate(0, 0, 1)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+
+	assets, paging, err := suite.repository.Paging(query)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), assets, 1)
+	assert.Equal(suite.T(), 2, paging.TotalPages)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *AssetRepositoryTestSuite) TestPaging_UnknownSortFallsBackToName() {
+	query := dto.AssetQuery{
+		PageRequest: dto.PageRequest{Page: 1, Size: 5, IncludeDeleted: true},
+		Sort:        "name; drop table asset",
+	}
+
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`left join asset_type as at on at.id = a.id_asset_type order by a.name asc, a.id limit $1 offset $2`)).
+		WithArgs(5, 0).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`select count(a.id) from asset as a`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	_, _, err := suite.repository.Paging(query)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *AssetRepositoryTestSuite) TestPagingCursor_Filtered() {
+	rows := sqlmock.NewRows([]string{"id", "name", "available", "status", "entry_date", "img_url", "total", "id_category", "category_name", "id_asset_type", "asset_type_name", "deleted_at"}).
+		AddRow("b", "Laptop", 2, "ready", time.Now(), "", 5, "1", "Elektronik", "2", "Laptop", nil)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`where a.id_category = $1 and a.deleted_at is null and a.id > $2 order by a.id limit $3`)).
+		WithArgs("1", "a", 11).WillReturnRows(rows)
+
+	assets, paging, err := suite.repository.PagingCursor(dto.AssetQuery{CategoryId: "1"}, dto.CursorRequest{Limit: 10, After: "a"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), assets, 1)
+	assert.Equal(suite.T(), dto.CursorPaging{Limit: 10}, paging)
+}
+
+func (suite *AssetRepositoryTestSuite) TestSaveAll_Success() {
+	assets := []model.AssetRequest{{Id: "1", Name: "Laptop", Total: 5, Available: 5}, {Id: "2", Name: "Meja", Total: 2, Available: 2}}
+	suite.mockSQL.ExpectBegin()
+	for _, asset := range assets {
+		suite.mockSQL.ExpectExec("insert into asset").
+			WithArgs(asset.Id, asset.CategoryId, asset.AssetTypeId, asset.Name, asset.Available, asset.Status, asset.EntryDate, asset.ImgUrl, asset.Total).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	suite.mockSQL.ExpectExec("insert into audit_log").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+
+	assert.NoError(suite.T(), suite.repository.SaveAll(assets, []model.AuditLog{testAudit, testAudit}))
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *AssetRepositoryTestSuite) TestSaveAll_Rollback() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("insert into asset").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("insert into asset").WillReturnError(errors.New("duplicate key"))
+	suite.mockSQL.ExpectRollback()
+
+	err := suite.repository.SaveAll([]model.AssetRequest{{Id: "1"}, {Id: "2"}}, []model.AuditLog{testAudit, testAudit})
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
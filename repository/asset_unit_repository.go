@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrUnitNotFound     = errors.New("asset unit not found")
+	ErrDuplicateSerial  = errors.New("serial number already registered")
+	ErrUnitNotAvailable = errors.New("asset unit is not available")
+	ErrUnitNotOnLoan    = errors.New("asset unit is not borrowed in this transaction")
+	ErrAssetBulkStock   = errors.New("asset stock is not tracked by unit")
+)
+
+type AssetUnitRepository interface {
+	Save(unit model.AssetUnit) error
+	Update(unit model.AssetUnit) error
+	FindById(id string) (model.AssetUnit, error)
+	FindBySerial(serial string) (model.AssetUnit, error)
+	FindByAsset(assetId string) ([]model.AssetUnit, error)
+	FindByTransaction(idManageAsset string) ([]model.AssetUnit, error)
+}
+
+// the asset of a unit is locked first, a unit change move its total and available by the difference
+// so the item on loan and the stock reserved by an approved loan request are kept
+const queryLockUnitAsset = `select a.total,
+	exists (select 1 from asset_unit as u where u.id_asset = a.id),
+	exists (select 1 from detail_manage_asset as d where d.id_asset = a.id and d.total_item > d.total_returned)
+	from asset as a where a.id = $1 for update`
+
+type assetUnitRepository struct {
+	db *sql.DB
+}
+
+// Save implements AssetUnitRepository.
+func (a *assetUnitRepository) Save(unit model.AssetUnit) error {
+	query := `insert into asset_unit (id, id_asset, serial_number, condition, status, purchase_date) values ($1, $2, $3, $4, $5, $6)`
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = lockUnitAsset(tx, unit.AssetId); err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(query, unit.Id, unit.AssetId, unit.SerialNumber, unit.Condition, unit.Status, unit.PurchaseDate)
+	if err != nil {
+		tx.Rollback()
+		return unitError(err)
+	}
+	if err = moveUnitStock(tx, unit.AssetId, "", unit.Status); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update implements AssetUnitRepository.
+// A borrowed unit is changed by the loan only, so it is left untouched here
+func (a *assetUnitRepository) Update(unit model.AssetUnit) error {
+	queryLock := `select id_asset, status from asset_unit where id = $1 and status <> $2 for update`
+	query := `update asset_unit set serial_number = $2, condition = $3, status = $4, purchase_date = $5 where id = $1`
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	var assetId, status string
+	if err = tx.QueryRow(queryLock, unit.Id, model.UnitStatusBorrowed).Scan(&assetId, &status); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrUnitNotAvailable
+		}
+		return err
+	}
+	if _, err = tx.Exec(query, unit.Id, unit.SerialNumber, unit.Condition, unit.Status, unit.PurchaseDate); err != nil {
+		tx.Rollback()
+		return unitError(err)
+	}
+	if err = moveUnitStock(tx, assetId, status, unit.Status); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindById implements AssetUnitRepository.
+func (a *assetUnitRepository) FindById(id string) (model.AssetUnit, error) {
+	query := `select id, id_asset, serial_number, condition, status, purchase_date, coalesce(holder_nik, '') from asset_unit where id = $1`
+
+	var unit model.AssetUnit
+	err := a.db.QueryRow(query, id).Scan(&unit.Id, &unit.AssetId, &unit.SerialNumber, &unit.Condition, &unit.Status, &unit.PurchaseDate, &unit.HolderNik)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.AssetUnit{}, ErrUnitNotFound
+		}
+		return model.AssetUnit{}, err
+	}
+	return unit, nil
+}
+
+// FindBySerial implements AssetUnitRepository.
+func (a *assetUnitRepository) FindBySerial(serial string) (model.AssetUnit, error) {
+	query := `select id, id_asset, serial_number, condition, status, purchase_date, coalesce(holder_nik, '') from asset_unit where serial_number = $1`
+
+	var unit model.AssetUnit
+	err := a.db.QueryRow(query, serial).Scan(&unit.Id, &unit.AssetId, &unit.SerialNumber, &unit.Condition, &unit.Status, &unit.PurchaseDate, &unit.HolderNik)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.AssetUnit{}, ErrUnitNotFound
+		}
+		return model.AssetUnit{}, err
+	}
+	return unit, nil
+}
+
+// FindByAsset implements AssetUnitRepository.
+func (a *assetUnitRepository) FindByAsset(assetId string) ([]model.AssetUnit, error) {
+	query := `select id, id_asset, serial_number, condition, status, purchase_date, coalesce(holder_nik, '') from asset_unit
+	where id_asset = $1 order by serial_number`
+
+	rows, err := a.db.Query(query, assetId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var units []model.AssetUnit
+	for rows.Next() {
+		var unit model.AssetUnit
+		err = rows.Scan(&unit.Id, &unit.AssetId, &unit.SerialNumber, &unit.Condition, &unit.Status, &unit.PurchaseDate, &unit.HolderNik)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, unit)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return units, nil
+}
+
+// FindByTransaction implements AssetUnitRepository.
+// It list every unit that was lent in the transaction, returned or not
+func (a *assetUnitRepository) FindByTransaction(idManageAsset string) ([]model.AssetUnit, error) {
+	query := `select u.id, u.id_asset, u.serial_number, u.condition, u.status, u.purchase_date, coalesce(u.holder_nik, ''), du.id_detail, du.returned_at
+	from detail_manage_asset_unit as du
+	join detail_manage_asset as d on d.id = du.id_detail
+	join asset_unit as u on u.id = du.id_unit
+	where d.id_manage_asset = $1 order by u.serial_number`
+
+	rows, err := a.db.Query(query, idManageAsset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var units []model.AssetUnit
+	for rows.Next() {
+		var unit model.AssetUnit
+		err = rows.Scan(&unit.Id, &unit.AssetId, &unit.SerialNumber, &unit.Condition, &unit.Status, &unit.PurchaseDate, &unit.HolderNik,
+			&unit.DetailId, &unit.ReturnedAt)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, unit)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return units, nil
+}
+
+// lockUnitAsset refuse the first unit of an asset that still count its stock in bulk,
+// that stock has to be converted to units by setting the total to 0 first
+func lockUnitAsset(tx *sql.Tx, assetId string) error {
+	var total int
+	var hasUnit, onLoan bool
+	if err := tx.QueryRow(queryLockUnitAsset, assetId).Scan(&total, &hasUnit, &onLoan); err != nil {
+		return err
+	}
+	if !hasUnit && (total > 0 || onLoan) {
+		return ErrAssetBulkStock
+	}
+	return nil
+}
+
+// moveUnitStock apply the status change of a unit to its asset, from is empty for a new unit.
+// Available cannot go below 0, the stock may be reserved by an approved loan request
+func moveUnitStock(exec execer, assetId, from, to string) error {
+	toTotal, toAvailable := unitStock(to)
+	fromTotal, fromAvailable := unitStock(from)
+	total, available := toTotal-fromTotal, toAvailable-fromAvailable
+	if total == 0 && available == 0 {
+		return nil
+	}
+
+	res, err := exec.Exec(`update asset set total = total + $2, available = available + $3 where id = $1 and available + $3 >= 0`,
+		assetId, total, available)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: asset %s", ErrInsufficientStock, assetId)
+	}
+	return nil
+}
+
+// unitStock is what a unit in the status add to its asset, a retired unit is no longer part of the total
+func unitStock(status string) (total, available int) {
+	switch status {
+	case "", model.UnitStatusRetired:
+		return 0, 0
+	case model.UnitStatusAvailable:
+		return 1, 1
+	}
+	return 1, 0
+}
+
+// the serial number carry a unique constraint
+func unitError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateSerial
+	}
+	return fmt.Errorf("Failed to exec %v", err.Error())
+}
+
+func NewAssetUnitRepository(db *sql.DB) AssetUnitRepository {
+	return &assetUnitRepository{
+		db: db,
+	}
+}
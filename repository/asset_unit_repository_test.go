@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AssetUnitRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo AssetUnitRepository
+}
+
+func (suite *AssetUnitRepositorySuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.db = db
+	suite.mock = mock
+	suite.repo = NewAssetUnitRepository(db)
+}
+
+func (suite *AssetUnitRepositorySuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestAssetUnitRepositorySuite(t *testing.T) {
+	suite.Run(t, new(AssetUnitRepositorySuite))
+}
+
+func (suite *AssetUnitRepositorySuite) TestSave_Success() {
+	purchase := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	unit := model.AssetUnit{Id: "u1", AssetId: "a1", SerialNumber: "SN-1", Condition: model.UnitConditionGood, Status: model.UnitStatusAvailable, PurchaseDate: &purchase}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select (.+) from asset as a where a.id = \\$1 for update").WithArgs("a1").
+		WillReturnRows(sqlmock.NewRows([]string{"total", "has_unit", "on_loan"}).AddRow(2, true, true))
+	suite.mock.ExpectExec("insert into asset_unit").
+		WithArgs(unit.Id, unit.AssetId, unit.SerialNumber, unit.Condition, unit.Status, unit.PurchaseDate).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	//the asset count move by the new unit only
+	suite.mock.ExpectExec("update asset set total = total \\+ \\$2, available = available \\+ \\$3").
+		WithArgs("a1", 1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	assert.NoError(suite.T(), suite.repo.Save(unit))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AssetUnitRepositorySuite) TestSave_BulkStock() {
+	for _, row := range [][]any{{5, false, false}, {0, false, true}} {
+		suite.mock.ExpectBegin()
+		suite.mock.ExpectQuery("select (.+) from asset as a").WithArgs("a1").
+			WillReturnRows(sqlmock.NewRows([]string{"total", "has_unit", "on_loan"}).AddRow(row[0], row[1], row[2]))
+		suite.mock.ExpectRollback()
+
+		err := suite.repo.Save(model.AssetUnit{Id: "u1", AssetId: "a1", SerialNumber: "SN-1", Status: model.UnitStatusAvailable})
+		assert.ErrorIs(suite.T(), err, ErrAssetBulkStock)
+	}
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AssetUnitRepositorySuite) TestSave_DuplicateSerial() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select (.+) from asset as a").WithArgs("a1").
+		WillReturnRows(sqlmock.NewRows([]string{"total", "has_unit", "on_loan"}).AddRow(0, false, false))
+	suite.mock.ExpectExec("insert into asset_unit").WillReturnError(&pq.Error{Code: "23505"})
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.Save(model.AssetUnit{Id: "u1", AssetId: "a1", SerialNumber: "SN-1"})
+	assert.ErrorIs(suite.T(), err, ErrDuplicateSerial)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AssetUnitRepositorySuite) TestUpdate_Success() {
+	unit := model.AssetUnit{Id: "u1", SerialNumber: "SN-1", Condition: model.UnitConditionDamaged, Status: model.UnitStatusMaintenance}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select id_asset, status from asset_unit (.+) for update").
+		WithArgs(unit.Id, model.UnitStatusBorrowed).
+		WillReturnRows(sqlmock.NewRows([]string{"id_asset", "status"}).AddRow("a1", model.UnitStatusAvailable))
+	suite.mock.ExpectExec("update asset_unit set").
+		WithArgs(unit.Id, unit.SerialNumber, unit.Condition, unit.Status, unit.PurchaseDate).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	//the unit stay in the total, only available is taken
+	suite.mock.ExpectExec("update asset set total = total \\+ \\$2, available = available \\+ \\$3").
+		WithArgs("a1", 0, -1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	assert.NoError(suite.T(), suite.repo.Update(unit))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AssetUnitRepositorySuite) TestUpdate_Reserved() {
+	unit := model.AssetUnit{Id: "u1", SerialNumber: "SN-1", Condition: model.UnitConditionGood, Status: model.UnitStatusRetired}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select id_asset, status from asset_unit").
+		WillReturnRows(sqlmock.NewRows([]string{"id_asset", "status"}).AddRow("a1", model.UnitStatusAvailable))
+	suite.mock.ExpectExec("update asset_unit set").WillReturnResult(sqlmock.NewResult(0, 1))
+	//every available item is reserved by an approved request
+	suite.mock.ExpectExec("update asset set").WithArgs("a1", -1, -1).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.Update(unit)
+	assert.ErrorIs(suite.T(), err, ErrInsufficientStock)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AssetUnitRepositorySuite) TestUpdate_NoStockChange() {
+	unit := model.AssetUnit{Id: "u1", SerialNumber: "SN-2", Condition: model.UnitConditionFair, Status: model.UnitStatusAvailable}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select id_asset, status from asset_unit").
+		WillReturnRows(sqlmock.NewRows([]string{"id_asset", "status"}).AddRow("a1", model.UnitStatusAvailable))
+	suite.mock.ExpectExec("update asset_unit set").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	assert.NoError(suite.T(), suite.repo.Update(unit))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AssetUnitRepositorySuite) TestUpdate_Borrowed() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select id_asset, status from asset_unit").WillReturnRows(sqlmock.NewRows([]string{"id_asset", "status"}))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.Update(model.AssetUnit{Id: "u1"})
+	assert.ErrorIs(suite.T(), err, ErrUnitNotAvailable)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AssetUnitRepositorySuite) TestFindById() {
+	suite.mock.ExpectQuery("select (.+) from asset_unit where id = \\$1").WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_asset", "serial_number", "condition", "status", "purchase_date", "holder_nik"}).
+			AddRow("u1", "a1", "SN-1", model.UnitConditionGood, model.UnitStatusBorrowed, nil, "123"))
+	suite.mock.ExpectQuery("select (.+) from asset_unit where id = \\$1").WithArgs("u2").WillReturnError(sql.ErrNoRows)
+
+	unit, err := suite.repo.FindById("u1")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "123", unit.HolderNik)
+	assert.Nil(suite.T(), unit.PurchaseDate)
+
+	_, err = suite.repo.FindById("u2")
+	assert.ErrorIs(suite.T(), err, ErrUnitNotFound)
+}
+
+func (suite *AssetUnitRepositorySuite) TestFindBySerial() {
+	suite.mock.ExpectQuery("select (.+) from asset_unit where serial_number = \\$1").WithArgs("SN-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_asset", "serial_number", "condition", "status", "purchase_date", "holder_nik"}).
+			AddRow("u1", "a1", "SN-1", model.UnitConditionGood, model.UnitStatusAvailable, nil, ""))
+	suite.mock.ExpectQuery("select (.+) from asset_unit where serial_number = \\$1").WithArgs("SN-2").WillReturnError(sql.ErrNoRows)
+
+	unit, err := suite.repo.FindBySerial("SN-1")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "u1", unit.Id)
+
+	_, err = suite.repo.FindBySerial("SN-2")
+	assert.ErrorIs(suite.T(), err, ErrUnitNotFound)
+}
+
+func (suite *AssetUnitRepositorySuite) TestFindByAsset() {
+	suite.mock.ExpectQuery("select (.+) from asset_unit").WithArgs("a1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_asset", "serial_number", "condition", "status", "purchase_date", "holder_nik"}).
+			AddRow("u1", "a1", "SN-1", model.UnitConditionGood, model.UnitStatusAvailable, nil, "").
+			AddRow("u2", "a1", "SN-2", model.UnitConditionFair, model.UnitStatusRetired, nil, ""))
+	suite.mock.ExpectQuery("select (.+) from asset_unit").WithArgs("a2").WillReturnError(errors.New("failed"))
+
+	units, err := suite.repo.FindByAsset("a1")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), units, 2)
+
+	units, err = suite.repo.FindByAsset("a2")
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), units)
+}
+
+func (suite *AssetUnitRepositorySuite) TestFindByTransaction() {
+	returned := time.Now()
+	suite.mock.ExpectQuery("select (.+) from detail_manage_asset_unit").WithArgs("t1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_asset", "serial_number", "condition", "status", "purchase_date", "holder_nik", "id_detail", "returned_at"}).
+			AddRow("u1", "a1", "SN-1", model.UnitConditionGood, model.UnitStatusBorrowed, nil, "123", "d1", nil).
+			AddRow("u2", "a1", "SN-2", model.UnitConditionGood, model.UnitStatusAvailable, nil, "", "d1", returned))
+
+	units, err := suite.repo.FindByTransaction("t1")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), units, 2)
+	assert.Equal(suite.T(), "d1", units[0].DetailId)
+	assert.Nil(suite.T(), units[0].ReturnedAt)
+	assert.NotNil(suite.T(), units[1].ReturnedAt)
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// AuditRepository read the audit log, its entries are inserted by the repositories of the changes
+// they describe, inside the same transaction
+type AuditRepository interface {
+	FindAll(payload dto.AuditRequest) ([]model.AuditLog, dto.Paging, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+// FindAll list the entries newest first, only the filled fields of the request are filtered
+func (a *auditRepository) FindAll(payload dto.AuditRequest) ([]model.AuditLog, dto.Paging, error) {
+	if payload.Page <= 0 {
+		payload.Page = 1
+	}
+	if payload.Size <= 0 {
+		payload.Size = 10
+	}
+
+	var conditions []string
+	var args []any
+	filters := []struct{ column, value string }{
+		{"entity", payload.Entity},
+		{"entity_id", payload.EntityId},
+		{"actor_id", payload.ActorId},
+	}
+	for _, filter := range filters {
+		if filter.value == "" {
+			continue
+		}
+		args = append(args, filter.value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", filter.column, len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " where " + strings.Join(conditions, " and ")
+	}
+
+	query := `select id, actor_id, entity, entity_id, action, coalesce(before, 'null'), coalesce(after, 'null'), created_at
+		from audit_log` + where + fmt.Sprintf(" order by created_at desc limit $%d offset $%d", len(args)+1, len(args)+2)
+	rows, err := a.db.Query(query, append(args, payload.Size, (payload.Page-1)*payload.Size)...)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+	defer rows.Close()
+
+	var entries []model.AuditLog
+	for rows.Next() {
+		var entry model.AuditLog
+		var before, after []byte
+		err = rows.Scan(&entry.Id, &entry.ActorId, &entry.Entity, &entry.EntityId, &entry.Action, &before, &after, &entry.CreatedAt)
+		if err != nil {
+			return nil, dto.Paging{}, err
+		}
+		entry.Before = rawJSON(before)
+		entry.After = rawJSON(after)
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	var count int
+	if err = a.db.QueryRow(`select count(id) from audit_log`+where, args...).Scan(&count); err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	paging := dto.Paging{
+		Page:       payload.Page,
+		Size:       payload.Size,
+		TotalRows:  count,
+		TotalPages: int(math.Ceil(float64(count) / float64(payload.Size))),
+	}
+	return entries, paging, nil
+}
+
+// insertAudit append the entries to the audit log, exec is the transaction of the change they describe
+func insertAudit(exec execer, entries ...model.AuditLog) error {
+	query := `insert into audit_log (id, actor_id, entity, entity_id, action, before, after, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)`
+	for _, entry := range entries {
+		_, err := exec.Exec(query, entry.Id, entry.ActorId, entry.Entity, entry.EntityId, entry.Action,
+			nullJSON(entry.Before), nullJSON(entry.After), entry.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to audit %s of %s %s: %v", entry.Action, entry.Entity, entry.EntityId, err)
+		}
+	}
+	return nil
+}
+
+// auditAfter set the after state of the entry to the row as it was saved
+func auditAfter(entry model.AuditLog, after any) (model.AuditLog, error) {
+	raw, err := json.Marshal(after)
+	if err != nil {
+		return model.AuditLog{}, err
+	}
+	entry.After = raw
+	return entry, nil
+}
+
+// inTx run change in one transaction, it is rolled back when change fail
+func inTx(db *sql.DB, change func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = change(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// nullJSON store a missing state as sql null, the driver would send []byte as bytea so it is passed as text
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
+
+func rawJSON(raw []byte) json.RawMessage {
+	if string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo AuditRepository
+}
+
+func (suite *AuditRepositorySuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.db = db
+	suite.mock = mock
+	suite.repo = NewAuditRepository(db)
+}
+
+func (suite *AuditRepositorySuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestAuditRepositorySuite(t *testing.T) {
+	suite.Run(t, new(AuditRepositorySuite))
+}
+
+func (suite *AuditRepositorySuite) TestInsertAudit_Success() {
+	now := time.Now()
+	entry := model.AuditLog{Id: "1", ActorId: "user-1", Entity: model.AuditEntityCategory, EntityId: "c-1",
+		Action: model.AuditActionCreate, After: json.RawMessage(`{"id":"c-1"}`), CreatedAt: now}
+	suite.mock.ExpectExec("insert into audit_log").
+		WithArgs("1", "user-1", model.AuditEntityCategory, "c-1", model.AuditActionCreate, nil, `{"id":"c-1"}`, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(suite.T(), insertAudit(suite.db, entry))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AuditRepositorySuite) TestInsertAudit_Fail() {
+	suite.mock.ExpectExec("insert into audit_log").WillReturnError(errors.New("db down"))
+
+	assert.Error(suite.T(), insertAudit(suite.db, model.AuditLog{Id: "1"}))
+}
+
+// the change is rolled back when its audit entry cannot be written
+func (suite *AuditRepositorySuite) TestInTx_AuditFail() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("update category").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("insert into audit_log").WillReturnError(errors.New("db down"))
+	suite.mock.ExpectRollback()
+
+	err := inTx(suite.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec("update category set name = 'x'"); err != nil {
+			return err
+		}
+		return insertAudit(tx, model.AuditLog{Id: "1"})
+	})
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AuditRepositorySuite) TestFindAll_Filtered() {
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "actor_id", "entity", "entity_id", "action", "before", "after", "created_at"}).
+		AddRow("2", "user-1", model.AuditEntityAsset, "a-1", model.AuditActionDelete, []byte(`{"id":"a-1"}`), []byte("null"), now)
+	suite.mock.ExpectQuery(`from audit_log where entity = \$1 and entity_id = \$2 order by created_at desc limit \$3 offset \$4`).
+		WithArgs(model.AuditEntityAsset, "a-1", 5, 5).
+		WillReturnRows(rows)
+	suite.mock.ExpectQuery(`select count\(id\) from audit_log where entity = \$1 and entity_id = \$2`).
+		WithArgs(model.AuditEntityAsset, "a-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+
+	entries, paging, err := suite.repo.FindAll(dto.AuditRequest{Entity: model.AuditEntityAsset, EntityId: "a-1", Page: 2, Size: 5})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	assert.JSONEq(suite.T(), `{"id":"a-1"}`, string(entries[0].Before))
+	assert.Nil(suite.T(), entries[0].After)
+	assert.Equal(suite.T(), 2, paging.TotalPages)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AuditRepositorySuite) TestFindAll_NoFilter() {
+	suite.mock.ExpectQuery(`from audit_log order by created_at desc limit \$1 offset \$2`).
+		WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "entity", "entity_id", "action", "before", "after", "created_at"}))
+	suite.mock.ExpectQuery(`select count\(id\) from audit_log`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	entries, paging, err := suite.repo.FindAll(dto.AuditRequest{})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), entries)
+	assert.Equal(suite.T(), 1, paging.Page)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *AuditRepositorySuite) TestFindAll_QueryFail() {
+	suite.mock.ExpectQuery("from audit_log").WillReturnError(errors.New("db down"))
+
+	_, _, err := suite.repo.FindAll(dto.AuditRequest{Entity: model.AuditEntityStaff})
+	assert.Error(suite.T(), err)
+}
+
+// testAudit is the entry the usecase pass along with a change
+var testAudit = model.AuditLog{Id: "audit-1", ActorId: "user-1"}
+
+// expectAudit expect the audit entry to be written and the change committed
+func expectAudit(mock sqlmock.Sqlmock) {
+	mock.ExpectExec("insert into audit_log").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+}
@@ -5,12 +5,15 @@ import (
 	"final-project-enigma-clean/model"
 )
 
+// CategoryRepository write every change with its audit entry in one transaction
 type CategoryRepository interface {
-	Save(category model.Category) error
+	Save(category model.Category, audit model.AuditLog) error
 	FindById(id string) (model.Category, error)
 	FindAll() ([]model.Category, error)
-	Update(category model.Category) error
-	Delete(id string) error
+	Update(category model.Category, audit model.AuditLog) error
+	Delete(id string, audit model.AuditLog) error
+	FindAllWithDeleted() ([]model.Category, error)
+	Restore(id string, audit model.AuditLog) error
 }
 
 type categoryRepository struct {
@@ -19,7 +22,7 @@ type categoryRepository struct {
 
 // FindById implements categoryRepository.
 func (c *categoryRepository) FindById(id string) (model.Category, error) {
-	row := c.db.QueryRow("SELECT id, name FROM category WHERE id = $1", id)
+	row := c.db.QueryRow("SELECT id, name FROM category WHERE id = $1 AND deleted_at IS NULL", id)
 	var category model.Category
 	err := row.Scan(&category.Id, &category.Name)
 	if err != nil {
@@ -31,17 +34,20 @@ func (c *categoryRepository) FindById(id string) (model.Category, error) {
 }
 
 // Delete implements categoryRepository.
-func (c *categoryRepository) Delete(id string) error {
-	_, err := c.db.Exec("DELETE FROM category WHERE id= $1", id)
-	if err != nil {
-		return err
-	}
-	return nil
+// the row is only marked deleted, assets keep pointing to it
+func (c *categoryRepository) Delete(id string, audit model.AuditLog) error {
+	return inTx(c.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE category SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 // FindAll implements categoryRepository.
 func (c *categoryRepository) FindAll() ([]model.Category, error) {
-	rows, err := c.db.Query("SELECT id, name FROM category")
+	rows, err := c.db.Query("SELECT id, name FROM category WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -57,22 +63,65 @@ func (c *categoryRepository) FindAll() ([]model.Category, error) {
 	return categories, nil
 }
 
-// Save implements categoryRepository.
-func (c *categoryRepository) Save(category model.Category) error {
-	_, err := c.db.Exec("INSERT INTO category VALUES ($1,$2)", category.Id, category.Name)
+// FindAllWithDeleted implements categoryRepository.
+func (c *categoryRepository) FindAllWithDeleted() ([]model.Category, error) {
+	rows, err := c.db.Query("SELECT id, name, deleted_at FROM category")
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var categories []model.Category
+	for rows.Next() {
+		var category model.Category
+		if err = rows.Scan(&category.Id, &category.Name, &category.DeletedAt); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
 	}
-	return nil
+	return categories, nil
+}
+
+// Restore implements categoryRepository.
+// the restored row is read back in the transaction as the after state of the audit entry
+func (c *categoryRepository) Restore(id string, audit model.AuditLog) error {
+	return inTx(c.db, func(tx *sql.Tx) error {
+		if err := restore(tx, "UPDATE category SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", id); err != nil {
+			return err
+		}
+		var restored model.Category
+		if err := tx.QueryRow("SELECT id, name FROM category WHERE id = $1", id).Scan(&restored.Id, &restored.Name); err != nil {
+			return err
+		}
+		audit, err := auditAfter(audit, restored)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
+}
+
+// Save implements categoryRepository.
+func (c *categoryRepository) Save(category model.Category, audit model.AuditLog) error {
+	return inTx(c.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO category (id, name) VALUES ($1,$2)", category.Id, category.Name)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 // Update implements categoryRepository.
-func (c *categoryRepository) Update(category model.Category) error {
-	_, err := c.db.Exec("UPDATE category SET name=$2 WHERE id=$1", category.Id, category.Name)
-	if err != nil {
-		return err
-	}
-	return nil
+func (c *categoryRepository) Update(category model.Category, audit model.AuditLog) error {
+	return inTx(c.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE category SET name=$2 WHERE id=$1 AND deleted_at IS NULL", category.Id, category.Name)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 func NewCategoryRepository(db *sql.DB) CategoryRepository {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"final-project-enigma-clean/model"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -35,8 +36,10 @@ func (suite *CategoryRepositoryTest) TestCreate_Success() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("INSERT INTO category").WithArgs(mockData.Id, mockData.Name).WillReturnResult(sqlmock.NewResult(1,1))
-	err := suite.repo.Save(mockData)
+	expectAudit(suite.mockSQL)
+	err := suite.repo.Save(mockData, testAudit)
 	assert.NoError(suite.T(), err)
 }
 
@@ -45,8 +48,10 @@ func (suite *CategoryRepositoryTest) TestCreate_Failed() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("INSERT INTO category").WithArgs(mockData.Id, mockData.Name).WillReturnError(errors.New("failed save category"))
-	err := suite.repo.Save(mockData)
+	suite.mockSQL.ExpectRollback()
+	err := suite.repo.Save(mockData, testAudit)
 	assert.Error(suite.T(), err)
 }
 
@@ -120,8 +125,10 @@ func (suite *CategoryRepositoryTest) TestUpdate_Success() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("UPDATE category SET").WithArgs(mockData.Id, mockData.Name).WillReturnResult(sqlmock.NewResult(1,1))
-	err := suite.repo.Update(mockData)
+	expectAudit(suite.mockSQL)
+	err := suite.repo.Update(mockData, testAudit)
 	assert.NoError(suite.T(), err)
 }	
 
@@ -130,19 +137,55 @@ func (suite *CategoryRepositoryTest) TestUpdate_Failed() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("UPDATE category SET").WithArgs(mockData.Id, mockData.Name).WillReturnError(errors.New("failed update category"))
-	err := suite.repo.Update(mockData)
+	suite.mockSQL.ExpectRollback()
+	err := suite.repo.Update(mockData, testAudit)
 	assert.Error(suite.T(), err)
 }
 
 func (suite *CategoryRepositoryTest) TestDelete_Success() {
-	suite.mockSQL.ExpectExec("DELETE FROM category").WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
-	gotErr := suite.repo.Delete("1")
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE category SET deleted_at").WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+	gotErr := suite.repo.Delete("1", testAudit)
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *CategoryRepositoryTest) TestDelete_Failed() {
-	suite.mockSQL.ExpectExec("DELETE FROM category").WithArgs("1").WillReturnError(errors.New("failed delete category"))
-	gotErr := suite.repo.Delete("1")
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE category SET deleted_at").WithArgs("1").WillReturnError(errors.New("failed delete category"))
+	suite.mockSQL.ExpectRollback()
+	gotErr := suite.repo.Delete("1", testAudit)
 	assert.Error(suite.T(), gotErr)
-}
\ No newline at end of file
+}
+func (suite *CategoryRepositoryTest) TestFindAllWithDeleted_Success() {
+	deletedAt := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "deleted_at"}).
+		AddRow("1", "Elektronik", nil).
+		AddRow("2", "Kendaraan", deletedAt)
+	suite.mockSQL.ExpectQuery("SELECT id, name, deleted_at FROM category").WillReturnRows(rows)
+
+	categories, err := suite.repo.FindAllWithDeleted()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), categories, 2)
+	assert.Nil(suite.T(), categories[0].DeletedAt)
+	assert.Equal(suite.T(), deletedAt, *categories[1].DeletedAt)
+}
+
+func (suite *CategoryRepositoryTest) TestRestore_Success() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE category SET deleted_at = NULL").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectQuery("SELECT id, name FROM category").WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "Elektronik"))
+	expectAudit(suite.mockSQL)
+	assert.NoError(suite.T(), suite.repo.Restore("1", testAudit))
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *CategoryRepositoryTest) TestRestore_NotDeleted() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE category SET deleted_at = NULL").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSQL.ExpectRollback()
+	assert.ErrorIs(suite.T(), suite.repo.Restore("1", testAudit), ErrNotDeleted)
+}
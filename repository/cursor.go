@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/util/helper"
+)
+
+// cursorPage trim the extra row fetched to know a next page exist,
+// the key of the last row kept is the cursor of the next page
+func cursorPage[T any](rows []T, limit int, key func(T) string) ([]T, dto.CursorPaging) {
+	paging := dto.CursorPaging{Limit: limit}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		paging.NextCursor = helper.EncodeCursor(key(rows[limit-1]))
+	}
+	return rows, paging
+}
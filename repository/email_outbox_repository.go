@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrOutboxNotFound  = errors.New("email not found in outbox")
+	ErrOutboxSensitive = errors.New("email carry a secret and cannot be resent")
+)
+
+type EmailOutboxRepository interface {
+	Enqueue(email model.EmailOutbox) error
+	ClaimDue(limit int, lease time.Duration) ([]model.EmailOutbox, error)
+	MarkSent(id string) error
+	MarkRetry(id string, attempts int, nextAttemptAt time.Time, lastError string) error
+	MarkFailed(id string, attempts int, lastError string) error
+	FindFailed(payload dto.PageRequest) ([]model.EmailOutbox, dto.Paging, error)
+	Requeue(id string) error
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx so the outbox row can join the caller transaction
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertOutbox write the email into the outbox, repositories call it inside their own
+// transaction so the email only exist when the triggering change is committed
+func insertOutbox(exec execer, email model.EmailOutbox) error {
+	query := `insert into email_outbox (id, recipient, subject, html, sensitive, status, attempts, next_attempt_at, created_at)
+		values ($1, $2, $3, $4, $5, $6, 0, $7, $7)`
+	_, err := exec.Exec(query, email.Id, email.Recipient, email.Subject, email.Html, email.Sensitive, model.OutboxStatusPending,
+		email.CreatedAt)
+	return err
+}
+
+type emailOutboxRepository struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func (e *emailOutboxRepository) Enqueue(email model.EmailOutbox) error {
+	if email.CreatedAt.IsZero() {
+		email.CreatedAt = e.now()
+	}
+	if err := insertOutbox(e.db, email); err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	return nil
+}
+
+// ClaimDue lease pending emails that are due, a leased email is invisible to other
+// workers until the lease expire, so a crashed worker does not lose the email
+func (e *emailOutboxRepository) ClaimDue(limit int, lease time.Duration) ([]model.EmailOutbox, error) {
+	now := e.now()
+	query := `update email_outbox set next_attempt_at = $3
+		where id in (select id from email_outbox where status = $1 and next_attempt_at <= $2
+			order by next_attempt_at limit $4 for update skip locked)
+		returning id, recipient, subject, html, status, attempts, coalesce(last_error, ''), next_attempt_at, created_at`
+	rows, err := e.db.Query(query, model.OutboxStatusPending, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var emails []model.EmailOutbox
+	for rows.Next() {
+		var email model.EmailOutbox
+		err = rows.Scan(&email.Id, &email.Recipient, &email.Subject, &email.Html, &email.Status, &email.Attempts,
+			&email.LastError, &email.NextAttemptAt, &email.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	return emails, rows.Err()
+}
+
+// MarkSent clear the html, a sent email is never delivered again and its body may carry a secret
+func (e *emailOutboxRepository) MarkSent(id string) error {
+	query := `update email_outbox set status = $2, sent_at = $3, attempts = attempts + 1, last_error = null, html = ''
+		where id = $1`
+	_, err := e.db.Exec(query, id, model.OutboxStatusSent, e.now())
+	return err
+}
+
+func (e *emailOutboxRepository) MarkRetry(id string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	query := `update email_outbox set attempts = $2, next_attempt_at = $3, last_error = $4 where id = $1`
+	_, err := e.db.Exec(query, id, attempts, nextAttemptAt, lastError)
+	return err
+}
+
+// MarkFailed dead-letter the email, it is only sent again by an admin resend.
+// A sensitive email lose its html as it cannot be resent
+func (e *emailOutboxRepository) MarkFailed(id string, attempts int, lastError string) error {
+	query := `update email_outbox set status = $2, attempts = $3, last_error = $4,
+		html = case when sensitive then '' else html end
+		where id = $1`
+	_, err := e.db.Exec(query, id, model.OutboxStatusFailed, attempts, lastError)
+	return err
+}
+
+func (e *emailOutboxRepository) FindFailed(payload dto.PageRequest) ([]model.EmailOutbox, dto.Paging, error) {
+	if payload.Page <= 0 {
+		payload.Page = 1
+	}
+	if payload.Size <= 0 {
+		payload.Size = 10
+	}
+	query := `select id, recipient, subject, sensitive, status, attempts, coalesce(last_error, ''), next_attempt_at, created_at
+		from email_outbox where status = $1 order by created_at desc limit $2 offset $3`
+	rows, err := e.db.Query(query, model.OutboxStatusFailed, payload.Size, (payload.Page-1)*payload.Size)
+	if err != nil {
+		return nil, dto.Paging{}, err
+	}
+	defer rows.Close()
+
+	var emails []model.EmailOutbox
+	for rows.Next() {
+		var email model.EmailOutbox
+		err = rows.Scan(&email.Id, &email.Recipient, &email.Subject, &email.Sensitive, &email.Status, &email.Attempts,
+			&email.LastError, &email.NextAttemptAt, &email.CreatedAt)
+		if err != nil {
+			return nil, dto.Paging{}, err
+		}
+		emails = append(emails, email)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	var count int
+	if err = e.db.QueryRow(`select count(id) from email_outbox where status = $1`, model.OutboxStatusFailed).Scan(&count); err != nil {
+		return nil, dto.Paging{}, err
+	}
+
+	paging := dto.Paging{
+		Page:       payload.Page,
+		Size:       payload.Size,
+		TotalRows:  count,
+		TotalPages: int(math.Ceil(float64(count) / float64(payload.Size))),
+	}
+	return emails, paging, nil
+}
+
+// Requeue give a dead-lettered email a fresh set of attempts, a sensitive one would send a stale secret
+// and is left failed
+func (e *emailOutboxRepository) Requeue(id string) error {
+	query := `update email_outbox set status = $2, attempts = 0, next_attempt_at = $3 where id = $1 and status = $4 and not sensitive`
+	res, err := e.db.Exec(query, id, model.OutboxStatusPending, e.now(), model.OutboxStatusFailed)
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var sensitive bool
+	err = e.db.QueryRow(`select sensitive from email_outbox where id = $1 and status = $2`, id, model.OutboxStatusFailed).Scan(&sensitive)
+	if err == sql.ErrNoRows {
+		return ErrOutboxNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	return ErrOutboxSensitive
+}
+
+func NewEmailOutboxRepository(db *sql.DB) EmailOutboxRepository {
+	return &emailOutboxRepository{
+		db:  db,
+		now: time.Now,
+	}
+}
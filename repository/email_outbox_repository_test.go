@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type EmailOutboxRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *emailOutboxRepository
+	now  time.Time
+}
+
+func (suite *EmailOutboxRepositorySuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.db = db
+	suite.mock = mock
+	suite.now = time.Date(2023, 9, 10, 7, 0, 0, 0, time.UTC)
+	suite.repo = &emailOutboxRepository{db: db, now: func() time.Time { return suite.now }}
+}
+
+func (suite *EmailOutboxRepositorySuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestEmailOutboxRepositorySuite(t *testing.T) {
+	suite.Run(t, new(EmailOutboxRepositorySuite))
+}
+
+func (suite *EmailOutboxRepositorySuite) TestEnqueue_Success() {
+	email := model.EmailOutbox{Id: "mail-1", Recipient: "a@example.com", Subject: "Hi", Html: "<p>hi</p>"}
+	suite.mock.ExpectExec("insert into email_outbox").
+		WithArgs(email.Id, email.Recipient, email.Subject, email.Html, false, model.OutboxStatusPending, suite.now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(suite.T(), suite.repo.Enqueue(email))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *EmailOutboxRepositorySuite) TestEnqueue_Fail() {
+	suite.mock.ExpectExec("insert into email_outbox").WillReturnError(errors.New("db down"))
+
+	assert.Error(suite.T(), suite.repo.Enqueue(model.EmailOutbox{Id: "mail-1"}))
+}
+
+func (suite *EmailOutboxRepositorySuite) TestClaimDue_Success() {
+	rows := sqlmock.NewRows([]string{"id", "recipient", "subject", "html", "status", "attempts", "last_error", "next_attempt_at", "created_at"}).
+		AddRow("mail-1", "a@example.com", "Hi", "<p>hi</p>", model.OutboxStatusPending, 2, "timeout", suite.now.Add(time.Minute), suite.now)
+	suite.mock.ExpectQuery("update email_outbox set next_attempt_at (.+) for update skip locked").
+		WithArgs(model.OutboxStatusPending, suite.now, suite.now.Add(time.Minute), 10).
+		WillReturnRows(rows)
+
+	emails, err := suite.repo.ClaimDue(10, time.Minute)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), emails, 1)
+	assert.Equal(suite.T(), 2, emails[0].Attempts)
+	assert.Equal(suite.T(), "<p>hi</p>", emails[0].Html)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *EmailOutboxRepositorySuite) TestMarkRetryAndFailed() {
+	next := suite.now.Add(time.Minute)
+	suite.mock.ExpectExec("update email_outbox set attempts").
+		WithArgs("mail-1", 3, next, "timeout").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec(regexp.QuoteMeta("html = case when sensitive then '' else html end")).
+		WithArgs("mail-1", model.OutboxStatusFailed, 8, "timeout").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(suite.T(), suite.repo.MarkRetry("mail-1", 3, next, "timeout"))
+	assert.NoError(suite.T(), suite.repo.MarkFailed("mail-1", 8, "timeout"))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *EmailOutboxRepositorySuite) TestFindFailed_Success() {
+	rows := sqlmock.NewRows([]string{"id", "recipient", "subject", "sensitive", "status", "attempts", "last_error", "next_attempt_at", "created_at"}).
+		AddRow("mail-1", "a@example.com", "Hi", false, model.OutboxStatusFailed, 8, "timeout", suite.now, suite.now)
+	suite.mock.ExpectQuery("select (.+) from email_outbox where status = \\$1").
+		WithArgs(model.OutboxStatusFailed, 5, 5).WillReturnRows(rows)
+	suite.mock.ExpectQuery("select count\\(id\\) from email_outbox").
+		WithArgs(model.OutboxStatusFailed).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+
+	emails, paging, err := suite.repo.FindFailed(dto.PageRequest{Page: 2, Size: 5})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), emails, 1)
+	assert.Equal(suite.T(), dto.Paging{Page: 2, Size: 5, TotalRows: 6, TotalPages: 2}, paging)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *EmailOutboxRepositorySuite) TestRequeue() {
+	suite.mock.ExpectExec("update email_outbox set status").
+		WithArgs("mail-1", model.OutboxStatusPending, suite.now, model.OutboxStatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("update email_outbox set status").
+		WithArgs("mail-2", model.OutboxStatusPending, suite.now, model.OutboxStatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery("select sensitive from email_outbox").
+		WithArgs("mail-2", model.OutboxStatusFailed).WillReturnError(sql.ErrNoRows)
+
+	assert.NoError(suite.T(), suite.repo.Requeue("mail-1"))
+	//only a dead-lettered email can be requeued
+	assert.ErrorIs(suite.T(), suite.repo.Requeue("mail-2"), ErrOutboxNotFound)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+// a sent otp email keep no trace of the code
+func (suite *EmailOutboxRepositorySuite) TestMarkSent_ClearHtml() {
+	suite.mock.ExpectExec(regexp.QuoteMeta("html = ''")).
+		WithArgs("mail-1", model.OutboxStatusSent, suite.now).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(suite.T(), suite.repo.MarkSent("mail-1"))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+// a dead-lettered otp would send a stale code, it is not requeued
+func (suite *EmailOutboxRepositorySuite) TestRequeue_Sensitive() {
+	suite.mock.ExpectExec("update email_outbox set status (.+) and not sensitive").
+		WithArgs("mail-1", model.OutboxStatusPending, suite.now, model.OutboxStatusFailed).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery("select sensitive from email_outbox").
+		WithArgs("mail-1", model.OutboxStatusFailed).WillReturnRows(sqlmock.NewRows([]string{"sensitive"}).AddRow(true))
+
+	assert.ErrorIs(suite.T(), suite.repo.Requeue("mail-1"), ErrOutboxSensitive)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,458 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrLoanRequestNotFound = errors.New("loan request not found")
+	ErrLoanRequestState    = errors.New("loan request cannot change from its current status")
+)
+
+// LoanRequestRepository keep the loan requests, every change write its audit entry and every transition
+// queue its notification emails in the same transaction so they are only kept when the change is committed
+type LoanRequestRepository interface {
+	Save(request model.LoanRequest, audit model.AuditLog) error
+	Update(request model.LoanRequest, audit model.AuditLog) error
+	FindById(id string) (model.LoanRequest, error)
+	FindAll(query dto.LoanRequestQuery) ([]model.LoanRequest, error)
+	FindApprovers(roles []string) ([]model.UserCredentials, error)
+	Submit(id string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error
+	Approve(id, approverId, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error
+	Reject(id, approverId, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error
+	Issue(id, issuerId string, loan dto.ManageAssetRequest, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error
+	MarkReturned(id string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) (bool, error)
+	Close(id, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error
+}
+
+const queryLoanRequest = `select r.id, u.id, u.name, u.email, u.locale, s.nik_staff, s.name, s.email, r.duration, r.status, r.note,
+	r.comment, coalesce(r.decided_by, ''), r.decided_at, coalesce(r.issued_by, ''), r.issued_at, coalesce(r.id_manage_asset, ''),
+	r.created_at, r.updated_at
+	from loan_request as r
+	join user_credential as u on u.id = r.id_user
+	join staff as s on s.nik_staff = r.nik_staff`
+
+type loanRequestRepository struct {
+	db *sql.DB
+}
+
+// Save implements LoanRequestRepository.
+func (l *loanRequestRepository) Save(request model.LoanRequest, audit model.AuditLog) error {
+	query := `insert into loan_request (id, id_user, nik_staff, duration, status, note, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $7)`
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(query, request.Id, request.Requester.ID, request.Staff.Nik_Staff, request.Duration, request.Status, request.Note, request.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = insertLoanRequestDetails(tx, request); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = insertAudit(tx, audit); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Update implements LoanRequestRepository.
+// Only a draft is edited, its lines are replaced by the lines of the request
+func (l *loanRequestRepository) Update(request model.LoanRequest, audit model.AuditLog) error {
+	query := `update loan_request set nik_staff = $2, duration = $3, note = $4, updated_at = $5 where id = $1`
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = lockLoanRequest(tx, request.Id, model.LoanRequestDraft); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(query, request.Id, request.Staff.Nik_Staff, request.Duration, request.Note, request.UpdatedAt); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(`delete from loan_request_detail where id_loan_request = $1`, request.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = insertLoanRequestDetails(tx, request); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = insertAudit(tx, audit); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindById implements LoanRequestRepository.
+func (l *loanRequestRepository) FindById(id string) (model.LoanRequest, error) {
+	request, err := scanLoanRequest(l.db.QueryRow(queryLoanRequest+" where r.id = $1", id))
+	if err == sql.ErrNoRows {
+		return model.LoanRequest{}, ErrLoanRequestNotFound
+	}
+	if err != nil {
+		return model.LoanRequest{}, err
+	}
+
+	requests := []model.LoanRequest{request}
+	if err = l.attachDetails(requests); err != nil {
+		return model.LoanRequest{}, err
+	}
+	return requests[0], nil
+}
+
+// FindAll implements LoanRequestRepository.
+// It list the requests newest first, with their lines
+func (l *loanRequestRepository) FindAll(query dto.LoanRequestQuery) ([]model.LoanRequest, error) {
+	var conditions []string
+	var args []any
+	filters := []struct{ column, value string }{
+		{"r.status", query.Status},
+		{"r.id_user", query.RequesterId},
+	}
+	for _, filter := range filters {
+		if filter.value == "" {
+			continue
+		}
+		args = append(args, filter.value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", filter.column, len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " where " + strings.Join(conditions, " and ")
+	}
+
+	rows, err := l.db.Query(queryLoanRequest+where+" order by r.created_at desc", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []model.LoanRequest
+	for rows.Next() {
+		request, err := scanLoanRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if err = l.attachDetails(requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// attachDetails read the lines of all the requests in one query
+func (l *loanRequestRepository) attachDetails(requests []model.LoanRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+	ids := make([]string, len(requests))
+	index := make(map[string]int, len(requests))
+	for i, request := range requests {
+		ids[i] = request.Id
+		index[request.Id] = i
+	}
+
+	query := `select d.id, d.id_loan_request, a.id, a.name, d.total_item from loan_request_detail as d
+		join asset as a on a.id = d.id_asset
+		where d.id_loan_request = any($1) order by a.name, d.id`
+	rows, err := l.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail model.LoanRequestDetail
+		if err = rows.Scan(&detail.Id, &detail.LoanRequestId, &detail.Asset.Id, &detail.Asset.Name, &detail.TotalItem); err != nil {
+			return err
+		}
+		i := index[detail.LoanRequestId]
+		requests[i].Detail = append(requests[i].Detail, detail)
+	}
+	return rows.Err()
+}
+
+// FindApprovers implements LoanRequestRepository.
+// It list the active users in the roles, they are told about a submitted request
+func (l *loanRequestRepository) FindApprovers(roles []string) ([]model.UserCredentials, error) {
+	query := `select id, name, email, locale from user_credential where role = any($1) and is_active order by name`
+
+	rows, err := l.db.Query(query, pq.Array(roles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []model.UserCredentials
+	for rows.Next() {
+		var user model.UserCredentials
+		if err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.Locale); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Submit implements LoanRequestRepository.
+func (l *loanRequestRepository) Submit(id string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	return l.transition(id, emails, audit, func(tx *sql.Tx) error {
+		if _, err := lockLoanRequest(tx, id, model.LoanRequestDraft); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`update loan_request set status = $2, updated_at = $3 where id = $1`, id, model.LoanRequestSubmitted, now)
+		return err
+	})
+}
+
+// Approve implements LoanRequestRepository.
+// The stock of every line is reserved with the approval, it fail as a whole when one asset is short
+func (l *loanRequestRepository) Approve(id, approverId, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	queryStock := "update asset set available = available - $2 where id = $1 and available >= $2"
+
+	return l.transition(id, emails, audit, func(tx *sql.Tx) error {
+		if _, err := lockLoanRequest(tx, id, model.LoanRequestSubmitted); err != nil {
+			return err
+		}
+		if err := decideLoanRequest(tx, id, model.LoanRequestApproved, approverId, comment, now); err != nil {
+			return err
+		}
+
+		details, err := loanRequestStock(tx, id)
+		if err != nil {
+			return err
+		}
+		for _, detail := range details {
+			res, err := tx.Exec(queryStock, detail.IdAsset, detail.TotalItem)
+			if err != nil {
+				return err
+			}
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return fmt.Errorf("%w: asset %s", ErrInsufficientStock, detail.IdAsset)
+			}
+		}
+		return nil
+	})
+}
+
+// Reject implements LoanRequestRepository.
+func (l *loanRequestRepository) Reject(id, approverId, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	return l.transition(id, emails, audit, func(tx *sql.Tx) error {
+		if _, err := lockLoanRequest(tx, id, model.LoanRequestSubmitted); err != nil {
+			return err
+		}
+		return decideLoanRequest(tx, id, model.LoanRequestRejected, approverId, comment, now)
+	})
+}
+
+// Issue implements LoanRequestRepository.
+// The loan is written like CreateTransaction does, except the stock that was reserved on approval
+func (l *loanRequestRepository) Issue(id, issuerId string, loan dto.ManageAssetRequest, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	query := "insert into manage_asset(id, id_user, nik_staff, submission_date, return_date) values($1, $2, $3, $4, $5)"
+	queryDetail := "insert into detail_manage_asset(id, id_asset, id_manage_asset, total_item, status) values ($1, $2, $3, $4, $5)"
+	queryRequest := `update loan_request set status = $2, issued_by = $3, issued_at = $4, id_manage_asset = $5, updated_at = $4 where id = $1`
+
+	return l.transition(id, emails, audit, func(tx *sql.Tx) error {
+		if _, err := lockLoanRequest(tx, id, model.LoanRequestApproved); err != nil {
+			return err
+		}
+		_, err := tx.Exec(query, loan.Id, loan.IdUser, loan.NikStaff, loan.SubmisstionDate, loan.ReturnDate)
+		if err != nil {
+			return err
+		}
+		for _, detail := range loan.ManageAssetDetailReq {
+			if _, err = tx.Exec(queryDetail, detail.Id, detail.IdAsset, loan.Id, detail.TotalItem, detail.Status); err != nil {
+				return err
+			}
+			if err = lendUnits(tx, detail, loan.NikStaff); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(queryRequest, id, model.LoanRequestIssued, issuerId, now, loan.Id)
+		return err
+	})
+}
+
+// MarkReturned implements LoanRequestRepository.
+// An issued request is returned once every item of its loan is back, it return false when the loan is not
+func (l *loanRequestRepository) MarkReturned(id string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) (bool, error) {
+	query := `update loan_request set status = $2, updated_at = $3 where id = $1 and status = $4
+		and exists (select 1 from manage_asset as m where m.id = loan_request.id_manage_asset and m.actual_return_date is not null)`
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	res, err := tx.Exec(query, id, model.LoanRequestReturned, now, model.LoanRequestIssued)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil || affected == 0 {
+		tx.Rollback()
+		return false, err
+	}
+	for _, email := range emails {
+		if err = insertOutbox(tx, email); err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("failed to queue email of loan request %s: %v", id, err)
+		}
+	}
+	if err = insertAudit(tx, audit); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Close implements LoanRequestRepository.
+// A request that was not issued can be closed, the stock reserved by an approval is given back
+func (l *loanRequestRepository) Close(id, comment string, now time.Time, emails []model.EmailOutbox, audit model.AuditLog) error {
+	queryStock := "update asset set available = available + $2 where id = $1"
+
+	return l.transition(id, emails, audit, func(tx *sql.Tx) error {
+		status, err := lockLoanRequest(tx, id, model.LoanRequestDraft, model.LoanRequestSubmitted, model.LoanRequestApproved)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`update loan_request set status = $2, comment = $3, updated_at = $4 where id = $1`, id, model.LoanRequestClosed, comment, now)
+		if err != nil || status != model.LoanRequestApproved {
+			return err
+		}
+
+		details, err := loanRequestStock(tx, id)
+		if err != nil {
+			return err
+		}
+		for _, detail := range details {
+			if _, err = tx.Exec(queryStock, detail.IdAsset, detail.TotalItem); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// transition run the change, queue the emails and write the audit entry in one transaction
+func (l *loanRequestRepository) transition(id string, emails []model.EmailOutbox, audit model.AuditLog, change func(tx *sql.Tx) error) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err = change(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, email := range emails {
+		if err = insertOutbox(tx, email); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to queue email of loan request %s: %v", id, err)
+		}
+	}
+	if err = insertAudit(tx, audit); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockLoanRequest lock the request row and check it is in one of the statuses,
+// so two transitions of the same request cannot both happen
+func lockLoanRequest(tx *sql.Tx, id string, from ...string) (string, error) {
+	var status string
+	err := tx.QueryRow(`select status from loan_request where id = $1 for update`, id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", ErrLoanRequestNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, allowed := range from {
+		if status == allowed {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrLoanRequestState, status)
+}
+
+func decideLoanRequest(tx *sql.Tx, id, status, approverId, comment string, now time.Time) error {
+	query := `update loan_request set status = $2, decided_by = $3, decided_at = $4, comment = $5, updated_at = $4 where id = $1`
+	_, err := tx.Exec(query, id, status, approverId, now, comment)
+	return err
+}
+
+// loanRequestStock sum the items of the request per asset, in the order the assets are locked by every loan
+func loanRequestStock(tx *sql.Tx, id string) ([]dto.ManageAssetDetailRequest, error) {
+	rows, err := tx.Query(`select id_asset, sum(total_item) from loan_request_detail where id_loan_request = $1 group by id_asset`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var details []dto.ManageAssetDetailRequest
+	for rows.Next() {
+		var detail dto.ManageAssetDetailRequest
+		if err = rows.Scan(&detail.IdAsset, &detail.TotalItem); err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].IdAsset < details[j].IdAsset })
+	return details, nil
+}
+
+func insertLoanRequestDetails(tx *sql.Tx, request model.LoanRequest) error {
+	query := `insert into loan_request_detail (id, id_loan_request, id_asset, total_item) values ($1, $2, $3, $4)`
+	for _, detail := range request.Detail {
+		if _, err := tx.Exec(query, detail.Id, request.Id, detail.Asset.Id, detail.TotalItem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanLoanRequest(row rowScanner) (model.LoanRequest, error) {
+	var request model.LoanRequest
+	err := row.Scan(&request.Id, &request.Requester.ID, &request.Requester.Name, &request.Requester.Email, &request.Requester.Locale,
+		&request.Staff.Nik_Staff, &request.Staff.Name, &request.Staff.Email, &request.Duration, &request.Status, &request.Note,
+		&request.Comment, &request.DecidedBy, &request.DecidedAt, &request.IssuedBy, &request.IssuedAt, &request.ManageAssetId,
+		&request.CreatedAt, &request.UpdatedAt)
+	return request, err
+}
+
+func NewLoanRequestRepository(db *sql.DB) LoanRequestRepository {
+	return &loanRequestRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoanRequestRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo LoanRequestRepository
+}
+
+func (suite *LoanRequestRepositorySuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.db = db
+	suite.mock = mock
+	suite.repo = NewLoanRequestRepository(db)
+}
+
+func (suite *LoanRequestRepositorySuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestLoanRequestRepositorySuite(t *testing.T) {
+	suite.Run(t, new(LoanRequestRepositorySuite))
+}
+
+func (suite *LoanRequestRepositorySuite) expectLock(id, status string) {
+	suite.mock.ExpectQuery("select status from loan_request where id = \\$1 for update").WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+}
+
+func (suite *LoanRequestRepositorySuite) TestFindAll_WithDetails() {
+	now := time.Now()
+	columns := []string{"id", "user_id", "user_name", "email", "locale", "nik_staff", "staff_name", "staff_email", "duration", "status",
+		"note", "comment", "decided_by", "decided_at", "issued_by", "issued_at", "id_manage_asset", "created_at", "updated_at"}
+	suite.mock.ExpectQuery("from loan_request as r .* where r.status = \\$1 and r.id_user = \\$2 order by r.created_at desc").
+		WithArgs(model.LoanRequestSubmitted, "user-1").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("r1", "user-1", "Stephanie", "s@mail.com", "en", "1001", "Budi", "", 3, model.LoanRequestSubmitted,
+				"", "", "", nil, "", nil, "", now, now))
+	suite.mock.ExpectQuery("from loan_request_detail as d").WithArgs(pq.Array([]string{"r1"})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_loan_request", "asset_id", "asset_name", "total_item"}).
+			AddRow("d1", "r1", "a1", "Kursi", 2))
+
+	requests, err := suite.repo.FindAll(dto.LoanRequestQuery{Status: model.LoanRequestSubmitted, RequesterId: "user-1"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), requests, 1)
+	assert.Equal(suite.T(), "Stephanie", requests[0].Requester.Name)
+	assert.Equal(suite.T(), 2, requests[0].Detail[0].TotalItem)
+}
+
+func (suite *LoanRequestRepositorySuite) TestSubmit_WrongStatus() {
+	suite.mock.ExpectBegin()
+	suite.expectLock("r1", model.LoanRequestApproved)
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.Submit("r1", time.Now(), nil, testAudit)
+	assert.ErrorIs(suite.T(), err, ErrLoanRequestState)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *LoanRequestRepositorySuite) TestApprove_InsufficientStock() {
+	now := time.Now()
+	suite.mock.ExpectBegin()
+	suite.expectLock("r1", model.LoanRequestSubmitted)
+	suite.mock.ExpectExec("update loan_request set status").
+		WithArgs("r1", model.LoanRequestApproved, "user-2", now, "ok").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectQuery("from loan_request_detail where id_loan_request").WithArgs("r1").
+		WillReturnRows(sqlmock.NewRows([]string{"id_asset", "total"}).AddRow("a2", 1).AddRow("a1", 5))
+	suite.mock.ExpectExec("update asset set available = available - \\$2").WithArgs("a1", 5).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("update asset set available = available - \\$2").WithArgs("a2", 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.Approve("r1", "user-2", "ok", now, []model.EmailOutbox{{Id: "e1"}}, testAudit)
+	assert.ErrorIs(suite.T(), err, ErrInsufficientStock)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *LoanRequestRepositorySuite) TestClose_ReleaseStock() {
+	now := time.Now()
+	suite.mock.ExpectBegin()
+	suite.expectLock("r1", model.LoanRequestApproved)
+	suite.mock.ExpectExec("update loan_request set status").
+		WithArgs("r1", model.LoanRequestClosed, "not needed", now).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectQuery("from loan_request_detail where id_loan_request").WithArgs("r1").
+		WillReturnRows(sqlmock.NewRows([]string{"id_asset", "total"}).AddRow("a1", 2))
+	suite.mock.ExpectExec("update asset set available = available \\+ \\$2").WithArgs("a1", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("insert into email_outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mock)
+
+	err := suite.repo.Close("r1", "not needed", now, []model.EmailOutbox{{Id: "e1", Recipient: "s@mail.com", CreatedAt: now}}, testAudit)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *LoanRequestRepositorySuite) TestIssue_Success() {
+	now := time.Now()
+	loan := dto.ManageAssetRequest{Id: "m1", IdUser: "user-2", NikStaff: "1001", SubmisstionDate: now, ReturnDate: now.AddDate(0, 0, 3),
+		ManageAssetDetailReq: []dto.ManageAssetDetailRequest{{Id: "md1", IdAsset: "a1", TotalItem: 1, Status: model.DetailStatusBorrowed, UnitIds: []string{"u1"}}}}
+	suite.mock.ExpectBegin()
+	suite.expectLock("r1", model.LoanRequestApproved)
+	suite.mock.ExpectExec("insert into manage_asset").WithArgs("m1", "user-2", "1001", now, loan.ReturnDate).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("insert into detail_manage_asset\\(").WithArgs("md1", "a1", "m1", 1, model.DetailStatusBorrowed).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("update asset_unit set status").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("insert into detail_manage_asset_unit").WithArgs("md1", "u1").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("update loan_request set status").WithArgs("r1", model.LoanRequestIssued, "user-2", now, "m1").WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(suite.mock)
+
+	assert.NoError(suite.T(), suite.repo.Issue("r1", "user-2", loan, now, nil, testAudit))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *LoanRequestRepositorySuite) TestMarkReturned_LoanOpen() {
+	now := time.Now()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("update loan_request set status").
+		WithArgs("r1", model.LoanRequestReturned, now, model.LoanRequestIssued).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+
+	returned, err := suite.repo.MarkReturned("r1", now, []model.EmailOutbox{{Id: "e1"}}, testAudit)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), returned)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
@@ -2,15 +2,33 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrInsufficientStock is returned when an asset does not have enough available item for a loan.
+var ErrInsufficientStock = errors.New("insufficient asset stock")
+
+// ErrReturnExceedsLoan is returned when a return would give back more items than are still borrowed.
+var ErrReturnExceedsLoan = errors.New("returned item exceeds borrowed item")
+
 type ManageAssetRepository interface {
-	CreateTransaction(payload dto.ManageAssetRequest) error
+	CreateTransaction(payload dto.ManageAssetRequest, audit model.AuditLog) error
+	ReturnTransaction(payload dto.ReturnAssetRequest, audit model.AuditLog) error
 	FindAllTransaction() ([]model.ManageAsset, error)
 	FindAllByTransId(id string) ([]model.ManageAsset, []model.ManageDetailAsset, error)
 	FindByNameTransaction(name string) ([]model.ManageAsset, []model.ManageDetailAsset, error)
+	FindOverdue(now time.Time) ([]model.OverdueTransaction, []model.ManageDetailAsset, error)
+	MarkOverdue(id string, now, remindedBefore time.Time, emails []model.EmailOutbox) (bool, error)
+	ExportDetails(filter dto.TransactionExportRequest, each func(model.TransactionDetailRow) error) error
+	FindOpenDetails(filter dto.OpenDetailRequest) ([]model.ManageDetailAsset, error)
 }
 
 type manageAssetRepository struct {
@@ -20,13 +38,13 @@ type manageAssetRepository struct {
 // FindByNameTransaction implements ManageAssetRepository.
 func (m *manageAssetRepository) FindByNameTransaction(name string) ([]model.ManageAsset, []model.ManageDetailAsset, error) {
 	query := `
-    SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date
+    SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date
     FROM manage_asset AS m
     JOIN user_credential AS u ON u.id = m.id_user
     JOIN staff AS s ON s.nik_staff = m.nik_staff
 	where s.name ilike $1`
 
-	queryDetail := `SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d
+	queryDetail := `SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d
 	JOIN asset AS a ON a.id = d.id_asset`
 
 	rows, err := m.db.Query(query, "%"+name+"%")
@@ -41,7 +59,7 @@ func (m *manageAssetRepository) FindByNameTransaction(name string) ([]model.Mana
 	var transactions []model.ManageAsset
 	for rows.Next() {
 		var t model.ManageAsset
-		rows.Scan(&t.Id, &t.User.ID, &t.User.Name, &t.Staff.Nik_Staff, &t.Staff.Name, &t.SubmissionDate, &t.ReturnDate)
+		rows.Scan(&t.Id, &t.User.ID, &t.User.Name, &t.Staff.Nik_Staff, &t.Staff.Name, &t.SubmissionDate, &t.ReturnDate, &t.ActualReturnDate)
 		transactions = append(transactions, t)
 	}
 	if rows.Err() != nil {
@@ -51,7 +69,7 @@ func (m *manageAssetRepository) FindByNameTransaction(name string) ([]model.Mana
 	var transactionDetail []model.ManageDetailAsset
 	for rowsDetail.Next() {
 		var td model.ManageDetailAsset
-		rowsDetail.Scan(&td.Id, &td.ManageAssetId, &td.Asset.Id, &td.Asset.Name, &td.TotalItem, &td.Status)
+		rowsDetail.Scan(&td.Id, &td.ManageAssetId, &td.Asset.Id, &td.Asset.Name, &td.TotalItem, &td.TotalReturned, &td.Status, &td.ReturnedAt)
 		transactionDetail = append(transactionDetail, td)
 	}
 	if rowsDetail.Err() != nil {
@@ -64,20 +82,21 @@ func (m *manageAssetRepository) FindByNameTransaction(name string) ([]model.Mana
 // FindAllByTransId implements ManageAssetRepository.
 func (m *manageAssetRepository) FindAllByTransId(id string) ([]model.ManageAsset, []model.ManageDetailAsset, error) {
 	query := `
-    SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date
+    SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date
     FROM manage_asset AS m
     JOIN user_credential AS u ON u.id = m.id_user
     JOIN staff AS s ON s.nik_staff = m.nik_staff
 	where m.id = $1`
 
-	queryDetail := `SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d
-	JOIN asset AS a ON a.id = d.id_asset`
+	queryDetail := `SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d
+	JOIN asset AS a ON a.id = d.id_asset
+	where d.id_manage_asset = $1`
 
 	rows, err := m.db.Query(query, id)
 	if err != nil {
 		return nil, nil, err
 	}
-	rowsDetail, err := m.db.Query(queryDetail)
+	rowsDetail, err := m.db.Query(queryDetail, id)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -85,7 +104,7 @@ func (m *manageAssetRepository) FindAllByTransId(id string) ([]model.ManageAsset
 	var transactions []model.ManageAsset
 	for rows.Next() {
 		var t model.ManageAsset
-		rows.Scan(&t.Id, &t.User.ID, &t.User.Name, &t.Staff.Nik_Staff, &t.Staff.Name, &t.SubmissionDate, &t.ReturnDate)
+		rows.Scan(&t.Id, &t.User.ID, &t.User.Name, &t.Staff.Nik_Staff, &t.Staff.Name, &t.SubmissionDate, &t.ReturnDate, &t.ActualReturnDate)
 		transactions = append(transactions, t)
 	}
 	if rows.Err() != nil {
@@ -95,7 +114,7 @@ func (m *manageAssetRepository) FindAllByTransId(id string) ([]model.ManageAsset
 	var transactionDetail []model.ManageDetailAsset
 	for rowsDetail.Next() {
 		var td model.ManageDetailAsset
-		rowsDetail.Scan(&td.Id, &td.ManageAssetId, &td.Asset.Id, &td.Asset.Name, &td.TotalItem, &td.Status)
+		rowsDetail.Scan(&td.Id, &td.ManageAssetId, &td.Asset.Id, &td.Asset.Name, &td.TotalItem, &td.TotalReturned, &td.Status, &td.ReturnedAt)
 		transactionDetail = append(transactionDetail, td)
 	}
 	if rowsDetail.Err() != nil {
@@ -106,9 +125,10 @@ func (m *manageAssetRepository) FindAllByTransId(id string) ([]model.ManageAsset
 }
 
 // FindAll implements ManageAssetRepository.
+// The detail lines of every transaction are read in one more query
 func (m *manageAssetRepository) FindAllTransaction() ([]model.ManageAsset, error) {
 
-	query := `SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date
+	query := `SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date
     FROM manage_asset AS m
     JOIN user_credential AS u ON u.id = m.id_user
     JOIN staff AS s ON s.nik_staff = m.nik_staff`
@@ -122,7 +142,7 @@ func (m *manageAssetRepository) FindAllTransaction() ([]model.ManageAsset, error
 	var transactions []model.ManageAsset
 	for rows.Next() {
 		var tr model.ManageAsset
-		rows.Scan(&tr.Id, &tr.User.ID, &tr.User.Name, &tr.Staff.Nik_Staff, &tr.Staff.Name, &tr.SubmissionDate, &tr.ReturnDate)
+		rows.Scan(&tr.Id, &tr.User.ID, &tr.User.Name, &tr.Staff.Nik_Staff, &tr.Staff.Name, &tr.SubmissionDate, &tr.ReturnDate, &tr.ActualReturnDate)
 		transactions = append(transactions, tr)
 	}
 
@@ -130,13 +150,166 @@ func (m *manageAssetRepository) FindAllTransaction() ([]model.ManageAsset, error
 		return nil, rows.Err()
 	}
 
+	if err = m.attachDetails(transactions); err != nil {
+		return nil, err
+	}
 	return transactions, nil
 }
 
+func (m *manageAssetRepository) attachDetails(transactions []model.ManageAsset) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	ids := make([]string, len(transactions))
+	index := make(map[string]int, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.Id
+		index[transaction.Id] = i
+	}
+
+	query := `SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at
+	FROM detail_manage_asset AS d
+	JOIN asset AS a ON a.id = d.id_asset
+	WHERE d.id_manage_asset = ANY($1) ORDER BY a.name, d.id`
+	rows, err := m.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail model.ManageDetailAsset
+		err = rows.Scan(&detail.Id, &detail.ManageAssetId, &detail.Asset.Id, &detail.Asset.Name, &detail.TotalItem, &detail.TotalReturned,
+			&detail.Status, &detail.ReturnedAt)
+		if err != nil {
+			return err
+		}
+		i := index[detail.ManageAssetId]
+		transactions[i].Detail = append(transactions[i].Detail, detail)
+	}
+	return rows.Err()
+}
+
+// ExportDetails implements ManageAssetRepository.
+// every detail line is handed to each as soon as it is read, an error of each stop the export
+func (m *manageAssetRepository) ExportDetails(filter dto.TransactionExportRequest, each func(model.TransactionDetailRow) error) error {
+	var conditions []string
+	var args []any
+	filters := []struct {
+		condition string
+		value     any
+		ok        bool
+	}{
+		{"m.submission_date >= $%d", filter.From, !filter.From.IsZero()},
+		//the end date is inclusive so the day after is the bound
+		{"m.submission_date < $%d", filter.To.AddDate(0, 0, 1), !filter.To.IsZero()},
+		{"m.nik_staff = $%d", filter.NikStaff, filter.NikStaff != ""},
+	}
+	for _, f := range filters {
+		if !f.ok {
+			continue
+		}
+		args = append(args, f.value)
+		conditions = append(conditions, fmt.Sprintf(f.condition, len(args)))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := `SELECT m.id, m.submission_date, m.return_date, m.actual_return_date, s.nik_staff, s.name, u.name,
+	a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at
+	FROM detail_manage_asset AS d
+	JOIN manage_asset AS m ON m.id = d.id_manage_asset
+	JOIN user_credential AS u ON u.id = m.id_user
+	JOIN staff AS s ON s.nik_staff = m.nik_staff
+	JOIN asset AS a ON a.id = d.id_asset` + where + `
+	ORDER BY m.submission_date, m.id, a.name`
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row model.TransactionDetailRow
+		err = rows.Scan(&row.TransactionId, &row.SubmissionDate, &row.ReturnDate, &row.ActualReturnDate, &row.NikStaff, &row.StaffName, &row.UserName,
+			&row.AssetId, &row.AssetName, &row.TotalItem, &row.TotalReturned, &row.Status, &row.ReturnedAt)
+		if err != nil {
+			return err
+		}
+		if err = each(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// FindOpenDetails implements ManageAssetRepository.
+// It list the detail lines of an asset with item still borrowed, the oldest loan first
+func (m *manageAssetRepository) FindOpenDetails(filter dto.OpenDetailRequest) ([]model.ManageDetailAsset, error) {
+	conditions := []string{"d.id_asset = $1", "d.total_returned < d.total_item"}
+	args := []any{filter.AssetId}
+	filters := []struct {
+		condition string
+		value     any
+		ok        bool
+	}{
+		{"m.nik_staff = $%d", filter.NikStaff, filter.NikStaff != ""},
+		{"EXISTS (SELECT 1 FROM detail_manage_asset_unit AS du WHERE du.id_detail = d.id AND du.id_unit = $%d AND du.returned_at IS NULL)",
+			filter.UnitId, filter.UnitId != ""},
+	}
+	for _, f := range filters {
+		if !f.ok {
+			continue
+		}
+		args = append(args, f.value)
+		conditions = append(conditions, fmt.Sprintf(f.condition, len(args)))
+	}
+
+	query := `SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status
+	FROM detail_manage_asset AS d
+	JOIN manage_asset AS m ON m.id = d.id_manage_asset
+	JOIN asset AS a ON a.id = d.id_asset
+	WHERE ` + strings.Join(conditions, " AND ") + `
+	ORDER BY m.submission_date, d.id`
+
+	rows, err := m.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var details []model.ManageDetailAsset
+	for rows.Next() {
+		var detail model.ManageDetailAsset
+		err = rows.Scan(&detail.Id, &detail.ManageAssetId, &detail.Asset.Id, &detail.Asset.Name, &detail.TotalItem, &detail.TotalReturned, &detail.Status)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, detail)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
 // CreateTransaksi implements ManageAssetRepository.
-func (m *manageAssetRepository) CreateTransaction(payload dto.ManageAssetRequest) error {
+func (m *manageAssetRepository) CreateTransaction(payload dto.ManageAssetRequest, audit model.AuditLog) error {
 
 	query := "insert into manage_asset(id, id_user, nik_staff, submission_date, return_date) values($1, $2, $3, $4, $5)"
+	//reserve the stock only when it is still available, the row lock keeps concurrent loans in line
+	queryStock := "update asset set available = available - $2 where id = $1 and available >= $2"
+	queryDetail := "insert into detail_manage_asset(id, id_asset, id_manage_asset, total_item, status) values ($1, $2, $3, $4, $5)"
+
+	//lock assets in the same order on every transaction to avoid deadlock
+	details := make([]dto.ManageAssetDetailRequest, len(payload.ManageAssetDetailReq))
+	copy(details, payload.ManageAssetDetailReq)
+	sort.SliceStable(details, func(i, j int) bool {
+		return details[i].IdAsset < details[j].IdAsset
+	})
 
 	tx, err := m.db.Begin()
 	if err != nil {
@@ -148,15 +321,235 @@ func (m *manageAssetRepository) CreateTransaction(payload dto.ManageAssetRequest
 		return err
 	}
 
-	queryDetail := "insert into detail_manage_asset(id, id_asset, id_manage_asset, total_item, status) values ($1, $2, $3, $4, $5)"
-	for _, v := range payload.ManageAssetDetailReq {
+	for _, v := range details {
+		res, err := tx.Exec(queryStock, v.IdAsset, v.TotalItem)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if affected == 0 {
+			tx.Rollback()
+			return fmt.Errorf("%w: asset %s", ErrInsufficientStock, v.IdAsset)
+		}
+
 		_, err = tx.Exec(queryDetail, v.Id, v.IdAsset, payload.Id, v.TotalItem, v.Status)
 		if err != nil {
 			tx.Rollback()
 			return err
 		}
+
+		if err = lendUnits(tx, v, payload.NikStaff); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = insertAudit(tx, audit); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReturnTransaction implements ManageAssetRepository.
+func (m *manageAssetRepository) ReturnTransaction(payload dto.ReturnAssetRequest, audit model.AuditLog) error {
+	queryDetail := `update detail_manage_asset set total_returned = total_returned + $3, returned_at = $4,
+	status = case when total_returned + $3 >= total_item then $5 else $6 end
+	where id = $1 and id_manage_asset = $2 and total_item - total_returned >= $3
+	returning id_asset`
+	queryAsset := "update asset set available = available + $2 where id = $1"
+	queryManage := `update manage_asset set actual_return_date = $2 where id = $1
+	and not exists (select 1 from detail_manage_asset where id_manage_asset = $1 and total_returned < total_item)`
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range payload.ReturnDetailReq {
+		var idAsset string
+		err = tx.QueryRow(queryDetail, v.IdDetail, payload.IdManageAsset, v.TotalItem, payload.ReturnDate,
+			model.DetailStatusReturned, model.DetailStatusPartiallyReturned).Scan(&idAsset)
+		if err != nil {
+			tx.Rollback()
+			if err == sql.ErrNoRows {
+				return ErrReturnExceedsLoan
+			}
+			return err
+		}
+
+		//give the returned item back to the asset stock
+		_, err = tx.Exec(queryAsset, idAsset, v.TotalItem)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err = returnUnits(tx, v, payload); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	//only stamp the actual return date once every detail is back
+	_, err = tx.Exec(queryManage, payload.IdManageAsset, payload.ReturnDate)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err = insertAudit(tx, audit); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindOverdue implements ManageAssetRepository.
+func (m *manageAssetRepository) FindOverdue(now time.Time) ([]model.OverdueTransaction, []model.ManageDetailAsset, error) {
+	query := `SELECT m.id, u.id, u.name, u.email, u.locale, s.nik_staff, s.name, s.email, m.submission_date, m.return_date, m.overdue_at, m.reminded_at
+	FROM manage_asset AS m
+	JOIN user_credential AS u ON u.id = m.id_user
+	JOIN staff AS s ON s.nik_staff = m.nik_staff
+	where m.actual_return_date is null and m.return_date < $1
+	order by m.return_date`
+
+	//only the items that are still borrowed
+	queryDetail := `SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status FROM detail_manage_asset AS d
+	JOIN asset AS a ON a.id = d.id_asset
+	JOIN manage_asset AS m ON m.id = d.id_manage_asset
+	where m.actual_return_date is null and m.return_date < $1 and d.total_returned < d.total_item`
+
+	rows, err := m.db.Query(query, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var transactions []model.OverdueTransaction
+	for rows.Next() {
+		var t model.OverdueTransaction
+		err = rows.Scan(&t.Id, &t.User.ID, &t.User.Name, &t.User.Email, &t.User.Locale, &t.Staff.Nik_Staff, &t.Staff.Name, &t.Staff.Email,
+			&t.SubmissionDate, &t.ReturnDate, &t.OverdueAt, &t.RemindedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	if rows.Err() != nil {
+		return nil, nil, rows.Err()
+	}
+
+	rowsDetail, err := m.db.Query(queryDetail, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rowsDetail.Close()
+
+	var transactionDetail []model.ManageDetailAsset
+	for rowsDetail.Next() {
+		var td model.ManageDetailAsset
+		err = rowsDetail.Scan(&td.Id, &td.ManageAssetId, &td.Asset.Id, &td.Asset.Name, &td.TotalItem, &td.TotalReturned, &td.Status)
+		if err != nil {
+			return nil, nil, err
+		}
+		transactionDetail = append(transactionDetail, td)
+	}
+	if rowsDetail.Err() != nil {
+		return nil, nil, rowsDetail.Err()
+	}
+
+	return transactions, transactionDetail, nil
+}
+
+// MarkOverdue implements ManageAssetRepository.
+// The reminder emails are queued in the same transaction, it return false when the loan
+// was returned or already reminded after remindedBefore, e.g. by another instance
+func (m *manageAssetRepository) MarkOverdue(id string, now, remindedBefore time.Time, emails []model.EmailOutbox) (bool, error) {
+	query := `update manage_asset set overdue_at = coalesce(overdue_at, $2), reminded_at = $2
+	where id = $1 and actual_return_date is null and (reminded_at is null or reminded_at < $3)`
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	res, err := tx.Exec(query, id, now, remindedBefore)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+
+	for _, email := range emails {
+		if err = insertOutbox(tx, email); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+// lendUnits hand the named units to the staff, a unit that is not available fail the whole loan
+func lendUnits(tx *sql.Tx, detail dto.ManageAssetDetailRequest, nikStaff string) error {
+	queryUnit := "update asset_unit set status = $3, holder_nik = $4 where id = $1 and id_asset = $2 and status = $5"
+	queryDetailUnit := "insert into detail_manage_asset_unit(id_detail, id_unit) values ($1, $2)"
+
+	unitIds := append([]string(nil), detail.UnitIds...)
+	sort.Strings(unitIds)
+	for _, unitId := range unitIds {
+		res, err := tx.Exec(queryUnit, unitId, detail.IdAsset, model.UnitStatusBorrowed, nikStaff, model.UnitStatusAvailable)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return fmt.Errorf("%w: unit %s", ErrUnitNotAvailable, unitId)
+		}
+		if _, err = tx.Exec(queryDetailUnit, detail.Id, unitId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// returnUnits put the named units back on the shelf, they must be borrowed in the same detail
+func returnUnits(tx *sql.Tx, detail dto.ReturnAssetDetailRequest, payload dto.ReturnAssetRequest) error {
+	queryDetailUnit := "update detail_manage_asset_unit set returned_at = $3 where id_detail = $1 and id_unit = $2 and returned_at is null"
+	queryUnit := "update asset_unit set status = $2, holder_nik = null where id = $1"
+
+	for _, unitId := range detail.UnitIds {
+		res, err := tx.Exec(queryDetailUnit, detail.IdDetail, unitId, payload.ReturnDate)
+		if err != nil {
+			return err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return fmt.Errorf("%w: unit %s", ErrUnitNotOnLoan, unitId)
+		}
+		if _, err = tx.Exec(queryUnit, unitId, model.UnitStatusAvailable); err != nil {
+			return err
+		}
 	}
-	tx.Commit()
 	return nil
 }
 
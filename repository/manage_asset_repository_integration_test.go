@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openIntegrationDB connect to a real postgres from TEST_DB_DSN inside a throwaway schema,
+// the test is skipped when the variable is not set
+func openIntegrationDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set, skipping postgres integration test")
+	}
+
+	schema := "it_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	admin, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	_, err = admin.Exec("create schema " + schema)
+	require.NoError(t, err)
+
+	//every pooled connection must resolve tables inside the throwaway schema
+	if strings.Contains(dsn, "://") {
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		dsn += sep + "search_path=" + schema
+	} else {
+		dsn += " search_path=" + schema
+	}
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		db.Close()
+		admin.Exec("drop schema " + schema + " cascade")
+		admin.Close()
+	})
+	return db
+}
+
+func TestCreateTransaction_ConcurrentNoOverLending(t *testing.T) {
+	db := openIntegrationDB(t)
+
+	ddl := []string{
+		`create table asset (id varchar(100) primary key, available int not null)`,
+		`create table manage_asset (id varchar(100) primary key, id_user varchar(100), nik_staff varchar(100),
+			submission_date timestamp, return_date timestamp)`,
+		`create table detail_manage_asset (id varchar(100) primary key, id_asset varchar(100) references asset(id),
+			id_manage_asset varchar(100) references manage_asset(id), total_item int, status varchar(100))`,
+		`create table audit_log (id varchar(100) primary key, actor_id varchar(100) not null, entity varchar(30) not null,
+			entity_id varchar(100) not null, action varchar(20) not null, before jsonb, after jsonb, created_at timestamp not null)`,
+	}
+	for _, q := range ddl {
+		_, err := db.Exec(q)
+		require.NoError(t, err)
+	}
+
+	const stock = 5
+	const borrowers = 30
+	_, err := db.Exec("insert into asset(id, available) values ($1, $2)", "laptop", stock)
+	require.NoError(t, err)
+
+	repo := NewManageAssetRepository(db)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	success, rejected := 0, 0
+	start := make(chan struct{})
+	for i := 0; i < borrowers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			id := uuid.NewString()
+			err := repo.CreateTransaction(dto.ManageAssetRequest{
+				Id:              id,
+				IdUser:          "user",
+				NikStaff:        fmt.Sprintf("staff-%d", i),
+				SubmisstionDate: time.Now(),
+				ReturnDate:      time.Now().AddDate(0, 0, 1),
+				ManageAssetDetailReq: []dto.ManageAssetDetailRequest{{
+					Id:        uuid.NewString(),
+					IdAsset:   "laptop",
+					TotalItem: 1,
+					Status:    "loaned",
+				}},
+			}, model.AuditLog{Id: uuid.NewString(), ActorId: "user", Entity: model.AuditEntityTransaction, EntityId: id,
+				Action: model.AuditActionCreate, CreatedAt: time.Now()})
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				success++
+				return
+			}
+			if errors.Is(err, ErrInsufficientStock) {
+				rejected++
+				return
+			}
+			t.Errorf("unexpected error: %v", err)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var available, lent, transactions, audits int
+	require.NoError(t, db.QueryRow("select available from asset where id = 'laptop'").Scan(&available))
+	require.NoError(t, db.QueryRow("select coalesce(sum(total_item), 0) from detail_manage_asset").Scan(&lent))
+	require.NoError(t, db.QueryRow("select count(*) from manage_asset").Scan(&transactions))
+	require.NoError(t, db.QueryRow("select count(*) from audit_log").Scan(&audits))
+
+	assert.Equal(t, stock, success)
+	assert.Equal(t, borrowers-stock, rejected)
+	assert.Equal(t, 0, available)
+	assert.Equal(t, stock, lent)
+	//rejected loans must not leave a header row behind
+	assert.Equal(t, stock, transactions)
+	//only the loans that were committed are audited
+	assert.Equal(t, stock, audits)
+}
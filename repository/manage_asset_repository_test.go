@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -55,12 +56,14 @@ func (suite *ManageAssetRepoTestSuite) TestCreate_Success() {
 	WithArgs(payload.Id, payload.IdUser, payload.NikStaff, payload.SubmisstionDate, payload.ReturnDate).WillReturnResult(sqlmock.NewResult(1, 1))
 
 	for _, data := range payload.ManageAssetDetailReq {
+		suite.mockSQL.ExpectExec("update asset set available = available -").
+		WithArgs(data.IdAsset, data.TotalItem).WillReturnResult(sqlmock.NewResult(0, 1))
 		suite.mockSQL.ExpectExec("insert into detail_manage_asset").
-		WithArgs(data.Id, payload.Id, data.IdAsset, data.TotalItem, data.Status).WillReturnResult(sqlmock.NewResult(1, 1))
+		WithArgs(data.Id, data.IdAsset, payload.Id, data.TotalItem, data.Status).WillReturnResult(sqlmock.NewResult(1, 1))
 	}
 
-	suite.mockSQL.ExpectCommit()
-	err := suite.repo.CreateTransaction(payload)
+	expectAudit(suite.mockSQL)
+	err := suite.repo.CreateTransaction(payload, testAudit)
 	assert.NoError(suite.T(), err)
 	assert.Nil(suite.T(), err)
 }
@@ -83,7 +86,7 @@ func (suite *ManageAssetRepoTestSuite) TestCreate_BeginError() {
 	}
 
 	suite.mockSQL.ExpectBegin().WillReturnError(errors.New("failed begin tansaction"))
-	err := suite.repo.CreateTransaction(payload)
+	err := suite.repo.CreateTransaction(payload, testAudit)
 	assert.Error(suite.T(), err)
 	assert.NotNil(suite.T(), err)
 }
@@ -109,7 +112,7 @@ func (suite *ManageAssetRepoTestSuite) TestCreate_ErrorManageQuery() {
 	suite.mockSQL.ExpectExec("insert into manage_asset").
 	WithArgs(payload.Id, payload.IdUser, payload.NikStaff, payload.SubmisstionDate, payload.ReturnDate).WillReturnError(errors.New("failed"))
 	suite.mockSQL.ExpectRollback()
-	err := suite.repo.CreateTransaction(payload)
+	err := suite.repo.CreateTransaction(payload, testAudit)
 	assert.Error(suite.T(), err)
 	assert.NotNil(suite.T(), err)
 }
@@ -135,15 +138,136 @@ func (suite *ManageAssetRepoTestSuite) TestCreate_ErrorManageDetailQuery() {
 	suite.mockSQL.ExpectExec("insert into manage_asset").
 	WithArgs(payload.Id, payload.IdUser, payload.NikStaff, payload.SubmisstionDate, payload.ReturnDate).WillReturnResult(sqlmock.NewResult(1, 1))
 	for _, data := range payload.ManageAssetDetailReq {
+		suite.mockSQL.ExpectExec("update asset set available = available -").
+		WithArgs(data.IdAsset, data.TotalItem).WillReturnResult(sqlmock.NewResult(0, 1))
 		suite.mockSQL.ExpectExec("insert into detail_manage_asset").
-		WithArgs(data.Id, payload.Id, data.IdAsset, data.TotalItem, data.Status).WillReturnError(errors.New("failed save manage detail"))
+		WithArgs(data.Id, data.IdAsset, payload.Id, data.TotalItem, data.Status).WillReturnError(errors.New("failed save manage detail"))
 	}
 	suite.mockSQL.ExpectRollback()
-	err := suite.repo.CreateTransaction(payload)
+	err := suite.repo.CreateTransaction(payload, testAudit)
 	assert.Error(suite.T(), err)
 	assert.NotNil(suite.T(), err)
 }
 
+func (suite *ManageAssetRepoTestSuite) TestCreate_InsufficientStock() {
+	payload := dto.ManageAssetRequest{
+		Id:                   "1",
+		IdUser:               "1",
+		NikStaff:             "111",
+		SubmisstionDate:      time.Now(),
+		ReturnDate:           time.Now(),
+		Duration:             2,
+		ManageAssetDetailReq: []dto.ManageAssetDetailRequest{{
+			Id:            "1",
+			IdManageAsset: "1",
+			IdAsset:       "1",
+			TotalItem:     3,
+			Status:        "ok",
+		}},
+	}
+
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("insert into manage_asset").
+	WithArgs(payload.Id, payload.IdUser, payload.NikStaff, payload.SubmisstionDate, payload.ReturnDate).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("update asset set available = available -").
+	WithArgs("1", 3).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSQL.ExpectRollback()
+
+	err := suite.repo.CreateTransaction(payload, testAudit)
+	assert.ErrorIs(suite.T(), err, ErrInsufficientStock)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestCreate_LockOrder() {
+	payload := dto.ManageAssetRequest{
+		Id:                   "1",
+		IdUser:               "1",
+		NikStaff:             "111",
+		SubmisstionDate:      time.Now(),
+		ReturnDate:           time.Now(),
+		Duration:             2,
+		ManageAssetDetailReq: []dto.ManageAssetDetailRequest{{
+			Id:      "d2",
+			IdAsset: "b",
+			TotalItem: 1,
+			Status:  "ok",
+		}, {
+			Id:      "d1",
+			IdAsset: "a",
+			TotalItem: 1,
+			Status:  "ok",
+		}},
+	}
+
+	//asset a must be reserved before asset b whatever the request order is
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("insert into manage_asset").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("update asset set available = available -").WithArgs("a", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectExec("insert into detail_manage_asset").WithArgs("d1", "a", "1", 1, "ok").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("update asset set available = available -").WithArgs("b", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectExec("insert into detail_manage_asset").WithArgs("d2", "b", "1", 1, "ok").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+
+	err := suite.repo.CreateTransaction(payload, testAudit)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestCreate_LendUnits() {
+	payload := dto.ManageAssetRequest{
+		Id:       "1",
+		NikStaff: "111",
+		ManageAssetDetailReq: []dto.ManageAssetDetailRequest{{
+			Id:        "d1",
+			IdAsset:   "a",
+			TotalItem: 2,
+			Status:    "ok",
+			UnitIds:   []string{"u2", "u1"},
+		}},
+	}
+
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("insert into manage_asset").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("update asset set available = available -").WithArgs("a", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectExec("insert into detail_manage_asset").WillReturnResult(sqlmock.NewResult(1, 1))
+	for _, unitId := range []string{"u1", "u2"} {
+		suite.mockSQL.ExpectExec("update asset_unit set status").
+			WithArgs(unitId, "a", model.UnitStatusBorrowed, "111", model.UnitStatusAvailable).WillReturnResult(sqlmock.NewResult(0, 1))
+		suite.mockSQL.ExpectExec("insert into detail_manage_asset_unit").WithArgs("d1", unitId).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	expectAudit(suite.mockSQL)
+
+	err := suite.repo.CreateTransaction(payload, testAudit)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestCreate_UnitNotAvailable() {
+	payload := dto.ManageAssetRequest{
+		Id:       "1",
+		NikStaff: "111",
+		ManageAssetDetailReq: []dto.ManageAssetDetailRequest{{
+			Id:        "d1",
+			IdAsset:   "a",
+			TotalItem: 1,
+			Status:    "ok",
+			UnitIds:   []string{"u1"},
+		}},
+	}
+
+	//the unit was taken by another loan in the meantime
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("insert into manage_asset").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("update asset set available = available -").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectExec("insert into detail_manage_asset").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("update asset_unit set status").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSQL.ExpectRollback()
+
+	err := suite.repo.CreateTransaction(payload, testAudit)
+	assert.ErrorIs(suite.T(), err, ErrUnitNotAvailable)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
 func (suite *ManageAssetRepoTestSuite) TestFindAll_Success() {
 	
 	datas := []model.ManageAsset{{
@@ -169,19 +293,41 @@ func (suite *ManageAssetRepoTestSuite) TestFindAll_Success() {
 		Detail:         []model.ManageDetailAsset{},
 	}}
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate)
+		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil)
 	}
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("FROM detail_manage_asset AS d").WillReturnRows(sqlmock.NewRows([]string{"id", "id_manage_asset", "id_asset", "name", "total_item", "total_returned", "status", "returned_at"}))
 	result, err := suite.repo.FindAllTransaction()
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), datas[0].Id, result[0].Id)
 }
 
+// every transaction carry its own detail lines
+func (suite *ManageAssetRepoTestSuite) TestFindAll_WithDetails() {
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"}).
+		AddRow("1", "u1", "Jhon", "1001", "Sigit", time.Time{}, time.Time{}, nil).
+		AddRow("2", "u1", "Jhon", "1002", "Budi", time.Time{}, time.Time{}, nil)
+	details := sqlmock.NewRows([]string{"id", "id_manage_asset", "id_asset", "name", "total_item", "total_returned", "status", "returned_at"}).
+		AddRow("d1", "2", "a1", "Kursi", 3, 1, "borrowed", nil).
+		AddRow("d2", "1", "a2", "Meja", 2, 0, "borrowed", nil).
+		AddRow("d3", "2", "a2", "Meja", 1, 0, "borrowed", nil)
+	suite.mockSQL.ExpectQuery("FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("FROM detail_manage_asset AS d (.+) ANY").WithArgs(pq.Array([]string{"1", "2"})).WillReturnRows(details)
+
+	result, err := suite.repo.FindAllTransaction()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), result[0].Detail, 1)
+	assert.Equal(suite.T(), 2, result[0].Detail[0].TotalItem)
+	assert.Len(suite.T(), result[1].Detail, 2)
+	assert.Equal(suite.T(), 1, result[1].Detail[0].TotalReturned)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
 func (suite *ManageAssetRepoTestSuite) TestFindAll_Failed() {
 
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnError(errors.New("failed get transaction"))
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnError(errors.New("failed get transaction"))
 	result, err := suite.repo.FindAllTransaction()
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
@@ -212,11 +358,11 @@ func (suite *ManageAssetRepoTestSuite) TestFindAll_RowsFailed() {
 		Detail:         []model.ManageDetailAsset{},
 	}}
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate).RowError(0, errors.New("errors row"))
+		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil).RowError(0, errors.New("errors row"))
 	}
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(rows)
 	result, err := suite.repo.FindAllTransaction()
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
@@ -256,17 +402,17 @@ func (suite *ManageAssetRepoTestSuite) TestFindAllById_Success() {
 		TotalItem:     2,
 		Status:        "Ready",
 	}}
-	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate)
+		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil)
 	}
-	rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "status"})
+	rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "total_returned", "status", "returned_at"})
 	for _, data := range dataDetails {
-		rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.Status)
+		rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.TotalReturned, data.Status, nil)
 	}
 
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(rows)
-	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d").WillReturnRows(rowDetail)
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d").WillReturnRows(rowDetail)
 
 	result, resulDetail, err := suite.repo.FindAllByTransId("1")
 	assert.NoError(suite.T(), err)
@@ -308,24 +454,24 @@ func (suite *ManageAssetRepoTestSuite) TestFindAllById_Failed() {
 	// 	TotalItem:     2,
 	// 	Status:        "Ready",
 	// }}
-	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate)
+		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil)
 	}
-	// rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "status"})
+	// rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "total_returned", "status", "returned_at"})
 	// for _, data := range dataDetails {
-	// 	rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.Status)
+	// 	rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.TotalReturned, data.Status, nil)
 	// }
 
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnError(errors.New("error get manage asset"))
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnError(errors.New("error get manage asset"))
 	
 	result, resultDetail, err := suite.repo.FindAllByTransId("1")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 	assert.Nil(suite.T(), resultDetail)
 	
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(rows)
-	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d").WillReturnError(errors.New("errors get manage detail"))
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d").WillReturnError(errors.New("errors get manage detail"))
 	result, resultDetail, err = suite.repo.FindAllByTransId("1")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
@@ -368,35 +514,35 @@ func (suite *ManageAssetRepoTestSuite) TestFindAllById_ManageRowsError() {
 	}}
 
 	//rows manage
-	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate).RowError(0, errors.New("erros row"))
+		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil).RowError(0, errors.New("erros row"))
 	}
 
-	rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "status"})
+	rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "total_returned", "status", "returned_at"})
 	for _, data := range dataDetails {
-		rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.Status)
+		rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.TotalReturned, data.Status, nil)
 	}
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(rows)
-	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d").WillReturnRows(rowDetail)
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d").WillReturnRows(rowDetail)
 
 	result, resultDetail, err := suite.repo.FindAllByTransId("1")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 	assert.Nil(suite.T(), resultDetail)
 
-	newRows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	newRows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		newRows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate)
+		newRows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil)
 	}
 
-	newRowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "status"})
+	newRowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "total_returned", "status", "returned_at"})
 	for _, data := range dataDetails {
-		newRowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.Status).RowError(0, errors.New("errors row"))
+		newRowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.TotalReturned, data.Status, nil).RowError(0, errors.New("errors row"))
 	}
 
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(newRows)
-	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d").WillReturnRows(newRowDetail)
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(newRows)
+	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d").WillReturnRows(newRowDetail)
 	result, resultDetail, err = suite.repo.FindAllByTransId("1")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
@@ -438,17 +584,17 @@ func (suite *ManageAssetRepoTestSuite) TestFindAllByName_Success() {
 		TotalItem:     2,
 		Status:        "Ready",
 	}}
-	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate)
+		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil)
 	}
-	rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "status"})
+	rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "total_returned", "status", "returned_at"})
 	for _, data := range dataDetails {
-		rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.Status)
+		rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.TotalReturned, data.Status, nil)
 	}
 
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WithArgs("%"+"Jhon"+"%").WillReturnRows(rows)
-	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d").WillReturnRows(rowDetail)
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WithArgs("%"+"Jhon"+"%").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d").WillReturnRows(rowDetail)
 
 	result, resulDetail, err := suite.repo.FindByNameTransaction("Jhon")
 	assert.NoError(suite.T(), err)
@@ -481,20 +627,20 @@ func (suite *ManageAssetRepoTestSuite) TestFindAllByName_Failed() {
 		Detail:         []model.ManageDetailAsset{},
 	}}
 
-	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate)
+		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil)
 	}
 
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnError(errors.New("error get manage asset"))
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnError(errors.New("error get manage asset"))
 	
 	result, resultDetail, err := suite.repo.FindByNameTransaction("Jhon")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 	assert.Nil(suite.T(), resultDetail)
 	
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(rows)
-	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d").WillReturnError(errors.New("errors get manage detail"))
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d").WillReturnError(errors.New("errors get manage detail"))
 	result, resultDetail, err = suite.repo.FindByNameTransaction("John")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
@@ -537,38 +683,283 @@ func (suite *ManageAssetRepoTestSuite) TestFindAllByName_ManageRowsError() {
 	}}
 
 	//rows manage
-	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	rows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate).RowError(0, errors.New("erros row"))
+		rows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil).RowError(0, errors.New("erros row"))
 	}
 
-	rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "status"})
+	rowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "total_returned", "status", "returned_at"})
 	for _, data := range dataDetails {
-		rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.Status)
+		rowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.TotalReturned, data.Status, nil)
 	}
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(rows)
-	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d").WillReturnRows(rowDetail)
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d").WillReturnRows(rowDetail)
 
 	result, resultDetail, err := suite.repo.FindByNameTransaction("Jhon")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 	assert.Nil(suite.T(), resultDetail)
 
-	newRows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date"})
+	newRows := sqlmock.NewRows([]string{"id", "user_id", "user_name", "staff_nik", "staff_name", "submission_date", "return_date", "actual_return_date"})
 	for _, data := range datas {
-		newRows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate)
+		newRows.AddRow(data.Id, data.User.ID, data.User.Name, data.Staff.Nik_Staff, data.Staff.Name, data.SubmissionDate, data.ReturnDate, nil)
 	}
 
-	newRowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "status"})
+	newRowDetail := sqlmock.NewRows([]string{"id", "id_manage", "id_asset", "asset_name", "total_item", "total_returned", "status", "returned_at"})
 	for _, data := range dataDetails {
-		newRowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.Status).RowError(0, errors.New("errors row"))
+		newRowDetail.AddRow(data.Id, data.ManageAssetId, data.Asset.Id, data.Asset.Name, data.TotalItem, data.TotalReturned, data.Status, nil).RowError(0, errors.New("errors row"))
 	}
 
-	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date FROM manage_asset AS m").WillReturnRows(newRows)
-	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.status FROM detail_manage_asset AS d").WillReturnRows(newRowDetail)
+	suite.mockSQL.ExpectQuery("SELECT m.id, u.id, u.name, s.nik_staff, s.name, m.submission_date, m.return_date, m.actual_return_date FROM manage_asset AS m").WillReturnRows(newRows)
+	suite.mockSQL.ExpectQuery("SELECT d.id, d.id_manage_asset, a.id, a.name, d.total_item, d.total_returned, d.status, d.returned_at FROM detail_manage_asset AS d").WillReturnRows(newRowDetail)
 	result, resultDetail, err = suite.repo.FindByNameTransaction("Jhon")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 	assert.Nil(suite.T(), resultDetail)
 
-}
\ No newline at end of file
+}
+func (suite *ManageAssetRepoTestSuite) TestReturn_Success() {
+	payload := dto.ReturnAssetRequest{
+		IdManageAsset: "1",
+		ReturnDate:    time.Now(),
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{
+			IdDetail:  "1",
+			TotalItem: 2,
+		}},
+	}
+
+	suite.mockSQL.ExpectBegin()
+	for _, data := range payload.ReturnDetailReq {
+		suite.mockSQL.ExpectQuery("update detail_manage_asset set total_returned").
+		WithArgs(data.IdDetail, payload.IdManageAsset, data.TotalItem, payload.ReturnDate, model.DetailStatusReturned, model.DetailStatusPartiallyReturned).
+		WillReturnRows(sqlmock.NewRows([]string{"id_asset"}).AddRow("1"))
+		suite.mockSQL.ExpectExec("update asset set available").WithArgs("1", data.TotalItem).WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	suite.mockSQL.ExpectExec("update manage_asset set actual_return_date").
+	WithArgs(payload.IdManageAsset, payload.ReturnDate).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+
+	err := suite.repo.ReturnTransaction(payload, testAudit)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestReturn_BeginError() {
+	payload := dto.ReturnAssetRequest{
+		IdManageAsset: "1",
+		ReturnDate:    time.Now(),
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{
+			IdDetail:  "1",
+			TotalItem: 2,
+		}},
+	}
+
+	suite.mockSQL.ExpectBegin().WillReturnError(errors.New("failed begin transaction"))
+	err := suite.repo.ReturnTransaction(payload, testAudit)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ManageAssetRepoTestSuite) TestReturn_ExceedsLoan() {
+	payload := dto.ReturnAssetRequest{
+		IdManageAsset: "1",
+		ReturnDate:    time.Now(),
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{
+			IdDetail:  "1",
+			TotalItem: 5,
+		}},
+	}
+
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectQuery("update detail_manage_asset set total_returned").
+	WillReturnRows(sqlmock.NewRows([]string{"id_asset"}))
+	suite.mockSQL.ExpectRollback()
+
+	err := suite.repo.ReturnTransaction(payload, testAudit)
+	assert.ErrorIs(suite.T(), err, ErrReturnExceedsLoan)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestReturn_UpdateAssetError() {
+	payload := dto.ReturnAssetRequest{
+		IdManageAsset: "1",
+		ReturnDate:    time.Now(),
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{
+			IdDetail:  "1",
+			TotalItem: 1,
+		}},
+	}
+
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectQuery("update detail_manage_asset set total_returned").
+	WillReturnRows(sqlmock.NewRows([]string{"id_asset"}).AddRow("1"))
+	suite.mockSQL.ExpectExec("update asset set available").WillReturnError(errors.New("failed update asset"))
+	suite.mockSQL.ExpectRollback()
+
+	err := suite.repo.ReturnTransaction(payload, testAudit)
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestReturn_Units() {
+	payload := dto.ReturnAssetRequest{
+		IdManageAsset: "1",
+		ReturnDate:    time.Now(),
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{
+			IdDetail:  "d1",
+			TotalItem: 1,
+			UnitIds:   []string{"u1"},
+		}},
+	}
+
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectQuery("update detail_manage_asset set total_returned").
+	WillReturnRows(sqlmock.NewRows([]string{"id_asset"}).AddRow("a"))
+	suite.mockSQL.ExpectExec("update asset set available").WithArgs("a", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("update detail_manage_asset_unit set returned_at").
+	WithArgs("d1", "u1", payload.ReturnDate).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectExec("update asset_unit set status").
+	WithArgs("u1", model.UnitStatusAvailable).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectExec("update manage_asset set actual_return_date").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+
+	err := suite.repo.ReturnTransaction(payload, testAudit)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestReturn_UnitNotOnLoan() {
+	payload := dto.ReturnAssetRequest{
+		IdManageAsset: "1",
+		ReturnDate:    time.Now(),
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{
+			IdDetail:  "d1",
+			TotalItem: 1,
+			UnitIds:   []string{"u1"},
+		}},
+	}
+
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectQuery("update detail_manage_asset set total_returned").
+	WillReturnRows(sqlmock.NewRows([]string{"id_asset"}).AddRow("a"))
+	suite.mockSQL.ExpectExec("update asset set available").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectExec("update detail_manage_asset_unit set returned_at").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSQL.ExpectRollback()
+
+	err := suite.repo.ReturnTransaction(payload, testAudit)
+	assert.ErrorIs(suite.T(), err, ErrUnitNotOnLoan)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestFindOverdue_Success() {
+	now := time.Date(2023, 9, 10, 7, 0, 0, 0, time.UTC)
+	returnDate := now.AddDate(0, 0, -3)
+	suite.mockSQL.ExpectQuery("SELECT (.+) FROM manage_asset AS m (.+) where m.actual_return_date is null and m.return_date < \\$1").
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "user_name", "user_email", "locale", "nik_staff", "staff_name", "staff_email", "submission_date", "return_date", "overdue_at", "reminded_at"}).
+			AddRow("1", "u1", "pal", "pal@example.com", "id", "123", "awd", "awd@example.com", returnDate.AddDate(0, 0, -7), returnDate, nil, nil))
+	suite.mockSQL.ExpectQuery("SELECT (.+) FROM detail_manage_asset AS d (.+) d.total_returned < d.total_item").
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "id_manage_asset", "id_asset", "name", "total_item", "total_returned", "status"}).
+			AddRow("d1", "1", "a1", "Laptop", 3, 1, model.DetailStatusPartiallyReturned))
+
+	transactions, details, err := suite.repo.FindOverdue(now)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), transactions, 1)
+	assert.Equal(suite.T(), "awd@example.com", transactions[0].Staff.Email)
+	assert.Equal(suite.T(), "id", transactions[0].User.Locale)
+	assert.Nil(suite.T(), transactions[0].RemindedAt)
+	assert.Len(suite.T(), details, 1)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestFindOverdue_Failed() {
+	suite.mockSQL.ExpectQuery("SELECT (.+) FROM manage_asset AS m").WillReturnError(errors.New("failed"))
+
+	transactions, details, err := suite.repo.FindOverdue(time.Now())
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), transactions)
+	assert.Nil(suite.T(), details)
+}
+
+func (suite *ManageAssetRepoTestSuite) TestMarkOverdue_Success() {
+	now := time.Now()
+	before := now.Add(-24 * time.Hour)
+	email := model.EmailOutbox{Id: "mail-1", Recipient: "awd@example.com", Subject: "Overdue", Html: "<p>late</p>", CreatedAt: now}
+
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("update manage_asset set overdue_at").
+		WithArgs("1", now, before).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectExec("insert into email_outbox").
+		WithArgs(email.Id, email.Recipient, email.Subject, email.Html, email.Sensitive, model.OutboxStatusPending, email.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mockSQL.ExpectCommit()
+
+	marked, err := suite.repo.MarkOverdue("1", now, before, []model.EmailOutbox{email})
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), marked)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestMarkOverdue_AlreadyReminded() {
+	now := time.Now()
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("update manage_asset set overdue_at").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSQL.ExpectRollback()
+
+	//no email is queued when another worker already reminded it
+	marked, err := suite.repo.MarkOverdue("1", now, now.Add(-24*time.Hour), []model.EmailOutbox{{Id: "mail-1"}})
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), marked)
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *ManageAssetRepoTestSuite) TestExportDetails_Filtered() {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	submitted := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "submission_date", "return_date", "actual_return_date", "nik_staff", "staff_name", "user_name",
+		"asset_id", "asset_name", "total_item", "total_returned", "status", "returned_at"}).
+		AddRow("t1", submitted, submitted.AddDate(0, 0, 7), nil, "1", "Budi", "Admin", "a1", "Laptop", 2, 0, "ready", nil).
+		AddRow("t1", submitted, submitted.AddDate(0, 0, 7), nil, "1", "Budi", "Admin", "a2", "Monitor", 1, 1, model.DetailStatusReturned, submitted)
+	suite.mockSQL.ExpectQuery(`WHERE m.submission_date >= \$1 AND m.submission_date < \$2 AND m.nik_staff = \$3 ORDER BY`).
+		WithArgs(from, to.AddDate(0, 0, 1), "1").WillReturnRows(rows)
+
+	var got []model.TransactionDetailRow
+	err := suite.repo.ExportDetails(dto.TransactionExportRequest{From: from, To: to, NikStaff: "1"}, func(row model.TransactionDetailRow) error {
+		got = append(got, row)
+		return nil
+	})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), got, 2)
+	assert.Equal(suite.T(), "Monitor", got[1].AssetName)
+	assert.NotNil(suite.T(), got[1].ReturnedAt)
+}
+
+func (suite *ManageAssetRepoTestSuite) TestExportDetails_StopOnError() {
+	rows := sqlmock.NewRows([]string{"id", "submission_date", "return_date", "actual_return_date", "nik_staff", "staff_name", "user_name",
+		"asset_id", "asset_name", "total_item", "total_returned", "status", "returned_at"}).
+		AddRow("t1", time.Now(), time.Now(), nil, "1", "Budi", "Admin", "a1", "Laptop", 2, 0, "ready", nil).
+		AddRow("t1", time.Now(), time.Now(), nil, "1", "Budi", "Admin", "a2", "Monitor", 1, 0, "ready", nil)
+	suite.mockSQL.ExpectQuery("FROM detail_manage_asset").WillReturnRows(rows)
+
+	calls := 0
+	err := suite.repo.ExportDetails(dto.TransactionExportRequest{}, func(row model.TransactionDetailRow) error {
+		calls++
+		return errors.New("client gone")
+	})
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), 1, calls)
+}
+
+func (suite *ManageAssetRepoTestSuite) TestFindOpenDetails() {
+	rows := sqlmock.NewRows([]string{"id", "id_manage_asset", "asset_id", "asset_name", "total_item", "total_returned", "status"}).
+		AddRow("d1", "t1", "a1", "Laptop", 3, 1, model.DetailStatusBorrowed)
+	suite.mockSQL.ExpectQuery(`WHERE d.id_asset = \$1 AND d.total_returned < d.total_item AND m.nik_staff = \$2 AND EXISTS \(.+du.id_unit = \$3.+\)\s+ORDER BY m.submission_date, d.id`).
+		WithArgs("a1", "1", "u1").WillReturnRows(rows)
+
+	details, err := suite.repo.FindOpenDetails(dto.OpenDetailRequest{AssetId: "a1", NikStaff: "1", UnitId: "u1"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), details, 1)
+	assert.Equal(suite.T(), "t1", details[0].ManageAssetId)
+	assert.Equal(suite.T(), 1, details[0].TotalReturned)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryOTP struct {
+	codeHash  string
+	expiresAt time.Time
+	attempts  int
+}
+
+// memoryOTPStore is meant for local development and single instance deployment,
+// pending codes are lost on restart
+type memoryOTPStore struct {
+	mu     sync.Mutex
+	codes  map[string]*memoryOTP
+	secret []byte
+	now    func() time.Time
+}
+
+func (m *memoryOTPStore) Save(purpose, email string, code int, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.purgeExpired()
+	m.codes[otpKey(purpose, email)] = &memoryOTP{
+		codeHash:  hashOTP(m.secret, purpose, email, code),
+		expiresAt: expiresAt,
+	}
+	return nil
+}
+
+func (m *memoryOTPStore) Verify(purpose, email string, code int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := otpKey(purpose, email)
+	stored, ok := m.codes[key]
+	if !ok {
+		return ErrOTPNotFound
+	}
+
+	err := checkOTP(stored.codeHash, stored.expiresAt, stored.attempts, hashOTP(m.secret, purpose, email, code), m.now())
+	if err == ErrOTPInvalid {
+		stored.attempts++
+		return err
+	}
+	delete(m.codes, key)
+	return err
+}
+
+// drop codes nobody came back for, so the map does not grow forever
+func (m *memoryOTPStore) purgeExpired() {
+	now := m.now()
+	for key, stored := range m.codes {
+		if !now.Before(stored.expiresAt) {
+			delete(m.codes, key)
+		}
+	}
+}
+
+func otpKey(purpose, email string) string {
+	return purpose + ":" + email
+}
+
+func NewMemoryOTPStore(secret []byte) OTPStore {
+	return &memoryOTPStore{
+		codes:  make(map[string]*memoryOTP),
+		secret: secret,
+		now:    time.Now,
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"final-project-enigma-clean/model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMemoryOTPStore(now *time.Time) *memoryOTPStore {
+	store := NewMemoryOTPStore(testOTPSecret).(*memoryOTPStore)
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestMemoryOTPStore_SingleUse(t *testing.T) {
+	now := time.Now()
+	store := newTestMemoryOTPStore(&now)
+
+	assert.NoError(t, store.Save(model.OTPPurposeLogin, "a@mail.com", 123456, now.Add(time.Minute)))
+	assert.NoError(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 123456))
+	assert.ErrorIs(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 123456), ErrOTPNotFound)
+}
+
+func TestMemoryOTPStore_PurposeScoped(t *testing.T) {
+	now := time.Now()
+	store := newTestMemoryOTPStore(&now)
+
+	assert.NoError(t, store.Save(model.OTPPurposeLogin, "a@mail.com", 123456, now.Add(time.Minute)))
+	assert.ErrorIs(t, store.Verify(model.OTPPurposeForgotPassword, "a@mail.com", 123456), ErrOTPNotFound)
+	assert.NoError(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 123456))
+}
+
+func TestMemoryOTPStore_Expired(t *testing.T) {
+	now := time.Now()
+	store := newTestMemoryOTPStore(&now)
+
+	assert.NoError(t, store.Save(model.OTPPurposeLogin, "a@mail.com", 123456, now.Add(time.Minute)))
+	now = now.Add(2 * time.Minute)
+	assert.ErrorIs(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 123456), ErrOTPExpired)
+	assert.ErrorIs(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 123456), ErrOTPNotFound)
+}
+
+func TestMemoryOTPStore_TooManyAttempts(t *testing.T) {
+	now := time.Now()
+	store := newTestMemoryOTPStore(&now)
+
+	assert.NoError(t, store.Save(model.OTPPurposeLogin, "a@mail.com", 123456, now.Add(time.Minute)))
+	for i := 0; i < OTPMaxAttempts-1; i++ {
+		assert.ErrorIs(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 111111), ErrOTPInvalid)
+	}
+	assert.ErrorIs(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 111111), ErrOTPTooManyAttempts)
+	//the code is burned, even the right one is rejected now
+	assert.ErrorIs(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 123456), ErrOTPNotFound)
+}
+
+func TestMemoryOTPStore_ResendResetAttempts(t *testing.T) {
+	now := time.Now()
+	store := newTestMemoryOTPStore(&now)
+
+	assert.NoError(t, store.Save(model.OTPPurposeLogin, "a@mail.com", 123456, now.Add(time.Minute)))
+	assert.ErrorIs(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 111111), ErrOTPInvalid)
+	assert.NoError(t, store.Save(model.OTPPurposeLogin, "a@mail.com", 222222, now.Add(time.Minute)))
+	assert.ErrorIs(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 123456), ErrOTPInvalid)
+	assert.NoError(t, store.Verify(model.OTPPurposeLogin, "a@mail.com", 222222))
+}
+
+func TestMemoryOTPStore_ConcurrentVerifyOnlyOneWins(t *testing.T) {
+	now := time.Now()
+	store := newTestMemoryOTPStore(&now)
+	assert.NoError(t, store.Save(model.OTPPurposeLogin, "a@mail.com", 123456, now.Add(time.Minute)))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	success := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if store.Verify(model.OTPPurposeLogin, "a@mail.com", 123456) == nil {
+				mu.Lock()
+				success++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, success)
+}
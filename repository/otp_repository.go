@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// OTPMaxAttempts is how many wrong codes are accepted before the otp is burned
+const OTPMaxAttempts = 5
+
+var (
+	ErrOTPNotFound        = errors.New("otp not found")
+	ErrOTPExpired         = errors.New("otp expired")
+	ErrOTPInvalid         = errors.New("otp is invalid")
+	ErrOTPTooManyAttempts = errors.New("too many invalid otp attempts")
+)
+
+// OTPStore keep one active otp per email and purpose, a verified code is consumed
+type OTPStore interface {
+	Save(purpose, email string, code int, expiresAt time.Time) error
+	Verify(purpose, email string, code int) error
+}
+
+type otpRepository struct {
+	db     *sql.DB
+	secret []byte
+	now    func() time.Time
+}
+
+// Save replace any pending otp of the same purpose
+func (o *otpRepository) Save(purpose, email string, code int, expiresAt time.Time) error {
+	query := `insert into user_otp (email, purpose, code_hash, expires_at, attempts) values ($1, $2, $3, $4, 0)
+		on conflict (email, purpose) do update set code_hash = excluded.code_hash, expires_at = excluded.expires_at, attempts = 0`
+	_, err := o.db.Exec(query, email, purpose, hashOTP(o.secret, purpose, email, code), expiresAt)
+	return err
+}
+
+// Verify lock the otp row so concurrent guesses are counted one by one
+func (o *otpRepository) Verify(purpose, email string, code int) error {
+	tx, err := o.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var codeHash string
+	var expiresAt time.Time
+	var attempts int
+	err = tx.QueryRow(`select code_hash, expires_at, attempts from user_otp where email = $1 and purpose = $2 for update`,
+		email, purpose).Scan(&codeHash, &expiresAt, &attempts)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrOTPNotFound
+		}
+		return err
+	}
+
+	verifyErr := checkOTP(codeHash, expiresAt, attempts, hashOTP(o.secret, purpose, email, code), o.now())
+	if verifyErr == ErrOTPInvalid {
+		_, err = tx.Exec(`update user_otp set attempts = attempts + 1 where email = $1 and purpose = $2`, email, purpose)
+	} else {
+		//expired, burned and used codes are all removed
+		_, err = tx.Exec(`delete from user_otp where email = $1 and purpose = $2`, email, purpose)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	return verifyErr
+}
+
+// checkOTP decide the verification result of a stored otp against the hash of the given code,
+// nil means the code is accepted
+func checkOTP(codeHash string, expiresAt time.Time, attempts int, givenHash string, now time.Time) error {
+	if !now.Before(expiresAt) {
+		return ErrOTPExpired
+	}
+	if attempts >= OTPMaxAttempts {
+		return ErrOTPTooManyAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(givenHash)) != 1 {
+		if attempts+1 >= OTPMaxAttempts {
+			return ErrOTPTooManyAttempts
+		}
+		return ErrOTPInvalid
+	}
+	return nil
+}
+
+// otp is never stored in plain text, the six digits are keyed with the server secret so a leaked
+// table cannot be brute forced offline, and bound to the email and purpose so a hash is not reusable
+func hashOTP(secret []byte, purpose, email string, code int) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(purpose + "\x00" + email + "\x00" + strconv.Itoa(code)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func NewOTPRepository(db *sql.DB, secret []byte) OTPStore {
+	return &otpRepository{
+		db:     db,
+		secret: secret,
+		now:    time.Now,
+	}
+}
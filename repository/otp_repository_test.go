@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var testOTPSecret = []byte("otp-secret")
+
+type OTPRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *otpRepository
+	now  time.Time
+}
+
+func (suite *OTPRepositorySuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.db = db
+	suite.mock = mock
+	suite.now = time.Date(2023, 9, 10, 7, 0, 0, 0, time.UTC)
+	suite.repo = &otpRepository{db: db, secret: testOTPSecret, now: func() time.Time { return suite.now }}
+}
+
+func (suite *OTPRepositorySuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func (suite *OTPRepositorySuite) TestSave_Success() {
+	expiresAt := suite.now.Add(30 * time.Minute)
+	suite.mock.ExpectExec("insert into user_otp (.+) on conflict").
+		WithArgs("test@example.com", model.OTPPurposeLogin, hashOTP(testOTPSecret, model.OTPPurposeLogin, "test@example.com", 123456), expiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := suite.repo.Save(model.OTPPurposeLogin, "test@example.com", 123456, expiresAt)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OTPRepositorySuite) TestVerify_Success() {
+	rows := sqlmock.NewRows([]string{"code_hash", "expires_at", "attempts"}).
+		AddRow(hashOTP(testOTPSecret, model.OTPPurposeLogin, "test@example.com", 123456), suite.now.Add(time.Minute), 0)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select code_hash, expires_at, attempts from user_otp (.+) for update").
+		WithArgs("test@example.com", model.OTPPurposeLogin).WillReturnRows(rows)
+	suite.mock.ExpectExec("delete from user_otp").
+		WithArgs("test@example.com", model.OTPPurposeLogin).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.Verify(model.OTPPurposeLogin, "test@example.com", 123456)
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OTPRepositorySuite) TestVerify_WrongCodeCountAttempt() {
+	rows := sqlmock.NewRows([]string{"code_hash", "expires_at", "attempts"}).
+		AddRow(hashOTP(testOTPSecret, model.OTPPurposeLogin, "test@example.com", 123456), suite.now.Add(time.Minute), 1)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select code_hash, expires_at, attempts from user_otp").
+		WithArgs("test@example.com", model.OTPPurposeLogin).WillReturnRows(rows)
+	suite.mock.ExpectExec("update user_otp set attempts = attempts \\+ 1").
+		WithArgs("test@example.com", model.OTPPurposeLogin).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.Verify(model.OTPPurposeLogin, "test@example.com", 654321)
+	assert.ErrorIs(suite.T(), err, ErrOTPInvalid)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OTPRepositorySuite) TestVerify_Expired() {
+	rows := sqlmock.NewRows([]string{"code_hash", "expires_at", "attempts"}).
+		AddRow(hashOTP(testOTPSecret, model.OTPPurposeLogin, "test@example.com", 123456), suite.now.Add(-time.Minute), 0)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select code_hash, expires_at, attempts from user_otp").
+		WithArgs("test@example.com", model.OTPPurposeLogin).WillReturnRows(rows)
+	suite.mock.ExpectExec("delete from user_otp").
+		WithArgs("test@example.com", model.OTPPurposeLogin).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	err := suite.repo.Verify(model.OTPPurposeLogin, "test@example.com", 123456)
+	assert.ErrorIs(suite.T(), err, ErrOTPExpired)
+}
+
+func (suite *OTPRepositorySuite) TestVerify_NotFound() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select code_hash, expires_at, attempts from user_otp").
+		WithArgs("test@example.com", model.OTPPurposeForgotPassword).WillReturnError(sql.ErrNoRows)
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.Verify(model.OTPPurposeForgotPassword, "test@example.com", 123456)
+	assert.ErrorIs(suite.T(), err, ErrOTPNotFound)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *OTPRepositorySuite) TestVerify_BeginError() {
+	suite.mock.ExpectBegin().WillReturnError(errors.New("begin failed"))
+
+	err := suite.repo.Verify(model.OTPPurposeLogin, "test@example.com", 123456)
+	assert.Error(suite.T(), err)
+}
+
+// the stored hash only match the code of the same secret, email and purpose
+func TestHashOTP_Bound(t *testing.T) {
+	hash := hashOTP(testOTPSecret, model.OTPPurposeLogin, "test@example.com", 123456)
+	assert.Equal(t, hash, hashOTP(testOTPSecret, model.OTPPurposeLogin, "test@example.com", 123456))
+	assert.NotEqual(t, hash, hashOTP([]byte("other-secret"), model.OTPPurposeLogin, "test@example.com", 123456))
+	assert.NotEqual(t, hash, hashOTP(testOTPSecret, model.OTPPurposeLogin, "other@example.com", 123456))
+	assert.NotEqual(t, hash, hashOTP(testOTPSecret, model.OTPPurposeForgotPassword, "test@example.com", 123456))
+}
+
+func TestOTPRepositorySuite(t *testing.T) {
+	suite.Run(t, new(OTPRepositorySuite))
+}
@@ -0,0 +1,22 @@
+package repository
+
+import "errors"
+
+// ErrNotDeleted is returned when restoring a row that does not exist or is not deleted
+var ErrNotDeleted = errors.New("no deleted row with that id")
+
+// restore clear deleted_at of one soft deleted row, the query only match a deleted row
+func restore(exec execer, query string, id string) error {
+	res, err := exec.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotDeleted
+	}
+	return nil
+}
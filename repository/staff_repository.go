@@ -5,16 +5,22 @@ import (
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"math"
+
+	"github.com/lib/pq"
 )
 
 type StaffRepository interface {
-	Save(payload model.Staff) error
+	Save(payload model.Staff, audit model.AuditLog) error
 	FindById(nik_staff string) (model.Staff, error)
 	FindByName(name string) ([]model.Staff, error)
 	FindByAll() ([]model.Staff, error)
-	Update(payload model.Staff) error
-	Delete(nik_staff string) error
+	Update(payload model.Staff, audit model.AuditLog) error
+	Delete(nik_staff string, audit model.AuditLog) error
 	Paging(payload dto.PageRequest) ([]model.Staff, dto.Paging, error)
+	PagingCursor(payload dto.CursorRequest) ([]model.Staff, dto.CursorPaging, error)
+	Restore(nik_staff string, audit model.AuditLog) error
+	FindByNiks(niks []string) ([]model.Staff, error)
+	Upsert(staffs []model.Staff, audits []model.AuditLog) error
 }
 
 type staffRepository struct {
@@ -22,25 +28,28 @@ type staffRepository struct {
 }
 
 // Delete implements StaffRepository.
-func (s *staffRepository) Delete(nik_staff string) error {
-	_, err := s.db.Exec("DELETE FROM staff WHERE nik_staff=$1", nik_staff)
-	if err != nil {
-		return err
-	}
-	return nil
+// the row is only marked deleted, the loans of the staff keep pointing to it
+func (s *staffRepository) Delete(nik_staff string, audit model.AuditLog) error {
+	return inTx(s.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE staff SET deleted_at = now() WHERE nik_staff=$1 AND deleted_at IS NULL", nik_staff)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 // FindByAll implements StaffRepository.
 func (s *staffRepository) FindByAll() ([]model.Staff, error) {
 	//nik_staff, name, phone_number, address, birth_date, img_url, divisi
-	rows, err := s.db.Query("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff")
+	rows, err := s.db.Query("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
 	var staffs []model.Staff
 	for rows.Next() {
 		var staff model.Staff
-		rows.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi)
+		rows.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi, &staff.Email)
 		staffs = append(staffs, staff)
 	}
 	if rows.Err() != nil {
@@ -51,9 +60,9 @@ func (s *staffRepository) FindByAll() ([]model.Staff, error) {
 
 // FindById implements StaffRepository.
 func (s *staffRepository) FindById(nik_staff string) (model.Staff, error) {
-	row := s.db.QueryRow("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff WHERE nik_staff=$1", nik_staff)
+	row := s.db.QueryRow("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff WHERE nik_staff=$1 AND deleted_at IS NULL", nik_staff)
 	var staff model.Staff
-	err := row.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi)
+	err := row.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi, &staff.Email)
 	if err != nil {
 		return model.Staff{}, err
 	}
@@ -62,14 +71,14 @@ func (s *staffRepository) FindById(nik_staff string) (model.Staff, error) {
 
 // FindByName implements StaffRepository.
 func (s *staffRepository) FindByName(name string) ([]model.Staff, error) {
-	rows, err := s.db.Query(`SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff WHERE name ILIKE $1`, "%"+name+"%")
+	rows, err := s.db.Query(`SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff WHERE name ILIKE $1 AND deleted_at IS NULL`, "%"+name+"%")
 	if err != nil {
 		return nil, err
 	}
 	var staffs []model.Staff
 	for rows.Next() {
 		var staff model.Staff
-		rows.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi)
+		rows.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi, &staff.Email)
 		staffs = append(staffs, staff)
 	}
 	if rows.Err() != nil {
@@ -83,8 +92,8 @@ func (s *staffRepository) Paging(payload dto.PageRequest) ([]model.Staff, dto.Pa
 	if payload.Page <= 0 {
 		payload.Page = 1
 	}
-	q := `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff LIMIT $2 OFFSET $1`
-	rows, err := s.db.Query(q, (payload.Page-1)*payload.Size, payload.Size)
+	q := `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email, deleted_at FROM staff WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
+	rows, err := s.db.Query(q, (payload.Page-1)*payload.Size, payload.Size, payload.IncludeDeleted)
 	if err != nil {
 		return nil, dto.Paging{}, err
 	}
@@ -92,14 +101,14 @@ func (s *staffRepository) Paging(payload dto.PageRequest) ([]model.Staff, dto.Pa
 	var staffs []model.Staff
 	for rows.Next() {
 		var staff model.Staff
-		err := rows.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi)
+		err := rows.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi, &staff.Email, &staff.DeletedAt)
 		if err != nil {
 			return nil, dto.Paging{}, err
 		}
 		staffs = append(staffs, staff)
 	}
 	var count int
-	row := s.db.QueryRow("SELECT COUNT(nik_staff) FROM staff")
+	row := s.db.QueryRow("SELECT COUNT(nik_staff) FROM staff WHERE ($1 OR deleted_at IS NULL)", payload.IncludeDeleted)
 	if err := row.Scan(&count); err != nil {
 		return nil, dto.Paging{}, err
 	}
@@ -115,22 +124,127 @@ func (s *staffRepository) Paging(payload dto.PageRequest) ([]model.Staff, dto.Pa
 
 }
 
-// Save implements StaffRepository.
-func (s *staffRepository) Save(payload model.Staff) error {
-	_, err := s.db.Exec("INSERT INTO staff (nik_staff, name, phone_number, address, birth_date, img_url, divisi) VALUES ($1, $2, $3, $4, $5, $6, $7)", payload.Nik_Staff, payload.Name, payload.Phone_number, payload.Address, payload.Birth_date, payload.Img_url, payload.Divisi)
+// PagingCursor implements StaffRepository.
+// the rows are read in nik_staff order after the last key, a staff inserted meanwhile never shift a page
+func (s *staffRepository) PagingCursor(payload dto.CursorRequest) ([]model.Staff, dto.CursorPaging, error) {
+	q := `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email, deleted_at FROM staff WHERE ($1 OR deleted_at IS NULL) AND nik_staff > $2 ORDER BY nik_staff LIMIT $3`
+	rows, err := s.db.Query(q, payload.IncludeDeleted, payload.After, payload.Limit+1)
 	if err != nil {
-		return err
+		return nil, dto.CursorPaging{}, err
 	}
-	return nil
+	defer rows.Close()
+
+	var staffs []model.Staff
+	for rows.Next() {
+		var staff model.Staff
+		err := rows.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi, &staff.Email, &staff.DeletedAt)
+		if err != nil {
+			return nil, dto.CursorPaging{}, err
+		}
+		staffs = append(staffs, staff)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dto.CursorPaging{}, err
+	}
+
+	staffs, paging := cursorPage(staffs, payload.Limit, func(staff model.Staff) string { return staff.Nik_Staff })
+	return staffs, paging, nil
+}
+
+// Restore implements StaffRepository.
+func (s *staffRepository) Restore(nik_staff string, audit model.AuditLog) error {
+	return inTx(s.db, func(tx *sql.Tx) error {
+		if err := restore(tx, "UPDATE staff SET deleted_at = NULL WHERE nik_staff=$1 AND deleted_at IS NOT NULL", nik_staff); err != nil {
+			return err
+		}
+		row := tx.QueryRow("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff WHERE nik_staff=$1", nik_staff)
+		var restored model.Staff
+		err := row.Scan(&restored.Nik_Staff, &restored.Name, &restored.Phone_number, &restored.Address, &restored.Birth_date, &restored.Img_url, &restored.Divisi, &restored.Email)
+		if err != nil {
+			return err
+		}
+		audit, err := auditAfter(audit, restored)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
+}
+
+// Save implements StaffRepository.
+func (s *staffRepository) Save(payload model.Staff, audit model.AuditLog) error {
+	return inTx(s.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO staff (nik_staff, name, phone_number, address, birth_date, img_url, divisi, email) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)", payload.Nik_Staff, payload.Name, payload.Phone_number, payload.Address, payload.Birth_date, payload.Img_url, payload.Divisi, payload.Email)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 // Update implements StaffRepository.
-func (s *staffRepository) Update(payload model.Staff) error {
-	_, err := s.db.Exec("UPDATE staff SET nik_staff=$1, name=$2, phone_number=$3, address=$4, birth_date=$5, img_url=$6, divisi=$7 WHERE nik_staff=$1", payload.Nik_Staff, payload.Name, payload.Phone_number, payload.Address, payload.Birth_date, payload.Img_url, payload.Divisi)
+func (s *staffRepository) Update(payload model.Staff, audit model.AuditLog) error {
+	return inTx(s.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE staff SET nik_staff=$1, name=$2, phone_number=$3, address=$4, birth_date=$5, img_url=$6, divisi=$7, email=$8 WHERE nik_staff=$1 AND deleted_at IS NULL", payload.Nik_Staff, payload.Name, payload.Phone_number, payload.Address, payload.Birth_date, payload.Img_url, payload.Divisi, payload.Email)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
+}
+
+// FindByNiks implements StaffRepository.
+// the deleted staff are listed too so an import can tell them apart from a new nik
+func (s *staffRepository) FindByNiks(niks []string) ([]model.Staff, error) {
+	rows, err := s.db.Query("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email, deleted_at FROM staff WHERE nik_staff = ANY($1)", pq.Array(niks))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var staffs []model.Staff
+	for rows.Next() {
+		var staff model.Staff
+		err := rows.Scan(&staff.Nik_Staff, &staff.Name, &staff.Phone_number, &staff.Address, &staff.Birth_date, &staff.Img_url, &staff.Divisi, &staff.Email, &staff.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		staffs = append(staffs, staff)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return staffs, nil
+}
+
+// Upsert implements StaffRepository.
+// a new nik is inserted and an existing one updated, all in one transaction.
+// birth_date, img_url and email are optional, a blank one keep the value already saved.
+// the audit entries of the import are written in the same transaction
+func (s *staffRepository) Upsert(staffs []model.Staff, audits []model.AuditLog) error {
+	query := `INSERT INTO staff (nik_staff, name, phone_number, address, birth_date, img_url, divisi, email) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (nik_staff) DO UPDATE SET name = excluded.name, phone_number = excluded.phone_number, address = excluded.address,
+	birth_date = COALESCE(NULLIF(excluded.birth_date, '0001-01-01'), staff.birth_date),
+	img_url = COALESCE(NULLIF(excluded.img_url, ''), staff.img_url), divisi = excluded.divisi,
+	email = COALESCE(NULLIF(excluded.email, ''), staff.email)
+	WHERE staff.deleted_at IS NULL`
+
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
-	return nil
+	for _, staff := range staffs {
+		_, err = tx.Exec(query, staff.Nik_Staff, staff.Name, staff.Phone_number, staff.Address, staff.Birth_date, staff.Img_url, staff.Divisi, staff.Email)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err = insertAudit(tx, audits...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
 }
 
 func NewStaffRepository(db *sql.DB) StaffRepository {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/util/helper"
 	"regexp"
 	"testing"
 	"time"
@@ -43,8 +44,10 @@ func (suite *StaffRepositoryTestSuite) TestCreate_Success() {
 		Img_url:      "jjj.png",
 		Divisi:       "IT",
 	}
-	suite.mockSQL.ExpectExec("INSERT INTO staff").WithArgs(mockData.Nik_Staff, mockData.Name, mockData.Phone_number, mockData.Address, mockData.Birth_date, mockData.Img_url, mockData.Divisi).WillReturnResult(sqlmock.NewResult(1, 1))
-	err := suite.repo.Save(mockData)
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("INSERT INTO staff").WithArgs(mockData.Nik_Staff, mockData.Name, mockData.Phone_number, mockData.Address, mockData.Birth_date, mockData.Img_url, mockData.Divisi, mockData.Email).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+	err := suite.repo.Save(mockData, testAudit)
 	assert.NoError(suite.T(), err)
 }
 
@@ -58,8 +61,10 @@ func (suite *StaffRepositoryTestSuite) TestCreate_Failed() {
 		Img_url:      "sss.png",
 		Divisi:       "IT",
 	}
-	suite.mockSQL.ExpectExec("INSERT INTO staff").WithArgs(mockData.Nik_Staff, mockData.Name, mockData.Phone_number, mockData.Address, mockData.Birth_date, mockData.Img_url, mockData.Divisi).WillReturnError(errors.New("failed save Staff"))
-	err := suite.repo.Save(mockData)
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("INSERT INTO staff").WithArgs(mockData.Nik_Staff, mockData.Name, mockData.Phone_number, mockData.Address, mockData.Birth_date, mockData.Img_url, mockData.Divisi, mockData.Email).WillReturnError(errors.New("failed save Staff"))
+	suite.mockSQL.ExpectRollback()
+	err := suite.repo.Save(mockData, testAudit)
 	assert.Error(suite.T(), err)
 }
 
@@ -86,13 +91,13 @@ func (suite *StaffRepositoryTestSuite) TestFindAll_Success() {
 	}
 
 	// Membuat rows mock dengan kolom yang sesuai
-	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi"})
+	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email"})
 	for _, asset := range expectedAssets {
-		rows.AddRow(asset.Nik_Staff, asset.Name, asset.Phone_number, asset.Address, asset.Birth_date, asset.Img_url, asset.Divisi)
+		rows.AddRow(asset.Nik_Staff, asset.Name, asset.Phone_number, asset.Address, asset.Birth_date, asset.Img_url, asset.Divisi, asset.Email)
 	}
 
 	// Mengharapkan query SELECT * FROM asset_type dan mengembalikan rows mock
-	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff").WillReturnRows(rows)
 
 	// Menjalankan fungsi yang diuji
 	got, err := suite.repo.FindByAll()
@@ -125,16 +130,16 @@ func (suite *StaffRepositoryTestSuite) TestFindAll_FailedRows() {
 	}
 
 	// Membuat rows mock dengan kolom yang sesuai
-	rows := sqlmock.NewRows([]string{"id", "name", "phone_number", "address", "birth_date", "img_url", "divisi"})
+	rows := sqlmock.NewRows([]string{"id", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email"})
 	for _, asset := range assets {
-		rows.AddRow(asset.Nik_Staff, asset.Name, asset.Phone_number, asset.Address, asset.Birth_date, asset.Img_url, asset.Divisi)
+		rows.AddRow(asset.Nik_Staff, asset.Name, asset.Phone_number, asset.Address, asset.Birth_date, asset.Img_url, asset.Divisi, asset.Email)
 	}
 
 	// Menambahkan row yang akan menghasilkan error
 	rows.RowError(0, errors.New("error new scan"))
 
 	// Mengharapkan query SELECT id, name FROM asset_type dan mengembalikan rows mock
-	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff").WillReturnRows(rows)
 
 	// Menjalankan fungsi yang diuji
 	got, err := suite.repo.FindByAll()
@@ -146,7 +151,7 @@ func (suite *StaffRepositoryTestSuite) TestFindAll_FailedRows() {
 
 func (suite *StaffRepositoryTestSuite) TestFindAll_Failed() {
 
-	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff").WillReturnError(errors.New("failed get staff"))
+	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff").WillReturnError(errors.New("failed get staff"))
 	got, err := suite.repo.FindByAll()
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), got)
@@ -162,8 +167,8 @@ func (suite *StaffRepositoryTestSuite) TestFindById_Success() {
 		Img_url:      "ssd.jpg",
 		Divisi:       "IT",
 	}
-	row := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi"}).AddRow(assets.Nik_Staff, assets.Name, assets.Phone_number, assets.Address, assets.Birth_date, assets.Img_url, assets.Divisi)
-	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff WHERE nik_staff").WithArgs("1").WillReturnRows(row)
+	row := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email"}).AddRow(assets.Nik_Staff, assets.Name, assets.Phone_number, assets.Address, assets.Birth_date, assets.Img_url, assets.Divisi, assets.Email)
+	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff WHERE nik_staff").WithArgs("1").WillReturnRows(row)
 	got, err := suite.repo.FindById("1")
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), assets, got)
@@ -186,8 +191,10 @@ func (suite *StaffRepositoryTestSuite) TestUpdate_Success() {
 		Img_url:      "jhj.jpg",
 		Divisi:       "IT",
 	}
-	suite.mockSQL.ExpectExec("UPDATE staff SET").WithArgs(mockData.Nik_Staff, mockData.Name, mockData.Phone_number, mockData.Address, mockData.Birth_date, mockData.Img_url, mockData.Divisi).WillReturnResult(sqlmock.NewResult(1, 1))
-	err := suite.repo.Update(mockData)
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE staff SET").WithArgs(mockData.Nik_Staff, mockData.Name, mockData.Phone_number, mockData.Address, mockData.Birth_date, mockData.Img_url, mockData.Divisi, mockData.Email).WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+	err := suite.repo.Update(mockData, testAudit)
 	assert.NoError(suite.T(), err)
 }
 
@@ -201,20 +208,26 @@ func (suite *StaffRepositoryTestSuite) TestUpdate_Failed() {
 		Img_url:      "jhhg.jpg",
 		Divisi:       "IT",
 	}
-	suite.mockSQL.ExpectExec("UPDATE staff SET").WithArgs(mockData.Nik_Staff, mockData.Name, mockData.Phone_number, mockData.Address, mockData.Birth_date, mockData.Img_url, mockData.Divisi).WillReturnError(errors.New("failed update staff"))
-	err := suite.repo.Update(mockData)
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE staff SET").WithArgs(mockData.Nik_Staff, mockData.Name, mockData.Phone_number, mockData.Address, mockData.Birth_date, mockData.Img_url, mockData.Divisi, mockData.Email).WillReturnError(errors.New("failed update staff"))
+	suite.mockSQL.ExpectRollback()
+	err := suite.repo.Update(mockData, testAudit)
 	assert.Error(suite.T(), err)
 }
 
 func (suite *StaffRepositoryTestSuite) TestDelete_Success() {
-	suite.mockSQL.ExpectExec("DELETE FROM staff").WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
-	gotErr := suite.repo.Delete("1")
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE staff SET deleted_at").WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+	gotErr := suite.repo.Delete("1", testAudit)
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *StaffRepositoryTestSuite) TestDelete_Failed() {
-	suite.mockSQL.ExpectExec("DELETE FROM staff").WithArgs("1").WillReturnError(errors.New("failed delete staff"))
-	gotErr := suite.repo.Delete("1")
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE staff SET deleted_at").WithArgs("1").WillReturnError(errors.New("failed delete staff"))
+	suite.mockSQL.ExpectRollback()
+	gotErr := suite.repo.Delete("1", testAudit)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -241,13 +254,13 @@ func (suite *StaffRepositoryTestSuite) TestFindByName_Success() {
 	}
 
 	// Membuat rows mock dengan kolom yang sesuai
-	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi"})
+	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email"})
 	for _, asset := range expectedAssets {
-		rows.AddRow(asset.Nik_Staff, asset.Name, asset.Phone_number, asset.Address, asset.Birth_date, asset.Img_url, asset.Divisi)
+		rows.AddRow(asset.Nik_Staff, asset.Name, asset.Phone_number, asset.Address, asset.Birth_date, asset.Img_url, asset.Divisi, asset.Email)
 	}
 
 	// Mengharapkan query SELECT * FROM asset_type dan mengembalikan rows mock
-	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff WHERE name ILIKE").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff WHERE name ILIKE").WillReturnRows(rows)
 
 	// Menjalankan fungsi yang diuji
 	got, err := suite.repo.FindByName("Bergerak")
@@ -280,16 +293,16 @@ func (suite *StaffRepositoryTestSuite) TestFindByName_FailedRows() {
 	}
 
 	// Membuat rows mock dengan kolom yang sesuai
-	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi"})
+	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email"})
 	for _, asset := range assets {
-		rows.AddRow(asset.Nik_Staff, asset.Name, asset.Phone_number, asset.Address, asset.Birth_date, asset.Img_url, asset.Divisi)
+		rows.AddRow(asset.Nik_Staff, asset.Name, asset.Phone_number, asset.Address, asset.Birth_date, asset.Img_url, asset.Divisi, asset.Email)
 	}
 
 	// Menambahkan row yang akan menghasilkan error
 	rows.RowError(0, errors.New("error new scan"))
 
 	// Mengharapkan query SELECT id, name FROM asset_type dan mengembalikan rows mock
-	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff WHERE name ILIKE").WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff WHERE name ILIKE").WillReturnRows(rows)
 
 	// Menjalankan fungsi yang diuji
 	got, err := suite.repo.FindByName("Bergerak")
@@ -301,7 +314,7 @@ func (suite *StaffRepositoryTestSuite) TestFindByName_FailedRows() {
 
 func (suite *StaffRepositoryTestSuite) TestFindByName_Failed() {
 
-	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff WHERE name ILIKE").WillReturnError(errors.New("failed get staff"))
+	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff WHERE name ILIKE").WillReturnError(errors.New("failed get staff"))
 	got, err := suite.repo.FindByName("Bergerak")
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), got)
@@ -333,14 +346,15 @@ func (suite *StaffRepositoryTestSuite) TestPaging_Success() {
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi"})
+	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email", "deleted_at"})
 	for _, v := range mockData {
-		rows.AddRow(v.Nik_Staff, v.Name, v.Phone_number, v.Address, v.Birth_date, v.Img_url, v.Divisi)
+		rows.AddRow(v.Nik_Staff, v.Name, v.Phone_number, v.Address, v.Birth_date, v.Img_url, v.Divisi, v.Email, nil)
 	}
-	expectedQuery := `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff LIMIT $2 OFFSET $1`
+	expectedQuery := `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email, deleted_at FROM staff WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WithArgs(
 		(mockPageRequest.Page-1)*mockPageRequest.Size,
 		mockPageRequest.Size,
+		false,
 	).WillReturnRows(rows)
 
 	rowCount := sqlmock.NewRows([]string{"count"})
@@ -381,7 +395,7 @@ func (suite *StaffRepositoryTestSuite) TestPaging_Fail() {
 	}
 
 	//err select paging
-	expectedQuery := `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff LIMIT $2 OFFSET $1`
+	expectedQuery := `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email, deleted_at FROM staff WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WillReturnError(errors.New("failed"))
 	actualTypeAsset, actualPaging, actualErr := suite.repo.Paging(dto.PageRequest{})
 	assert.Error(suite.T(), actualErr)
@@ -389,7 +403,7 @@ func (suite *StaffRepositoryTestSuite) TestPaging_Fail() {
 	assert.Equal(suite.T(), 0, actualPaging.TotalRows)
 
 	// Konfigurasi untuk mengharapkan panggilan ke rows.Scan dengan kesalahan
-	expectedQuery = `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff LIMIT $2 OFFSET $1`
+	expectedQuery = `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email, deleted_at FROM staff WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
 	// data sql yg apa aja, jangan semuanya
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WillReturnRows(
 		sqlmock.NewRows([]string{"nik_staff", "name"}).AddRow("invalid", "data"),
@@ -402,14 +416,14 @@ func (suite *StaffRepositoryTestSuite) TestPaging_Fail() {
 	assert.Equal(suite.T(), 0, actualPaging.TotalRows)
 
 	//err select count
-	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi"})
+	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email", "deleted_at"})
 	for _, v := range mockData {
-		rows.AddRow(v.Nik_Staff, v.Name, v.Phone_number, v.Address, v.Birth_date, v.Img_url, v.Divisi)
+		rows.AddRow(v.Nik_Staff, v.Name, v.Phone_number, v.Address, v.Birth_date, v.Img_url, v.Divisi, v.Email, nil)
 	}
-	expectedQuery = `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi FROM staff LIMIT $2 OFFSET $1`
+	expectedQuery = `SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email, deleted_at FROM staff WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WithArgs(
 		(mockPageRequest.Page-1)*mockPageRequest.Size,
-		mockPageRequest.Size).WillReturnRows(rows)
+		mockPageRequest.Size, false).WillReturnRows(rows)
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(nik_staff) FROM staff`)).WillReturnError(errors.New("failed"))
 
 	actualTypeAsset, actualPaging, actualErr = suite.repo.Paging(mockPageRequest)
@@ -417,3 +431,117 @@ func (suite *StaffRepositoryTestSuite) TestPaging_Fail() {
 	assert.Nil(suite.T(), actualTypeAsset)
 	assert.Equal(suite.T(), 0, actualPaging.TotalRows)
 }
+
+func (suite *StaffRepositoryTestSuite) TestPaging_IncludeDeleted() {
+	deletedAt := time.Now()
+	rows := sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email", "deleted_at"}).
+		AddRow("1", "Budi", "081234567890", "jkt", time.Time{}, "", "IT", "", deletedAt)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`WHERE ($3 OR deleted_at IS NULL)`)).WithArgs(0, 5, true).WillReturnRows(rows)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(nik_staff) FROM staff WHERE ($1 OR deleted_at IS NULL)`)).WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	staffs, _, err := suite.repo.Paging(dto.PageRequest{Page: 1, Size: 5, IncludeDeleted: true})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), deletedAt, *staffs[0].DeletedAt)
+}
+
+func (suite *StaffRepositoryTestSuite) TestRestore_Success() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE staff SET deleted_at = NULL").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectQuery("SELECT nik_staff, name, phone_number, address, birth_date, img_url, divisi, email FROM staff").WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email"}).AddRow("1", "Budi", "082284163929", "Pekanbaru", time.Now(), "", "IT", ""))
+	expectAudit(suite.mockSQL)
+	assert.NoError(suite.T(), suite.repo.Restore("1", testAudit))
+}
+
+func (suite *StaffRepositoryTestSuite) TestRestore_NotDeleted() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE staff SET deleted_at = NULL").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSQL.ExpectRollback()
+	assert.ErrorIs(suite.T(), suite.repo.Restore("1", testAudit), ErrNotDeleted)
+}
+
+func (suite *StaffRepositoryTestSuite) TestPagingCursor_NextPage() {
+	columns := []string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email", "deleted_at"}
+	rows := sqlmock.NewRows(columns).
+		AddRow("2", "Budi", "0822", "pku", time.Time{}, "", "IT", "", nil).
+		AddRow("3", "Sari", "0812", "jkt", time.Time{}, "", "IT", "", nil).
+		AddRow("4", "Tono", "0813", "bdg", time.Time{}, "", "IT", "", nil)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("FROM staff WHERE ($1 OR deleted_at IS NULL) AND nik_staff > $2 ORDER BY nik_staff LIMIT $3")).
+		WithArgs(false, "1", 3).WillReturnRows(rows)
+
+	staffs, paging, err := suite.repo.PagingCursor(dto.CursorRequest{Limit: 2, After: "1"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), staffs, 2)
+	after, err := helper.DecodeCursor(paging.NextCursor)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "3", after)
+}
+
+func (suite *StaffRepositoryTestSuite) TestPagingCursor_LastPage() {
+	columns := []string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email", "deleted_at"}
+	rows := sqlmock.NewRows(columns).AddRow("2", "Budi", "0822", "pku", time.Time{}, "", "IT", "", nil)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("FROM staff WHERE")).WithArgs(true, "", 3).WillReturnRows(rows)
+
+	staffs, paging, err := suite.repo.PagingCursor(dto.CursorRequest{Limit: 2, IncludeDeleted: true})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), staffs, 1)
+	assert.Empty(suite.T(), paging.NextCursor)
+}
+
+func (suite *StaffRepositoryTestSuite) TestPagingCursor_Failed() {
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("FROM staff WHERE")).WillReturnError(errors.New("db down"))
+
+	staffs, _, err := suite.repo.PagingCursor(dto.CursorRequest{Limit: 2})
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), staffs)
+}
+
+func (suite *StaffRepositoryTestSuite) TestFindByNiks_IncludeDeleted() {
+	deletedAt := time.Now()
+	columns := []string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email", "deleted_at"}
+	rows := sqlmock.NewRows(columns).AddRow("2", "Budi", "0822", "pku", time.Time{}, "", "IT", "", deletedAt)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("WHERE nik_staff = ANY($1)")).WithArgs(sqlmock.AnyArg()).WillReturnRows(rows)
+
+	staffs, err := suite.repo.FindByNiks([]string{"1", "2"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), staffs, 1)
+	assert.NotNil(suite.T(), staffs[0].DeletedAt)
+}
+
+func (suite *StaffRepositoryTestSuite) TestUpsert_Success() {
+	staffs := []model.Staff{{Nik_Staff: "1", Name: "Budi"}, {Nik_Staff: "2", Name: "Sari"}}
+	suite.mockSQL.ExpectBegin()
+	for _, staff := range staffs {
+		suite.mockSQL.ExpectExec(regexp.QuoteMeta("ON CONFLICT (nik_staff) DO UPDATE")).
+			WithArgs(staff.Nik_Staff, staff.Name, staff.Phone_number, staff.Address, staff.Birth_date, staff.Img_url, staff.Divisi, staff.Email).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	suite.mockSQL.ExpectExec("insert into audit_log").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+
+	assert.NoError(suite.T(), suite.repo.Upsert(staffs, []model.AuditLog{testAudit, testAudit}))
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+// a staff already saved with an email keep it when the row leave the email blank
+func (suite *StaffRepositoryTestSuite) TestUpsert_KeepOptional() {
+	staff := model.Staff{Nik_Staff: "1", Name: "Budi", Phone_number: "082284163929", Address: "Pekanbaru", Divisi: "IT"}
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec(regexp.QuoteMeta("email = COALESCE(NULLIF(excluded.email, ''), staff.email)")).
+		WithArgs(staff.Nik_Staff, staff.Name, staff.Phone_number, staff.Address, time.Time{}, "", staff.Divisi, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+
+	assert.NoError(suite.T(), suite.repo.Upsert([]model.Staff{staff}, []model.AuditLog{testAudit}))
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
+
+func (suite *StaffRepositoryTestSuite) TestUpsert_Rollback() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("INSERT INTO staff").WillReturnError(errors.New("db down"))
+	suite.mockSQL.ExpectRollback()
+
+	assert.Error(suite.T(), suite.repo.Upsert([]model.Staff{{Nik_Staff: "1"}}, []model.AuditLog{testAudit}))
+	assert.NoError(suite.T(), suite.mockSQL.ExpectationsWereMet())
+}
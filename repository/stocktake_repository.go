@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrStocktakeNotFound = errors.New("stocktake not found")
+	ErrStocktakeClosed   = errors.New("stocktake is closed")
+)
+
+type StocktakeRepository interface {
+	Save(stocktake model.Stocktake, audit model.AuditLog) error
+	FindById(id string) (model.Stocktake, error)
+	FindAll(status string) ([]model.Stocktake, error)
+	SaveCount(count model.StocktakeCount) error
+	FindCounts(id string) ([]model.StocktakeCount, error)
+	Lines(stocktake model.Stocktake) ([]model.StocktakeLine, error)
+	Close(id, closedBy string, closedAt time.Time, audit model.AuditLog, adjustments []model.StocktakeAdjustment) error
+}
+
+const queryStocktake = `select id, name, coalesce(id_category, ''), status, note, opened_by, opened_at, coalesce(closed_by, ''), closed_at
+	from stocktake`
+
+type stocktakeRepository struct {
+	db *sql.DB
+}
+
+// Save implements StocktakeRepository.
+func (s *stocktakeRepository) Save(stocktake model.Stocktake, audit model.AuditLog) error {
+	query := `insert into stocktake (id, name, id_category, status, note, opened_by, opened_at)
+		values ($1, $2, nullif($3, ''), $4, $5, $6, $7)`
+	return inTx(s.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec(query, stocktake.Id, stocktake.Name, stocktake.CategoryId, stocktake.Status, stocktake.Note,
+			stocktake.OpenedBy, stocktake.OpenedAt)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
+}
+
+// FindById implements StocktakeRepository.
+func (s *stocktakeRepository) FindById(id string) (model.Stocktake, error) {
+	stocktake, err := scanStocktake(s.db.QueryRow(queryStocktake+" where id = $1", id))
+	if err == sql.ErrNoRows {
+		return model.Stocktake{}, ErrStocktakeNotFound
+	}
+	return stocktake, err
+}
+
+// FindAll implements StocktakeRepository.
+// It list the sessions newest first, only the ones in the status when it is given
+func (s *stocktakeRepository) FindAll(status string) ([]model.Stocktake, error) {
+	rows, err := s.db.Query(queryStocktake+" where $1 = '' or status = $1 order by opened_at desc", status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocktakes []model.Stocktake
+	for rows.Next() {
+		stocktake, err := scanStocktake(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocktakes = append(stocktakes, stocktake)
+	}
+	return stocktakes, rows.Err()
+}
+
+// SaveCount implements StocktakeRepository.
+// Counting an asset again at the same location replace the previous count
+func (s *stocktakeRepository) SaveCount(count model.StocktakeCount) error {
+	query := `insert into stocktake_count (id_stocktake, id_asset, location, counted, counted_by, counted_at)
+		values ($1, $2, $3, $4, $5, $6)
+		on conflict (id_stocktake, id_asset, location)
+		do update set counted = excluded.counted, counted_by = excluded.counted_by, counted_at = excluded.counted_at`
+	_, err := s.db.Exec(query, count.StocktakeId, count.AssetId, count.Location, count.Counted, count.CountedBy, count.CountedAt)
+	return err
+}
+
+// FindCounts implements StocktakeRepository.
+func (s *stocktakeRepository) FindCounts(id string) ([]model.StocktakeCount, error) {
+	query := `select sc.id_stocktake, sc.id_asset, a.name, sc.location, sc.counted, sc.counted_by, sc.counted_at
+		from stocktake_count as sc
+		join asset as a on a.id = sc.id_asset
+		where sc.id_stocktake = $1
+		order by a.name, sc.location`
+
+	rows, err := s.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []model.StocktakeCount
+	for rows.Next() {
+		var count model.StocktakeCount
+		err = rows.Scan(&count.StocktakeId, &count.AssetId, &count.AssetName, &count.Location, &count.Counted, &count.CountedBy, &count.CountedAt)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	return counts, rows.Err()
+}
+
+// Lines implements StocktakeRepository.
+// It read every asset in the scope of the session with the item still on loan, the item reserved by
+// an approved loan request and the sum of its counts, Counted is nil for an asset that was not counted
+func (s *stocktakeRepository) Lines(stocktake model.Stocktake) ([]model.StocktakeLine, error) {
+	query := `select a.id, a.name, coalesce(c.name, ''), a.total, a.available,
+		coalesce((select sum(d.total_item - d.total_returned) from detail_manage_asset as d where d.id_asset = a.id), 0),
+		coalesce((select sum(ld.total_item) from loan_request_detail as ld
+			join loan_request as l on l.id = ld.id_loan_request
+			where ld.id_asset = a.id and l.status = $3), 0),
+		exists (select 1 from asset_unit as u where u.id_asset = a.id),
+		(select sum(sc.counted) from stocktake_count as sc where sc.id_stocktake = $1 and sc.id_asset = a.id)
+		from asset as a
+		left join category as c on c.id = a.id_category
+		where a.deleted_at is null and ($2 = '' or a.id_category = $2)
+		order by a.name, a.id`
+
+	rows, err := s.db.Query(query, stocktake.Id, stocktake.CategoryId, model.LoanRequestApproved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []model.StocktakeLine
+	for rows.Next() {
+		var line model.StocktakeLine
+		var counted sql.NullInt64
+		err = rows.Scan(&line.AssetId, &line.AssetName, &line.Category, &line.Total, &line.Available, &line.OnLoan, &line.Reserved,
+			&line.TrackedByUnit, &counted)
+		if err != nil {
+			return nil, err
+		}
+		if counted.Valid {
+			value := int(counted.Int64)
+			line.Counted = &value
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// Close implements StocktakeRepository.
+// Only an open session is closed, so two requests cannot both close it. The adjustments are posted in the
+// same transaction, a failed one roll back the close so the session can be closed again
+func (s *stocktakeRepository) Close(id, closedBy string, closedAt time.Time, audit model.AuditLog, adjustments []model.StocktakeAdjustment) error {
+	return inTx(s.db, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`update stocktake set status = $2, closed_by = $3, closed_at = $4 where id = $1 and status = $5`,
+			id, model.StocktakeClosed, closedBy, closedAt, model.StocktakeOpen)
+		if err != nil {
+			return err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrStocktakeClosed
+		}
+		if err = insertAudit(tx, audit); err != nil {
+			return err
+		}
+		for _, adjustment := range adjustments {
+			if err = postAdjustment(tx, adjustment); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// postAdjustment move the total by the difference and available by the difference less the drift, relative to the row
+// so a loan made meanwhile is kept. The asset is read back for its audit entry
+func postAdjustment(tx *sql.Tx, adjustment model.StocktakeAdjustment) error {
+	query := `update asset set total = total + $2, available = available + $3
+	where id = $1 and deleted_at is null and available + $3 >= 0`
+
+	line := adjustment.Line
+	result, err := tx.Exec(query, line.AssetId, *line.Difference, *line.Difference-line.AvailableDrift)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: asset %s", ErrInsufficientStock, line.AssetId)
+	}
+	if err = insertAssetAudit(tx, adjustment.AssetAudit, line.AssetId); err != nil {
+		return err
+	}
+	return insertAudit(tx, adjustment.Audit)
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanStocktake(row rowScanner) (model.Stocktake, error) {
+	var stocktake model.Stocktake
+	err := row.Scan(&stocktake.Id, &stocktake.Name, &stocktake.CategoryId, &stocktake.Status, &stocktake.Note,
+		&stocktake.OpenedBy, &stocktake.OpenedAt, &stocktake.ClosedBy, &stocktake.ClosedAt)
+	return stocktake, err
+}
+
+func NewStocktakeRepository(db *sql.DB) StocktakeRepository {
+	return &stocktakeRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"final-project-enigma-clean/model"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type StocktakeRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo StocktakeRepository
+}
+
+func (suite *StocktakeRepositorySuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.db = db
+	suite.mock = mock
+	suite.repo = NewStocktakeRepository(db)
+}
+
+func (suite *StocktakeRepositorySuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestStocktakeRepositorySuite(t *testing.T) {
+	suite.Run(t, new(StocktakeRepositorySuite))
+}
+
+func (suite *StocktakeRepositorySuite) TestFindById_NotFound() {
+	suite.mock.ExpectQuery("from stocktake where id").WithArgs("s1").WillReturnError(sql.ErrNoRows)
+
+	_, err := suite.repo.FindById("s1")
+	assert.ErrorIs(suite.T(), err, ErrStocktakeNotFound)
+}
+
+func (suite *StocktakeRepositorySuite) TestSaveCount_Upsert() {
+	now := time.Now()
+	suite.mock.ExpectExec("insert into stocktake_count .* on conflict").
+		WithArgs("s1", "a1", "Gudang", 4, "user-1", now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := suite.repo.SaveCount(model.StocktakeCount{StocktakeId: "s1", AssetId: "a1", Location: "Gudang", Counted: 4, CountedBy: "user-1", CountedAt: now})
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StocktakeRepositorySuite) TestLines_Success() {
+	rows := sqlmock.NewRows([]string{"id", "name", "category", "total", "available", "on_loan", "reserved", "by_unit", "counted"}).
+		AddRow("a1", "Kursi", "Furniture", 10, 5, 2, 3, false, 7).
+		AddRow("a2", "Laptop", "Elektronik", 3, 3, 0, 0, true, nil)
+	suite.mock.ExpectQuery("from asset as a").WithArgs("s1", "c1", model.LoanRequestApproved).WillReturnRows(rows)
+
+	lines, err := suite.repo.Lines(model.Stocktake{Id: "s1", CategoryId: "c1"})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), lines, 2)
+	assert.Equal(suite.T(), 2, lines[0].OnLoan)
+	assert.Equal(suite.T(), 3, lines[0].Reserved)
+	assert.Equal(suite.T(), 7, *lines[0].Counted)
+	assert.True(suite.T(), lines[1].TrackedByUnit)
+	assert.Nil(suite.T(), lines[1].Counted)
+}
+
+func (suite *StocktakeRepositorySuite) TestClose_Success() {
+	now := time.Now()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("update stocktake set status").
+		WithArgs("s1", model.StocktakeClosed, "user-1", now, model.StocktakeOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(suite.mock)
+
+	assert.NoError(suite.T(), suite.repo.Close("s1", "user-1", now, testAudit, nil))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *StocktakeRepositorySuite) TestClose_AlreadyClosed() {
+	now := time.Now()
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("update stocktake set status").
+		WithArgs("s1", model.StocktakeClosed, "user-1", now, model.StocktakeOpen).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+
+	assert.ErrorIs(suite.T(), suite.repo.Close("s1", "user-1", now, testAudit, nil), ErrStocktakeClosed)
+}
+
+// the asset move relative to its row in the transaction of the close, so a loan made after the report is kept
+func (suite *StocktakeRepositorySuite) TestClose_Adjust() {
+	now := time.Now()
+	difference := -2
+	line := model.StocktakeLine{AssetId: "a1", Difference: &difference, AvailableDrift: -1}
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("update stocktake set status").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("insert into audit_log").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec(regexp.QuoteMeta("set total = total + $2, available = available + $3")).
+		WithArgs("a1", -2, -1).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectQuery("select a.id").WithArgs("a1").WillReturnRows(savedAssetRow("a1"))
+	suite.mock.ExpectExec("insert into audit_log").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mock)
+
+	adjustments := []model.StocktakeAdjustment{{Line: line, AssetAudit: testAudit, Audit: testAudit}}
+	assert.NoError(suite.T(), suite.repo.Close("s1", "user-1", now, testAudit, adjustments))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+// a failed adjustment roll back the close, so the session is still open to count again
+func (suite *StocktakeRepositorySuite) TestClose_AdjustNotEnoughAvailable() {
+	now := time.Now()
+	difference := -5
+	line := model.StocktakeLine{AssetId: "a1", Difference: &difference}
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("update stocktake set status").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("insert into audit_log").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("update asset").WithArgs("a1", -5, -5).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectRollback()
+
+	adjustments := []model.StocktakeAdjustment{{Line: line, AssetAudit: testAudit, Audit: testAudit}}
+	assert.ErrorIs(suite.T(), suite.repo.Close("s1", "user-1", now, testAudit, adjustments), ErrInsufficientStock)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"final-project-enigma-clean/model"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTokenNotFound = errors.New("refresh token not found")
+	ErrTokenExpired  = errors.New("refresh token expired")
+	ErrTokenRevoked  = errors.New("refresh token revoked")
+	ErrTokenReused   = errors.New("refresh token reused")
+)
+
+type TokenRepository interface {
+	Save(token model.RefreshToken) error
+	Rotate(oldHash string, next model.RefreshToken) (model.RefreshToken, error)
+	RevokeByHash(tokenHash string) error
+	RevokeAllForUser(userID string) error
+	IsSessionActive(userID, familyID string) (bool, error)
+}
+
+type tokenRepository struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func (t *tokenRepository) Save(token model.RefreshToken) error {
+	query := `insert into refresh_token (id, user_id, family_id, token_hash, expires_at) values ($1, $2, $3, $4, $5)`
+	if _, err := t.db.Exec(query, token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt); err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	return nil
+}
+
+// Rotate consume the old refresh token and store the next one in the same family,
+// presenting a token that was already rotated revoke the whole family
+func (t *tokenRepository) Rotate(oldHash string, next model.RefreshToken) (model.RefreshToken, error) {
+	tx, err := t.db.Begin()
+	if err != nil {
+		return model.RefreshToken{}, err
+	}
+
+	var old model.RefreshToken
+	query := `select id, user_id, family_id, expires_at, used_at, revoked_at from refresh_token where token_hash = $1 for update`
+	err = tx.QueryRow(query, oldHash).Scan(&old.ID, &old.UserID, &old.FamilyID, &old.ExpiresAt, &old.UsedAt, &old.RevokedAt)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return model.RefreshToken{}, ErrTokenNotFound
+		}
+		return model.RefreshToken{}, err
+	}
+
+	now := t.now()
+	switch {
+	case old.RevokedAt != nil:
+		tx.Rollback()
+		return model.RefreshToken{}, ErrTokenRevoked
+	case old.UsedAt != nil:
+		//somebody hold a copy of an old token, kill every session of this login
+		if _, err = tx.Exec(`update refresh_token set revoked_at = $2 where family_id = $1 and revoked_at is null`, old.FamilyID, now); err != nil {
+			tx.Rollback()
+			return model.RefreshToken{}, err
+		}
+		if err = tx.Commit(); err != nil {
+			return model.RefreshToken{}, err
+		}
+		return model.RefreshToken{}, ErrTokenReused
+	case !now.Before(old.ExpiresAt):
+		tx.Rollback()
+		return model.RefreshToken{}, ErrTokenExpired
+	}
+
+	if _, err = tx.Exec(`update refresh_token set used_at = $2 where id = $1`, old.ID, now); err != nil {
+		tx.Rollback()
+		return model.RefreshToken{}, err
+	}
+
+	next.UserID = old.UserID
+	next.FamilyID = old.FamilyID
+	_, err = tx.Exec(`insert into refresh_token (id, user_id, family_id, token_hash, expires_at) values ($1, $2, $3, $4, $5)`,
+		next.ID, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt)
+	if err != nil {
+		tx.Rollback()
+		return model.RefreshToken{}, err
+	}
+
+	return next, tx.Commit()
+}
+
+// RevokeByHash revoke the family the token belongs to, used on logout
+func (t *tokenRepository) RevokeByHash(tokenHash string) error {
+	query := `update refresh_token set revoked_at = $2 where revoked_at is null
+		and family_id = (select family_id from refresh_token where token_hash = $1)`
+	res, err := t.db.Exec(query, tokenHash, t.now())
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	if affected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func (t *tokenRepository) RevokeAllForUser(userID string) error {
+	query := `update refresh_token set revoked_at = $2 where user_id = $1 and revoked_at is null`
+	if _, err := t.db.Exec(query, userID, t.now()); err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	return nil
+}
+
+// IsSessionActive tell whether the user is still active and the login family is not revoked,
+// it is checked on every authenticated request so it stay one round trip
+func (t *tokenRepository) IsSessionActive(userID, familyID string) (bool, error) {
+	query := `select u.is_active and exists(select 1 from refresh_token r where r.family_id = $2 and r.user_id = u.id and r.revoked_at is null)
+		from user_credential u where u.id = $1`
+	var active bool
+	err := t.db.QueryRow(query, userID, familyID).Scan(&active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return active, nil
+}
+
+func NewTokenRepository(db *sql.DB) TokenRepository {
+	return &tokenRepository{
+		db:  db,
+		now: time.Now,
+	}
+}
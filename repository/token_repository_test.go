@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"final-project-enigma-clean/model"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type TokenRepositorySuite struct {
+	suite.Suite
+	db   *sql.DB
+	mock sqlmock.Sqlmock
+	repo *tokenRepository
+	now  time.Time
+}
+
+func (suite *TokenRepositorySuite) SetupTest() {
+	db, mock, _ := sqlmock.New()
+	suite.db = db
+	suite.mock = mock
+	suite.now = time.Date(2023, 9, 10, 7, 0, 0, 0, time.UTC)
+	suite.repo = &tokenRepository{db: db, now: func() time.Time { return suite.now }}
+}
+
+func (suite *TokenRepositorySuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func (suite *TokenRepositorySuite) tokenRows(usedAt, revokedAt *time.Time, expiresAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "family_id", "expires_at", "used_at", "revoked_at"}).
+		AddRow("token-1", "user-1", "family-1", expiresAt, usedAt, revokedAt)
+}
+
+func (suite *TokenRepositorySuite) TestSave_Success() {
+	token := model.RefreshToken{ID: "token-1", UserID: "user-1", FamilyID: "family-1", TokenHash: "hash", ExpiresAt: suite.now}
+	suite.mock.ExpectExec("insert into refresh_token").
+		WithArgs(token.ID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	assert.NoError(suite.T(), suite.repo.Save(token))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *TokenRepositorySuite) TestRotate_Success() {
+	next := model.RefreshToken{ID: "token-2", TokenHash: "next-hash", ExpiresAt: suite.now.Add(time.Hour)}
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select (.+) from refresh_token where token_hash = \\$1 for update").
+		WithArgs("old-hash").WillReturnRows(suite.tokenRows(nil, nil, suite.now.Add(time.Hour)))
+	suite.mock.ExpectExec("update refresh_token set used_at").
+		WithArgs("token-1", suite.now).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectExec("insert into refresh_token").
+		WithArgs(next.ID, "user-1", "family-1", next.TokenHash, next.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectCommit()
+
+	rotated, err := suite.repo.Rotate("old-hash", next)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "user-1", rotated.UserID)
+	assert.Equal(suite.T(), "family-1", rotated.FamilyID)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *TokenRepositorySuite) TestRotate_ReusedRevokeFamily() {
+	usedAt := suite.now.Add(-time.Minute)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select (.+) from refresh_token").
+		WithArgs("old-hash").WillReturnRows(suite.tokenRows(&usedAt, nil, suite.now.Add(time.Hour)))
+	suite.mock.ExpectExec("update refresh_token set revoked_at = \\$2 where family_id = \\$1").
+		WithArgs("family-1", suite.now).WillReturnResult(sqlmock.NewResult(0, 2))
+	suite.mock.ExpectCommit()
+
+	_, err := suite.repo.Rotate("old-hash", model.RefreshToken{ID: "token-2"})
+	assert.ErrorIs(suite.T(), err, ErrTokenReused)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *TokenRepositorySuite) TestRotate_Revoked() {
+	revokedAt := suite.now.Add(-time.Minute)
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select (.+) from refresh_token").
+		WithArgs("old-hash").WillReturnRows(suite.tokenRows(nil, &revokedAt, suite.now.Add(time.Hour)))
+	suite.mock.ExpectRollback()
+
+	_, err := suite.repo.Rotate("old-hash", model.RefreshToken{ID: "token-2"})
+	assert.ErrorIs(suite.T(), err, ErrTokenRevoked)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *TokenRepositorySuite) TestRotate_Expired() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select (.+) from refresh_token").
+		WithArgs("old-hash").WillReturnRows(suite.tokenRows(nil, nil, suite.now.Add(-time.Minute)))
+	suite.mock.ExpectRollback()
+
+	_, err := suite.repo.Rotate("old-hash", model.RefreshToken{ID: "token-2"})
+	assert.ErrorIs(suite.T(), err, ErrTokenExpired)
+}
+
+func (suite *TokenRepositorySuite) TestRotate_NotFound() {
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectQuery("select (.+) from refresh_token").
+		WithArgs("old-hash").WillReturnError(sql.ErrNoRows)
+	suite.mock.ExpectRollback()
+
+	_, err := suite.repo.Rotate("old-hash", model.RefreshToken{ID: "token-2"})
+	assert.ErrorIs(suite.T(), err, ErrTokenNotFound)
+}
+
+func (suite *TokenRepositorySuite) TestRevokeByHash_NotFound() {
+	suite.mock.ExpectExec("update refresh_token set revoked_at").
+		WithArgs("hash", suite.now).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.ErrorIs(suite.T(), suite.repo.RevokeByHash("hash"), ErrTokenNotFound)
+}
+
+func (suite *TokenRepositorySuite) TestRevokeAllForUser() {
+	suite.mock.ExpectExec("update refresh_token set revoked_at = \\$2 where user_id = \\$1").
+		WithArgs("user-1", suite.now).WillReturnResult(sqlmock.NewResult(0, 3))
+
+	assert.NoError(suite.T(), suite.repo.RevokeAllForUser("user-1"))
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *TokenRepositorySuite) TestIsSessionActive() {
+	suite.mock.ExpectQuery("select u.is_active and exists(.+) from user_credential u where u.id = \\$1").
+		WithArgs("user-1", "family-1").WillReturnRows(sqlmock.NewRows([]string{"active"}).AddRow(true))
+
+	active, err := suite.repo.IsSessionActive("user-1", "family-1")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), active)
+}
+
+func (suite *TokenRepositorySuite) TestIsSessionActive_UnknownUser() {
+	suite.mock.ExpectQuery("select u.is_active").
+		WithArgs("user-1", "family-1").WillReturnError(sql.ErrNoRows)
+
+	active, err := suite.repo.IsSessionActive("user-1", "family-1")
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), active)
+}
+
+func TestTokenRepositorySuite(t *testing.T) {
+	suite.Run(t, new(TokenRepositorySuite))
+}
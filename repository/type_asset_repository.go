@@ -8,13 +8,15 @@ import (
 )
 
 type TypeAssetRepository interface {
-	Save(payload model.TypeAsset) error
+	Save(payload model.TypeAsset, audit model.AuditLog) error
 	FindById(id string) (model.TypeAsset, error)
 	FindByName(name string) ([]model.TypeAsset, error)
 	FindAll() ([]model.TypeAsset, error)
-	Update(payload model.TypeAsset) error
-	Delete(id string) error
+	Update(payload model.TypeAsset, audit model.AuditLog) error
+	Delete(id string, audit model.AuditLog) error
 	Paging(payload dto.PageRequest) ([]model.TypeAsset, dto.Paging, error)
+	PagingCursor(payload dto.CursorRequest) ([]model.TypeAsset, dto.CursorPaging, error)
+	Restore(id string, audit model.AuditLog) error
 }
 
 type typeAssetRepository struct {
@@ -23,7 +25,7 @@ type typeAssetRepository struct {
 
 // FindById implements TypeAssetRepository.
 func (t *typeAssetRepository) FindById(id string) (model.TypeAsset, error) {
-	row := t.db.QueryRow("SELECT id,name FROM asset_type WHERE id=$1", id)
+	row := t.db.QueryRow("SELECT id,name FROM asset_type WHERE id=$1 AND deleted_at IS NULL", id)
 	var typeAsset model.TypeAsset
 	err := row.Scan(&typeAsset.Id, &typeAsset.Name)
 	if err != nil {
@@ -35,17 +37,20 @@ func (t *typeAssetRepository) FindById(id string) (model.TypeAsset, error) {
 }
 
 // Delete implements TypeAssetRepository.
-func (t *typeAssetRepository) Delete(id string) error {
-	_, err := t.db.Exec("DELETE FROM asset_type WHERE id= $1", id)
-	if err != nil {
-		return err
-	}
-	return nil
+// the row is only marked deleted, assets keep pointing to it
+func (t *typeAssetRepository) Delete(id string, audit model.AuditLog) error {
+	return inTx(t.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE asset_type SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", id)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 // FindAll implements TypeAssetRepository.
 func (t *typeAssetRepository) FindAll() ([]model.TypeAsset, error) {
-	rows, err := t.db.Query("SELECT id, name FROM asset_type")
+	rows, err := t.db.Query("SELECT id, name FROM asset_type WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +68,7 @@ func (t *typeAssetRepository) FindAll() ([]model.TypeAsset, error) {
 
 // FindByName implements TypeAssetRepository.
 func (t *typeAssetRepository) FindByName(name string) ([]model.TypeAsset, error) {
-	rows, err := t.db.Query(`SELECT id, name FROM asset_type WHERE name ILIKE $1`, "%"+name+"%")
+	rows, err := t.db.Query(`SELECT id, name FROM asset_type WHERE name ILIKE $1 AND deleted_at IS NULL`, "%"+name+"%")
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +90,8 @@ func (t *typeAssetRepository) Paging(payload dto.PageRequest) ([]model.TypeAsset
 	if payload.Page <= 0 {
 		payload.Page = 1
 	}
-	q := `SELECT id, name FROM asset_type LIMIT $2 OFFSET $1`
-	rows, err := t.db.Query(q, (payload.Page-1)*payload.Size, payload.Size)
+	q := `SELECT id, name, deleted_at FROM asset_type WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
+	rows, err := t.db.Query(q, (payload.Page-1)*payload.Size, payload.Size, payload.IncludeDeleted)
 	if err != nil {
 		return nil, dto.Paging{}, err
 	}
@@ -94,14 +99,14 @@ func (t *typeAssetRepository) Paging(payload dto.PageRequest) ([]model.TypeAsset
 	var typeAssets []model.TypeAsset
 	for rows.Next() {
 		var typeAsset model.TypeAsset
-		err := rows.Scan(&typeAsset.Id, &typeAsset.Name)
+		err := rows.Scan(&typeAsset.Id, &typeAsset.Name, &typeAsset.DeletedAt)
 		if err != nil {
 			return nil, dto.Paging{}, err
 		}
 		typeAssets = append(typeAssets, typeAsset)
 	}
 	var count int
-	row := t.db.QueryRow("SELECT COUNT(id) FROM asset_type")
+	row := t.db.QueryRow("SELECT COUNT(id) FROM asset_type WHERE ($1 OR deleted_at IS NULL)", payload.IncludeDeleted)
 	if err := row.Scan(&count); err != nil {
 		return nil, dto.Paging{}, err
 	}
@@ -117,22 +122,71 @@ func (t *typeAssetRepository) Paging(payload dto.PageRequest) ([]model.TypeAsset
 
 }
 
-// Save implements TypeAssetRepository.
-func (t *typeAssetRepository) Save(payload model.TypeAsset) error {
-	_, err := t.db.Exec("INSERT INTO asset_type VALUES ($1,$2)", payload.Id, payload.Name)
+// PagingCursor implements TypeAssetRepository.
+// the rows are read in id order after the last key, a type inserted meanwhile never shift a page
+func (t *typeAssetRepository) PagingCursor(payload dto.CursorRequest) ([]model.TypeAsset, dto.CursorPaging, error) {
+	q := `SELECT id, name, deleted_at FROM asset_type WHERE ($1 OR deleted_at IS NULL) AND id > $2 ORDER BY id LIMIT $3`
+	rows, err := t.db.Query(q, payload.IncludeDeleted, payload.After, payload.Limit+1)
 	if err != nil {
-		return err
+		return nil, dto.CursorPaging{}, err
+	}
+	defer rows.Close()
+
+	var typeAssets []model.TypeAsset
+	for rows.Next() {
+		var typeAsset model.TypeAsset
+		err := rows.Scan(&typeAsset.Id, &typeAsset.Name, &typeAsset.DeletedAt)
+		if err != nil {
+			return nil, dto.CursorPaging{}, err
+		}
+		typeAssets = append(typeAssets, typeAsset)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, dto.CursorPaging{}, err
 	}
-	return nil
+
+	typeAssets, paging := cursorPage(typeAssets, payload.Limit, func(typeAsset model.TypeAsset) string { return typeAsset.Id })
+	return typeAssets, paging, nil
+}
+
+// Restore implements TypeAssetRepository.
+func (t *typeAssetRepository) Restore(id string, audit model.AuditLog) error {
+	return inTx(t.db, func(tx *sql.Tx) error {
+		if err := restore(tx, "UPDATE asset_type SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", id); err != nil {
+			return err
+		}
+		var restored model.TypeAsset
+		if err := tx.QueryRow("SELECT id, name FROM asset_type WHERE id = $1", id).Scan(&restored.Id, &restored.Name); err != nil {
+			return err
+		}
+		audit, err := auditAfter(audit, restored)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
+}
+
+// Save implements TypeAssetRepository.
+func (t *typeAssetRepository) Save(payload model.TypeAsset, audit model.AuditLog) error {
+	return inTx(t.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO asset_type (id, name) VALUES ($1,$2)", payload.Id, payload.Name)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 // Update implements TypeAssetRepository.
-func (t *typeAssetRepository) Update(payload model.TypeAsset) error {
-	_, err := t.db.Exec("UPDATE asset_type SET name=$2 WHERE id=$1", payload.Id, payload.Name)
-	if err != nil {
-		return err
-	}
-	return nil
+func (t *typeAssetRepository) Update(payload model.TypeAsset, audit model.AuditLog) error {
+	return inTx(t.db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("UPDATE asset_type SET name=$2 WHERE id=$1 AND deleted_at IS NULL", payload.Id, payload.Name)
+		if err != nil {
+			return err
+		}
+		return insertAudit(tx, audit)
+	})
 }
 
 func NewTypeAssetRepository(db *sql.DB) TypeAssetRepository {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/util/helper"
 	"regexp"
 	"testing"
 
@@ -37,8 +38,10 @@ func (suite *TypeAssetRepositoryTestSuite) TestCreate_Success() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("INSERT INTO asset_type").WithArgs(mockData.Id, mockData.Name).WillReturnResult(sqlmock.NewResult(1, 1))
-	err := suite.repo.Save(mockData)
+	expectAudit(suite.mockSQL)
+	err := suite.repo.Save(mockData, testAudit)
 	assert.NoError(suite.T(), err)
 }
 
@@ -47,8 +50,10 @@ func (suite *TypeAssetRepositoryTestSuite) TestCreate_Failed() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("INSERT INTO asset_type").WithArgs(mockData.Id, mockData.Name).WillReturnError(errors.New("failed save category"))
-	err := suite.repo.Save(mockData)
+	suite.mockSQL.ExpectRollback()
+	err := suite.repo.Save(mockData, testAudit)
 	assert.Error(suite.T(), err)
 }
 
@@ -145,8 +150,10 @@ func (suite *TypeAssetRepositoryTestSuite) TestUpdate_Success() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("UPDATE asset_type SET").WithArgs(mockData.Id, mockData.Name).WillReturnResult(sqlmock.NewResult(1, 1))
-	err := suite.repo.Update(mockData)
+	expectAudit(suite.mockSQL)
+	err := suite.repo.Update(mockData, testAudit)
 	assert.NoError(suite.T(), err)
 }
 
@@ -155,20 +162,26 @@ func (suite *TypeAssetRepositoryTestSuite) TestUpdate_Failed() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
+	suite.mockSQL.ExpectBegin()
 	suite.mockSQL.ExpectExec("UPDATE asset_type SET").WithArgs(mockData.Id, mockData.Name).WillReturnError(errors.New("failed update type asset"))
-	err := suite.repo.Update(mockData)
+	suite.mockSQL.ExpectRollback()
+	err := suite.repo.Update(mockData, testAudit)
 	assert.Error(suite.T(), err)
 }
 
 func (suite *TypeAssetRepositoryTestSuite) TestDelete_Success() {
-	suite.mockSQL.ExpectExec("DELETE FROM asset_type").WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
-	gotErr := suite.repo.Delete("1")
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE asset_type SET deleted_at").WithArgs("1").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectAudit(suite.mockSQL)
+	gotErr := suite.repo.Delete("1", testAudit)
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *TypeAssetRepositoryTestSuite) TestDelete_Failed() {
-	suite.mockSQL.ExpectExec("DELETE FROM asset_type").WithArgs("1").WillReturnError(errors.New("failed delete type asset"))
-	gotErr := suite.repo.Delete("1")
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE asset_type SET deleted_at").WithArgs("1").WillReturnError(errors.New("failed delete type asset"))
+	suite.mockSQL.ExpectRollback()
+	gotErr := suite.repo.Delete("1", testAudit)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -257,14 +270,15 @@ func (suite *TypeAssetRepositoryTestSuite) TestPaging_Success() {
 		},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows := sqlmock.NewRows([]string{"id", "name", "deleted_at"})
 	for _, v := range mockData {
-		rows.AddRow(v.Id, v.Name)
+		rows.AddRow(v.Id, v.Name, nil)
 	}
-	expectedQuery := `SELECT id, name FROM asset_type LIMIT $2 OFFSET $1`
+	expectedQuery := `SELECT id, name, deleted_at FROM asset_type WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WithArgs(
 		(mockPageRequest.Page-1)*mockPageRequest.Size,
 		mockPageRequest.Size,
+		false,
 	).WillReturnRows(rows)
 
 	rowCount := sqlmock.NewRows([]string{"count"})
@@ -295,7 +309,7 @@ func (suite *TypeAssetRepositoryTestSuite) TestPaging_Fail() {
 	}
 
 	//err select paging
-	expectedQuery := `SELECT id, name FROM asset_type LIMIT $2 OFFSET $1`
+	expectedQuery := `SELECT id, name, deleted_at FROM asset_type WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WillReturnError(errors.New("failed"))
 	actualTypeAsset, actualPaging, actualErr := suite.repo.Paging(dto.PageRequest{})
 	assert.Error(suite.T(), actualErr)
@@ -303,7 +317,7 @@ func (suite *TypeAssetRepositoryTestSuite) TestPaging_Fail() {
 	assert.Equal(suite.T(), 0, actualPaging.TotalRows)
 
 	// Konfigurasi untuk mengharapkan panggilan ke rows.Scan dengan kesalahan
-	expectedQuery = `SELECT id, name FROM asset_type LIMIT $2 OFFSET $1`
+	expectedQuery = `SELECT id, name, deleted_at FROM asset_type WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WillReturnRows(
 		sqlmock.NewRows([]string{"id", "name"}).AddRow("invalid", "data"),
 	).WillReturnRows(
@@ -315,14 +329,14 @@ func (suite *TypeAssetRepositoryTestSuite) TestPaging_Fail() {
 	assert.Equal(suite.T(), 0, actualPaging.TotalRows)
 
 	//err select count
-	rows := sqlmock.NewRows([]string{"id", "name"})
+	rows := sqlmock.NewRows([]string{"id", "name", "deleted_at"})
 	for _, v := range mockData {
-		rows.AddRow(v.Id, v.Name)
+		rows.AddRow(v.Id, v.Name, nil)
 	}
-	expectedQuery = `SELECT id, name FROM asset_type LIMIT $2 OFFSET $1`
+	expectedQuery = `SELECT id, name, deleted_at FROM asset_type WHERE ($3 OR deleted_at IS NULL) LIMIT $2 OFFSET $1`
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(expectedQuery)).WithArgs(
 		(mockPageRequest.Page-1)*mockPageRequest.Size,
-		mockPageRequest.Size).WillReturnRows(rows)
+		mockPageRequest.Size, false).WillReturnRows(rows)
 	suite.mockSQL.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(id) FROM asset_type`)).WillReturnError(errors.New("failed"))
 
 	actualTypeAsset, actualPaging, actualErr = suite.repo.Paging(mockPageRequest)
@@ -330,3 +344,32 @@ func (suite *TypeAssetRepositoryTestSuite) TestPaging_Fail() {
 	assert.Nil(suite.T(), actualTypeAsset)
 	assert.Equal(suite.T(), 0, actualPaging.TotalRows)
 }
+
+func (suite *TypeAssetRepositoryTestSuite) TestRestore_Success() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE asset_type SET deleted_at = NULL").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mockSQL.ExpectQuery("SELECT id, name FROM asset_type").WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow("1", "Bergerak"))
+	expectAudit(suite.mockSQL)
+	assert.NoError(suite.T(), suite.repo.Restore("1", testAudit))
+}
+
+func (suite *TypeAssetRepositoryTestSuite) TestRestore_NotDeleted() {
+	suite.mockSQL.ExpectBegin()
+	suite.mockSQL.ExpectExec("UPDATE asset_type SET deleted_at = NULL").WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mockSQL.ExpectRollback()
+	assert.ErrorIs(suite.T(), suite.repo.Restore("1", testAudit), ErrNotDeleted)
+}
+
+func (suite *TypeAssetRepositoryTestSuite) TestPagingCursor_NextPage() {
+	rows := sqlmock.NewRows([]string{"id", "name", "deleted_at"}).
+		AddRow("a", "Laptop", nil).
+		AddRow("b", "Monitor", nil)
+	suite.mockSQL.ExpectQuery(regexp.QuoteMeta("FROM asset_type WHERE ($1 OR deleted_at IS NULL) AND id > $2 ORDER BY id LIMIT $3")).
+		WithArgs(false, "", 2).WillReturnRows(rows)
+
+	typeAssets, paging, err := suite.repo.PagingCursor(dto.CursorRequest{Limit: 1})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.TypeAsset{{Id: "a", Name: "Laptop"}}, typeAssets)
+	assert.Equal(suite.T(), helper.EncodeCursor("a"), paging.NextCursor)
+}
@@ -2,8 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"final-project-enigma-clean/model"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -37,14 +39,20 @@ func (suite *UserCredentialsRepositorySuite) TestUserRegister() {
 		Name:     "John Doe",
 		IsActive: true,
 	}
+	welcome := model.EmailOutbox{Id: "mail-1", Recipient: user.Email, Subject: "Welcome", Html: "<p>hi</p>", CreatedAt: time.Now()}
 
 	// Expectation: SQLMock will expect an INSERT query with specific arguments
+	suite.mock.ExpectBegin()
 	suite.mock.ExpectExec("insert into user_credential (.+)").
-		WithArgs(user.ID, user.Email, user.Password, user.Name, user.IsActive).
+		WithArgs(user.ID, user.Email, user.Password, user.Name, user.IsActive, user.Role, user.Locale).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("insert into email_outbox (.+)").
+		WithArgs(welcome.Id, welcome.Recipient, welcome.Subject, welcome.Html, welcome.Sensitive, model.OutboxStatusPending, welcome.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectCommit()
 
 	// Call the method to be tested
-	err := suite.repo.UserRegister(user)
+	err := suite.repo.UserRegister(user, welcome)
 
 	// Assertion: Ensure that there is no error and that SQLMock expectations are met
 	assert.NoError(suite.T(), err)
@@ -64,13 +72,27 @@ func (suite *UserCredentialsRepositorySuite) TestUserRegister_Failure_EmptyEmail
 	// Expectation: No expectations set, as it's an invalid test case
 
 	// Call the method to be tested
-	err := suite.repo.UserRegister(user)
+	err := suite.repo.UserRegister(user, model.EmailOutbox{})
 
 	// Assertion: Ensure that there is an error and SQLMock expectations are not met
 	assert.Error(suite.T(), err)
 	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
 }
 
+func (suite *UserCredentialsRepositorySuite) TestUserRegister_OutboxFailRollback() {
+	user := model.UserRegisterRequest{ID: "1", Email: "test@example.com", Password: "password", Name: "John Doe"}
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("insert into user_credential (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectExec("insert into email_outbox (.+)").WillReturnError(errors.New("outbox error"))
+	suite.mock.ExpectRollback()
+
+	err := suite.repo.UserRegister(user, model.EmailOutbox{Id: "mail-1"})
+
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
 // test user login
 func (suite *UserCredentialsRepositorySuite) TestUserLogin() {
 	user := model.UserLoginRequest{
@@ -208,6 +230,98 @@ func (suite *UserCredentialsRepositorySuite) TestFindUserEmailPass() {
 	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
 }
 
+func (suite *UserCredentialsRepositorySuite) TestFindUserByEmail() {
+	email := "test@example.com"
+	expectedUser := model.UserCredentials{
+		ID:       "1",
+		Email:    email,
+		Name:     "John Doe",
+		IsActive: true,
+		Role:     model.RoleViewer,
+		Locale:   "id",
+	}
+
+	suite.mock.ExpectQuery("SELECT id, email, name, is_active, role, locale FROM user_credential WHERE email = (.+)").
+		WithArgs(email).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "name", "is_active", "role", "locale"}).
+			AddRow(expectedUser.ID, expectedUser.Email, expectedUser.Name, expectedUser.IsActive, expectedUser.Role, expectedUser.Locale))
+
+	user, err := suite.repo.FindUserByEmail(email)
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), expectedUser, user)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *UserCredentialsRepositorySuite) TestFindUserByEmail_NotFound() {
+	suite.mock.ExpectQuery("SELECT id, email, name, is_active, role, locale FROM user_credential WHERE email = (.+)").
+		WithArgs("none@example.com").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := suite.repo.FindUserByEmail("none@example.com")
+
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *UserCredentialsRepositorySuite) TestUpdateRole() {
+	suite.mock.ExpectExec("update user_credential set role = (.+) where id = (.+)").
+		WithArgs("1", model.RoleAdmin).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.UpdateRole("1", model.RoleAdmin)
+
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *UserCredentialsRepositorySuite) TestUpdateRole_NotFound() {
+	suite.mock.ExpectExec("update user_credential set role = (.+) where id = (.+)").
+		WithArgs("2", model.RoleAdmin).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := suite.repo.UpdateRole("2", model.RoleAdmin)
+
+	assert.Error(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *UserCredentialsRepositorySuite) TestFindUserByID() {
+	rows := sqlmock.NewRows([]string{"id", "email", "name", "is_active", "role", "locale"}).
+		AddRow("1", "test@example.com", "John Doe", true, model.RoleViewer, "en")
+	suite.mock.ExpectQuery("SELECT id, email, name, is_active, role, locale FROM user_credential WHERE id = (.+)").
+		WithArgs("1").
+		WillReturnRows(rows)
+
+	user, err := suite.repo.FindUserByID("1")
+
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "test@example.com", user.Email)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *UserCredentialsRepositorySuite) TestUpdateStatus() {
+	suite.mock.ExpectExec("update user_credential set is_active = (.+) where id = (.+)").
+		WithArgs("1", false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.UpdateStatus("1", false)
+
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *UserCredentialsRepositorySuite) TestUpdateLocale() {
+	suite.mock.ExpectExec("update user_credential set locale = (.+) where id = (.+)").
+		WithArgs("1", "id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := suite.repo.UpdateLocale("1", "id")
+
+	assert.NoError(suite.T(), err)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
 func TestUserDetailsRepositorySuite(t *testing.T) {
 	suite.Run(t, new(UserCredentialsRepositorySuite))
 }
@@ -7,7 +7,7 @@ import (
 )
 
 type UserCredentialsRepository interface {
-	UserRegister(user model.UserRegisterRequest) error
+	UserRegister(user model.UserRegisterRequest, welcome model.EmailOutbox) error
 	UserLogin(user model.UserLoginRequest) (string, error)
 	FindUserEmail(email string) (user model.UserLoginRequest, err error)
 	FindUserEmailPass(email string) (userPass model.ChangePasswordRequest, err error)
@@ -15,6 +15,11 @@ type UserCredentialsRepository interface {
 	GetUserPassword(email string) (string, error)
 	CheckEmailExist(email string) bool
 	ForgotPass(email, newPass, confirmPass string) error
+	FindUserByEmail(email string) (model.UserCredentials, error)
+	UpdateRole(id, role string) error
+	FindUserByID(id string) (model.UserCredentials, error)
+	UpdateStatus(id string, isActive bool) error
+	UpdateLocale(id, locale string) error
 }
 
 type userCredentialRepository struct {
@@ -37,19 +42,31 @@ func (u userCredentialRepository) FindUserEmailPass(email string) (userPass mode
 	return user, nil
 }
 
-// user register
-func (u userCredentialRepository) UserRegister(user model.UserRegisterRequest) error {
+// user register, the welcome email is queued in the same transaction
+// so it is never sent for a user that was not saved
+func (u userCredentialRepository) UserRegister(user model.UserRegisterRequest, welcome model.EmailOutbox) error {
 	//register logic
 
 	user.IsActive = true
 
-	query := "insert into user_credential (id,email,password,name,is_active) values ($1, $2, $3, $4, $5)"
-	_, err := u.db.Exec(query, user.ID, user.Email, user.Password, user.Name, user.IsActive)
+	tx, err := u.db.Begin()
 	if err != nil {
+		return fmt.Errorf("Failed to begin transaction %v", err.Error())
+	}
+
+	query := "insert into user_credential (id,email,password,name,is_active,role,locale) values ($1, $2, $3, $4, $5, $6, $7)"
+	_, err = tx.Exec(query, user.ID, user.Email, user.Password, user.Name, user.IsActive, user.Role, user.Locale)
+	if err != nil {
+		tx.Rollback()
 		return fmt.Errorf("Failed to exec query %v", err.Error())
 	}
 
-	return nil
+	if err = insertOutbox(tx, welcome); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Failed to queue email %v", err.Error())
+	}
+
+	return tx.Commit()
 }
 
 // user login
@@ -135,6 +152,85 @@ func (u userCredentialRepository) CheckEmailExist(email string) bool {
 	return count > 0 //count > 0 mean is username already exist on database
 }
 
+// find user with the role, used for issuing token
+func (u userCredentialRepository) FindUserByEmail(email string) (model.UserCredentials, error) {
+	query := "SELECT id, email, name, is_active, role, locale FROM user_credential WHERE email = $1"
+	var user model.UserCredentials
+
+	err := u.db.QueryRow(query, email).Scan(&user.ID, &user.Email, &user.Name, &user.IsActive, &user.Role, &user.Locale)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.UserCredentials{}, fmt.Errorf("Invalid Credentials")
+		}
+		return model.UserCredentials{}, fmt.Errorf("Failed to run query: %v", err.Error())
+	}
+
+	return user, nil
+}
+
+func (u userCredentialRepository) UpdateRole(id, role string) error {
+	query := "update user_credential set role = $2 where id = $1"
+	res, err := u.db.Exec(query, id, role)
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	if affected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+func (u userCredentialRepository) FindUserByID(id string) (model.UserCredentials, error) {
+	query := "SELECT id, email, name, is_active, role, locale FROM user_credential WHERE id = $1"
+	var user model.UserCredentials
+
+	err := u.db.QueryRow(query, id).Scan(&user.ID, &user.Email, &user.Name, &user.IsActive, &user.Role, &user.Locale)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return model.UserCredentials{}, fmt.Errorf("user not found")
+		}
+		return model.UserCredentials{}, fmt.Errorf("Failed to run query: %v", err.Error())
+	}
+
+	return user, nil
+}
+
+func (u userCredentialRepository) UpdateStatus(id string, isActive bool) error {
+	query := "update user_credential set is_active = $2 where id = $1"
+	res, err := u.db.Exec(query, id, isActive)
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	if affected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
+func (u userCredentialRepository) UpdateLocale(id, locale string) error {
+	query := "update user_credential set locale = $2 where id = $1"
+	res, err := u.db.Exec(query, id, locale)
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("Failed to exec %v", err.Error())
+	}
+	if affected == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
 func NewUserDetailsRepository(db *sql.DB) UserCredentialsRepository {
 	return &userCredentialRepository{
 		db: db,
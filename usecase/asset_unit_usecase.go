@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"errors"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/repository"
+	"final-project-enigma-clean/util/helper"
+	"fmt"
+)
+
+type AssetUnitUsecase interface {
+	Create(payload model.AssetUnit) error
+	Update(payload model.AssetUnit) error
+	FindById(id string) (model.AssetUnit, error)
+	FindBySerial(serial string) (model.AssetUnit, error)
+	FindByAsset(assetId string) ([]model.AssetUnit, error)
+	FindByTransaction(idManageAsset string) ([]model.AssetUnit, error)
+}
+
+type assetUnitUsecase struct {
+	repo    repository.AssetUnitRepository
+	assetUC AssetUsecase
+}
+
+// Create implements AssetUnitUsecase.
+func (a *assetUnitUsecase) Create(payload model.AssetUnit) error {
+	if payload.AssetId == "" {
+		return exception.BadRequestErr("asset id cannot empty")
+	}
+	if payload.Condition == "" {
+		payload.Condition = model.UnitConditionGood
+	}
+	if payload.Status == "" {
+		payload.Status = model.UnitStatusAvailable
+	}
+	//a new unit cannot start as borrowed or retired
+	if payload.Status != model.UnitStatusAvailable && payload.Status != model.UnitStatusMaintenance {
+		return exception.BadRequestErr("status must be available or maintenance")
+	}
+	if err := validateUnit(payload); err != nil {
+		return err
+	}
+
+	if _, err := a.assetUC.FindById(payload.AssetId); err != nil {
+		return err
+	}
+
+	payload.Id = helper.GenerateUUID()
+	if err := a.repo.Save(payload); err != nil {
+		return unitErr(err)
+	}
+	return nil
+}
+
+// Update implements AssetUnitUsecase.
+func (a *assetUnitUsecase) Update(payload model.AssetUnit) error {
+	if payload.Id == "" {
+		return exception.BadRequestErr("id cannot empty")
+	}
+	if payload.Status == model.UnitStatusBorrowed {
+		return exception.BadRequestErr("unit is borrowed through a transaction only")
+	}
+	if err := validateUnit(payload); err != nil {
+		return err
+	}
+
+	if _, err := a.FindById(payload.Id); err != nil {
+		return err
+	}
+	if err := a.repo.Update(payload); err != nil {
+		return unitErr(err)
+	}
+	return nil
+}
+
+// FindById implements AssetUnitUsecase.
+func (a *assetUnitUsecase) FindById(id string) (model.AssetUnit, error) {
+	unit, err := a.repo.FindById(id)
+	if err != nil {
+		return model.AssetUnit{}, unitErr(err)
+	}
+	return unit, nil
+}
+
+// FindBySerial implements AssetUnitUsecase.
+func (a *assetUnitUsecase) FindBySerial(serial string) (model.AssetUnit, error) {
+	unit, err := a.repo.FindBySerial(serial)
+	if err != nil {
+		return model.AssetUnit{}, unitErr(err)
+	}
+	return unit, nil
+}
+
+// FindByAsset implements AssetUnitUsecase.
+func (a *assetUnitUsecase) FindByAsset(assetId string) ([]model.AssetUnit, error) {
+	units, err := a.repo.FindByAsset(assetId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find asset unit: %v", err)
+	}
+	return units, nil
+}
+
+// FindByTransaction implements AssetUnitUsecase.
+func (a *assetUnitUsecase) FindByTransaction(idManageAsset string) ([]model.AssetUnit, error) {
+	units, err := a.repo.FindByTransaction(idManageAsset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transaction unit: %v", err)
+	}
+	return units, nil
+}
+
+func validateUnit(payload model.AssetUnit) error {
+	if payload.SerialNumber == "" {
+		return exception.BadRequestErr("serial number cannot empty")
+	}
+	switch payload.Condition {
+	case model.UnitConditionGood, model.UnitConditionFair, model.UnitConditionDamaged:
+	default:
+		return exception.BadRequestErr("condition must be good, fair or damaged")
+	}
+	switch payload.Status {
+	case model.UnitStatusAvailable, model.UnitStatusMaintenance, model.UnitStatusRetired:
+	default:
+		return exception.BadRequestErr("status must be available, maintenance or retired")
+	}
+	return nil
+}
+
+func unitErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrUnitNotFound):
+		return exception.NotFoundErr("asset unit not found")
+	case errors.Is(err, repository.ErrDuplicateSerial):
+		return exception.BadRequestErr("serial number already registered")
+	case errors.Is(err, repository.ErrUnitNotAvailable):
+		return exception.BadRequestErr("unit is borrowed, return it first")
+	case errors.Is(err, repository.ErrAssetBulkStock):
+		return exception.BadRequestErr("asset stock is counted in bulk, set its total to 0 before registering its units")
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return exception.BadRequestErr("unit is reserved by an approved loan request")
+	}
+	return err
+}
+
+func NewAssetUnitUsecase(repo repository.AssetUnitRepository, assetUC AssetUsecase) AssetUnitUsecase {
+	return &assetUnitUsecase{
+		repo:    repo,
+		assetUC: assetUC,
+	}
+}
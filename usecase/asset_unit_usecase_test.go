@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"errors"
+	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/repository"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type AssetUnitUsecaseSuite struct {
+	suite.Suite
+	repo    *repomock.AssetUnitRepoMock
+	assetUC *usecasemock.AssetUsecaseMock
+	usecase AssetUnitUsecase
+}
+
+func (suite *AssetUnitUsecaseSuite) SetupTest() {
+	suite.repo = new(repomock.AssetUnitRepoMock)
+	suite.assetUC = new(usecasemock.AssetUsecaseMock)
+	suite.usecase = NewAssetUnitUsecase(suite.repo, suite.assetUC)
+}
+
+func TestAssetUnitUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(AssetUnitUsecaseSuite))
+}
+
+func (suite *AssetUnitUsecaseSuite) TestCreate_Success() {
+	suite.assetUC.On("FindById", "a1").Return(model.Asset{Id: "a1"}, nil)
+	suite.repo.On("Save", mock.MatchedBy(func(unit model.AssetUnit) bool {
+		//condition and status fall back to the defaults
+		return unit.Id != "" && unit.Condition == model.UnitConditionGood && unit.Status == model.UnitStatusAvailable
+	})).Return(nil)
+
+	err := suite.usecase.Create(model.AssetUnit{AssetId: "a1", SerialNumber: "SN-1"})
+	assert.NoError(suite.T(), err)
+	suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *AssetUnitUsecaseSuite) TestCreate_Invalid() {
+	cases := []model.AssetUnit{
+		{SerialNumber: "SN-1"},
+		{AssetId: "a1"},
+		{AssetId: "a1", SerialNumber: "SN-1", Status: model.UnitStatusBorrowed},
+		{AssetId: "a1", SerialNumber: "SN-1", Condition: "broken"},
+	}
+	for _, payload := range cases {
+		err := suite.usecase.Create(payload)
+		var httpErr *exception.Http
+		assert.ErrorAs(suite.T(), err, &httpErr)
+		assert.Equal(suite.T(), http.StatusBadRequest, httpErr.StatusCode)
+	}
+	suite.repo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *AssetUnitUsecaseSuite) TestCreate_DuplicateSerial() {
+	suite.assetUC.On("FindById", "a1").Return(model.Asset{Id: "a1"}, nil)
+	suite.repo.On("Save", mock.Anything).Return(repository.ErrDuplicateSerial)
+
+	err := suite.usecase.Create(model.AssetUnit{AssetId: "a1", SerialNumber: "SN-1"})
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.StatusCode)
+}
+
+func (suite *AssetUnitUsecaseSuite) TestUpdate_Success() {
+	payload := model.AssetUnit{Id: "u1", SerialNumber: "SN-1", Condition: model.UnitConditionDamaged, Status: model.UnitStatusMaintenance}
+	suite.repo.On("FindById", "u1").Return(model.AssetUnit{Id: "u1"}, nil)
+	suite.repo.On("Update", payload).Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.Update(payload))
+	suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *AssetUnitUsecaseSuite) TestUpdate_Borrowed() {
+	err := suite.usecase.Update(model.AssetUnit{Id: "u1", SerialNumber: "SN-1", Condition: model.UnitConditionGood, Status: model.UnitStatusBorrowed})
+	assert.Error(suite.T(), err)
+	suite.repo.AssertNotCalled(suite.T(), "Update", mock.Anything)
+}
+
+func (suite *AssetUnitUsecaseSuite) TestUpdate_NotFound() {
+	suite.repo.On("FindById", "u1").Return(model.AssetUnit{}, repository.ErrUnitNotFound)
+
+	err := suite.usecase.Update(model.AssetUnit{Id: "u1", SerialNumber: "SN-1", Condition: model.UnitConditionGood, Status: model.UnitStatusAvailable})
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.StatusCode)
+}
+
+func (suite *AssetUnitUsecaseSuite) TestFindByAsset() {
+	suite.repo.On("FindByAsset", "a1").Return([]model.AssetUnit{{Id: "u1"}}, nil)
+	suite.repo.On("FindByAsset", "a2").Return([]model.AssetUnit(nil), errors.New("failed"))
+
+	units, err := suite.usecase.FindByAsset("a1")
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), units, 1)
+
+	_, err = suite.usecase.FindByAsset("a2")
+	assert.Error(suite.T(), err)
+}
@@ -1,74 +1,192 @@
 package usecase
 
 import (
+	"bytes"
+	"errors"
 	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"final-project-enigma-clean/repository"
 	"final-project-enigma-clean/util/helper"
+	"final-project-enigma-clean/util/label"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type AssetUsecase interface {
-	Create(payload model.AssetRequest) error
+	Create(actorId string, payload model.AssetRequest) error
 	FindAll() ([]model.Asset, error)
 	FindById(id string) (model.Asset, error)
-	Update(payload model.AssetRequest) error
-	UpdateAvailable(id string, amount int) error
-	Delete(id string) error
+	Update(actorId string, payload model.AssetRequest) error
+	Delete(actorId, id string) error
+	Restore(actorId, id string) error
 	FindByName(name string) ([]model.Asset, error)
-	Paging(payload dto.PageRequest) ([]model.Asset, dto.Paging, error)
+	Paging(payload dto.AssetQuery) ([]model.Asset, dto.Paging, error)
+	PagingCursor(query dto.AssetQuery, payload dto.CursorRequest) ([]model.Asset, dto.CursorPaging, error)
+	Report(query dto.AssetQuery) ([]model.Asset, error)
+	Import(actorId string, rows [][]string, dryRun bool) (dto.ImportResult, error)
+	Label(id string, payload dto.LabelRequest) ([]byte, error)
+	LabelSheet(query dto.AssetQuery) ([]byte, error)
 }
 
+// maxAssetPageSize keep a single listing request from reading the whole table
+const maxAssetPageSize = 100
+
+const (
+	defaultLabelSize = 256
+	minLabelSize     = 64
+	maxLabelSize     = 2048
+	// maxSheetLabels is about 40 pages of labels, a larger batch should be split by category or type
+	maxSheetLabels = 1000
+)
+
 type assetUsecase struct {
 	repo repository.AssetRepository
 	//get category usecase
 	categoryUc CategoryUsecase
 	//get asset type usecase
 	typeAssetUC TypeAssetUseCase
+	auditUC     AuditUsecase
 }
 
-func (a *assetUsecase) Paging(payload dto.PageRequest) ([]model.Asset, dto.Paging, error) {
+func (a *assetUsecase) Paging(payload dto.AssetQuery) ([]model.Asset, dto.Paging, error) {
 	if payload.Page <= 0 {
 		payload.Page = 1
 	}
+	if payload.Size <= 0 {
+		payload.Size = 5
+	}
+	if payload.Size > maxAssetPageSize {
+		return nil, dto.Paging{}, exception.BadRequestErr(fmt.Sprintf("size cannot be more than %d", maxAssetPageSize))
+	}
+	if payload.Sort != "" && !isAssetSortField(payload.Sort) {
+		return nil, dto.Paging{}, exception.BadRequestErr("sort must be one of " + strings.Join(dto.AssetSortFields, ", "))
+	}
+	payload.Direction = strings.ToLower(payload.Direction)
+	if payload.Direction != "" && payload.Direction != "asc" && payload.Direction != "desc" {
+		return nil, dto.Paging{}, exception.BadRequestErr("direction must be asc or desc")
+	}
+	if err := validateEntryRange(payload); err != nil {
+		return nil, dto.Paging{}, err
+	}
 
 	return a.repo.Paging(payload)
 }
 
-// UpdateAmount implements AssetUsecase.
-func (a *assetUsecase) UpdateAvailable(id string, amount int) error {
+// Report implements AssetUsecase.
+// It read every asset matching the filters in the sort of the query, page by page, the page and size are ignored
+func (a *assetUsecase) Report(query dto.AssetQuery) ([]model.Asset, error) {
+	query.Page, query.Size = 1, maxAssetPageSize
+	var assets []model.Asset
+	for {
+		found, paging, err := a.Paging(query)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, found...)
+		if query.Page >= paging.TotalPages {
+			return assets, nil
+		}
+		query.Page++
+	}
+}
 
-	asset, err := a.FindById(id)
+// PagingCursor implements AssetUsecase.
+// a cursor page is always read in id order, so the query cannot be sorted
+func (a *assetUsecase) PagingCursor(query dto.AssetQuery, payload dto.CursorRequest) ([]model.Asset, dto.CursorPaging, error) {
+	if query.Sort != "" || query.Direction != "" {
+		return nil, dto.CursorPaging{}, exception.BadRequestErr("sort cannot be combined with cursor")
+	}
+	if err := validateEntryRange(query); err != nil {
+		return nil, dto.CursorPaging{}, err
+	}
+	payload, err := cursorRequest(payload)
 	if err != nil {
-		return err
+		return nil, dto.CursorPaging{}, err
 	}
 
-	asset.Available -= amount
-	err = a.repo.UpdateAvailable(id, asset.Available)
+	return a.repo.PagingCursor(query, payload)
+}
+
+// Label implements AssetUsecase.
+// the label encode the asset id, a qr code by default
+func (a *assetUsecase) Label(id string, payload dto.LabelRequest) ([]byte, error) {
+	if payload.Type == "" {
+		payload.Type = label.QR
+	}
+	if payload.Format == "" {
+		payload.Format = label.PNG
+	}
+	if payload.Size == 0 {
+		payload.Size = defaultLabelSize
+	}
+	if payload.Size < minLabelSize || payload.Size > maxLabelSize {
+		return nil, exception.BadRequestErr(fmt.Sprintf("size must be between %d and %d", minLabelSize, maxLabelSize))
+	}
+	asset, err := a.FindById(id)
 	if err != nil {
-		return fmt.Errorf("failed update amount, %s", err)
+		return nil, err
 	}
 
-	return nil
+	var buf bytes.Buffer
+	err = label.Write(&buf, payload.Type, payload.Format, asset.Id, payload.Size)
+	if errors.Is(err, label.ErrUnsupportedKind) || errors.Is(err, label.ErrUnsupportedFormat) {
+		return nil, exception.BadRequestErr(err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-// FindByName implements AssetUsecase.
-func (a *assetUsecase) FindByName(name string) ([]model.Asset, error) {
-	if name == "" {
-		return nil, exception.BadRequestErr("name cannot empty")
+// LabelSheet implements AssetUsecase.
+// every asset matching the filters of the query is read page by page in id order,
+// the labels are then laid out by name
+func (a *assetUsecase) LabelSheet(query dto.AssetQuery) ([]byte, error) {
+	if err := validateEntryRange(query); err != nil {
+		return nil, err
 	}
+	query.Sort, query.Direction = "", ""
 
-	assets, err := a.repo.FindByName(name)
-	if err != nil {
-		return nil, fmt.Errorf("failed get assets, %s", err)
+	var assets []model.Asset
+	page := dto.CursorRequest{Limit: maxCursorLimit, IncludeDeleted: query.IncludeDeleted}
+	for {
+		found, paging, err := a.repo.PagingCursor(query, page)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, found...)
+		if len(assets) > maxSheetLabels {
+			return nil, exception.BadRequestErr(fmt.Sprintf("cannot print more than %d labels at once, narrow the filters", maxSheetLabels))
+		}
+		if paging.NextCursor == "" || len(found) == 0 {
+			break
+		}
+		page.After = found[len(found)-1].Id
 	}
-	return assets, nil
+	sort.SliceStable(assets, func(i, j int) bool {
+		return strings.ToLower(assets[i].Name) < strings.ToLower(assets[j].Name)
+	})
+
+	labels := make([]label.Label, len(assets))
+	for i, asset := range assets {
+		labels[i] = label.Label{
+			Code:  asset.Id,
+			Title: asset.Name,
+			Text:  strings.Join([]string{asset.Category.Name, asset.AssetType.Name, asset.Id}, "\n"),
+		}
+	}
+	var buf bytes.Buffer
+	if err := label.Sheet(&buf, labels); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
-// Create implements AssetUsecase.
-func (a *assetUsecase) Create(payload model.AssetRequest) error {
+func validateAssetRequest(payload model.AssetRequest) error {
 	if payload.Name == "" {
 		return exception.BadRequestErr("name cannot empty")
 	}
@@ -81,15 +199,43 @@ func (a *assetUsecase) Create(payload model.AssetRequest) error {
 	if payload.Status == "" {
 		return exception.BadRequestErr("status cannot empty")
 	}
+	return nil
+}
+
+func validateEntryRange(payload dto.AssetQuery) error {
+	if !payload.EntryFrom.IsZero() && !payload.EntryTo.IsZero() && payload.EntryTo.Before(payload.EntryFrom) {
+		return exception.BadRequestErr("entry_to cannot be before entry_from")
+	}
+	return nil
+}
+
+// FindByName implements AssetUsecase.
+func (a *assetUsecase) FindByName(name string) ([]model.Asset, error) {
+	if name == "" {
+		return nil, exception.BadRequestErr("name cannot empty")
+	}
+
+	assets, err := a.repo.FindByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed get assets, %s", err)
+	}
+	return assets, nil
+}
+
+// Create implements AssetUsecase.
+func (a *assetUsecase) Create(actorId string, payload model.AssetRequest) error {
+	if err := validateAssetRequest(payload); err != nil {
+		return err
+	}
 
 	//implement asset type find by id
-	_, err := a.typeAssetUC.FindById(payload.AssetTypeId)
+	assetType, err := a.typeAssetUC.FindById(payload.AssetTypeId)
 	if err != nil {
 		return err
 	}
 
 	//implement category find by id
-	_, err = a.categoryUc.FindById(payload.CategoryId)
+	category, err := a.categoryUc.FindById(payload.CategoryId)
 	if err != nil {
 		return err
 	}
@@ -98,27 +244,120 @@ func (a *assetUsecase) Create(payload model.AssetRequest) error {
 	payload.Id = helper.GenerateUUID()
 	payload.EntryDate = time.Now()
 	payload.Available = payload.Total
-	err = a.repo.Save(payload)
+	audit, err := a.auditUC.Entry(actorId, model.AuditEntityAsset, payload.Id, model.AuditActionCreate, nil, assetOf(payload, category, assetType))
+	if err != nil {
+		return err
+	}
+	err = a.repo.Save(payload, audit)
 	if err != nil {
 		return fmt.Errorf("failed save asset %s", err)
 	}
-
 	return nil
 }
 
+// assetImportColumns are the columns an asset import file need, img_url is optional
+var assetImportColumns = []string{"name", "category", "asset_type", "total", "status"}
+
+// Import implements AssetUsecase.
+// category and asset_type hold the id or the name, every row is validated like Create
+// and the valid rows are saved together unless it is a dry run
+func (a *assetUsecase) Import(actorId string, rows [][]string, dryRun bool) (dto.ImportResult, error) {
+	table, err := importTable(rows, assetImportColumns...)
+	if err != nil {
+		return dto.ImportResult{}, err
+	}
+
+	categories, err := a.categoryUc.FindAll()
+	if err != nil {
+		return dto.ImportResult{}, err
+	}
+	categoryRef := newImportRef("category")
+	categoryById := make(map[string]model.Category)
+	for _, category := range categories {
+		categoryRef.add(category.Id, category.Name)
+		categoryById[category.Id] = category
+	}
+	assetTypes, err := a.typeAssetUC.FindAll()
+	if err != nil {
+		return dto.ImportResult{}, err
+	}
+	typeRef := newImportRef("asset type")
+	typeById := make(map[string]model.TypeAsset)
+	for _, assetType := range assetTypes {
+		typeRef.add(assetType.Id, assetType.Name)
+		typeById[assetType.Id] = assetType
+	}
+
+	result := dto.ImportResult{DryRun: dryRun, Errors: []dto.ImportRowError{}}
+	var valid []model.AssetRequest
+	now := time.Now()
+	for i, row := range table.Rows {
+		if row == nil {
+			continue
+		}
+		result.TotalRows++
+
+		payload := model.AssetRequest{
+			Name:   table.Cell(row, "name"),
+			Status: table.Cell(row, "status"),
+			ImgUrl: table.Cell(row, "img_url"),
+		}
+		if payload.Total, err = strconv.Atoi(table.Cell(row, "total")); err != nil {
+			result.Errors = append(result.Errors, rowError(table.Line(i), exception.BadRequestErr("total must be a number")))
+			continue
+		}
+		if payload.CategoryId, err = categoryRef.resolve(table.Cell(row, "category")); err == nil {
+			payload.AssetTypeId, err = typeRef.resolve(table.Cell(row, "asset_type"))
+		}
+		if err == nil {
+			err = validateAssetRequest(payload)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, rowError(table.Line(i), err))
+			continue
+		}
+
+		payload.Id = helper.GenerateUUID()
+		payload.EntryDate = now
+		payload.Available = payload.Total
+		valid = append(valid, payload)
+	}
+	result.ValidRows = len(valid)
+	if dryRun || len(valid) == 0 {
+		return result, nil
+	}
+
+	audits := make([]model.AuditLog, len(valid))
+	for i, payload := range valid {
+		audits[i], err = a.auditUC.Entry(actorId, model.AuditEntityAsset, payload.Id, model.AuditActionCreate, nil,
+			assetOf(payload, categoryById[payload.CategoryId], typeById[payload.AssetTypeId]))
+		if err != nil {
+			return dto.ImportResult{}, err
+		}
+	}
+	if err = a.repo.SaveAll(valid, audits); err != nil {
+		return dto.ImportResult{}, fmt.Errorf("failed import assets %s", err)
+	}
+	result.Imported = len(valid)
+	return result, nil
+}
+
 // Delete implements AssetUsecase.
-func (a *assetUsecase) Delete(id string) error {
+func (a *assetUsecase) Delete(actorId, id string) error {
 	//find assert first
-	_, err := a.FindById(id)
+	asset, err := a.FindById(id)
 	if err != nil {
 		return err
 	}
 
-	err = a.repo.Delete(id)
+	audit, err := a.auditUC.Entry(actorId, model.AuditEntityAsset, id, model.AuditActionDelete, asset, nil)
+	if err != nil {
+		return err
+	}
+	err = a.repo.Delete(id, audit)
 	if err != nil {
 		return fmt.Errorf("failed to delete asset, %s", err)
 	}
-
 	return nil
 }
 
@@ -142,7 +381,7 @@ func (a *assetUsecase) FindById(id string) (model.Asset, error) {
 }
 
 // Update implements AssetUsecase.
-func (a *assetUsecase) Update(payload model.AssetRequest) error {
+func (a *assetUsecase) Update(actorId string, payload model.AssetRequest) error {
 	if payload.Name == "" {
 		return exception.BadRequestErr("name cannot empty")
 	}
@@ -157,13 +396,13 @@ func (a *assetUsecase) Update(payload model.AssetRequest) error {
 	}
 
 	//implement asset type find by id
-	_, err := a.typeAssetUC.FindById(payload.AssetTypeId)
+	assetType, err := a.typeAssetUC.FindById(payload.AssetTypeId)
 	if err != nil {
 		return err
 	}
 
 	//implement category find by id
-	_, err = a.categoryUc.FindById(payload.CategoryId)
+	category, err := a.categoryUc.FindById(payload.CategoryId)
 	if err != nil {
 		return err
 	}
@@ -176,18 +415,64 @@ func (a *assetUsecase) Update(payload model.AssetRequest) error {
 	//calculation for update available
 	payload.Available = (payload.Total - asset.Total) + asset.Available
 
-	err = a.repo.Update(payload)
+	//the counts of an asset tracked per unit are kept by the repository, it set the after state to what was saved
+	audit, err := a.auditUC.Entry(actorId, model.AuditEntityAsset, payload.Id, model.AuditActionUpdate, asset, assetOf(payload, category, assetType))
+	if err != nil {
+		return err
+	}
+	err = a.repo.Update(payload, audit)
 	if err != nil {
 		return fmt.Errorf("failed update asset %s", err)
 	}
+	return nil
+}
 
+// Restore implements AssetUsecase.
+// it bring back a deleted asset, the repository add the restored row to the audit entry
+func (a *assetUsecase) Restore(actorId, id string) error {
+	audit, err := a.auditUC.Entry(actorId, model.AuditEntityAsset, id, model.AuditActionRestore, nil, nil)
+	if err != nil {
+		return err
+	}
+	err = a.repo.Restore(id, audit)
+	if errors.Is(err, repository.ErrNotDeleted) {
+		return exception.NotFoundErr("deleted asset not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore asset: %v", err)
+	}
 	return nil
 }
 
-func NewAssetUsecase(assetRepo repository.AssetRepository, typeAssetUC TypeAssetUseCase, categoryUC CategoryUsecase) AssetUsecase {
+// assetOf build the asset as it is saved from the request
+func isAssetSortField(field string) bool {
+	for _, sortField := range dto.AssetSortFields {
+		if sortField == field {
+			return true
+		}
+	}
+	return false
+}
+
+func assetOf(payload model.AssetRequest, category model.Category, assetType model.TypeAsset) model.Asset {
+	return model.Asset{
+		Id:        payload.Id,
+		Category:  category,
+		AssetType: assetType,
+		Name:      payload.Name,
+		Available: payload.Available,
+		Total:     payload.Total,
+		Status:    payload.Status,
+		EntryDate: payload.EntryDate,
+		ImgUrl:    payload.ImgUrl,
+	}
+}
+
+func NewAssetUsecase(assetRepo repository.AssetRepository, typeAssetUC TypeAssetUseCase, categoryUC CategoryUsecase, auditUC AuditUsecase) AssetUsecase {
 	return &assetUsecase{
 		repo:        assetRepo,
 		categoryUc:  categoryUC,
 		typeAssetUC: typeAssetUC,
+		auditUC:     auditUC,
 	}
 }
@@ -4,12 +4,16 @@ import (
 	"errors"
 	"final-project-enigma-clean/__mock__/repomock"
 	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -19,13 +23,16 @@ type AssetUsecaseTestSuite struct {
 	usecase     AssetUsecase
 	typeAssetUC *usecasemock.TypeAssetUsecaseMock
 	categoryUC  *usecasemock.CategoryUsecaseMock
+	auditUC     *usecasemock.AuditUsecaseMock
 }
 
 func (suite *AssetUsecaseTestSuite) SetupTest() {
 	suite.repoMock = new(repomock.AssetRepoMock)
 	suite.typeAssetUC = new(usecasemock.TypeAssetUsecaseMock)
 	suite.categoryUC = new(usecasemock.CategoryUsecaseMock)
-	suite.usecase = NewAssetUsecase(suite.repoMock, suite.typeAssetUC, suite.categoryUC)
+	suite.auditUC = new(usecasemock.AuditUsecaseMock)
+	suite.auditUC.On("Entry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testAudit, nil).Maybe()
+	suite.usecase = NewAssetUsecase(suite.repoMock, suite.typeAssetUC, suite.categoryUC, suite.auditUC)
 }
 
 func TestAssetusecaseTestSuite(t *testing.T) {
@@ -56,8 +63,8 @@ func (suite *AssetUsecaseTestSuite) TestCreate_Success() {
 
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(typeAsset, nil)
 	suite.categoryUC.On("FindById", payload.CategoryId).Return(category, nil)
-	suite.repoMock.On("Save", payload).Return(nil)
-	gotError := suite.usecase.Create(payload)
+	suite.repoMock.On("Save", payload, testAudit).Return(nil)
+	gotError := suite.usecase.Create(testActor, payload)
 	assert.NoError(suite.T(), gotError)
 	assert.Nil(suite.T(), gotError)
 }
@@ -85,8 +92,8 @@ func (suite *AssetUsecaseTestSuite) TestCreate_Failed() {
 
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(typeAsset, nil)
 	suite.categoryUC.On("FindById", payload.CategoryId).Return(category, nil)
-	suite.repoMock.On("Save", payload).Return(errors.New("failed to create asset"))
-	gotError := suite.usecase.Create(payload)
+	suite.repoMock.On("Save", payload, testAudit).Return(errors.New("failed to create asset"))
+	gotError := suite.usecase.Create(testActor, payload)
 	assert.Error(suite.T(), gotError)
 	assert.NotNil(suite.T(), gotError)
 }
@@ -94,7 +101,7 @@ func (suite *AssetUsecaseTestSuite) TestCreate_Failed() {
 func (suite *AssetUsecaseTestSuite) TestCreate_EmptyField() {
 
 	//Test name empty
-	gotError := suite.usecase.Create(model.AssetRequest{
+	gotError := suite.usecase.Create(testActor, model.AssetRequest{
 		CategoryId:  "1",
 		AssetTypeId: "1",
 		Name:        "",
@@ -105,7 +112,7 @@ func (suite *AssetUsecaseTestSuite) TestCreate_EmptyField() {
 	assert.Error(suite.T(), gotError)
 
 	//test category Id or asset type Id empty
-	gotError = suite.usecase.Create(model.AssetRequest{
+	gotError = suite.usecase.Create(testActor, model.AssetRequest{
 		CategoryId:  "",
 		AssetTypeId: "",
 		Name:        "Laptop",
@@ -116,7 +123,7 @@ func (suite *AssetUsecaseTestSuite) TestCreate_EmptyField() {
 	assert.Error(suite.T(), gotError)
 
 	//test Available minus
-	gotError = suite.usecase.Create(model.AssetRequest{
+	gotError = suite.usecase.Create(testActor, model.AssetRequest{
 		CategoryId:  "TEST1",
 		AssetTypeId: "TEST1",
 		Name:        "Laptop",
@@ -128,7 +135,7 @@ func (suite *AssetUsecaseTestSuite) TestCreate_EmptyField() {
 	assert.Error(suite.T(), gotError)
 
 	//test status empty
-	gotError = suite.usecase.Create(model.AssetRequest{
+	gotError = suite.usecase.Create(testActor, model.AssetRequest{
 		CategoryId:  "TEST1",
 		AssetTypeId: "TEST1",
 		Name:        "Laptop",
@@ -153,7 +160,7 @@ func (suite *AssetUsecaseTestSuite) TestCreate_InvalidTypeAsset() {
 	}
 
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(model.TypeAsset{}, errors.New("failed get asset type"))
-	gotError := suite.usecase.Create(payload)
+	gotError := suite.usecase.Create(testActor, payload)
 	assert.Error(suite.T(), gotError)
 	assert.NotNil(suite.T(), gotError)
 }
@@ -177,8 +184,8 @@ func (suite *AssetUsecaseTestSuite) TestCreate_InvalidCategory() {
 
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(typeAsset, nil)
 	suite.categoryUC.On("FindById", payload.CategoryId).Return(model.Category{}, errors.New("failed get category"))
-	suite.repoMock.On("Save", payload).Return(errors.New("failed to create asset"))
-	gotError := suite.usecase.Create(payload)
+	suite.repoMock.On("Save", payload, testAudit).Return(errors.New("failed to create asset"))
+	gotError := suite.usecase.Create(testActor, payload)
 	assert.Error(suite.T(), gotError)
 	assert.NotNil(suite.T(), gotError)
 }
@@ -261,8 +268,8 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_Success() {
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(typeAsset, nil)
 	suite.categoryUC.On("FindById", payload.CategoryId).Return(category, nil)
 	suite.repoMock.On("FindById", payload.Id).Return(asset, nil)
-	suite.repoMock.On("Update", payload).Return(nil)
-	gotError := suite.usecase.Update(payload)
+	suite.repoMock.On("Update", payload, testAudit).Return(nil)
+	gotError := suite.usecase.Update(testActor, payload)
 	assert.NoError(suite.T(), gotError)
 	assert.Nil(suite.T(), gotError)
 	assert.Equal(suite.T(), payload.Available, asset.Available)
@@ -271,7 +278,7 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_Success() {
 func (suite *AssetUsecaseTestSuite) TestUpdate_EmptyField() {
 
 	//Test name empty
-	gotError := suite.usecase.Update(model.AssetRequest{
+	gotError := suite.usecase.Update(testActor, model.AssetRequest{
 		CategoryId:  "1",
 		AssetTypeId: "1",
 		Name:        "",
@@ -282,7 +289,7 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_EmptyField() {
 	assert.Error(suite.T(), gotError)
 
 	//test category Id or asset type Id empty
-	gotError = suite.usecase.Update(model.AssetRequest{
+	gotError = suite.usecase.Update(testActor, model.AssetRequest{
 		CategoryId:  "",
 		AssetTypeId: "",
 		Name:        "Laptop",
@@ -293,7 +300,7 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_EmptyField() {
 	assert.Error(suite.T(), gotError)
 
 	//test Available minus
-	gotError = suite.usecase.Update(model.AssetRequest{
+	gotError = suite.usecase.Update(testActor, model.AssetRequest{
 		CategoryId:  "TEST1",
 		AssetTypeId: "TEST1",
 		Name:        "Laptop",
@@ -305,7 +312,7 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_EmptyField() {
 	assert.Error(suite.T(), gotError)
 
 	//test status empty
-	gotError = suite.usecase.Update(model.AssetRequest{
+	gotError = suite.usecase.Update(testActor, model.AssetRequest{
 		CategoryId:  "TEST1",
 		AssetTypeId: "TEST1",
 		Name:        "Laptop",
@@ -340,7 +347,7 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_InvalId() {
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(typeAsset, nil)
 	suite.categoryUC.On("FindById", payload.CategoryId).Return(category, nil)
 	suite.repoMock.On("FindById", "xx").Return(model.Asset{}, errors.New("cannot found asset with Id"))
-	gotError := suite.usecase.Update(payload)
+	gotError := suite.usecase.Update(testActor, payload)
 	assert.NotNil(suite.T(), gotError)
 	assert.Error(suite.T(), gotError)
 }
@@ -358,7 +365,7 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_InvalidTypeAsset() {
 	}
 
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(model.TypeAsset{}, errors.New("failed get type asset"))
-	gotError := suite.usecase.Update(payload)
+	gotError := suite.usecase.Update(testActor, payload)
 	assert.NotNil(suite.T(), gotError)
 	assert.Error(suite.T(), gotError)
 }
@@ -381,7 +388,7 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_InvalidCategory() {
 
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(typeAsset, nil)
 	suite.categoryUC.On("FindById", payload.CategoryId).Return(typeAsset, errors.New("failed get category"))
-	gotError := suite.usecase.Update(payload)
+	gotError := suite.usecase.Update(testActor, payload)
 	assert.NotNil(suite.T(), gotError)
 	assert.Error(suite.T(), gotError)
 }
@@ -426,8 +433,8 @@ func (suite *AssetUsecaseTestSuite) TestUpdate_Failed() {
 	suite.typeAssetUC.On("FindById", payload.AssetTypeId).Return(typeAsset, nil)
 	suite.categoryUC.On("FindById", payload.CategoryId).Return(category, nil)
 	suite.repoMock.On("FindById", payload.Id).Return(asset, nil)
-	suite.repoMock.On("Update", payload).Return(errors.New("failed update asset"))
-	gotError := suite.usecase.Update(payload)
+	suite.repoMock.On("Update", payload, testAudit).Return(errors.New("failed update asset"))
+	gotError := suite.usecase.Update(testActor, payload)
 	assert.NotNil(suite.T(), gotError)
 	assert.Error(suite.T(), gotError)
 }
@@ -451,15 +458,16 @@ func (suite *AssetUsecaseTestSuite) TestDelete_Success() {
 		Total:     10,
 	}
 	suite.repoMock.On("FindById", "1").Return(asset, nil)
-	suite.repoMock.On("Delete", "1").Return(nil)
-	gotError := suite.usecase.Delete("1")
+	suite.repoMock.On("Delete", "1", testAudit).Return(nil)
+	gotError := suite.usecase.Delete(testActor, "1")
 	assert.NoError(suite.T(), gotError)
 	assert.Nil(suite.T(), gotError)
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityAsset, "1", model.AuditActionDelete, asset, nil)
 }
 
 func (suite *AssetUsecaseTestSuite) TestDelete_InvalId() {
 	suite.repoMock.On("FindById", "xx").Return(model.Asset{}, errors.New("cannot found asset with Id"))
-	gotError := suite.usecase.Delete("xx")
+	gotError := suite.usecase.Delete(testActor, "xx")
 	assert.NotNil(suite.T(), gotError)
 	assert.Error(suite.T(), gotError)
 }
@@ -477,58 +485,12 @@ func (suite *AssetUsecaseTestSuite) TestDelete_Failed() {
 		Total:     10,
 	}
 	suite.repoMock.On("FindById", "1").Return(asset, nil)
-	suite.repoMock.On("Delete", "1").Return(errors.New("failed delete asset"))
-	gotError := suite.usecase.Delete("1")
+	suite.repoMock.On("Delete", "1", testAudit).Return(errors.New("failed delete asset"))
+	gotError := suite.usecase.Delete(testActor, "1")
 	assert.Error(suite.T(), gotError)
 	assert.NotNil(suite.T(), gotError)
 }
 
-func (suite *AssetUsecaseTestSuite) TestUpdateAvailable_Success() {
-	asset := model.Asset{
-		Id:        "1",
-		Category:  model.Category{Id: "1", Name: "Bergerak"},
-		AssetType: model.TypeAsset{Id: "1", Name: "Elektronik"},
-		Name:      "Laptop",
-		Available: 5,
-		Status:    "Ready",
-		EntryDate: time.Time{},
-		ImgUrl:    "",
-		Total:     10,
-	}
-	suite.repoMock.On("FindById", "1").Return(asset, nil)
-	suite.repoMock.On("UpdateAvailable", "1", 3).Return(nil)
-	err := suite.usecase.UpdateAvailable("1", 2)
-	assert.NoError(suite.T(), err)
-	assert.Nil(suite.T(), err)
-}
-
-func (suite *AssetUsecaseTestSuite) TestUpdateAvailable_Failed() {
-	asset := model.Asset{
-		Id:        "1",
-		Category:  model.Category{Id: "1", Name: "Bergerak"},
-		AssetType: model.TypeAsset{Id: "1", Name: "Elektronik"},
-		Name:      "Laptop",
-		Available: 5,
-		Status:    "Ready",
-		EntryDate: time.Time{},
-		ImgUrl:    "",
-		Total:     10,
-	}
-	suite.repoMock.On("FindById", "1").Return(asset, nil)
-	suite.repoMock.On("UpdateAvailable", "1", 3).Return(errors.New("failed update"))
-	err := suite.usecase.UpdateAvailable("1", 2)
-	assert.Error(suite.T(), err)
-	assert.NotNil(suite.T(), err)
-}
-
-func (suite *AssetUsecaseTestSuite) TestUpdateAvailable_InvalidId() {
-
-	suite.repoMock.On("FindById", "1").Return(model.Asset{}, errors.New("failed get asset with id"))
-	err := suite.usecase.UpdateAvailable("1", 2)
-	assert.Error(suite.T(), err)
-	assert.NotNil(suite.T(), err)
-}
-
 func (suite *AssetUsecaseTestSuite) TestFindByName_Success() {
 	asset := []model.Asset{
 		{
@@ -582,9 +544,8 @@ func (suite *AssetUsecaseTestSuite) TestPaging_Success() {
 		TotalRows:  1,
 		TotalPages: 1,
 	}
-	mockPageRequest := dto.PageRequest{
-		Page: 1,
-		Size: 5,
+	mockPageRequest := dto.AssetQuery{
+		PageRequest: dto.PageRequest{Page: 1, Size: 5},
 	}
 	suite.repoMock.On("Paging", mockPageRequest).Return(asset, mockPaging, nil)
 	gotAssets, gotPaging, gotErr := suite.usecase.Paging(mockPageRequest)
@@ -595,3 +556,159 @@ func (suite *AssetUsecaseTestSuite) TestPaging_Success() {
 	assert.Equal(suite.T(), mockPaging, gotPaging)
 	assert.Equal(suite.T(), mockPaging.Size, gotPaging.Size)
 }
+
+func (suite *AssetUsecaseTestSuite) TestRestore_Success() {
+	suite.repoMock.On("Restore", "1", testAudit).Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.Restore(testActor, "1"))
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityAsset, "1", model.AuditActionRestore, nil, nil)
+}
+
+func (suite *AssetUsecaseTestSuite) TestRestore_Failed() {
+	suite.repoMock.On("Restore", "1", testAudit).Return(errors.New("db down"))
+
+	assert.Error(suite.T(), suite.usecase.Restore(testActor, "1"))
+}
+
+func (suite *AssetUsecaseTestSuite) TestPaging_Defaults() {
+	expected := dto.AssetQuery{PageRequest: dto.PageRequest{Page: 1, Size: 5}, Direction: "desc"}
+	suite.repoMock.On("Paging", expected).Return([]model.Asset{}, dto.Paging{}, nil)
+
+	_, _, err := suite.usecase.Paging(dto.AssetQuery{Direction: "DESC"})
+	assert.NoError(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *AssetUsecaseTestSuite) TestPaging_InvalidQuery() {
+	page := dto.PageRequest{Page: 1, Size: 5}
+	invalid := []dto.AssetQuery{
+		{PageRequest: dto.PageRequest{Page: 1, Size: 500}},
+		{PageRequest: page, Sort: "img_url"},
+		{PageRequest: page, Direction: "up"},
+		{PageRequest: page, EntryFrom: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), EntryTo: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, query := range invalid {
+		_, _, err := suite.usecase.Paging(query)
+		assert.IsType(suite.T(), &exception.Http{}, err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "Paging", mock.Anything)
+}
+
+// a report walk every page of the filtered list in its sort, the requested page is ignored
+func (suite *AssetUsecaseTestSuite) TestReport_AllPages() {
+	query := dto.AssetQuery{PageRequest: dto.PageRequest{Page: 3, Size: 5}, Status: "Ready", Sort: "total", Direction: "desc"}
+	first, second := query, query
+	first.Page, first.Size = 1, maxAssetPageSize
+	second.Page, second.Size = 2, maxAssetPageSize
+	suite.repoMock.On("Paging", first).Return([]model.Asset{{Id: "a"}}, dto.Paging{Page: 1, TotalPages: 2}, nil)
+	suite.repoMock.On("Paging", second).Return([]model.Asset{{Id: "b"}}, dto.Paging{Page: 2, TotalPages: 2}, nil)
+
+	assets, err := suite.usecase.Report(query)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.Asset{{Id: "a"}, {Id: "b"}}, assets)
+}
+
+func (suite *AssetUsecaseTestSuite) TestPagingCursor_Sorted() {
+	_, _, err := suite.usecase.PagingCursor(dto.AssetQuery{Sort: "name"}, dto.CursorRequest{Limit: 10})
+	assert.IsType(suite.T(), &exception.Http{}, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "PagingCursor", mock.Anything, mock.Anything)
+}
+
+func (suite *AssetUsecaseTestSuite) importCatalog() {
+	suite.categoryUC.On("FindAll").Return([]model.Category{{Id: "c1", Name: "Elektronik"}, {Id: "c2", Name: "Furniture"}, {Id: "c3", Name: "Furniture"}}, nil)
+	suite.typeAssetUC.On("FindAll").Return([]model.TypeAsset{{Id: "t1", Name: "Laptop"}}, nil)
+}
+
+var assetImportRows = [][]string{
+	{"name", "category", "asset_type", "total", "status", "img_url"},
+	{"Laptop Dell", "elektronik", "Laptop", "5", "ready"},
+	{"Meja", "Furniture", "t1", "2", "ready"},
+	{},
+	{"Laptop HP", "c1", "t1", "lima", "ready"},
+	{"Kursi", "Kendaraan", "t1", "1", "ready"},
+	{"Laptop Asus", "c1", "t1", "3", ""},
+}
+
+func (suite *AssetUsecaseTestSuite) TestImport_DryRun() {
+	suite.importCatalog()
+
+	result, err := suite.usecase.Import(testActor, assetImportRows, true)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 5, result.TotalRows)
+	assert.Equal(suite.T(), 1, result.ValidRows)
+	assert.Equal(suite.T(), 0, result.Imported)
+	assert.Equal(suite.T(), []dto.ImportRowError{
+		{Row: 3, Message: "category name Furniture is ambiguous, use its id"},
+		{Row: 5, Message: "total must be a number"},
+		{Row: 6, Message: "category Kendaraan not found"},
+		{Row: 7, Message: "status cannot empty"},
+	}, result.Errors)
+	suite.repoMock.AssertNotCalled(suite.T(), "SaveAll", mock.Anything, mock.Anything)
+}
+
+func (suite *AssetUsecaseTestSuite) TestImport_SaveValidRows() {
+	suite.importCatalog()
+	suite.repoMock.On("SaveAll", mock.MatchedBy(func(assets []model.AssetRequest) bool {
+		return len(assets) == 1 && assets[0].CategoryId == "c1" && assets[0].AssetTypeId == "t1" && assets[0].Available == 5
+	}), []model.AuditLog{testAudit}).Return(nil)
+
+	result, err := suite.usecase.Import(testActor, assetImportRows, false)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, result.Imported)
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityAsset, mock.Anything, model.AuditActionCreate, nil, mock.Anything)
+}
+
+func (suite *AssetUsecaseTestSuite) TestImport_SaveFailed() {
+	suite.importCatalog()
+	suite.repoMock.On("SaveAll", mock.Anything, mock.Anything).Return(errors.New("db down"))
+
+	result, err := suite.usecase.Import(testActor, assetImportRows, false)
+	assert.Error(suite.T(), err)
+	assert.Equal(suite.T(), 0, result.Imported)
+}
+
+func (suite *AssetUsecaseTestSuite) TestImport_MissingColumn() {
+	_, err := suite.usecase.Import(testActor, [][]string{{"name", "total"}, {"Laptop", "1"}}, true)
+	assert.IsType(suite.T(), &exception.Http{}, err)
+	suite.categoryUC.AssertNotCalled(suite.T(), "FindAll")
+}
+
+func (suite *AssetUsecaseTestSuite) TestLabel_Svg() {
+	suite.repoMock.On("FindById", "1").Return(model.Asset{Id: "1", Name: "Laptop"}, nil)
+
+	svg, err := suite.usecase.Label("1", dto.LabelRequest{Type: "code128", Format: "svg"})
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), string(svg), "<svg")
+}
+
+func (suite *AssetUsecaseTestSuite) TestLabel_Invalid() {
+	suite.repoMock.On("FindById", "1").Return(model.Asset{Id: "1", Name: "Laptop"}, nil)
+
+	for _, payload := range []dto.LabelRequest{{Type: "ean13"}, {Format: "gif"}, {Size: 10}} {
+		_, err := suite.usecase.Label("1", payload)
+		assert.IsType(suite.T(), &exception.Http{}, err)
+	}
+}
+
+func (suite *AssetUsecaseTestSuite) TestLabelSheet_AllPages() {
+	query := dto.AssetQuery{CategoryId: "1"}
+	first := make([]model.Asset, maxCursorLimit)
+	for i := range first {
+		first[i] = model.Asset{Id: fmt.Sprintf("a%03d", i), Name: "Laptop"}
+	}
+	suite.repoMock.On("PagingCursor", query, dto.CursorRequest{Limit: maxCursorLimit}).Return(first, dto.CursorPaging{Limit: maxCursorLimit, NextCursor: "next"}, nil)
+	suite.repoMock.On("PagingCursor", query, dto.CursorRequest{Limit: maxCursorLimit, After: "a099"}).Return([]model.Asset{{Id: "b", Name: "Meja"}}, dto.CursorPaging{Limit: maxCursorLimit}, nil)
+
+	pdf, err := suite.usecase.LabelSheet(dto.AssetQuery{CategoryId: "1", Sort: "name"})
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(string(pdf), "%PDF-"))
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *AssetUsecaseTestSuite) TestLabelSheet_TooMany() {
+	page := make([]model.Asset, maxCursorLimit)
+	suite.repoMock.On("PagingCursor", mock.Anything, mock.Anything).Return(page, dto.CursorPaging{NextCursor: "next"}, nil)
+
+	_, err := suite.usecase.LabelSheet(dto.AssetQuery{})
+	assert.IsType(suite.T(), &exception.Http{}, err)
+}
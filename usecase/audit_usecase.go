@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"encoding/json"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/repository"
+	"final-project-enigma-clean/util/helper"
+	"fmt"
+	"time"
+)
+
+// maxAuditPageSize keep a single listing request from reading the whole audit log
+const maxAuditPageSize = 100
+
+type AuditUsecase interface {
+	Entry(actorId, entity, entityId, action string, before, after any) (model.AuditLog, error)
+	List(payload dto.AuditRequest) ([]model.AuditLog, dto.Paging, error)
+}
+
+type auditUsecase struct {
+	repo repository.AuditRepository
+	now  func() time.Time
+}
+
+// Entry build the audit log entry of a change, it is handed to the repository saving the change
+// and inserted in the same transaction, so the change and its entry are committed or rolled back together
+func (a *auditUsecase) Entry(actorId, entity, entityId, action string, before, after any) (model.AuditLog, error) {
+	entry := model.AuditLog{
+		Id:        helper.GenerateUUID(),
+		ActorId:   actorId,
+		Entity:    entity,
+		EntityId:  entityId,
+		Action:    action,
+		CreatedAt: a.now(),
+	}
+
+	var err error
+	if entry.Before, err = marshalState(before); err != nil {
+		return model.AuditLog{}, fmt.Errorf("failed to audit %s of %s %s: %v", action, entity, entityId, err)
+	}
+	if entry.After, err = marshalState(after); err != nil {
+		return model.AuditLog{}, fmt.Errorf("failed to audit %s of %s %s: %v", action, entity, entityId, err)
+	}
+	return entry, nil
+}
+
+// List the audit log newest first
+func (a *auditUsecase) List(payload dto.AuditRequest) ([]model.AuditLog, dto.Paging, error) {
+	if payload.Entity != "" && !isAuditEntity(payload.Entity) {
+		return nil, dto.Paging{}, exception.BadRequestErr(fmt.Sprintf("unknown entity %s", payload.Entity))
+	}
+	if payload.EntityId != "" && payload.Entity == "" {
+		return nil, dto.Paging{}, exception.BadRequestErr("entity is required to filter by id")
+	}
+	if payload.Size <= 0 || payload.Size > maxAuditPageSize {
+		return nil, dto.Paging{}, exception.BadRequestErr(fmt.Sprintf("size must be between 1 and %d", maxAuditPageSize))
+	}
+
+	entries, paging, err := a.repo.FindAll(payload)
+	if err != nil {
+		return nil, dto.Paging{}, fmt.Errorf("failed to get audit log: %v", err)
+	}
+	return entries, paging, nil
+}
+
+func marshalState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+func isAuditEntity(entity string) bool {
+	switch entity {
+	case model.AuditEntityAsset, model.AuditEntityCategory, model.AuditEntityTypeAsset,
+		model.AuditEntityStaff, model.AuditEntityTransaction, model.AuditEntityStocktake,
+		model.AuditEntityLoanRequest:
+		return true
+	}
+	return false
+}
+
+func NewAuditUsecase(repo repository.AuditRepository) AuditUsecase {
+	return &auditUsecase{
+		repo: repo,
+		now:  time.Now,
+	}
+}
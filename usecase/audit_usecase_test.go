@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"errors"
+	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// testActor is the user making the changes in the usecase tests
+const testActor = "user-1"
+
+// testAudit is the entry the audit mock build, the repositories must be handed it with the change
+var testAudit = model.AuditLog{Id: "audit-1"}
+
+type AuditUsecaseSuite struct {
+	suite.Suite
+	repo    *repomock.AuditRepoMock
+	usecase *auditUsecase
+	now     time.Time
+}
+
+func (suite *AuditUsecaseSuite) SetupTest() {
+	suite.repo = new(repomock.AuditRepoMock)
+	suite.now = time.Date(2023, 9, 10, 7, 0, 0, 0, time.UTC)
+	suite.usecase = &auditUsecase{repo: suite.repo, now: func() time.Time { return suite.now }}
+}
+
+func TestAuditUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(AuditUsecaseSuite))
+}
+
+func (suite *AuditUsecaseSuite) TestEntry_Update() {
+	entry, err := suite.usecase.Entry("user-1", model.AuditEntityCategory, "c-1", model.AuditActionUpdate,
+		model.Category{Id: "c-1", Name: "Old"}, model.Category{Id: "c-1", Name: "New"})
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), entry.Id)
+	assert.Equal(suite.T(), "user-1", entry.ActorId)
+	assert.Equal(suite.T(), model.AuditEntityCategory, entry.Entity)
+	assert.Equal(suite.T(), "c-1", entry.EntityId)
+	assert.Equal(suite.T(), model.AuditActionUpdate, entry.Action)
+	assert.Equal(suite.T(), `{"id":"c-1","name":"Old"}`, string(entry.Before))
+	assert.Equal(suite.T(), `{"id":"c-1","name":"New"}`, string(entry.After))
+	assert.True(suite.T(), entry.CreatedAt.Equal(suite.now))
+}
+
+func (suite *AuditUsecaseSuite) TestEntry_DeleteHasNoAfter() {
+	entry, err := suite.usecase.Entry("user-1", model.AuditEntityStaff, "1", model.AuditActionDelete, model.Staff{Nik_Staff: "1"}, nil)
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), entry.Before)
+	assert.Nil(suite.T(), entry.After)
+}
+
+func (suite *AuditUsecaseSuite) TestEntry_MarshalFail() {
+	_, err := suite.usecase.Entry("user-1", model.AuditEntityAsset, "a-1", model.AuditActionCreate, nil, make(chan int))
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AuditUsecaseSuite) TestList_Success() {
+	payload := dto.AuditRequest{Entity: model.AuditEntityAsset, EntityId: "a-1", Page: 1, Size: 5}
+	suite.repo.On("FindAll", payload).Return([]model.AuditLog{{Id: "1"}}, dto.Paging{Page: 1, Size: 5, TotalRows: 1, TotalPages: 1}, nil)
+
+	entries, paging, err := suite.usecase.List(payload)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), 1, paging.TotalRows)
+}
+
+func (suite *AuditUsecaseSuite) TestList_UnknownEntity() {
+	_, _, err := suite.usecase.List(dto.AuditRequest{Entity: "planet"})
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.StatusCode)
+	suite.repo.AssertNotCalled(suite.T(), "FindAll", mock.Anything)
+}
+
+func (suite *AuditUsecaseSuite) TestList_IdWithoutEntity() {
+	_, _, err := suite.usecase.List(dto.AuditRequest{EntityId: "a-1"})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AuditUsecaseSuite) TestList_Fail() {
+	suite.repo.On("FindAll", mock.Anything).Return([]model.AuditLog(nil), dto.Paging{}, errors.New("db down"))
+
+	_, _, err := suite.usecase.List(dto.AuditRequest{Page: 1, Size: 10})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *AuditUsecaseSuite) TestList_InvalidSize() {
+	for _, size := range []int{0, -1, maxAuditPageSize + 1} {
+		_, _, err := suite.usecase.List(dto.AuditRequest{Page: 1, Size: size})
+		var httpErr *exception.Http
+		assert.ErrorAs(suite.T(), err, &httpErr)
+		assert.Equal(suite.T(), http.StatusBadRequest, httpErr.StatusCode)
+	}
+	suite.repo.AssertNotCalled(suite.T(), "FindAll", mock.Anything)
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"errors"
 	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/repository"
@@ -9,15 +10,18 @@ import (
 )
 
 type CategoryUsecase interface {
-	CreateNew(payload model.Category) error
+	CreateNew(actorId string, payload model.Category) error
 	FindById(id string) (model.Category, error)
 	FindAll() ([]model.Category, error)
-	Update(payload model.Category) error
-	Delete(id string) error
+	FindAllWithDeleted() ([]model.Category, error)
+	Update(actorId string, payload model.Category) error
+	Delete(actorId, id string) error
+	Restore(actorId, id string) error
 }
 
 type categoryUsecase struct {
-	repo repository.CategoryRepository
+	repo    repository.CategoryRepository
+	auditUC AuditUsecase
 }
 
 // FindById implements CategoryUseCase.
@@ -31,14 +35,18 @@ func (c *categoryUsecase) FindById(id string) (model.Category, error) {
 }
 
 // CreateNew implements CategoryUseCase.
-func (c *categoryUsecase) CreateNew(payload model.Category) error {
+func (c *categoryUsecase) CreateNew(actorId string, payload model.Category) error {
 	if payload.Name == "" {
 		return exception.BadRequestErr("name is required")
 	}
 
 	//commented for unit testing
 	payload.Id = helper.GenerateUUID()
-	err := c.repo.Save(payload)
+	audit, err := c.auditUC.Entry(actorId, model.AuditEntityCategory, payload.Id, model.AuditActionCreate, nil, payload)
+	if err != nil {
+		return err
+	}
+	err = c.repo.Save(payload, audit)
 	if err != nil {
 		return fmt.Errorf("failed to create new category: %v", err)
 	}
@@ -46,12 +54,16 @@ func (c *categoryUsecase) CreateNew(payload model.Category) error {
 }
 
 // Delete implements CategoryUseCase.
-func (c *categoryUsecase) Delete(id string) error {
+func (c *categoryUsecase) Delete(actorId, id string) error {
 	Category, err := c.FindById(id)
 	if err != nil {
 		return err
 	}
-	err = c.repo.Delete(Category.Id)
+	audit, err := c.auditUC.Entry(actorId, model.AuditEntityCategory, Category.Id, model.AuditActionDelete, Category, nil)
+	if err != nil {
+		return err
+	}
+	err = c.repo.Delete(Category.Id, audit)
 	if err != nil {
 		return fmt.Errorf("failed to delete category: %v", err)
 	}
@@ -67,24 +79,55 @@ func (c *categoryUsecase) FindAll() ([]model.Category, error) {
 	return Category, nil
 }
 
+// FindAllWithDeleted implements CategoryUseCase.
+func (c *categoryUsecase) FindAllWithDeleted() ([]model.Category, error) {
+	categories, err := c.repo.FindAllWithDeleted()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find all category: %v", err)
+	}
+	return categories, nil
+}
+
 // Update implements CategoryUseCase.
-func (c *categoryUsecase) Update(payload model.Category) error {
+func (c *categoryUsecase) Update(actorId string, payload model.Category) error {
 	if payload.Name == "" {
 		return exception.BadRequestErr("name is required")
 	}
-	_, err := c.FindById(payload.Id)
+	before, err := c.FindById(payload.Id)
+	if err != nil {
+		return err
+	}
+	audit, err := c.auditUC.Entry(actorId, model.AuditEntityCategory, payload.Id, model.AuditActionUpdate, before, payload)
 	if err != nil {
 		return err
 	}
-	err = c.repo.Update(payload)
+	err = c.repo.Update(payload, audit)
 	if err != nil {
 		return fmt.Errorf("failed to update category: %v", err)
 	}
 	return nil
 }
 
-func NewCategoryUseCase(repo repository.CategoryRepository) CategoryUsecase {
+// Restore implements CategoryUsecase.
+// it bring back a deleted category, the repository add the restored row to the audit entry
+func (c *categoryUsecase) Restore(actorId, id string) error {
+	audit, err := c.auditUC.Entry(actorId, model.AuditEntityCategory, id, model.AuditActionRestore, nil, nil)
+	if err != nil {
+		return err
+	}
+	err = c.repo.Restore(id, audit)
+	if errors.Is(err, repository.ErrNotDeleted) {
+		return exception.NotFoundErr("deleted category not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore category: %v", err)
+	}
+	return nil
+}
+
+func NewCategoryUseCase(repo repository.CategoryRepository, auditUC AuditUsecase) CategoryUsecase {
 	return &categoryUsecase{
-		repo: repo,
+		repo:    repo,
+		auditUC: auditUC,
 	}
 }
@@ -3,22 +3,30 @@ package usecase
 import (
 	"errors"
 	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/repository"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
 type CategoryUsecaseTest struct {
 	suite.Suite
 	repo    *repomock.CategoryRepoMock
+	auditUC *usecasemock.AuditUsecaseMock
 	usecase CategoryUsecase
 }
 
 func (suite *CategoryUsecaseTest) SetupTest() {
 	suite.repo = new(repomock.CategoryRepoMock)
-	suite.usecase = NewCategoryUseCase(suite.repo)
+	suite.auditUC = new(usecasemock.AuditUsecaseMock)
+	suite.auditUC.On("Entry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testAudit, nil).Maybe()
+	suite.usecase = NewCategoryUseCase(suite.repo, suite.auditUC)
 }
 
 func TestCategoryUsecaseSuiteTest(t *testing.T) {
@@ -32,14 +40,14 @@ func (suite *CategoryUsecaseTest) TestCreate_Success() {
 		Name: "Bergerak",
 	}
 
-	suite.repo.On("Save", mockData).Return(nil)
-	gotErr := suite.usecase.CreateNew(mockData)
+	suite.repo.On("Save", mockData, testAudit).Return(nil)
+	gotErr := suite.usecase.CreateNew(testActor, mockData)
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *CategoryUsecaseTest) TestCreate_EmptyField() {
 
-	gotErr := suite.usecase.CreateNew(model.Category{
+	gotErr := suite.usecase.CreateNew(testActor, model.Category{
 		Id:   "1",
 		Name: "",
 	})
@@ -52,8 +60,8 @@ func (suite *CategoryUsecaseTest) TestCreate_Failed() {
 		Name: "Bergerak",
 	}
 
-	suite.repo.On("Save", mockData).Return(errors.New("failed save category"))
-	gotErr := suite.usecase.CreateNew(mockData)
+	suite.repo.On("Save", mockData, testAudit).Return(errors.New("failed save category"))
+	gotErr := suite.usecase.CreateNew(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -102,15 +110,17 @@ func (suite *CategoryUsecaseTest) TestUpdate_Success() {
 		Id:   "1",
 		Name: "Bergerak",
 	}
-	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Update", mockData).Return(nil)
-	gotErr := suite.usecase.Update(mockData)
+	before := model.Category{Id: "1", Name: "Tidak Bergerak"}
+	suite.repo.On("FindById", "1").Return(before, nil)
+	suite.repo.On("Update", mockData, testAudit).Return(nil)
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.NoError(suite.T(), gotErr)
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityCategory, "1", model.AuditActionUpdate, before, mockData)
 }
 
 func (suite *CategoryUsecaseTest) TestUpdate_EmptyField() {
 
-	gotErr := suite.usecase.Update(model.Category{
+	gotErr := suite.usecase.Update(testActor, model.Category{
 		Id:   "1",
 		Name: "",
 	})
@@ -123,7 +133,7 @@ func (suite *CategoryUsecaseTest) TestUpdate_InvalidId() {
 		Name: "Bergerak",
 	}
 	suite.repo.On("FindById", "1").Return(model.Category{}, errors.New("failed get category"))
-	gotErr := suite.usecase.Update(mockData)
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -134,9 +144,11 @@ func (suite *CategoryUsecaseTest) TestUpdate_Failed() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Update", mockData).Return(errors.New("failed update category"))
-	gotErr := suite.usecase.Update(mockData)
+	suite.repo.On("Update", mockData, testAudit).Return(errors.New("failed update category"))
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
+	//the entry go to the repository, it is rolled back with the change
+	suite.repo.AssertCalled(suite.T(), "Update", mockData, testAudit)
 }
 
 func (suite *CategoryUsecaseTest) TestDelete_Success() {
@@ -146,14 +158,14 @@ func (suite *CategoryUsecaseTest) TestDelete_Success() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Delete", "1").Return(nil)
-	gotErr := suite.usecase.Delete("1")
+	suite.repo.On("Delete", "1", testAudit).Return(nil)
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *CategoryUsecaseTest) TestDelete_InvalidId() {
 	suite.repo.On("FindById", "1").Return(model.Category{}, errors.New("failed get category"))
-	gotErr := suite.usecase.Delete("1")
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -164,7 +176,23 @@ func (suite *CategoryUsecaseTest) TestDelete_Failed() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Delete", "1").Return(errors.New("failed delete"))
-	gotErr := suite.usecase.Delete("1")
+	suite.repo.On("Delete", "1", testAudit).Return(errors.New("failed delete"))
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.Error(suite.T(), gotErr)
 }
+
+func (suite *CategoryUsecaseTest) TestRestore_Success() {
+	suite.repo.On("Restore", "1", testAudit).Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.Restore(testActor, "1"))
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityCategory, "1", model.AuditActionRestore, nil, nil)
+}
+
+func (suite *CategoryUsecaseTest) TestRestore_NotDeleted() {
+	suite.repo.On("Restore", "1", testAudit).Return(repository.ErrNotDeleted)
+
+	err := suite.usecase.Restore(testActor, "1")
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.StatusCode)
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/util/helper"
+	"fmt"
+)
+
+const (
+	defaultCursorLimit = 10
+	maxCursorLimit     = 100
+)
+
+// cursorRequest default the limit and decode the cursor of the previous page,
+// an empty cursor start from the first row
+func cursorRequest(payload dto.CursorRequest) (dto.CursorRequest, error) {
+	if payload.Limit <= 0 {
+		payload.Limit = defaultCursorLimit
+	}
+	if payload.Limit > maxCursorLimit {
+		return dto.CursorRequest{}, exception.BadRequestErr(fmt.Sprintf("limit cannot be more than %d", maxCursorLimit))
+	}
+	payload.After = ""
+	if payload.Cursor != "" {
+		after, err := helper.DecodeCursor(payload.Cursor)
+		if err != nil {
+			return dto.CursorRequest{}, exception.BadRequestErr(err.Error())
+		}
+		payload.After = after
+	}
+	return payload, nil
+}
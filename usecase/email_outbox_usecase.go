@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/repository"
+	"final-project-enigma-clean/util/emailtemplate"
+	"final-project-enigma-clean/util/helper"
+	"final-project-enigma-clean/util/mailer"
+	"fmt"
+	"time"
+
+	"github.com/gookit/slog"
+)
+
+const (
+	// OutboxMaxAttempts is how many times an email is tried before it is dead-lettered
+	OutboxMaxAttempts = 8
+
+	outboxBatchSize   = 10
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+	// a claimed email is retried by another worker when the lease expire,
+	// it must be longer than a whole batch of sends
+	outboxLease = 5 * time.Minute
+	mailTimeout = 15 * time.Second
+)
+
+type EmailOutboxUsecase interface {
+	ProcessDue(ctx context.Context) error
+	ListFailed(payload dto.PageRequest) ([]model.EmailOutbox, dto.Paging, error)
+	Resend(id string) error
+}
+
+type emailOutboxUsecase struct {
+	outboxRepo repository.EmailOutboxRepository
+	mailer     mailer.Mailer
+}
+
+// ProcessDue send the due emails, a failed send is retried with exponential backoff
+func (e *emailOutboxUsecase) ProcessDue(ctx context.Context) error {
+	emails, err := e.outboxRepo.ClaimDue(outboxBatchSize, outboxLease)
+	if err != nil {
+		return fmt.Errorf("failed to claim emails: %v", err)
+	}
+
+	for _, email := range emails {
+		if ctx.Err() != nil {
+			//unsent emails are picked up again when the lease expire
+			return ctx.Err()
+		}
+		if err = e.deliver(ctx, email); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *emailOutboxUsecase) deliver(ctx context.Context, email model.EmailOutbox) error {
+	sendCtx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+
+	sendErr := e.mailer.Send(sendCtx, mailer.Message{
+		To:      []string{email.Recipient},
+		Subject: email.Subject,
+		HTML:    email.Html,
+	})
+	if sendErr == nil {
+		return e.outboxRepo.MarkSent(email.Id)
+	}
+	if ctx.Err() != nil {
+		//worker is stopping, it is not the provider fault
+		return ctx.Err()
+	}
+
+	attempts := email.Attempts + 1
+	if attempts >= OutboxMaxAttempts {
+		slog.Errorf("email %s to %v dead-lettered after %d attempts: %v", email.Id, email.Recipient, attempts, sendErr)
+		return e.outboxRepo.MarkFailed(email.Id, attempts, sendErr.Error())
+	}
+	slog.Warnf("failed to send email %s to %v, attempt %d: %v", email.Id, email.Recipient, attempts, sendErr)
+	return e.outboxRepo.MarkRetry(email.Id, attempts, time.Now().Add(outboxBackoff(attempts)), sendErr.Error())
+}
+
+func (e *emailOutboxUsecase) ListFailed(payload dto.PageRequest) ([]model.EmailOutbox, dto.Paging, error) {
+	return e.outboxRepo.FindFailed(payload)
+}
+
+// Resend move a dead-lettered email back to the queue
+func (e *emailOutboxUsecase) Resend(id string) error {
+	err := e.outboxRepo.Requeue(id)
+	switch {
+	case errors.Is(err, repository.ErrOutboxNotFound):
+		return exception.NotFoundErr("failed email not found")
+	case errors.Is(err, repository.ErrOutboxSensitive):
+		return exception.BadRequestErr("email carry a one time code, it cannot be resent")
+	}
+	return err
+}
+
+// outboxBackoff double the wait on every attempt, 30s, 1m, 2m ... up to an hour
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return backoff
+}
+
+// outboxMessage render the event in the recipient locale into an outbox row, an otp email is
+// marked sensitive so its body is not kept once it is delivered
+func outboxMessage(to, event, locale string, data any) (model.EmailOutbox, error) {
+	subject, body, err := emailtemplate.Render(event, locale, data)
+	if err != nil {
+		return model.EmailOutbox{}, err
+	}
+	return model.EmailOutbox{
+		Id:        helper.GenerateUUID(),
+		Recipient: to,
+		Subject:   subject,
+		Html:      body,
+		Sensitive: emailtemplate.IsSensitive(event),
+		Status:    model.OutboxStatusPending,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+func NewEmailOutboxUsecase(outboxRepo repository.EmailOutboxRepository, mailer mailer.Mailer) EmailOutboxUsecase {
+	return &emailOutboxUsecase{
+		outboxRepo: outboxRepo,
+		mailer:     mailer,
+	}
+}
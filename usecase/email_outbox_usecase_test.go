@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"final-project-enigma-clean/__mock__/mailermock"
+	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/repository"
+	"final-project-enigma-clean/util/mailer"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type EmailOutboxUsecaseSuite struct {
+	suite.Suite
+	repo    *repomock.EmailOutboxRepoMock
+	mailer  *mailermock.MailerMock
+	usecase EmailOutboxUsecase
+}
+
+func (suite *EmailOutboxUsecaseSuite) SetupTest() {
+	suite.repo = new(repomock.EmailOutboxRepoMock)
+	suite.mailer = new(mailermock.MailerMock)
+	suite.usecase = NewEmailOutboxUsecase(suite.repo, suite.mailer)
+}
+
+func TestEmailOutboxUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(EmailOutboxUsecaseSuite))
+}
+
+func (suite *EmailOutboxUsecaseSuite) TestProcessDue_Sent() {
+	suite.repo.On("ClaimDue", outboxBatchSize, outboxLease).
+		Return([]model.EmailOutbox{{Id: "mail-1", Recipient: "a@example.com", Subject: "Hi", Html: "<p>hi</p>"}}, nil)
+	suite.mailer.On("Send", mock.Anything, mailer.Message{To: []string{"a@example.com"}, Subject: "Hi", HTML: "<p>hi</p>"}).Return(nil)
+	suite.repo.On("MarkSent", "mail-1").Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.ProcessDue(context.Background()))
+	suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *EmailOutboxUsecaseSuite) TestProcessDue_RetryWithBackoff() {
+	suite.repo.On("ClaimDue", outboxBatchSize, outboxLease).
+		Return([]model.EmailOutbox{{Id: "mail-1", Recipient: "a@example.com", Attempts: 2}}, nil)
+	suite.mailer.On("Send", mock.Anything, mock.AnythingOfType("mailer.Message")).Return(errors.New("provider down"))
+	before := time.Now()
+	suite.repo.On("MarkRetry", "mail-1", 3, mock.MatchedBy(func(next time.Time) bool {
+		return !next.Before(before.Add(2 * time.Minute))
+	}), "provider down").Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.ProcessDue(context.Background()))
+	suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *EmailOutboxUsecaseSuite) TestProcessDue_DeadLetter() {
+	suite.repo.On("ClaimDue", outboxBatchSize, outboxLease).
+		Return([]model.EmailOutbox{{Id: "mail-1", Attempts: OutboxMaxAttempts - 1}}, nil)
+	suite.mailer.On("Send", mock.Anything, mock.AnythingOfType("mailer.Message")).Return(errors.New("provider down"))
+	suite.repo.On("MarkFailed", "mail-1", OutboxMaxAttempts, "provider down").Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.ProcessDue(context.Background()))
+	suite.repo.AssertExpectations(suite.T())
+	suite.repo.AssertNotCalled(suite.T(), "MarkRetry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *EmailOutboxUsecaseSuite) TestProcessDue_ClaimFail() {
+	suite.repo.On("ClaimDue", outboxBatchSize, outboxLease).Return([]model.EmailOutbox(nil), errors.New("db down"))
+
+	assert.Error(suite.T(), suite.usecase.ProcessDue(context.Background()))
+	suite.mailer.AssertNotCalled(suite.T(), "Send", mock.Anything, mock.Anything)
+}
+
+func (suite *EmailOutboxUsecaseSuite) TestResend_NotFound() {
+	suite.repo.On("Requeue", "mail-1").Return(nil)
+	suite.repo.On("Requeue", "mail-2").Return(repository.ErrOutboxNotFound)
+
+	assert.NoError(suite.T(), suite.usecase.Resend("mail-1"))
+	err := suite.usecase.Resend("mail-2")
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.StatusCode)
+}
+
+func (suite *EmailOutboxUsecaseSuite) TestResend_Sensitive() {
+	suite.repo.On("Requeue", "mail-1").Return(repository.ErrOutboxSensitive)
+
+	err := suite.usecase.Resend("mail-1")
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusBadRequest, httpErr.StatusCode)
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, outboxBackoff(1))
+	assert.Equal(t, time.Minute, outboxBackoff(2))
+	assert.Equal(t, 4*time.Minute, outboxBackoff(4))
+	assert.Equal(t, time.Hour, outboxBackoff(20))
+}
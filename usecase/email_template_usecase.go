@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/util/emailtemplate"
+	"final-project-enigma-clean/util/mailer"
+	"fmt"
+)
+
+type EmailTemplateUsecase interface {
+	Events() []string
+	Preview(event, locale string) (mailer.Message, error)
+}
+
+type emailTemplateUsecase struct{}
+
+func (e *emailTemplateUsecase) Events() []string {
+	return emailtemplate.Events()
+}
+
+// Preview render the event with placeholder data
+func (e *emailTemplateUsecase) Preview(event, locale string) (mailer.Message, error) {
+	if locale != "" && !emailtemplate.IsValidLocale(locale) {
+		return mailer.Message{}, exception.BadRequestErr("locale must be id or en")
+	}
+
+	data, err := emailtemplate.SampleData(event)
+	if err != nil {
+		return mailer.Message{}, exception.BadRequestErr(fmt.Sprintf("event %s is not valid", event))
+	}
+	return emailtemplate.Message("preview@example.com", event, locale, data)
+}
+
+func NewEmailTemplateUsecase() EmailTemplateUsecase {
+	return &emailTemplateUsecase{}
+}
@@ -0,0 +1,24 @@
+package usecase_test
+
+import (
+	"final-project-enigma-clean/usecase"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailTemplatePreview_Success(t *testing.T) {
+	msg, err := usecase.NewEmailTemplateUsecase().Preview("overdue_reminder", "id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Pengingat peminjaman terlambat", msg.Subject)
+	assert.Contains(t, msg.HTML, "Laptop")
+}
+
+func TestEmailTemplatePreview_Invalid(t *testing.T) {
+	_, err := usecase.NewEmailTemplateUsecase().Preview("birthday", "en")
+	assert.Error(t, err)
+
+	_, err = usecase.NewEmailTemplateUsecase().Preview("welcome", "fr")
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"errors"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/util/spreadsheet"
+	"fmt"
+	"strings"
+)
+
+// maxImportRows keep a single upload from holding a transaction for too long
+const maxImportRows = 5000
+
+// importTable check the header of an uploaded file and the number of its rows
+func importTable(rows [][]string, columns ...string) (spreadsheet.Table, error) {
+	table := spreadsheet.NewTable(rows)
+	for _, column := range columns {
+		if !table.Has(column) {
+			return spreadsheet.Table{}, exception.BadRequestErr("missing column " + column)
+		}
+	}
+	if len(table.Rows) == 0 {
+		return spreadsheet.Table{}, exception.BadRequestErr("file has no rows")
+	}
+	if len(table.Rows) > maxImportRows {
+		return spreadsheet.Table{}, exception.BadRequestErr(fmt.Sprintf("file cannot have more than %d rows", maxImportRows))
+	}
+	return table, nil
+}
+
+// rowError keep the description of a validation error without its prefix
+func rowError(line int, err error) dto.ImportRowError {
+	var httpErr *exception.Http
+	if errors.As(err, &httpErr) {
+		return dto.ImportRowError{Row: line, Message: httpErr.Description}
+	}
+	return dto.ImportRowError{Row: line, Message: err.Error()}
+}
+
+// importRef resolve a cell holding either the id or the name of a reference
+type importRef struct {
+	entity string
+	ids    map[string]bool
+	names  map[string][]string
+}
+
+func newImportRef(entity string) importRef {
+	return importRef{entity: entity, ids: make(map[string]bool), names: make(map[string][]string)}
+}
+
+func (r importRef) add(id, name string) {
+	r.ids[id] = true
+	key := strings.ToLower(name)
+	r.names[key] = append(r.names[key], id)
+}
+
+// resolve return the id of the cell, a name shared by several rows must be given as the id
+func (r importRef) resolve(value string) (string, error) {
+	if value == "" || r.ids[value] {
+		return value, nil
+	}
+	ids := r.names[strings.ToLower(value)]
+	switch len(ids) {
+	case 0:
+		return "", exception.BadRequestErr(fmt.Sprintf("%s %s not found", r.entity, value))
+	case 1:
+		return ids[0], nil
+	}
+	return "", exception.BadRequestErr(fmt.Sprintf("%s name %s is ambiguous, use its id", r.entity, value))
+}
@@ -0,0 +1,487 @@
+package usecase
+
+import (
+	"errors"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/repository"
+	"final-project-enigma-clean/util/emailtemplate"
+	"final-project-enigma-clean/util/helper"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoanRequestUsecase is the approval workflow in front of a loan. A requester edit a draft and submit it,
+// an approver approve it, which reserve the stock, or reject it with a comment, and the approved request
+// is issued as a loan when the items are handed over. Every transition notify the people concerned
+type LoanRequestUsecase interface {
+	Create(actorId string, payload dto.LoanRequestRequest) (model.LoanRequest, error)
+	Update(actorId, id string, payload dto.LoanRequestRequest) error
+	FindAll(actorId, role string, query dto.LoanRequestQuery) ([]model.LoanRequest, error)
+	FindById(actorId, role, id string) (model.LoanRequest, error)
+	Submit(actorId, id string) error
+	Approve(actorId, id string, payload dto.LoanDecisionRequest) error
+	Reject(actorId, id string, payload dto.LoanDecisionRequest) error
+	Issue(actorId, id string, payload dto.LoanIssueRequest) (model.LoanRequest, error)
+	Return(actorId, id string, payload dto.LoanReturnRequest) error
+	Close(actorId, role, id string, payload dto.LoanDecisionRequest) error
+}
+
+type loanRequestUsecase struct {
+	repo     repository.LoanRequestRepository
+	staffUC  StaffUseCase
+	assetUC  AssetUsecase
+	unitUC   AssetUnitUsecase
+	manageUC ManageAssetUsecase
+	auditUC  AuditUsecase
+}
+
+// Create implements LoanRequestUsecase.
+// The request start as a draft of the actor
+func (l *loanRequestUsecase) Create(actorId string, payload dto.LoanRequestRequest) (model.LoanRequest, error) {
+	request, err := l.validate(payload)
+	if err != nil {
+		return model.LoanRequest{}, err
+	}
+	request.Id = helper.GenerateUUID()
+	request.Requester.ID = actorId
+	request.Status = model.LoanRequestDraft
+	request.CreatedAt = time.Now()
+	request.UpdatedAt = request.CreatedAt
+	audit, err := l.auditUC.Entry(actorId, model.AuditEntityLoanRequest, request.Id, model.AuditActionCreate, nil, request)
+	if err != nil {
+		return model.LoanRequest{}, err
+	}
+	if err = l.repo.Save(request, audit); err != nil {
+		return model.LoanRequest{}, fmt.Errorf("failed to save loan request: %v", err)
+	}
+	return request, nil
+}
+
+// Update implements LoanRequestUsecase.
+// Only the requester edit a request, and only while it is a draft
+func (l *loanRequestUsecase) Update(actorId, id string, payload dto.LoanRequestRequest) error {
+	before, err := l.find(id)
+	if err != nil {
+		return err
+	}
+	if before.Requester.ID != actorId {
+		return exception.ForbiddenErr("only the requester can edit a loan request")
+	}
+	if before.Status != model.LoanRequestDraft {
+		return exception.BadRequestErr(fmt.Sprintf("a %s loan request cannot be edited", before.Status))
+	}
+
+	request, err := l.validate(payload)
+	if err != nil {
+		return err
+	}
+	request.Id = id
+	request.Requester = before.Requester
+	request.Status = before.Status
+	request.CreatedAt = before.CreatedAt
+	request.UpdatedAt = time.Now()
+	audit, err := l.auditUC.Entry(actorId, model.AuditEntityLoanRequest, id, model.AuditActionUpdate, before, request)
+	if err != nil {
+		return err
+	}
+	if err = l.repo.Update(request, audit); err != nil {
+		return loanRequestErr(err)
+	}
+	return nil
+}
+
+// FindAll implements LoanRequestUsecase.
+// An approver see every request, anyone else only their own
+func (l *loanRequestUsecase) FindAll(actorId, role string, query dto.LoanRequestQuery) ([]model.LoanRequest, error) {
+	if query.Status != "" && !isLoanRequestStatus(query.Status) {
+		return nil, exception.BadRequestErr(fmt.Sprintf("unknown status %s", query.Status))
+	}
+	if !model.HasPermission(role, model.PermLoanApprove) {
+		query.RequesterId = actorId
+	}
+	requests, err := l.repo.FindAll(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get loan requests: %v", err)
+	}
+	return requests, nil
+}
+
+// FindById implements LoanRequestUsecase.
+func (l *loanRequestUsecase) FindById(actorId, role, id string) (model.LoanRequest, error) {
+	request, err := l.find(id)
+	if err != nil {
+		return model.LoanRequest{}, err
+	}
+	//the request of someone else is reported as missing, not as forbidden
+	if request.Requester.ID != actorId && !model.HasPermission(role, model.PermLoanApprove) {
+		return model.LoanRequest{}, exception.NotFoundErr("loan request not found")
+	}
+	return request, nil
+}
+
+// Submit implements LoanRequestUsecase.
+// The approvers are notified, except the requester when they are one
+func (l *loanRequestUsecase) Submit(actorId, id string) error {
+	request, err := l.find(id)
+	if err != nil {
+		return err
+	}
+	if request.Requester.ID != actorId {
+		return exception.ForbiddenErr("only the requester can submit a loan request")
+	}
+	if err = expectLoanRequest(request, model.LoanRequestDraft); err != nil {
+		return err
+	}
+
+	approvers, err := l.repo.FindApprovers(model.RolesWithPermission(model.PermLoanApprove))
+	if err != nil {
+		return fmt.Errorf("failed to find approvers: %v", err)
+	}
+	recipients := make([]model.UserCredentials, 0, len(approvers))
+	for _, approver := range approvers {
+		if approver.ID != actorId {
+			recipients = append(recipients, approver)
+		}
+	}
+	return l.transition(actorId, model.AuditActionSubmit, request, moved(actorId, request, model.LoanRequestSubmitted), recipients,
+		func(after model.LoanRequest, emails []model.EmailOutbox, audit model.AuditLog) error {
+			return l.repo.Submit(id, after.UpdatedAt, emails, audit)
+		})
+}
+
+// Approve implements LoanRequestUsecase.
+func (l *loanRequestUsecase) Approve(actorId, id string, payload dto.LoanDecisionRequest) error {
+	request, err := l.decidable(actorId, id)
+	if err != nil {
+		return err
+	}
+	after := moved(actorId, request, model.LoanRequestApproved)
+	after.Comment = strings.TrimSpace(payload.Comment)
+	return l.transition(actorId, model.AuditActionApprove, request, after, []model.UserCredentials{request.Requester},
+		func(after model.LoanRequest, emails []model.EmailOutbox, audit model.AuditLog) error {
+			return l.repo.Approve(id, actorId, after.Comment, after.UpdatedAt, emails, audit)
+		})
+}
+
+// Reject implements LoanRequestUsecase.
+// A rejection always tell the requester why
+func (l *loanRequestUsecase) Reject(actorId, id string, payload dto.LoanDecisionRequest) error {
+	payload.Comment = strings.TrimSpace(payload.Comment)
+	if payload.Comment == "" {
+		return exception.BadRequestErr("comment is required to reject a loan request")
+	}
+	request, err := l.decidable(actorId, id)
+	if err != nil {
+		return err
+	}
+	after := moved(actorId, request, model.LoanRequestRejected)
+	after.Comment = payload.Comment
+	return l.transition(actorId, model.AuditActionReject, request, after, []model.UserCredentials{request.Requester},
+		func(after model.LoanRequest, emails []model.EmailOutbox, audit model.AuditLog) error {
+			return l.repo.Reject(id, actorId, after.Comment, after.UpdatedAt, emails, audit)
+		})
+}
+
+// Issue implements LoanRequestUsecase.
+// The loan is created from the lines of the request, an asset tracked per unit is handed over by the
+// units named in the payload. The return date is counted from today
+func (l *loanRequestUsecase) Issue(actorId, id string, payload dto.LoanIssueRequest) (model.LoanRequest, error) {
+	request, err := l.find(id)
+	if err != nil {
+		return model.LoanRequest{}, err
+	}
+	if err = expectLoanRequest(request, model.LoanRequestApproved); err != nil {
+		return model.LoanRequest{}, err
+	}
+
+	lines := make(map[string]bool)
+	for _, detail := range request.Detail {
+		lines[detail.Id] = true
+	}
+	unitIds := make(map[string][]string)
+	for _, detail := range payload.Detail {
+		if !lines[detail.IdDetail] {
+			return model.LoanRequest{}, exception.BadRequestErr(fmt.Sprintf("detail %s not found in loan request", detail.IdDetail))
+		}
+		unitIds[detail.IdDetail] = detail.UnitIds
+	}
+	now := time.Now()
+	loan := dto.ManageAssetRequest{
+		Id:              helper.GenerateUUID(),
+		IdUser:          actorId,
+		NikStaff:        request.Staff.Nik_Staff,
+		SubmisstionDate: now,
+		ReturnDate:      now.AddDate(0, 0, request.Duration),
+		Duration:        request.Duration,
+	}
+	for _, detail := range request.Detail {
+		lend := dto.ManageAssetDetailRequest{
+			Id:        helper.GenerateUUID(),
+			IdAsset:   detail.Asset.Id,
+			TotalItem: detail.TotalItem,
+			Status:    model.DetailStatusBorrowed,
+			UnitIds:   unitIds[detail.Id],
+		}
+		//the units must match the approved quantity, it is the stock that was reserved
+		if lend, err = validateLendUnits(l.unitUC, lend); err != nil {
+			return model.LoanRequest{}, err
+		}
+		loan.ManageAssetDetailReq = append(loan.ManageAssetDetailReq, lend)
+	}
+
+	issued := moved(actorId, request, model.LoanRequestIssued)
+	issued.ManageAssetId = loan.Id
+	err = l.transition(actorId, model.AuditActionIssue, request, issued, []model.UserCredentials{request.Requester},
+		func(after model.LoanRequest, emails []model.EmailOutbox, audit model.AuditLog) error {
+			return l.repo.Issue(id, actorId, loan, after.UpdatedAt, emails, audit)
+		}, func(data *emailtemplate.LoanRequestData) { data.ReturnDate = loan.ReturnDate })
+	if err != nil {
+		return model.LoanRequest{}, err
+	}
+	return issued, nil
+}
+
+// Return implements LoanRequestUsecase.
+// The items are returned to the loan of the request, the request is returned with the last item.
+// Without return lines it only catch up with a loan that was returned directly
+func (l *loanRequestUsecase) Return(actorId, id string, payload dto.LoanReturnRequest) error {
+	request, err := l.find(id)
+	if err != nil {
+		return err
+	}
+	if err = expectLoanRequest(request, model.LoanRequestIssued); err != nil {
+		return err
+	}
+	if len(payload.ReturnDetailReq) > 0 {
+		err = l.manageUC.ReturnTransaction(dto.ReturnAssetRequest{
+			IdManageAsset:   request.ManageAssetId,
+			IdUser:          actorId,
+			ReturnDetailReq: payload.ReturnDetailReq,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	after := moved(actorId, request, model.LoanRequestReturned)
+	emails, err := loanRequestEmails(after, []model.UserCredentials{request.Requester})
+	if err != nil {
+		return err
+	}
+	audit, err := l.auditUC.Entry(actorId, model.AuditEntityLoanRequest, id, model.AuditActionReturn, request, after)
+	if err != nil {
+		return err
+	}
+	returned, err := l.repo.MarkReturned(id, after.UpdatedAt, emails, audit)
+	if err != nil {
+		return fmt.Errorf("failed to return loan request: %v", err)
+	}
+	if !returned && len(payload.ReturnDetailReq) == 0 {
+		return exception.BadRequestErr("the loan of this request still has items to return")
+	}
+	return nil
+}
+
+// Close implements LoanRequestUsecase.
+// The requester or an approver close a request that was not issued, an approved request give its stock back
+func (l *loanRequestUsecase) Close(actorId, role, id string, payload dto.LoanDecisionRequest) error {
+	request, err := l.find(id)
+	if err != nil {
+		return err
+	}
+	if request.Requester.ID != actorId && !model.HasPermission(role, model.PermLoanApprove) {
+		return exception.ForbiddenErr("only the requester or an approver can close a loan request")
+	}
+	if err = expectLoanRequest(request, model.LoanRequestDraft, model.LoanRequestSubmitted, model.LoanRequestApproved); err != nil {
+		return err
+	}
+
+	//a draft was never seen by anyone else, there is nobody to tell
+	var recipients []model.UserCredentials
+	if request.Status != model.LoanRequestDraft && request.Requester.ID != actorId {
+		recipients = append(recipients, request.Requester)
+	}
+	after := moved(actorId, request, model.LoanRequestClosed)
+	after.Comment = strings.TrimSpace(payload.Comment)
+	return l.transition(actorId, model.AuditActionClose, request, after, recipients,
+		func(after model.LoanRequest, emails []model.EmailOutbox, audit model.AuditLog) error {
+			return l.repo.Close(id, after.Comment, after.UpdatedAt, emails, audit)
+		})
+}
+
+// transition save the request as after, with the emails of the recipients and its audit entry
+func (l *loanRequestUsecase) transition(actorId, action string, before, after model.LoanRequest, recipients []model.UserCredentials,
+	save func(after model.LoanRequest, emails []model.EmailOutbox, audit model.AuditLog) error, options ...func(*emailtemplate.LoanRequestData)) error {
+	emails, err := loanRequestEmails(after, recipients, options...)
+	if err != nil {
+		return err
+	}
+	audit, err := l.auditUC.Entry(actorId, model.AuditEntityLoanRequest, before.Id, action, before, after)
+	if err != nil {
+		return err
+	}
+	if err = save(after, emails, audit); err != nil {
+		return loanRequestErr(err)
+	}
+	return nil
+}
+
+// moved is the request once the actor moved it to status
+func moved(actorId string, request model.LoanRequest, status string) model.LoanRequest {
+	request.Status = status
+	request.UpdatedAt = time.Now()
+	switch status {
+	case model.LoanRequestApproved, model.LoanRequestRejected:
+		request.DecidedBy, request.DecidedAt = actorId, &request.UpdatedAt
+	case model.LoanRequestIssued:
+		request.IssuedBy, request.IssuedAt = actorId, &request.UpdatedAt
+	}
+	return request
+}
+
+// decidable find a submitted request the actor may decide on, nobody approve their own request
+func (l *loanRequestUsecase) decidable(actorId, id string) (model.LoanRequest, error) {
+	request, err := l.find(id)
+	if err != nil {
+		return model.LoanRequest{}, err
+	}
+	if request.Requester.ID == actorId {
+		return model.LoanRequest{}, exception.ForbiddenErr("an approver cannot decide on their own loan request")
+	}
+	if err = expectLoanRequest(request, model.LoanRequestSubmitted); err != nil {
+		return model.LoanRequest{}, err
+	}
+	return request, nil
+}
+
+// validate check the staff and the lines of a request, an asset is requested on one line only
+func (l *loanRequestUsecase) validate(payload dto.LoanRequestRequest) (model.LoanRequest, error) {
+	if payload.NikStaff == "" {
+		return model.LoanRequest{}, exception.BadRequestErr("nik staff cannot empty")
+	}
+	if payload.Duration <= 0 {
+		return model.LoanRequest{}, exception.BadRequestErr("duration must be at least 1 day")
+	}
+	if len(payload.Detail) == 0 {
+		return model.LoanRequest{}, exception.BadRequestErr("detail cannot empty")
+	}
+	staff, err := l.staffUC.FindById(payload.NikStaff)
+	if err != nil {
+		return model.LoanRequest{}, err
+	}
+
+	request := model.LoanRequest{Staff: staff, Duration: payload.Duration, Note: strings.TrimSpace(payload.Note)}
+	seen := make(map[string]bool)
+	for _, detail := range payload.Detail {
+		if detail.IdAsset == "" {
+			return model.LoanRequest{}, exception.BadRequestErr("id asset cannot empty")
+		}
+		if detail.TotalItem <= 0 {
+			return model.LoanRequest{}, exception.BadRequestErr("total item must greater than 0")
+		}
+		if seen[detail.IdAsset] {
+			return model.LoanRequest{}, exception.BadRequestErr(fmt.Sprintf("asset %s requested more than once", detail.IdAsset))
+		}
+		seen[detail.IdAsset] = true
+
+		asset, err := l.assetUC.FindById(detail.IdAsset)
+		if err != nil {
+			return model.LoanRequest{}, err
+		}
+		request.Detail = append(request.Detail, model.LoanRequestDetail{
+			Id:        helper.GenerateUUID(),
+			Asset:     model.Asset{Id: asset.Id, Name: asset.Name},
+			TotalItem: detail.TotalItem,
+		})
+	}
+	return request, nil
+}
+
+func (l *loanRequestUsecase) find(id string) (model.LoanRequest, error) {
+	request, err := l.repo.FindById(id)
+	if err != nil {
+		return model.LoanRequest{}, loanRequestErr(err)
+	}
+	return request, nil
+}
+
+// expectLoanRequest report a request that is not in one of the statuses
+func expectLoanRequest(request model.LoanRequest, statuses ...string) error {
+	for _, status := range statuses {
+		if request.Status == status {
+			return nil
+		}
+	}
+	return exception.BadRequestErr(fmt.Sprintf("loan request is %s, it must be %s", request.Status, strings.Join(statuses, " or ")))
+}
+
+// loanRequestEmails tell every recipient with an email about the status of the request, in their own language
+func loanRequestEmails(request model.LoanRequest, recipients []model.UserCredentials, options ...func(*emailtemplate.LoanRequestData)) ([]model.EmailOutbox, error) {
+	items := make([]emailtemplate.LoanRequestItem, 0, len(request.Detail))
+	for _, detail := range request.Detail {
+		items = append(items, emailtemplate.LoanRequestItem{AssetName: detail.Asset.Name, Quantity: detail.TotalItem})
+	}
+
+	var emails []model.EmailOutbox
+	for _, recipient := range recipients {
+		if recipient.Email == "" {
+			continue
+		}
+		data := emailtemplate.LoanRequestData{
+			Name:      recipient.Name,
+			RequestID: request.Id,
+			Status:    request.Status,
+			Requester: request.Requester.Name,
+			StaffName: request.Staff.Name,
+			Comment:   request.Comment,
+			Items:     items,
+		}
+		for _, option := range options {
+			option(&data)
+		}
+		email, err := outboxMessage(recipient.Email, emailtemplate.EventLoanRequest, recipient.Locale, data)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	return emails, nil
+}
+
+func isLoanRequestStatus(status string) bool {
+	switch status {
+	case model.LoanRequestDraft, model.LoanRequestSubmitted, model.LoanRequestApproved, model.LoanRequestRejected,
+		model.LoanRequestIssued, model.LoanRequestReturned, model.LoanRequestClosed:
+		return true
+	}
+	return false
+}
+
+func loanRequestErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrLoanRequestNotFound):
+		return exception.NotFoundErr("loan request not found")
+	case errors.Is(err, repository.ErrLoanRequestState):
+		return exception.BadRequestErr("loan request was changed meanwhile, reload it")
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return exception.BadRequestErr("Barang tidak cukup")
+	case errors.Is(err, repository.ErrUnitNotAvailable):
+		return exception.BadRequestErr(err.Error())
+	}
+	return err
+}
+
+func NewLoanRequestUsecase(repo repository.LoanRequestRepository, staffUC StaffUseCase, assetUC AssetUsecase, unitUC AssetUnitUsecase,
+	manageUC ManageAssetUsecase, auditUC AuditUsecase) LoanRequestUsecase {
+	return &loanRequestUsecase{
+		repo:     repo,
+		staffUC:  staffUC,
+		assetUC:  assetUC,
+		unitUC:   unitUC,
+		manageUC: manageUC,
+		auditUC:  auditUC,
+	}
+}
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/repository"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoanRequestUsecaseSuite struct {
+	suite.Suite
+	repo     *repomock.LoanRequestRepoMock
+	staffUC  *usecasemock.StaffUsecaseMock
+	assetUC  *usecasemock.AssetUsecaseMock
+	unitUC   *usecasemock.AssetUnitUsecaseMock
+	manageUC *usecasemock.ManageAssetsMock
+	auditUC  *usecasemock.AuditUsecaseMock
+	usecase  LoanRequestUsecase
+}
+
+const testApprover = "user-2"
+
+func loanRequest(status string) model.LoanRequest {
+	return model.LoanRequest{
+		Id:        "r1",
+		Requester: model.UserCredentials{ID: testActor, Name: "Stephanie", Email: "stephanie@mail.com"},
+		Staff:     model.Staff{Nik_Staff: "1001", Name: "Budi"},
+		Duration:  3,
+		Status:    status,
+		Detail:    []model.LoanRequestDetail{{Id: "d1", Asset: model.Asset{Id: "a1", Name: "Kursi"}, TotalItem: 2}},
+	}
+}
+
+func (suite *LoanRequestUsecaseSuite) SetupTest() {
+	suite.repo = new(repomock.LoanRequestRepoMock)
+	suite.staffUC = new(usecasemock.StaffUsecaseMock)
+	suite.assetUC = new(usecasemock.AssetUsecaseMock)
+	suite.unitUC = new(usecasemock.AssetUnitUsecaseMock)
+	suite.manageUC = new(usecasemock.ManageAssetsMock)
+	suite.auditUC = new(usecasemock.AuditUsecaseMock)
+	suite.auditUC.On("Entry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testAudit, nil).Maybe()
+	suite.usecase = NewLoanRequestUsecase(suite.repo, suite.staffUC, suite.assetUC, suite.unitUC, suite.manageUC, suite.auditUC)
+}
+
+func TestLoanRequestUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(LoanRequestUsecaseSuite))
+}
+
+// sentTo match emails sent to exactly the recipients
+func sentTo(recipients ...string) any {
+	return mock.MatchedBy(func(emails []model.EmailOutbox) bool {
+		if len(emails) != len(recipients) {
+			return false
+		}
+		for i, email := range emails {
+			if email.Recipient != recipients[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (suite *LoanRequestUsecaseSuite) TestCreate_Invalid() {
+	for _, payload := range []dto.LoanRequestRequest{
+		{Duration: 3, Detail: []dto.LoanRequestDetailRequest{{IdAsset: "a1", TotalItem: 1}}},
+		{NikStaff: "1001", Detail: []dto.LoanRequestDetailRequest{{IdAsset: "a1", TotalItem: 1}}},
+		{NikStaff: "1001", Duration: 3},
+	} {
+		_, err := suite.usecase.Create(testActor, payload)
+		assert.IsType(suite.T(), &exception.Http{}, err)
+	}
+	suite.repo.AssertNotCalled(suite.T(), "Save", mock.Anything, mock.Anything)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestCreate_DuplicateAsset() {
+	suite.staffUC.On("FindById", "1001").Return(model.Staff{Nik_Staff: "1001"}, nil)
+	suite.assetUC.On("FindById", "a1").Return(model.Asset{Id: "a1", Name: "Kursi"}, nil)
+
+	_, err := suite.usecase.Create(testActor, dto.LoanRequestRequest{NikStaff: "1001", Duration: 3, Detail: []dto.LoanRequestDetailRequest{
+		{IdAsset: "a1", TotalItem: 1}, {IdAsset: "a1", TotalItem: 2},
+	}})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestCreate_Draft() {
+	suite.staffUC.On("FindById", "1001").Return(model.Staff{Nik_Staff: "1001", Name: "Budi"}, nil)
+	suite.assetUC.On("FindById", "a1").Return(model.Asset{Id: "a1", Name: "Kursi"}, nil)
+	suite.repo.On("Save", mock.MatchedBy(func(request model.LoanRequest) bool {
+		return request.Id != "" && request.Requester.ID == testActor && request.Status == model.LoanRequestDraft &&
+			len(request.Detail) == 1 && request.Detail[0].Id != "" && request.Detail[0].TotalItem == 2
+	}), testAudit).Return(nil)
+
+	request, err := suite.usecase.Create(testActor, dto.LoanRequestRequest{NikStaff: "1001", Duration: 3,
+		Detail: []dto.LoanRequestDetailRequest{{IdAsset: "a1", TotalItem: 2}}})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.LoanRequestDraft, request.Status)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestFindAll_OwnRequests() {
+	suite.repo.On("FindAll", dto.LoanRequestQuery{RequesterId: testActor}).Return([]model.LoanRequest{loanRequest(model.LoanRequestDraft)}, nil)
+
+	requests, err := suite.usecase.FindAll(testActor, model.RoleViewer, dto.LoanRequestQuery{})
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), requests, 1)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestFindById_OtherRequester() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestDraft), nil)
+
+	_, err := suite.usecase.FindById("user-3", model.RoleViewer, "r1")
+	assert.Equal(suite.T(), http.StatusNotFound, err.(*exception.Http).StatusCode)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestSubmit_NotifyApprovers() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestDraft), nil)
+	suite.repo.On("FindApprovers", []string{model.RoleAdmin, model.RoleAssetManager}).Return([]model.UserCredentials{
+		{ID: testActor, Email: "stephanie@mail.com"},
+		{ID: testApprover, Name: "Andi", Email: "andi@mail.com", Locale: "id"},
+		{ID: "user-3", Name: "Rina"},
+	}, nil)
+	suite.repo.On("Submit", "r1", mock.Anything, sentTo("andi@mail.com"), testAudit).Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.Submit(testActor, "r1"))
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityLoanRequest, "r1", model.AuditActionSubmit, mock.Anything, mock.Anything)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestSubmit_NotDraft() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestApproved), nil)
+
+	err := suite.usecase.Submit(testActor, "r1")
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+	suite.repo.AssertNotCalled(suite.T(), "Submit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestApprove_OwnRequest() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestSubmitted), nil)
+
+	err := suite.usecase.Approve(testActor, "r1", dto.LoanDecisionRequest{})
+	assert.Equal(suite.T(), http.StatusForbidden, err.(*exception.Http).StatusCode)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestApprove_InsufficientStock() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestSubmitted), nil)
+	suite.repo.On("Approve", "r1", testApprover, "ok", mock.Anything, sentTo("stephanie@mail.com"), testAudit).Return(repository.ErrInsufficientStock)
+
+	err := suite.usecase.Approve(testApprover, "r1", dto.LoanDecisionRequest{Comment: " ok "})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestReject_WithoutComment() {
+	err := suite.usecase.Reject(testApprover, "r1", dto.LoanDecisionRequest{Comment: " "})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+	suite.repo.AssertNotCalled(suite.T(), "FindById", mock.Anything)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestReject_Success() {
+	before := loanRequest(model.LoanRequestSubmitted)
+	suite.repo.On("FindById", "r1").Return(before, nil)
+	suite.repo.On("Reject", "r1", testApprover, "out of stock", mock.Anything, sentTo("stephanie@mail.com"), testAudit).Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.Reject(testApprover, "r1", dto.LoanDecisionRequest{Comment: "out of stock"}))
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testApprover, model.AuditEntityLoanRequest, "r1", model.AuditActionReject, before,
+		mock.MatchedBy(func(after model.LoanRequest) bool {
+			return after.Status == model.LoanRequestRejected && after.Comment == "out of stock" && after.DecidedBy == testApprover
+		}))
+}
+
+func (suite *LoanRequestUsecaseSuite) TestIssue_Success() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestApproved), nil)
+	suite.unitUC.On("FindByAsset", "a1").Return([]model.AssetUnit{}, nil)
+	suite.repo.On("Issue", "r1", testApprover, mock.MatchedBy(func(loan dto.ManageAssetRequest) bool {
+		return loan.Id != "" && loan.IdUser == testApprover && loan.NikStaff == "1001" && len(loan.ManageAssetDetailReq) == 1 &&
+			loan.ManageAssetDetailReq[0].IdAsset == "a1" && loan.ManageAssetDetailReq[0].TotalItem == 2 &&
+			loan.ReturnDate.Sub(loan.SubmisstionDate).Hours() == 72
+	}), mock.Anything, sentTo("stephanie@mail.com"), testAudit).Return(nil)
+
+	issued, err := suite.usecase.Issue(testApprover, "r1", dto.LoanIssueRequest{})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), model.LoanRequestIssued, issued.Status)
+	assert.NotEmpty(suite.T(), issued.ManageAssetId)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestIssue_UnknownDetail() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestApproved), nil)
+
+	_, err := suite.usecase.Issue(testApprover, "r1", dto.LoanIssueRequest{Detail: []dto.LoanIssueDetailRequest{{IdDetail: "x"}}})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+	suite.repo.AssertNotCalled(suite.T(), "Issue", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestReturn_Partial() {
+	request := loanRequest(model.LoanRequestIssued)
+	request.ManageAssetId = "m1"
+	returns := []dto.ReturnAssetDetailRequest{{IdDetail: "md1", TotalItem: 1}}
+	suite.repo.On("FindById", "r1").Return(request, nil)
+	suite.manageUC.On("ReturnTransaction", dto.ReturnAssetRequest{IdManageAsset: "m1", IdUser: testApprover, ReturnDetailReq: returns}).Return(nil)
+	suite.repo.On("MarkReturned", "r1", mock.Anything, sentTo("stephanie@mail.com"), testAudit).Return(false, nil)
+
+	assert.NoError(suite.T(), suite.usecase.Return(testApprover, "r1", dto.LoanReturnRequest{ReturnDetailReq: returns}))
+	suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *LoanRequestUsecaseSuite) TestClose_ByOtherViewer() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestSubmitted), nil)
+
+	err := suite.usecase.Close("user-3", model.RoleViewer, "r1", dto.LoanDecisionRequest{})
+	assert.Equal(suite.T(), http.StatusForbidden, err.(*exception.Http).StatusCode)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestClose_ByApprover() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestApproved), nil)
+	suite.repo.On("Close", "r1", "not picked up", mock.Anything, sentTo("stephanie@mail.com"), testAudit).Return(nil)
+
+	err := suite.usecase.Close(testApprover, model.RoleAssetManager, "r1", dto.LoanDecisionRequest{Comment: "not picked up"})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *LoanRequestUsecaseSuite) TestClose_Issued() {
+	suite.repo.On("FindById", "r1").Return(loanRequest(model.LoanRequestIssued), nil)
+
+	err := suite.usecase.Close(testActor, model.RoleViewer, "r1", dto.LoanDecisionRequest{})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+}
@@ -1,27 +1,45 @@
 package usecase
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"final-project-enigma-clean/repository"
+	"final-project-enigma-clean/util/emailtemplate"
 	"final-project-enigma-clean/util/helper"
+	"final-project-enigma-clean/util/receipt"
 	"fmt"
+	"math"
+	"sort"
 	"time"
 )
 
 type ManageAssetUsecase interface {
 	CreateTransaction(payload dto.ManageAssetRequest) error
+	ReturnTransaction(payload dto.ReturnAssetRequest) error
 	ShowAllAsset() ([]model.ManageAsset, error)
 	FindByTransactionID(id string) ([]model.ManageAsset, error)
 	FindTransactionByName(name string) ([]model.ManageAsset, error)
 	DownloadAssets() ([]byte, error)
+	Receipt(id string) ([]byte, error)
+	ExportDetails(filter dto.TransactionExportRequest, each func(model.TransactionDetailRow) error) error
+	FindOpenDetails(filter dto.OpenDetailRequest) ([]model.ManageDetailAsset, error)
+	FindOverdue() ([]model.OverdueTransaction, error)
+	RemindOverdue(ctx context.Context) error
 }
 
+// OverdueReminderInterval is how long to wait before reminding the same overdue loan again
+const OverdueReminderInterval = 24 * time.Hour
+
 type manageAssetUsecase struct {
 	repo    repository.ManageAssetRepository
 	staffUC StaffUseCase
 	assetUC AssetUsecase
+	unitUC  AssetUnitUsecase
+	auditUC AuditUsecase
 }
 
 // FindTransactionByName implements ManageAssetUsecase.
@@ -81,7 +99,10 @@ func (m *manageAssetUsecase) CreateTransaction(payload dto.ManageAssetRequest) e
 		if err != nil {
 			return err
 		}
-		//valdiation asset amount available or not
+		if detail, err = validateLendUnits(m.unitUC, detail); err != nil {
+			return err
+		}
+		//fail fast when stock is clearly not enough, the repository does the authoritative check
 		if asset.Available < detail.TotalItem {
 			return exception.BadRequestErr("Barang tidak cukup")
 		}
@@ -101,21 +122,124 @@ func (m *manageAssetUsecase) CreateTransaction(payload dto.ManageAssetRequest) e
 	//comment time.now if you want to run unit testing
 	payload.SubmisstionDate = time.Now()
 	payload.ReturnDate = payload.SubmisstionDate.AddDate(0, 0, payload.Duration)
-	err = m.repo.CreateTransaction(payload)
+	audit, err := m.auditUC.Entry(payload.IdUser, model.AuditEntityTransaction, payload.Id, model.AuditActionCreate, nil, payload)
+	if err != nil {
+		return err
+	}
+	//stock is checked and reserved inside the same db transaction
+	err = m.repo.CreateTransaction(payload, audit)
 	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return exception.BadRequestErr("Barang tidak cukup")
+		}
+		if errors.Is(err, repository.ErrUnitNotAvailable) {
+			return exception.BadRequestErr(err.Error())
+		}
 		return fmt.Errorf(err.Error())
 	}
-	//update amount of asset when success
-	for _, detail := range payload.ManageAssetDetailReq {
-		err = m.assetUC.UpdateAvailable(detail.IdAsset, detail.TotalItem)
-		if err != nil {
+	return nil
+}
+
+// ReturnTransaction implements ManageAssetUsecase.
+func (m *manageAssetUsecase) ReturnTransaction(payload dto.ReturnAssetRequest) error {
+	if payload.IdManageAsset == "" {
+		return exception.BadRequestErr("id manage asset cannot empty")
+	}
+	if len(payload.ReturnDetailReq) == 0 {
+		return exception.BadRequestErr("return detail cannot empty")
+	}
+
+	transactions, transactionDetails, err := m.repo.FindAllByTransId(payload.IdManageAsset)
+	if err != nil {
+		return err
+	}
+	if len(transactions) == 0 {
+		return exception.BadRequestErr("transaction not found")
+	}
+
+	detailMap := make(map[string]model.ManageDetailAsset)
+	for _, detail := range transactionDetails {
+		if detail.ManageAssetId == payload.IdManageAsset {
+			detailMap[detail.Id] = detail
+		}
+	}
+
+	units, err := m.unitUC.FindByTransaction(payload.IdManageAsset)
+	if err != nil {
+		return err
+	}
+	//units still on loan, grouped by detail
+	onLoan := make(map[string]map[string]bool)
+	for _, unit := range units {
+		if onLoan[unit.DetailId] == nil {
+			onLoan[unit.DetailId] = make(map[string]bool)
+		}
+		if unit.ReturnedAt == nil {
+			onLoan[unit.DetailId][unit.Id] = true
+		}
+	}
+
+	//looping for validation request return detail
+	seen := make(map[string]bool)
+	returnDetails := make([]dto.ReturnAssetDetailRequest, 0, len(payload.ReturnDetailReq))
+	for _, ret := range payload.ReturnDetailReq {
+		if ret.IdDetail == "" {
+			return exception.BadRequestErr("id detail cannot empty")
+		}
+		if ret, err = validateReturnUnits(ret, onLoan); err != nil {
 			return err
 		}
+		returnDetails = append(returnDetails, ret)
+		if ret.TotalItem <= 0 {
+			return exception.BadRequestErr("total item must greater than 0")
+		}
+		if seen[ret.IdDetail] {
+			return exception.BadRequestErr(fmt.Sprintf("detail %s returned more than once", ret.IdDetail))
+		}
+		seen[ret.IdDetail] = true
+
+		detail, ok := detailMap[ret.IdDetail]
+		if !ok {
+			return exception.BadRequestErr(fmt.Sprintf("detail %s not found in transaction", ret.IdDetail))
+		}
+		//validation item still borrowed
+		if ret.TotalItem > detail.TotalItem-detail.TotalReturned {
+			return exception.BadRequestErr(fmt.Sprintf("total item for detail %s exceeds borrowed item", ret.IdDetail))
+		}
 	}
 
+	payload.ReturnDetailReq = returnDetails
+	payload.ReturnDate = time.Now()
+	audit, err := m.auditUC.Entry(payload.IdUser, model.AuditEntityTransaction, payload.IdManageAsset, model.AuditActionReturn,
+		returnedDetails(detailMap, nil), returnedDetails(detailMap, payload.ReturnDetailReq))
+	if err != nil {
+		return err
+	}
+	err = m.repo.ReturnTransaction(payload, audit)
+	if err != nil {
+		if errors.Is(err, repository.ErrReturnExceedsLoan) || errors.Is(err, repository.ErrUnitNotOnLoan) {
+			return exception.BadRequestErr(err.Error())
+		}
+		return fmt.Errorf("failed return transaction, %s", err)
+	}
 	return nil
 }
 
+// returnedDetails list the details of a transaction with the returns applied
+func returnedDetails(detailMap map[string]model.ManageDetailAsset, returns []dto.ReturnAssetDetailRequest) []model.ManageDetailAsset {
+	returned := make(map[string]int)
+	for _, ret := range returns {
+		returned[ret.IdDetail] += ret.TotalItem
+	}
+	details := make([]model.ManageDetailAsset, 0, len(detailMap))
+	for _, detail := range detailMap {
+		detail.TotalReturned += returned[detail.Id]
+		details = append(details, detail)
+	}
+	sort.Slice(details, func(i, j int) bool { return details[i].Id < details[j].Id })
+	return details
+}
+
 func (m *manageAssetUsecase) ShowAllAsset() ([]model.ManageAsset, error) {
 	//TODO implement me
 	return m.repo.FindAllTransaction()
@@ -149,9 +273,96 @@ func (m *manageAssetUsecase) FindByTransactionID(id string) ([]model.ManageAsset
 			datas = append(datas, transaction)
 		}
 	}
+
+	//name the units lent in each detail
+	units, err := m.unitUC.FindByTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range datas {
+		for i := range transaction.Detail {
+			for _, unit := range units {
+				if unit.DetailId == transaction.Detail[i].Id {
+					transaction.Detail[i].Units = append(transaction.Detail[i].Units, unit)
+				}
+			}
+		}
+	}
 	return datas, nil
 }
 
+// validateLendUnits check the named units of a loan, an asset with units must be lent by unit
+// while a bulk asset without serials keep being lent by count
+func validateLendUnits(unitUC AssetUnitUsecase, detail dto.ManageAssetDetailRequest) (dto.ManageAssetDetailRequest, error) {
+	units, err := unitUC.FindByAsset(detail.IdAsset)
+	if err != nil {
+		return detail, err
+	}
+	if len(units) == 0 {
+		if len(detail.UnitIds) > 0 {
+			return detail, exception.BadRequestErr(fmt.Sprintf("asset %s has no units", detail.IdAsset))
+		}
+		return detail, nil
+	}
+
+	if len(detail.UnitIds) == 0 {
+		return detail, exception.BadRequestErr(fmt.Sprintf("asset %s is tracked per unit, unit ids cannot empty", detail.IdAsset))
+	}
+	if detail.TotalItem != 0 && detail.TotalItem != len(detail.UnitIds) {
+		return detail, exception.BadRequestErr("total item must equal the number of units")
+	}
+
+	unitMap := make(map[string]model.AssetUnit)
+	for _, unit := range units {
+		unitMap[unit.Id] = unit
+	}
+	seen := make(map[string]bool)
+	for _, unitId := range detail.UnitIds {
+		if seen[unitId] {
+			return detail, exception.BadRequestErr(fmt.Sprintf("unit %s listed more than once", unitId))
+		}
+		seen[unitId] = true
+
+		unit, ok := unitMap[unitId]
+		if !ok {
+			return detail, exception.BadRequestErr(fmt.Sprintf("unit %s not found in asset %s", unitId, detail.IdAsset))
+		}
+		if unit.Status != model.UnitStatusAvailable {
+			return detail, exception.BadRequestErr(fmt.Sprintf("unit %s is %s", unit.SerialNumber, unit.Status))
+		}
+	}
+	detail.TotalItem = len(detail.UnitIds)
+	return detail, nil
+}
+
+// validateReturnUnits check the named units are still borrowed in the detail,
+// a detail lent by unit must be returned by unit
+func validateReturnUnits(ret dto.ReturnAssetDetailRequest, onLoan map[string]map[string]bool) (dto.ReturnAssetDetailRequest, error) {
+	borrowed, tracked := onLoan[ret.IdDetail]
+	if !tracked {
+		if len(ret.UnitIds) > 0 {
+			return ret, exception.BadRequestErr(fmt.Sprintf("detail %s was not lent by unit", ret.IdDetail))
+		}
+		return ret, nil
+	}
+
+	if len(ret.UnitIds) == 0 {
+		return ret, exception.BadRequestErr(fmt.Sprintf("detail %s is lent by unit, unit ids cannot empty", ret.IdDetail))
+	}
+	if ret.TotalItem != 0 && ret.TotalItem != len(ret.UnitIds) {
+		return ret, exception.BadRequestErr("total item must equal the number of units")
+	}
+	seen := make(map[string]bool)
+	for _, unitId := range ret.UnitIds {
+		if seen[unitId] || !borrowed[unitId] {
+			return ret, exception.BadRequestErr(fmt.Sprintf("unit %s is not borrowed in detail %s", unitId, ret.IdDetail))
+		}
+		seen[unitId] = true
+	}
+	ret.TotalItem = len(ret.UnitIds)
+	return ret, nil
+}
+
 func (m *manageAssetUsecase) DownloadAssets() ([]byte, error) {
 	//TODO implement me
 	assets, err := m.repo.FindAllTransaction()
@@ -164,10 +375,146 @@ func (m *manageAssetUsecase) DownloadAssets() ([]byte, error) {
 	return csvData, nil
 }
 
-func NewManageAssetUsecase(repo repository.ManageAssetRepository, staffUC StaffUseCase, assetUC AssetUsecase) ManageAssetUsecase {
+// Receipt render the handover form of a loan as a pdf
+func (m *manageAssetUsecase) Receipt(id string) ([]byte, error) {
+	transactions, err := m.FindByTransactionID(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(transactions) == 0 {
+		return nil, exception.NotFoundErr("transaction not found")
+	}
+
+	var buf bytes.Buffer
+	if err = receipt.Write(&buf, transactions[0], time.Now()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportDetails hand every detail line of the loans matching the filter to each, without loading them all
+func (m *manageAssetUsecase) ExportDetails(filter dto.TransactionExportRequest, each func(model.TransactionDetailRow) error) error {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return exception.BadRequestErr("to cannot be before from")
+	}
+	return m.repo.ExportDetails(filter, each)
+}
+
+// FindOpenDetails list the detail lines of an asset with item still borrowed, the oldest loan first
+func (m *manageAssetUsecase) FindOpenDetails(filter dto.OpenDetailRequest) ([]model.ManageDetailAsset, error) {
+	if filter.AssetId == "" {
+		return nil, exception.BadRequestErr("id asset cannot empty")
+	}
+	return m.repo.FindOpenDetails(filter)
+}
+
+// FindOverdue list loans past the return date with the unreturned items
+func (m *manageAssetUsecase) FindOverdue() ([]model.OverdueTransaction, error) {
+	return m.findOverdue(time.Now())
+}
+
+func (m *manageAssetUsecase) findOverdue(now time.Time) ([]model.OverdueTransaction, error) {
+	transactions, transactionDetails, err := m.repo.FindOverdue(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find overdue transaction: %v", err)
+	}
+
+	detailMap := make(map[string][]model.ManageDetailAsset)
+	for _, detail := range transactionDetails {
+		detailMap[detail.ManageAssetId] = append(detailMap[detail.ManageAssetId], detail)
+	}
+
+	datas := make([]model.OverdueTransaction, 0, len(transactions))
+	for _, transaction := range transactions {
+		transaction.Detail = detailMap[transaction.Id]
+		transaction.DaysOverdue = daysOverdue(transaction.ReturnDate, now)
+		datas = append(datas, transaction)
+	}
+	return datas, nil
+}
+
+// RemindOverdue mark the overdue loans and email the staff and the user who issued the loan,
+// a loan is reminded again every OverdueReminderInterval until it is returned
+func (m *manageAssetUsecase) RemindOverdue(ctx context.Context) error {
+	now := time.Now()
+	transactions, err := m.findOverdue(now)
+	if err != nil {
+		return err
+	}
+
+	remindedBefore := now.Add(-OverdueReminderInterval)
+	for _, transaction := range transactions {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if transaction.RemindedAt != nil && transaction.RemindedAt.After(remindedBefore) {
+			continue
+		}
+
+		emails, err := overdueEmails(transaction)
+		if err != nil {
+			return err
+		}
+		//false mean it was returned or reminded meanwhile, nothing to do
+		if _, err = m.repo.MarkOverdue(transaction.Id, now, remindedBefore, emails); err != nil {
+			return fmt.Errorf("failed to mark transaction %s overdue: %v", transaction.Id, err)
+		}
+	}
+	return nil
+}
+
+// one reminder for the borrowing staff and one for the issuing user, in their own language
+func overdueEmails(transaction model.OverdueTransaction) ([]model.EmailOutbox, error) {
+	items := make([]emailtemplate.OverdueItem, 0, len(transaction.Detail))
+	for _, detail := range transaction.Detail {
+		items = append(items, emailtemplate.OverdueItem{
+			AssetName: detail.Asset.Name,
+			Remaining: detail.TotalItem - detail.TotalReturned,
+		})
+	}
+	data := func(name string) emailtemplate.OverdueData {
+		return emailtemplate.OverdueData{
+			Name:          name,
+			TransactionID: transaction.Id,
+			ReturnDate:    transaction.ReturnDate,
+			DaysOverdue:   transaction.DaysOverdue,
+			Items:         items,
+		}
+	}
+
+	var emails []model.EmailOutbox
+	//staff registered before email was added have none
+	if transaction.Staff.Email != "" {
+		email, err := outboxMessage(transaction.Staff.Email, emailtemplate.EventOverdueReminder, emailtemplate.DefaultLocale, data(transaction.Staff.Name))
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	if transaction.User.Email != "" {
+		email, err := outboxMessage(transaction.User.Email, emailtemplate.EventOverdueReminder, transaction.User.Locale, data(transaction.User.Name))
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+	return emails, nil
+}
+
+// a loan that is one hour late is one day overdue
+func daysOverdue(returnDate, now time.Time) int {
+	if !now.After(returnDate) {
+		return 0
+	}
+	return int(math.Ceil(now.Sub(returnDate).Hours() / 24))
+}
+
+func NewManageAssetUsecase(repo repository.ManageAssetRepository, staffUC StaffUseCase, assetUC AssetUsecase, unitUC AssetUnitUsecase, auditUC AuditUsecase) ManageAssetUsecase {
 	return &manageAssetUsecase{
 		repo:    repo,
 		staffUC: staffUC,
 		assetUC: assetUC,
+		unitUC:  unitUC,
+		auditUC: auditUC,
 	}
 }
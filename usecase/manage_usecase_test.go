@@ -1,15 +1,21 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"final-project-enigma-clean/__mock__/repomock"
 	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/repository"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -18,6 +24,8 @@ type ManageAssetUsecaseTestSuite struct {
 	staffUC  *usecasemock.StaffUsecaseMock
 	assetUC  *usecasemock.AssetUsecaseMock
 	repoMock *repomock.ManageAssetRepoMock
+	unitUC   *usecasemock.AssetUnitUsecaseMock
+	auditUC  *usecasemock.AuditUsecaseMock
 	usecase  ManageAssetUsecase
 }
 
@@ -25,7 +33,16 @@ func (suite *ManageAssetUsecaseTestSuite) SetupTest() {
 	suite.staffUC = new(usecasemock.StaffUsecaseMock)
 	suite.assetUC = new(usecasemock.AssetUsecaseMock)
 	suite.repoMock = new(repomock.ManageAssetRepoMock)
-	suite.usecase = NewManageAssetUsecase(suite.repoMock, suite.staffUC, suite.assetUC)
+	suite.unitUC = new(usecasemock.AssetUnitUsecaseMock)
+	suite.auditUC = new(usecasemock.AuditUsecaseMock)
+	suite.auditUC.On("Entry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testAudit, nil).Maybe()
+	suite.usecase = NewManageAssetUsecase(suite.repoMock, suite.staffUC, suite.assetUC, suite.unitUC, suite.auditUC)
+}
+
+// bulk assets without serial numbers, lent and returned by count
+func (suite *ManageAssetUsecaseTestSuite) withoutUnits() {
+	suite.unitUC.On("FindByAsset", mock.Anything).Return([]model.AssetUnit{}, nil).Maybe()
+	suite.unitUC.On("FindByTransaction", mock.Anything).Return([]model.AssetUnit{}, nil).Maybe()
 }
 
 func TestManageAssetUsecaseTestSuite(t *testing.T) {
@@ -33,6 +50,7 @@ func TestManageAssetUsecaseTestSuite(t *testing.T) {
 }
 
 func (suite *ManageAssetUsecaseTestSuite) TestTransaction_Success() {
+	suite.withoutUnits()
 
 	mockData := dto.ManageAssetRequest{
 		Id:       "1",
@@ -82,15 +100,13 @@ func (suite *ManageAssetUsecaseTestSuite) TestTransaction_Success() {
 	}
 
 	suite.staffUC.On("FindById", "1").Return(staffMock, nil)
-	suite.repoMock.On("CreateTransaction", mockData).Return(nil)
-	for _, detail := range mockData.ManageAssetDetailReq {
-		suite.assetUC.On("UpdateAvailable", detail.IdAsset, detail.TotalItem).Return(nil)
-	}
+	suite.repoMock.On("CreateTransaction", mockData, testAudit).Return(nil)
 	err := suite.usecase.CreateTransaction(mockData)
 	assert.NoError(suite.T(), err)
 }
 
 func (suite *ManageAssetUsecaseTestSuite) TestTransaction_Failed() {
+	suite.withoutUnits()
 
 	mockData := dto.ManageAssetRequest{
 		Id:       "1",
@@ -140,10 +156,7 @@ func (suite *ManageAssetUsecaseTestSuite) TestTransaction_Failed() {
 	}
 
 	suite.staffUC.On("FindById", "1").Return(staffMock, nil)
-	suite.repoMock.On("CreateTransaction", mockData).Return(errors.New("failed save transaction"))
-	for _, detail := range mockData.ManageAssetDetailReq {
-		suite.assetUC.On("UpdateAvailable", detail.IdAsset, detail.TotalItem).Return(nil)
-	}
+	suite.repoMock.On("CreateTransaction", mockData, testAudit).Return(errors.New("failed save transaction"))
 	err := suite.usecase.CreateTransaction(mockData)
 	assert.Error(suite.T(), err)
 }
@@ -255,15 +268,13 @@ func (suite *ManageAssetUsecaseTestSuite) TestTransaction_InvalidIdAsset() {
 	}
 
 	suite.staffUC.On("FindById", "1").Return(staffMock, nil)
-	suite.repoMock.On("CreateTransaction", mockData).Return(errors.New("failed save transaction"))
-	for _, detail := range mockData.ManageAssetDetailReq {
-		suite.assetUC.On("UpdateAvailable", detail.IdAsset, detail.TotalItem).Return(nil)
-	}
+	suite.repoMock.On("CreateTransaction", mockData, testAudit).Return(errors.New("failed save transaction"))
 	err := suite.usecase.CreateTransaction(mockData)
 	assert.Error(suite.T(), err)
 }
 
 func (suite *ManageAssetUsecaseTestSuite) TestTransaction_InvalidStaffId() {
+	suite.withoutUnits()
 
 	mockData := dto.ManageAssetRequest{
 		Id:       "1",
@@ -303,15 +314,13 @@ func (suite *ManageAssetUsecaseTestSuite) TestTransaction_InvalidStaffId() {
 	}
 
 	suite.staffUC.On("FindById", "1").Return(model.Staff{}, errors.New("failed get staff"))
-	suite.repoMock.On("CreateTransaction", mockData).Return(nil)
-	for _, detail := range mockData.ManageAssetDetailReq {
-		suite.assetUC.On("UpdateAvailable", detail.IdAsset, detail.TotalItem).Return(nil)
-	}
+	suite.repoMock.On("CreateTransaction", mockData, testAudit).Return(nil)
 	err := suite.usecase.CreateTransaction(mockData)
 	assert.Error(suite.T(), err)
 }
 
-func (suite *ManageAssetUsecaseTestSuite) TestTransaction_FailUpdateAsset() {
+func (suite *ManageAssetUsecaseTestSuite) TestTransaction_InsufficientStock() {
+	suite.withoutUnits()
 
 	mockData := dto.ManageAssetRequest{
 		Id:       "1",
@@ -361,12 +370,10 @@ func (suite *ManageAssetUsecaseTestSuite) TestTransaction_FailUpdateAsset() {
 	}
 
 	suite.staffUC.On("FindById", "1").Return(staffMock, nil)
-	suite.repoMock.On("CreateTransaction", mockData).Return(nil)
-	for _, detail := range mockData.ManageAssetDetailReq {
-		suite.assetUC.On("UpdateAvailable", detail.IdAsset, detail.TotalItem).Return(errors.New("failed update available"))
-	}
+	suite.repoMock.On("CreateTransaction", mock.AnythingOfType("dto.ManageAssetRequest"), testAudit).Return(fmt.Errorf("%w: asset 1", repository.ErrInsufficientStock))
 	err := suite.usecase.CreateTransaction(mockData)
 	assert.Error(suite.T(), err)
+	assert.IsType(suite.T(), &exception.Http{}, err)
 }
 
 func (suite *ManageAssetUsecaseTestSuite) TestShowList_Success() {
@@ -401,6 +408,7 @@ func (suite *ManageAssetUsecaseTestSuite) TestShowList_Success() {
 }
 
 func (suite *ManageAssetUsecaseTestSuite) TestShowById_Success() {
+	suite.withoutUnits()
 	mockData := []model.ManageAsset{{
 		Id: "1",
 		User: model.UserCredentials{
@@ -527,3 +535,314 @@ func (suite *ManageAssetUsecaseTestSuite) TestDownload_Failed() {
 	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), got)
 }
+
+func (suite *ManageAssetUsecaseTestSuite) TestReturn_Success() {
+	suite.withoutUnits()
+	mockData := []model.ManageAsset{{Id: "1"}}
+	mockDataDetail := []model.ManageDetailAsset{{
+		Id:            "1",
+		ManageAssetId: "1",
+		Asset:         model.Asset{Id: "1"},
+		TotalItem:     3,
+		TotalReturned: 1,
+		Status:        "a",
+	}}
+	payload := dto.ReturnAssetRequest{
+		IdManageAsset: "1",
+		IdUser:        testActor,
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{
+			IdDetail:  "1",
+			TotalItem: 2,
+		}},
+	}
+
+	suite.repoMock.On("FindAllByTransId", "1").Return(mockData, mockDataDetail, nil)
+	suite.repoMock.On("ReturnTransaction", mock.AnythingOfType("dto.ReturnAssetRequest"), testAudit).Return(nil)
+	err := suite.usecase.ReturnTransaction(payload)
+	assert.NoError(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+
+	after := mockDataDetail[0]
+	after.TotalReturned = 3
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityTransaction, "1", model.AuditActionReturn,
+		mockDataDetail, []model.ManageDetailAsset{after})
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestReturn_EmptyField() {
+	//id manage asset empty
+	err := suite.usecase.ReturnTransaction(dto.ReturnAssetRequest{
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{IdDetail: "1", TotalItem: 1}},
+	})
+	assert.Error(suite.T(), err)
+
+	//detail empty
+	err = suite.usecase.ReturnTransaction(dto.ReturnAssetRequest{IdManageAsset: "1"})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestReturn_ExceedsBorrowed() {
+	suite.withoutUnits()
+	mockData := []model.ManageAsset{{Id: "1"}}
+	mockDataDetail := []model.ManageDetailAsset{{
+		Id:            "1",
+		ManageAssetId: "1",
+		TotalItem:     3,
+		TotalReturned: 2,
+	}}
+
+	suite.repoMock.On("FindAllByTransId", "1").Return(mockData, mockDataDetail, nil)
+	err := suite.usecase.ReturnTransaction(dto.ReturnAssetRequest{
+		IdManageAsset:   "1",
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{IdDetail: "1", TotalItem: 2}},
+	})
+	assert.Error(suite.T(), err)
+	suite.repoMock.AssertNotCalled(suite.T(), "ReturnTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestReturn_DetailNotFound() {
+	suite.withoutUnits()
+	mockData := []model.ManageAsset{{Id: "1"}}
+
+	suite.repoMock.On("FindAllByTransId", "1").Return(mockData, []model.ManageDetailAsset{}, nil)
+	err := suite.usecase.ReturnTransaction(dto.ReturnAssetRequest{
+		IdManageAsset:   "1",
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{IdDetail: "2", TotalItem: 1}},
+	})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestReturn_RepoFailed() {
+	suite.withoutUnits()
+	mockData := []model.ManageAsset{{Id: "1"}}
+	mockDataDetail := []model.ManageDetailAsset{{
+		Id:            "1",
+		ManageAssetId: "1",
+		TotalItem:     1,
+	}}
+
+	suite.repoMock.On("FindAllByTransId", "1").Return(mockData, mockDataDetail, nil)
+	suite.repoMock.On("ReturnTransaction", mock.AnythingOfType("dto.ReturnAssetRequest"), testAudit).Return(errors.New("failed return"))
+	err := suite.usecase.ReturnTransaction(dto.ReturnAssetRequest{
+		IdManageAsset:   "1",
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{IdDetail: "1", TotalItem: 1}},
+	})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestTransaction_Units() {
+	units := []model.AssetUnit{
+		{Id: "u1", AssetId: "1", SerialNumber: "SN-1", Status: model.UnitStatusAvailable},
+		{Id: "u2", AssetId: "1", SerialNumber: "SN-2", Status: model.UnitStatusAvailable},
+	}
+	payload := dto.ManageAssetRequest{
+		NikStaff: "111",
+		ManageAssetDetailReq: []dto.ManageAssetDetailRequest{{
+			IdAsset: "1",
+			Status:  "ok",
+			UnitIds: []string{"u1", "u2"},
+		}},
+	}
+
+	suite.assetUC.On("FindById", "1").Return(model.Asset{Id: "1", Available: 2}, nil)
+	suite.unitUC.On("FindByAsset", "1").Return(units, nil)
+	suite.staffUC.On("FindById", "111").Return(model.Staff{Nik_Staff: "111"}, nil)
+	//the total item follow the named units
+	suite.repoMock.On("CreateTransaction", mock.MatchedBy(func(req dto.ManageAssetRequest) bool {
+		return req.ManageAssetDetailReq[0].TotalItem == 2
+	}), testAudit).Return(nil)
+
+	err := suite.usecase.CreateTransaction(payload)
+	assert.NoError(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestTransaction_UnitNotAvailable() {
+	units := []model.AssetUnit{
+		{Id: "u1", AssetId: "1", SerialNumber: "SN-1", Status: model.UnitStatusBorrowed},
+	}
+	suite.assetUC.On("FindById", "1").Return(model.Asset{Id: "1", Available: 1}, nil)
+	suite.unitUC.On("FindByAsset", "1").Return(units, nil)
+
+	cases := [][]string{{"u1"}, {"u9"}, nil}
+	for _, unitIds := range cases {
+		err := suite.usecase.CreateTransaction(dto.ManageAssetRequest{
+			NikStaff: "111",
+			ManageAssetDetailReq: []dto.ManageAssetDetailRequest{{
+				IdAsset: "1",
+				Status:  "ok",
+				UnitIds: unitIds,
+			}},
+		})
+		assert.Error(suite.T(), err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "CreateTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestReturn_Units() {
+	returned := time.Now()
+	mockData := []model.ManageAsset{{Id: "1"}}
+	mockDataDetail := []model.ManageDetailAsset{{
+		Id:            "d1",
+		ManageAssetId: "1",
+		TotalItem:     3,
+		TotalReturned: 1,
+	}}
+	units := []model.AssetUnit{
+		{Id: "u1", DetailId: "d1", ReturnedAt: &returned},
+		{Id: "u2", DetailId: "d1"},
+		{Id: "u3", DetailId: "d1"},
+	}
+
+	suite.repoMock.On("FindAllByTransId", "1").Return(mockData, mockDataDetail, nil)
+	suite.unitUC.On("FindByTransaction", "1").Return(units, nil)
+	suite.repoMock.On("ReturnTransaction", mock.MatchedBy(func(req dto.ReturnAssetRequest) bool {
+		return req.ReturnDetailReq[0].TotalItem == 2
+	}), testAudit).Return(nil)
+
+	err := suite.usecase.ReturnTransaction(dto.ReturnAssetRequest{
+		IdManageAsset:   "1",
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{IdDetail: "d1", UnitIds: []string{"u2", "u3"}}},
+	})
+	assert.NoError(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestReturn_UnitNotOnLoan() {
+	returned := time.Now()
+	mockData := []model.ManageAsset{{Id: "1"}}
+	mockDataDetail := []model.ManageDetailAsset{{
+		Id:            "d1",
+		ManageAssetId: "1",
+		TotalItem:     2,
+		TotalReturned: 1,
+	}}
+	units := []model.AssetUnit{
+		{Id: "u1", DetailId: "d1", ReturnedAt: &returned},
+		{Id: "u2", DetailId: "d1"},
+	}
+
+	suite.repoMock.On("FindAllByTransId", "1").Return(mockData, mockDataDetail, nil)
+	suite.unitUC.On("FindByTransaction", "1").Return(units, nil)
+
+	//already returned, unknown unit and a count return on a detail lent by unit
+	cases := []dto.ReturnAssetDetailRequest{
+		{IdDetail: "d1", UnitIds: []string{"u1"}},
+		{IdDetail: "d1", UnitIds: []string{"u9"}},
+		{IdDetail: "d1", TotalItem: 1},
+	}
+	for _, ret := range cases {
+		err := suite.usecase.ReturnTransaction(dto.ReturnAssetRequest{
+			IdManageAsset:   "1",
+			ReturnDetailReq: []dto.ReturnAssetDetailRequest{ret},
+		})
+		assert.Error(suite.T(), err)
+	}
+	suite.repoMock.AssertNotCalled(suite.T(), "ReturnTransaction", mock.Anything, mock.Anything)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestFindOverdue_Success() {
+	returnDate := time.Now().Add(-49 * time.Hour)
+	transactions := []model.OverdueTransaction{{ManageAsset: model.ManageAsset{Id: "1", ReturnDate: returnDate}}}
+	details := []model.ManageDetailAsset{{Id: "d1", ManageAssetId: "1", TotalItem: 2}}
+	suite.repoMock.On("FindOverdue", mock.AnythingOfType("time.Time")).Return(transactions, details, nil)
+
+	overdue, err := suite.usecase.FindOverdue()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), overdue, 1)
+	assert.Equal(suite.T(), 3, overdue[0].DaysOverdue)
+	assert.Len(suite.T(), overdue[0].Detail, 1)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestFindOverdue_Failed() {
+	suite.repoMock.On("FindOverdue", mock.AnythingOfType("time.Time")).Return(nil, nil, errors.New("failed"))
+
+	overdue, err := suite.usecase.FindOverdue()
+	assert.Error(suite.T(), err)
+	assert.Nil(suite.T(), overdue)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestRemindOverdue() {
+	recently := time.Now().Add(-time.Hour)
+	transactions := []model.OverdueTransaction{
+		{ManageAsset: model.ManageAsset{
+			Id:         "1",
+			User:       model.UserCredentials{Name: "pal", Email: "pal@example.com", Locale: "id"},
+			Staff:      model.Staff{Name: "awd", Email: "awd@example.com"},
+			ReturnDate: time.Now().Add(-30 * time.Hour),
+		}},
+		//staff without email, only the user is reminded
+		{ManageAsset: model.ManageAsset{
+			Id:         "2",
+			User:       model.UserCredentials{Name: "pal", Email: "pal@example.com"},
+			Staff:      model.Staff{Name: "noemail"},
+			ReturnDate: time.Now().Add(-30 * time.Hour),
+		}},
+		//reminded less than a day ago
+		{ManageAsset: model.ManageAsset{Id: "3", ReturnDate: time.Now().Add(-30 * time.Hour)}, RemindedAt: &recently},
+	}
+	details := []model.ManageDetailAsset{{Id: "d1", ManageAssetId: "1", Asset: model.Asset{Name: "Laptop"}, TotalItem: 3, TotalReturned: 1}}
+	suite.repoMock.On("FindOverdue", mock.AnythingOfType("time.Time")).Return(transactions, details, nil)
+	suite.repoMock.On("MarkOverdue", "1", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), mock.MatchedBy(func(emails []model.EmailOutbox) bool {
+		return len(emails) == 2 && emails[0].Recipient == "awd@example.com" && emails[1].Recipient == "pal@example.com" &&
+			strings.Contains(emails[0].Html, "Laptop")
+	})).Return(true, nil)
+	suite.repoMock.On("MarkOverdue", "2", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), mock.MatchedBy(func(emails []model.EmailOutbox) bool {
+		return len(emails) == 1 && emails[0].Recipient == "pal@example.com"
+	})).Return(false, nil)
+
+	err := suite.usecase.RemindOverdue(context.Background())
+	assert.NoError(suite.T(), err)
+	suite.repoMock.AssertExpectations(suite.T())
+	suite.repoMock.AssertNumberOfCalls(suite.T(), "MarkOverdue", 2)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestRemindOverdue_MarkFailed() {
+	transactions := []model.OverdueTransaction{{ManageAsset: model.ManageAsset{
+		Id:         "1",
+		User:       model.UserCredentials{Email: "pal@example.com"},
+		ReturnDate: time.Now().Add(-30 * time.Hour),
+	}}}
+	suite.repoMock.On("FindOverdue", mock.AnythingOfType("time.Time")).Return(transactions, []model.ManageDetailAsset{}, nil)
+	suite.repoMock.On("MarkOverdue", "1", mock.Anything, mock.Anything, mock.Anything).Return(false, errors.New("failed"))
+
+	assert.Error(suite.T(), suite.usecase.RemindOverdue(context.Background()))
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestExportDetails_InvalidRange() {
+	filter := dto.TransactionExportRequest{From: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	err := suite.usecase.ExportDetails(filter, func(model.TransactionDetailRow) error { return nil })
+	assert.IsType(suite.T(), &exception.Http{}, err)
+	suite.repoMock.AssertNotCalled(suite.T(), "ExportDetails", mock.Anything, mock.Anything)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestExportDetails_Success() {
+	filter := dto.TransactionExportRequest{NikStaff: "1"}
+	suite.repoMock.On("ExportDetails", filter, mock.Anything).Return([]model.TransactionDetailRow{{TransactionId: "t1"}}, nil)
+
+	var got []string
+	err := suite.usecase.ExportDetails(filter, func(row model.TransactionDetailRow) error {
+		got = append(got, row.TransactionId)
+		return nil
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"t1"}, got)
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestReceipt_Success() {
+	suite.withoutUnits()
+	mockData := []model.ManageAsset{{Id: "1", Staff: model.Staff{Nik_Staff: "1", Name: "Budi"}, SubmissionDate: time.Now(), ReturnDate: time.Now()}}
+	mockDataDetail := []model.ManageDetailAsset{{Id: "d1", ManageAssetId: "1", Asset: model.Asset{Name: "Laptop"}, TotalItem: 1}}
+	suite.repoMock.On("FindAllByTransId", "1").Return(mockData, mockDataDetail, nil)
+
+	pdf, err := suite.usecase.Receipt("1")
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(string(pdf), "%PDF-"))
+}
+
+func (suite *ManageAssetUsecaseTestSuite) TestReceipt_NotFound() {
+	suite.withoutUnits()
+	suite.repoMock.On("FindAllByTransId", "1").Return([]model.ManageAsset{}, []model.ManageDetailAsset{}, nil)
+
+	_, err := suite.usecase.Receipt("1")
+	assert.Equal(suite.T(), exception.NotFoundErr("transaction not found"), err)
+}
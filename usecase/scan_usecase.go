@@ -0,0 +1,226 @@
+package usecase
+
+import (
+	"errors"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type ScanUsecase interface {
+	Scan(actorId string, payload dto.ScanRequest) (dto.ScanResponse, error)
+}
+
+type scanUsecase struct {
+	assetUC  AssetUsecase
+	unitUC   AssetUnitUsecase
+	manageUC ManageAssetUsecase
+}
+
+// Scan implements ScanUsecase.
+// The code is looked up as an asset id first and then as a unit serial number,
+// the intent is carried out by the asset and loan usecases
+func (s *scanUsecase) Scan(actorId string, payload dto.ScanRequest) (dto.ScanResponse, error) {
+	payload.Code = strings.TrimSpace(payload.Code)
+	if payload.Code == "" {
+		return dto.ScanResponse{}, exception.BadRequestErr("code cannot empty")
+	}
+	switch payload.Intent {
+	case dto.ScanLookup, dto.ScanCheckOut, dto.ScanCheckIn, dto.ScanStocktake:
+	default:
+		return dto.ScanResponse{}, exception.BadRequestErr(fmt.Sprintf("intent must be one of %s, %s, %s, %s",
+			dto.ScanLookup, dto.ScanCheckOut, dto.ScanCheckIn, dto.ScanStocktake))
+	}
+	if payload.Quantity < 0 {
+		return dto.ScanResponse{}, exception.BadRequestErr("quantity cannot be negative")
+	}
+	if payload.Quantity == 0 {
+		payload.Quantity = 1
+	}
+
+	asset, unit, err := s.resolve(payload.Code)
+	if err != nil {
+		return dto.ScanResponse{}, err
+	}
+	//a unit is a single item
+	if unit.Id != "" && payload.Quantity != 1 {
+		return dto.ScanResponse{}, exception.BadRequestErr("a scanned unit is a single item, quantity must be 1")
+	}
+
+	switch payload.Intent {
+	case dto.ScanCheckOut:
+		return s.checkOut(actorId, payload, asset, unit)
+	case dto.ScanCheckIn:
+		return s.checkIn(actorId, payload, asset, unit)
+	case dto.ScanStocktake:
+		return stocktake(payload, asset, unit)
+	}
+	return scanResponse(payload.Intent, asset, unit, "found"), nil
+}
+
+// resolve find the asset of the code, and the unit when the code is a serial number
+func (s *scanUsecase) resolve(code string) (model.Asset, model.AssetUnit, error) {
+	//FindById report every failure as a bad request, so a miss fall through to the units
+	if asset, err := s.assetUC.FindById(code); err == nil {
+		return asset, model.AssetUnit{}, nil
+	}
+	unit, err := s.unitUC.FindBySerial(code)
+	if err != nil {
+		var httpErr *exception.Http
+		if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+			return model.Asset{}, model.AssetUnit{}, exception.NotFoundErr(fmt.Sprintf("no asset or unit with code %s", code))
+		}
+		return model.Asset{}, model.AssetUnit{}, err
+	}
+	asset, err := s.assetUC.FindById(unit.AssetId)
+	if err != nil {
+		return model.Asset{}, model.AssetUnit{}, err
+	}
+	return asset, unit, nil
+}
+
+// checkOut lend the scanned item at once, the controller allow it to the direct loan override only
+func (s *scanUsecase) checkOut(actorId string, payload dto.ScanRequest, asset model.Asset, unit model.AssetUnit) (dto.ScanResponse, error) {
+	detail := dto.ManageAssetDetailRequest{
+		IdAsset:   asset.Id,
+		TotalItem: payload.Quantity,
+		Status:    model.DetailStatusBorrowed,
+	}
+	if unit.Id != "" {
+		detail.UnitIds = []string{unit.Id}
+	}
+	err := s.manageUC.CreateTransaction(dto.ManageAssetRequest{
+		IdUser:               actorId,
+		NikStaff:             payload.NikStaff,
+		Duration:             payload.Duration,
+		ManageAssetDetailReq: []dto.ManageAssetDetailRequest{detail},
+	})
+	if err != nil {
+		return dto.ScanResponse{}, err
+	}
+	return s.refreshed(payload.Intent, asset, unit, fmt.Sprintf("%d %s checked out to %s", payload.Quantity, asset.Name, payload.NikStaff))
+}
+
+func (s *scanUsecase) checkIn(actorId string, payload dto.ScanRequest, asset model.Asset, unit model.AssetUnit) (dto.ScanResponse, error) {
+	details, err := s.manageUC.FindOpenDetails(dto.OpenDetailRequest{
+		AssetId:  asset.Id,
+		NikStaff: payload.NikStaff,
+		UnitId:   unit.Id,
+	})
+	if err != nil {
+		return dto.ScanResponse{}, err
+	}
+	if len(details) == 0 {
+		return dto.ScanResponse{}, exception.BadRequestErr(fmt.Sprintf("%s is not on loan", asset.Name))
+	}
+	//a unit is held by one loan, a bulk asset may be lent to several staff
+	if unit.Id == "" && payload.NikStaff == "" {
+		loans := make(map[string]bool)
+		for _, detail := range details {
+			loans[detail.ManageAssetId] = true
+		}
+		if len(loans) > 1 {
+			return dto.ScanResponse{}, exception.BadRequestErr(fmt.Sprintf("%s is on loan in %d transactions, give the nik_staff", asset.Name, len(loans)))
+		}
+	}
+
+	//the oldest line with enough item left is returned first
+	detail := details[0]
+	for _, open := range details {
+		if open.TotalItem-open.TotalReturned >= payload.Quantity {
+			detail = open
+			break
+		}
+	}
+	ret := dto.ReturnAssetDetailRequest{IdDetail: detail.Id, TotalItem: payload.Quantity}
+	if unit.Id != "" {
+		ret.UnitIds = []string{unit.Id}
+	}
+	err = s.manageUC.ReturnTransaction(dto.ReturnAssetRequest{
+		IdManageAsset:   detail.ManageAssetId,
+		IdUser:          actorId,
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{ret},
+	})
+	if err != nil {
+		return dto.ScanResponse{}, err
+	}
+
+	response, err := s.refreshed(payload.Intent, asset, unit, fmt.Sprintf("%d %s checked in", payload.Quantity, asset.Name))
+	response.TransactionId = detail.ManageAssetId
+	return response, err
+}
+
+// stocktake compare the count with the item expected on site, borrowed item are not
+func stocktake(payload dto.ScanRequest, asset model.Asset, unit model.AssetUnit) (dto.ScanResponse, error) {
+	var expected, counted int
+	if unit.Id != "" {
+		counted = 1
+		if unit.Status != model.UnitStatusBorrowed && unit.Status != model.UnitStatusRetired {
+			expected = 1
+		}
+	} else {
+		if payload.Counted == nil {
+			return dto.ScanResponse{}, exception.BadRequestErr("counted is required for a stocktake of an asset")
+		}
+		if *payload.Counted < 0 {
+			return dto.ScanResponse{}, exception.BadRequestErr("counted cannot be negative")
+		}
+		counted = *payload.Counted
+		expected = asset.Available
+	}
+	difference := counted - expected
+
+	message := "count matches"
+	switch {
+	case unit.Id != "" && expected == 0:
+		message = fmt.Sprintf("unit is recorded as %s", unit.Status)
+	case difference > 0:
+		message = fmt.Sprintf("%d more than expected", difference)
+	case difference < 0:
+		message = fmt.Sprintf("%d missing", -difference)
+	}
+	response := scanResponse(payload.Intent, asset, unit, message)
+	response.Expected, response.Counted, response.Difference = &expected, &counted, &difference
+	return response, nil
+}
+
+// refreshed read the asset and unit again so the response show the stock after the action
+func (s *scanUsecase) refreshed(intent string, asset model.Asset, unit model.AssetUnit, message string) (dto.ScanResponse, error) {
+	asset, err := s.assetUC.FindById(asset.Id)
+	if err != nil {
+		return dto.ScanResponse{}, err
+	}
+	if unit.Id != "" {
+		if unit, err = s.unitUC.FindById(unit.Id); err != nil {
+			return dto.ScanResponse{}, err
+		}
+	}
+	return scanResponse(intent, asset, unit, message), nil
+}
+
+func scanResponse(intent string, asset model.Asset, unit model.AssetUnit, message string) dto.ScanResponse {
+	return dto.ScanResponse{
+		Intent:       intent,
+		AssetId:      asset.Id,
+		Name:         asset.Name,
+		Status:       asset.Status,
+		Available:    asset.Available,
+		Total:        asset.Total,
+		UnitId:       unit.Id,
+		SerialNumber: unit.SerialNumber,
+		UnitStatus:   unit.Status,
+		HolderNik:    unit.HolderNik,
+		Message:      message,
+	}
+}
+
+func NewScanUsecase(assetUC AssetUsecase, unitUC AssetUnitUsecase, manageUC ManageAssetUsecase) ScanUsecase {
+	return &scanUsecase{
+		assetUC:  assetUC,
+		unitUC:   unitUC,
+		manageUC: manageUC,
+	}
+}
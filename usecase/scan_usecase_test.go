@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ScanUsecaseSuite struct {
+	suite.Suite
+	assetUC  *usecasemock.AssetUsecaseMock
+	unitUC   *usecasemock.AssetUnitUsecaseMock
+	manageUC *usecasemock.ManageAssetsMock
+	usecase  ScanUsecase
+}
+
+var scannedAsset = model.Asset{Id: "a1", Name: "Kursi", Status: "Ready", Available: 8, Total: 10}
+
+func (suite *ScanUsecaseSuite) SetupTest() {
+	suite.assetUC = new(usecasemock.AssetUsecaseMock)
+	suite.unitUC = new(usecasemock.AssetUnitUsecaseMock)
+	suite.manageUC = new(usecasemock.ManageAssetsMock)
+	suite.usecase = NewScanUsecase(suite.assetUC, suite.unitUC, suite.manageUC)
+}
+
+func TestScanUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(ScanUsecaseSuite))
+}
+
+func (suite *ScanUsecaseSuite) TestScan_Invalid() {
+	for _, payload := range []dto.ScanRequest{
+		{Code: " ", Intent: dto.ScanLookup},
+		{Code: "a1", Intent: "borrow"},
+		{Code: "a1", Intent: dto.ScanCheckOut, Quantity: -1},
+	} {
+		_, err := suite.usecase.Scan(testActor, payload)
+		assert.IsType(suite.T(), &exception.Http{}, err)
+	}
+	suite.assetUC.AssertNotCalled(suite.T(), "FindById", mock.Anything)
+}
+
+func (suite *ScanUsecaseSuite) TestScan_LookupSerial() {
+	unit := model.AssetUnit{Id: "u1", AssetId: "a1", SerialNumber: "SN-1", Status: model.UnitStatusAvailable}
+	suite.assetUC.On("FindById", "SN-1").Return(model.Asset{}, exception.BadRequestErr("not found"))
+	suite.unitUC.On("FindBySerial", "SN-1").Return(unit, nil)
+	suite.assetUC.On("FindById", "a1").Return(scannedAsset, nil)
+
+	response, err := suite.usecase.Scan(testActor, dto.ScanRequest{Code: "SN-1", Intent: dto.ScanLookup})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "a1", response.AssetId)
+	assert.Equal(suite.T(), "u1", response.UnitId)
+}
+
+func (suite *ScanUsecaseSuite) TestScan_UnknownCode() {
+	suite.assetUC.On("FindById", "x").Return(model.Asset{}, exception.BadRequestErr("not found"))
+	suite.unitUC.On("FindBySerial", "x").Return(model.AssetUnit{}, exception.NotFoundErr("asset unit not found"))
+
+	_, err := suite.usecase.Scan(testActor, dto.ScanRequest{Code: "x", Intent: dto.ScanLookup})
+	assert.Equal(suite.T(), http.StatusNotFound, err.(*exception.Http).StatusCode)
+}
+
+func (suite *ScanUsecaseSuite) TestScan_CheckOut() {
+	suite.assetUC.On("FindById", "a1").Return(scannedAsset, nil)
+	suite.manageUC.On("CreateTransaction", dto.ManageAssetRequest{
+		IdUser:   testActor,
+		NikStaff: "1001",
+		Duration: 3,
+		ManageAssetDetailReq: []dto.ManageAssetDetailRequest{
+			{IdAsset: "a1", TotalItem: 2, Status: model.DetailStatusBorrowed},
+		},
+	}).Return(nil)
+
+	response, err := suite.usecase.Scan(testActor, dto.ScanRequest{Code: "a1", Intent: dto.ScanCheckOut, NikStaff: "1001", Quantity: 2, Duration: 3})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "2 Kursi checked out to 1001", response.Message)
+	suite.manageUC.AssertExpectations(suite.T())
+}
+
+func (suite *ScanUsecaseSuite) TestScan_CheckInAmbiguous() {
+	suite.assetUC.On("FindById", "a1").Return(scannedAsset, nil)
+	suite.manageUC.On("FindOpenDetails", dto.OpenDetailRequest{AssetId: "a1"}).Return([]model.ManageDetailAsset{
+		{Id: "d1", ManageAssetId: "t1", TotalItem: 1},
+		{Id: "d2", ManageAssetId: "t2", TotalItem: 1},
+	}, nil)
+
+	_, err := suite.usecase.Scan(testActor, dto.ScanRequest{Code: "a1", Intent: dto.ScanCheckIn})
+	assert.Equal(suite.T(), exception.BadRequestErr("Kursi is on loan in 2 transactions, give the nik_staff"), err)
+	suite.manageUC.AssertNotCalled(suite.T(), "ReturnTransaction", mock.Anything)
+}
+
+func (suite *ScanUsecaseSuite) TestScan_CheckInStaff() {
+	suite.assetUC.On("FindById", "a1").Return(scannedAsset, nil)
+	suite.manageUC.On("FindOpenDetails", dto.OpenDetailRequest{AssetId: "a1", NikStaff: "1001"}).Return([]model.ManageDetailAsset{
+		{Id: "d1", ManageAssetId: "t1", TotalItem: 1},
+		{Id: "d2", ManageAssetId: "t2", TotalItem: 5, TotalReturned: 1},
+	}, nil)
+	suite.manageUC.On("ReturnTransaction", dto.ReturnAssetRequest{
+		IdManageAsset:   "t2",
+		IdUser:          testActor,
+		ReturnDetailReq: []dto.ReturnAssetDetailRequest{{IdDetail: "d2", TotalItem: 2}},
+	}).Return(nil)
+
+	response, err := suite.usecase.Scan(testActor, dto.ScanRequest{Code: "a1", Intent: dto.ScanCheckIn, NikStaff: "1001", Quantity: 2})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "t2", response.TransactionId)
+}
+
+func (suite *ScanUsecaseSuite) TestScan_Stocktake() {
+	suite.assetUC.On("FindById", "a1").Return(scannedAsset, nil)
+
+	_, err := suite.usecase.Scan(testActor, dto.ScanRequest{Code: "a1", Intent: dto.ScanStocktake})
+	assert.IsType(suite.T(), &exception.Http{}, err)
+
+	counted := 6
+	response, err := suite.usecase.Scan(testActor, dto.ScanRequest{Code: "a1", Intent: dto.ScanStocktake, Counted: &counted})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 8, *response.Expected)
+	assert.Equal(suite.T(), -2, *response.Difference)
+	assert.Equal(suite.T(), "2 missing", response.Message)
+}
@@ -1,25 +1,34 @@
 package usecase
 
 import (
+	"errors"
 	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"final-project-enigma-clean/repository"
 	"fmt"
+	"net/mail"
+	"sort"
+	"time"
 )
 
 type StaffUseCase interface {
-	CreateNew(payload model.Staff) error
+	CreateNew(actorId string, payload model.Staff) error
 	FindByName(name string) ([]model.Staff, error)
 	FindById(nik_staff string) (model.Staff, error)
 	FindByAll() ([]model.Staff, error)
-	Update(payload model.Staff) error
-	Delete(nik_staff string) error
+	Update(actorId string, payload model.Staff) error
+	Delete(actorId, nik_staff string) error
+	Restore(actorId, nik_staff string) error
 	Paging(payload dto.PageRequest) ([]model.Staff, dto.Paging, error)
+	PagingCursor(payload dto.CursorRequest) ([]model.Staff, dto.CursorPaging, error)
+	Report(includeDeleted bool) ([]model.Staff, error)
+	Import(actorId string, rows [][]string, dryRun bool) (dto.ImportResult, error)
 }
 
 type staffUseCase struct {
-	repo repository.StaffRepository
+	repo    repository.StaffRepository
+	auditUC AuditUsecase
 }
 
 // FindById implements StaffUseCase.
@@ -33,36 +42,144 @@ func (s *staffUseCase) FindById(nik_staff string) (model.Staff, error) {
 }
 
 // CreateNew implements StaffUseCase.
-func (s *staffUseCase) CreateNew(payload model.Staff) error {
-	if payload.Nik_Staff == "" {
-		return exception.BadRequestErr("nik staff cannot Empty")
+func (s *staffUseCase) CreateNew(actorId string, payload model.Staff) error {
+	if err := validateStaff(payload); err != nil {
+		return err
 	}
-	if payload.Name == "" {
-		return exception.BadRequestErr("name cannot Empty")
+	audit, err := s.auditUC.Entry(actorId, model.AuditEntityStaff, payload.Nik_Staff, model.AuditActionCreate, nil, payload)
+	if err != nil {
+		return err
 	}
-	if len(payload.Phone_number) < 10 || len(payload.Phone_number) > 15 {
-		return exception.BadRequestErr("phone number must be between 10 and 15 characters")
+	err = s.repo.Save(payload, audit)
+	if err != nil {
+		return fmt.Errorf("failed to create new staff: %v", err)
 	}
-	if payload.Address == "" {
-		return exception.BadRequestErr("address cannot Empty")
+	return nil
+}
+
+// staffImportColumns are the columns a staff import file need, birth_date, img_url and email are optional
+var staffImportColumns = []string{"nik_staff", "name", "phone_number", "address", "divisi"}
+
+// keepOptional is the staff as Upsert save it, a blank optional column keep the value of before
+func keepOptional(staff, before model.Staff) model.Staff {
+	if staff.Birth_date.IsZero() {
+		staff.Birth_date = before.Birth_date
 	}
-	if payload.Divisi == "" {
-		return exception.BadRequestErr("divisi cannot Empty")
+	if staff.Img_url == "" {
+		staff.Img_url = before.Img_url
 	}
-	err := s.repo.Save(payload)
+	if staff.Email == "" {
+		staff.Email = before.Email
+	}
+	return staff
+}
+
+// Import implements StaffUseCase.
+// every row is validated like CreateNew, a known nik update that staff and a new one create it,
+// the valid rows are saved together unless it is a dry run
+func (s *staffUseCase) Import(actorId string, rows [][]string, dryRun bool) (dto.ImportResult, error) {
+	table, err := importTable(rows, staffImportColumns...)
 	if err != nil {
-		return fmt.Errorf("failed to create new staff: %v", err)
+		return dto.ImportResult{}, err
 	}
-	return nil
+
+	result := dto.ImportResult{DryRun: dryRun, Errors: []dto.ImportRowError{}}
+	var valid []model.Staff
+	lines := make(map[string]int)
+	for i, row := range table.Rows {
+		if row == nil {
+			continue
+		}
+		result.TotalRows++
+
+		staff := model.Staff{
+			Nik_Staff:    table.Cell(row, "nik_staff"),
+			Name:         table.Cell(row, "name"),
+			Phone_number: table.Cell(row, "phone_number"),
+			Address:      table.Cell(row, "address"),
+			Img_url:      table.Cell(row, "img_url"),
+			Divisi:       table.Cell(row, "divisi"),
+			Email:        table.Cell(row, "email"),
+		}
+		if birthDate := table.Cell(row, "birth_date"); birthDate != "" {
+			if staff.Birth_date, err = time.Parse("2006-01-02", birthDate); err != nil {
+				result.Errors = append(result.Errors, rowError(table.Line(i), exception.BadRequestErr("birth date must be formatted as YYYY-MM-DD")))
+				continue
+			}
+		}
+		if line, ok := lines[staff.Nik_Staff]; ok {
+			result.Errors = append(result.Errors, rowError(table.Line(i), exception.BadRequestErr(fmt.Sprintf("nik staff %s is already in row %d", staff.Nik_Staff, line))))
+			continue
+		}
+		if err = validateStaff(staff); err != nil {
+			result.Errors = append(result.Errors, rowError(table.Line(i), err))
+			continue
+		}
+		lines[staff.Nik_Staff] = table.Line(i)
+		valid = append(valid, staff)
+	}
+
+	//the staff already saved tell an update from a create, a deleted one must be restored first
+	existing := make(map[string]model.Staff)
+	if len(valid) > 0 {
+		niks := make([]string, len(valid))
+		for i, staff := range valid {
+			niks[i] = staff.Nik_Staff
+		}
+		found, err := s.repo.FindByNiks(niks)
+		if err != nil {
+			return dto.ImportResult{}, fmt.Errorf("failed to find staff: %v", err)
+		}
+		for _, staff := range found {
+			existing[staff.Nik_Staff] = staff
+		}
+	}
+	var upserts []model.Staff
+	for _, staff := range valid {
+		if before, ok := existing[staff.Nik_Staff]; ok && before.DeletedAt != nil {
+			result.Errors = append(result.Errors, rowError(lines[staff.Nik_Staff], exception.BadRequestErr(fmt.Sprintf("staff %s is deleted, restore it first", staff.Nik_Staff))))
+			continue
+		}
+		upserts = append(upserts, staff)
+	}
+	sort.Slice(result.Errors, func(i, j int) bool { return result.Errors[i].Row < result.Errors[j].Row })
+	result.ValidRows = len(upserts)
+	if dryRun || len(upserts) == 0 {
+		return result, nil
+	}
+
+	audits := make([]model.AuditLog, 0, len(upserts))
+	for _, staff := range upserts {
+		var audit model.AuditLog
+		if before, ok := existing[staff.Nik_Staff]; ok {
+			result.Updated++
+			audit, err = s.auditUC.Entry(actorId, model.AuditEntityStaff, staff.Nik_Staff, model.AuditActionUpdate, before, keepOptional(staff, before))
+		} else {
+			audit, err = s.auditUC.Entry(actorId, model.AuditEntityStaff, staff.Nik_Staff, model.AuditActionCreate, nil, staff)
+		}
+		if err != nil {
+			return dto.ImportResult{}, err
+		}
+		audits = append(audits, audit)
+	}
+	if err = s.repo.Upsert(upserts, audits); err != nil {
+		return dto.ImportResult{}, fmt.Errorf("failed to import staff: %v", err)
+	}
+	result.Imported = len(upserts)
+	return result, nil
 }
 
 // Delete implements StaffUseCase.
-func (s *staffUseCase) Delete(nik_staff string) error {
+func (s *staffUseCase) Delete(actorId, nik_staff string) error {
 	staff, err := s.FindById(nik_staff)
 	if err != nil {
 		return err
 	}
-	err = s.repo.Delete(staff.Nik_Staff)
+	audit, err := s.auditUC.Entry(actorId, model.AuditEntityStaff, staff.Nik_Staff, model.AuditActionDelete, staff, nil)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Delete(staff.Nik_Staff, audit)
 	if err != nil {
 		return fmt.Errorf("failed to delete staff: %v", err)
 	}
@@ -88,13 +205,40 @@ func (s *staffUseCase) FindByName(name string) ([]model.Staff, error) {
 
 }
 
+// PagingCursor implements StaffUseCase.
+func (s *staffUseCase) PagingCursor(payload dto.CursorRequest) ([]model.Staff, dto.CursorPaging, error) {
+	payload, err := cursorRequest(payload)
+	if err != nil {
+		return nil, dto.CursorPaging{}, err
+	}
+	return s.repo.PagingCursor(payload)
+}
+
 // Paging implements StaffUseCase.
 func (s *staffUseCase) Paging(payload dto.PageRequest) ([]model.Staff, dto.Paging, error) {
 	return s.repo.Paging(payload)
 }
 
+// Report implements StaffUseCase.
+// It read every staff page by page in nik order, with the deleted ones when asked
+func (s *staffUseCase) Report(includeDeleted bool) ([]model.Staff, error) {
+	var staffs []model.Staff
+	page := dto.CursorRequest{Limit: maxCursorLimit, IncludeDeleted: includeDeleted}
+	for {
+		found, paging, err := s.repo.PagingCursor(page)
+		if err != nil {
+			return nil, err
+		}
+		staffs = append(staffs, found...)
+		if paging.NextCursor == "" || len(found) == 0 {
+			return staffs, nil
+		}
+		page.After = found[len(found)-1].Nik_Staff
+	}
+}
+
 // Update implements StaffUseCase.
-func (s *staffUseCase) Update(payload model.Staff) error {
+func (s *staffUseCase) Update(actorId string, payload model.Staff) error {
 	if payload.Nik_Staff == "" {
 		return exception.BadRequestErr("nik staff cannot Empty")
 	}
@@ -110,19 +254,68 @@ func (s *staffUseCase) Update(payload model.Staff) error {
 	if payload.Divisi == "" {
 		return exception.BadRequestErr("divisi cannot Empty")
 	}
-	_, err := s.FindById(payload.Nik_Staff)
+	//email is optional, staff without email only miss the overdue reminder
+	if _, err := mail.ParseAddress(payload.Email); payload.Email != "" && err != nil {
+		return exception.BadRequestErr("email is not valid")
+	}
+	before, err := s.FindById(payload.Nik_Staff)
+	if err != nil {
+		return err
+	}
+	audit, err := s.auditUC.Entry(actorId, model.AuditEntityStaff, payload.Nik_Staff, model.AuditActionUpdate, before, payload)
 	if err != nil {
 		return err
 	}
-	err = s.repo.Update(payload)
+	err = s.repo.Update(payload, audit)
 	if err != nil {
 		return fmt.Errorf("failed to update staff: %v", err)
 	}
 	return nil
 }
 
-func NewStaffUseCase(repo repository.StaffRepository) StaffUseCase {
+// Restore implements StaffUseCase.
+// it bring back a deleted staff, the repository add the restored row to the audit entry
+func (s *staffUseCase) Restore(actorId, nik_staff string) error {
+	audit, err := s.auditUC.Entry(actorId, model.AuditEntityStaff, nik_staff, model.AuditActionRestore, nil, nil)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Restore(nik_staff, audit)
+	if errors.Is(err, repository.ErrNotDeleted) {
+		return exception.NotFoundErr("deleted staff not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore staff: %v", err)
+	}
+	return nil
+}
+
+func validateStaff(payload model.Staff) error {
+	if payload.Nik_Staff == "" {
+		return exception.BadRequestErr("nik staff cannot Empty")
+	}
+	if payload.Name == "" {
+		return exception.BadRequestErr("name cannot Empty")
+	}
+	if len(payload.Phone_number) < 10 || len(payload.Phone_number) > 15 {
+		return exception.BadRequestErr("phone number must be between 10 and 15 characters")
+	}
+	if payload.Address == "" {
+		return exception.BadRequestErr("address cannot Empty")
+	}
+	if payload.Divisi == "" {
+		return exception.BadRequestErr("divisi cannot Empty")
+	}
+	//email is optional, staff without email only miss the overdue reminder
+	if _, err := mail.ParseAddress(payload.Email); payload.Email != "" && err != nil {
+		return exception.BadRequestErr("email is not valid")
+	}
+	return nil
+}
+
+func NewStaffUseCase(repo repository.StaffRepository, auditUC AuditUsecase) StaffUseCase {
 	return &staffUseCase{
-		repo: repo,
+		repo:    repo,
+		auditUC: auditUC,
 	}
 }
@@ -3,24 +3,31 @@ package usecase
 import (
 	"errors"
 	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/util/helper"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
 type StaffUsecaseTestSuite struct {
 	suite.Suite
 	repo    *repomock.StaffRepoMock
+	auditUC *usecasemock.AuditUsecaseMock
 	usecase StaffUseCase
 }
 
 func (suite *StaffUsecaseTestSuite) SetupTest() {
 	suite.repo = new(repomock.StaffRepoMock)
-	suite.usecase = NewStaffUseCase(suite.repo)
+	suite.auditUC = new(usecasemock.AuditUsecaseMock)
+	suite.auditUC.On("Entry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testAudit, nil).Maybe()
+	suite.usecase = NewStaffUseCase(suite.repo, suite.auditUC)
 }
 
 func TestStafftUsecaseTestSuite(t *testing.T) {
@@ -37,8 +44,8 @@ func (suite *StaffUsecaseTestSuite) TestCreate_Success() {
 		Img_url:      "jhj.jpg",
 		Divisi:       "IT",
 	}
-	suite.repo.On("Save", mockData).Return(nil)
-	err := suite.usecase.CreateNew(mockData)
+	suite.repo.On("Save", mockData, testAudit).Return(nil)
+	err := suite.usecase.CreateNew(testActor, mockData)
 	assert.Nil(suite.T(), err)
 	assert.NoError(suite.T(), err)
 
@@ -46,7 +53,7 @@ func (suite *StaffUsecaseTestSuite) TestCreate_Success() {
 
 func (suite *StaffUsecaseTestSuite) TestCreate_EmptyField() {
 
-	emptyNik := suite.usecase.CreateNew(model.Staff{
+	emptyNik := suite.usecase.CreateNew(testActor, model.Staff{
 		Nik_Staff:    "",
 		Name:         "Product A",
 		Phone_number: "082284163929",
@@ -55,7 +62,7 @@ func (suite *StaffUsecaseTestSuite) TestCreate_EmptyField() {
 		Img_url:      "jhj.jpg",
 		Divisi:       "IT",
 	})
-	emptyName := suite.usecase.CreateNew(model.Staff{
+	emptyName := suite.usecase.CreateNew(testActor, model.Staff{
 		Nik_Staff:    "qqqq",
 		Name:         "",
 		Phone_number: "082284163929",
@@ -64,7 +71,7 @@ func (suite *StaffUsecaseTestSuite) TestCreate_EmptyField() {
 		Img_url:      "jhj.jpg",
 		Divisi:       "IT",
 	})
-	emptyPhone := suite.usecase.CreateNew(model.Staff{
+	emptyPhone := suite.usecase.CreateNew(testActor, model.Staff{
 		Nik_Staff:    "11651103422",
 		Name:         "rizki",
 		Phone_number: "0822",
@@ -73,7 +80,7 @@ func (suite *StaffUsecaseTestSuite) TestCreate_EmptyField() {
 		Img_url:      "jhj.jpg",
 		Divisi:       "IT",
 	})
-	emptyAddress := suite.usecase.CreateNew(model.Staff{
+	emptyAddress := suite.usecase.CreateNew(testActor, model.Staff{
 		Nik_Staff:    "11651103422",
 		Name:         "rizki",
 		Phone_number: "082284163929",
@@ -82,7 +89,7 @@ func (suite *StaffUsecaseTestSuite) TestCreate_EmptyField() {
 		Img_url:      "images.jpg",
 		Divisi:       "IT",
 	})
-	emptyDivisi := suite.usecase.CreateNew(model.Staff{
+	emptyDivisi := suite.usecase.CreateNew(testActor, model.Staff{
 		Nik_Staff:    "11651103422",
 		Name:         "rizki",
 		Phone_number: "082284163929",
@@ -99,6 +106,19 @@ func (suite *StaffUsecaseTestSuite) TestCreate_EmptyField() {
 
 }
 
+func (suite *StaffUsecaseTestSuite) TestCreate_InvalidEmail() {
+	err := suite.usecase.CreateNew(testActor, model.Staff{
+		Nik_Staff:    "11651103422",
+		Name:         "Product A",
+		Phone_number: "082284163929",
+		Address:      "pku",
+		Divisi:       "IT",
+		Email:        "not-an-email",
+	})
+	assert.Error(suite.T(), err)
+	suite.repo.AssertNotCalled(suite.T(), "Save", mock.Anything, mock.Anything)
+}
+
 func (suite *StaffUsecaseTestSuite) TestCreate_Failed() {
 	mockData := model.Staff{
 		Nik_Staff:    "11651103422hdgfdsfjgdsygfds788",
@@ -110,8 +130,8 @@ func (suite *StaffUsecaseTestSuite) TestCreate_Failed() {
 		Divisi:       "IT",
 	}
 
-	suite.repo.On("Save", mockData).Return(errors.New("failed to create new staff:"))
-	gotErr := suite.usecase.CreateNew(mockData)
+	suite.repo.On("Save", mockData, testAudit).Return(errors.New("failed to create new staff:"))
+	gotErr := suite.usecase.CreateNew(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -201,15 +221,15 @@ func (suite *StaffUsecaseTestSuite) TestUpdate_Success() {
 		Divisi:       "IT",
 	}
 	suite.repo.On("FindById", "11651103422").Return(mockData, nil)
-	suite.repo.On("Update", mockData).Return(nil)
-	gotErr := suite.usecase.Update(mockData)
+	suite.repo.On("Update", mockData, testAudit).Return(nil)
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.Nil(suite.T(), gotErr)
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *StaffUsecaseTestSuite) TestUpdate_EmptyField() {
 
-	emptyNik := suite.usecase.Update(model.Staff{
+	emptyNik := suite.usecase.Update(testActor, model.Staff{
 		Nik_Staff:    "",
 		Name:         "rizki",
 		Phone_number: "082284163929",
@@ -218,7 +238,7 @@ func (suite *StaffUsecaseTestSuite) TestUpdate_EmptyField() {
 		Img_url:      "images.jpg",
 		Divisi:       "IT",
 	})
-	emptyName := suite.usecase.Update(model.Staff{
+	emptyName := suite.usecase.Update(testActor, model.Staff{
 		Nik_Staff:    "1",
 		Name:         "",
 		Phone_number: "082284163929",
@@ -227,7 +247,7 @@ func (suite *StaffUsecaseTestSuite) TestUpdate_EmptyField() {
 		Img_url:      "images.jpg",
 		Divisi:       "IT",
 	})
-	emptyPhone := suite.usecase.Update(model.Staff{
+	emptyPhone := suite.usecase.Update(testActor, model.Staff{
 		Nik_Staff:    "1",
 		Name:         "Rizki",
 		Phone_number: "082284",
@@ -236,7 +256,7 @@ func (suite *StaffUsecaseTestSuite) TestUpdate_EmptyField() {
 		Img_url:      "images.jpg",
 		Divisi:       "IT",
 	})
-	emptyAddress := suite.usecase.Update(model.Staff{
+	emptyAddress := suite.usecase.Update(testActor, model.Staff{
 		Nik_Staff:    "1",
 		Name:         "Rizki",
 		Phone_number: "082284163929",
@@ -245,7 +265,7 @@ func (suite *StaffUsecaseTestSuite) TestUpdate_EmptyField() {
 		Img_url:      "images.jpg",
 		Divisi:       "It",
 	})
-	emptyDivisi := suite.usecase.Update(model.Staff{
+	emptyDivisi := suite.usecase.Update(testActor, model.Staff{
 		Nik_Staff:    "1",
 		Name:         "Rizki",
 		Phone_number: "082284163929",
@@ -273,7 +293,7 @@ func (suite *StaffUsecaseTestSuite) TestUpdate_InvalidId() {
 	}
 
 	suite.repo.On("FindById", "1").Return(model.Staff{}, errors.New("failed get staff"))
-	gotErr := suite.usecase.Update(mockData)
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -289,8 +309,8 @@ func (suite *StaffUsecaseTestSuite) TestUpdate_Failed() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Update", mockData).Return(errors.New("failed to update staff:"))
-	gotErr := suite.usecase.Update(mockData)
+	suite.repo.On("Update", mockData, testAudit).Return(errors.New("failed to update staff:"))
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -306,14 +326,14 @@ func (suite *StaffUsecaseTestSuite) TestDelete_Success() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Delete", "1").Return(nil)
-	gotErr := suite.usecase.Delete("1")
+	suite.repo.On("Delete", "1", testAudit).Return(nil)
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *StaffUsecaseTestSuite) TestDelete_InvalidId() {
 	suite.repo.On("FindById", "1").Return(model.Staff{}, errors.New("failed get staff"))
-	gotErr := suite.usecase.Delete("1")
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -329,11 +349,22 @@ func (suite *StaffUsecaseTestSuite) TestDelete_Failed() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Delete", "1").Return(errors.New("failed delete"))
-	gotErr := suite.usecase.Delete("1")
+	suite.repo.On("Delete", "1", testAudit).Return(errors.New("failed delete"))
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.Error(suite.T(), gotErr)
 }
 
+func (suite *StaffUsecaseTestSuite) TestReport_AllPages() {
+	suite.repo.On("PagingCursor", dto.CursorRequest{Limit: maxCursorLimit, IncludeDeleted: true}).
+		Return([]model.Staff{{Nik_Staff: "1"}}, dto.CursorPaging{NextCursor: "next"}, nil)
+	suite.repo.On("PagingCursor", dto.CursorRequest{Limit: maxCursorLimit, IncludeDeleted: true, After: "1"}).
+		Return([]model.Staff{{Nik_Staff: "2"}}, dto.CursorPaging{}, nil)
+
+	staffs, err := suite.usecase.Report(true)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []model.Staff{{Nik_Staff: "1"}, {Nik_Staff: "2"}}, staffs)
+}
+
 func (suite *StaffUsecaseTestSuite) TestPaging_Success() {
 	mockData := []model.Staff{
 		{
@@ -365,3 +396,76 @@ func (suite *StaffUsecaseTestSuite) TestPaging_Success() {
 	assert.Equal(suite.T(), mockPaging, gotPaging)
 	assert.Equal(suite.T(), mockPaging.Size, gotPaging.Size)
 }
+
+func (suite *StaffUsecaseTestSuite) TestPagingCursor_DecodeCursor() {
+	page := dto.CursorPaging{Limit: 10, NextCursor: helper.EncodeCursor("3")}
+	suite.repo.On("PagingCursor", dto.CursorRequest{Cursor: helper.EncodeCursor("1"), Limit: 10, After: "1"}).Return([]model.Staff{}, page, nil)
+
+	_, gotPaging, err := suite.usecase.PagingCursor(dto.CursorRequest{Cursor: helper.EncodeCursor("1")})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), page, gotPaging)
+}
+
+func (suite *StaffUsecaseTestSuite) TestPagingCursor_Invalid() {
+	for _, payload := range []dto.CursorRequest{{Cursor: "not-a-cursor"}, {Limit: 1000}} {
+		_, _, err := suite.usecase.PagingCursor(payload)
+		assert.IsType(suite.T(), &exception.Http{}, err)
+	}
+	suite.repo.AssertNotCalled(suite.T(), "PagingCursor", mock.Anything)
+}
+
+var staffImportRows = [][]string{
+	{"nik_staff", "name", "phone_number", "address", "birth_date", "divisi", "email"},
+	{"1", "Budi", "082284163929", "Pekanbaru", "1990-02-01", "IT", "budi@mail.com"},
+	{"2", "Sari", "0812", "Jakarta", "", "HR"},
+	{"3", "Tono", "081234567890", "Bandung", "01/02/1990", "IT"},
+	{"1", "Budi", "082284163929", "Pekanbaru", "", "IT"},
+	{"4", "Rina", "081234567891", "Medan", "", "Finance"},
+	{"5", "Dewi", "081234567892", "Solo", "", "IT"},
+}
+
+func (suite *StaffUsecaseTestSuite) TestImport_DryRun() {
+	deletedAt := time.Now()
+	suite.repo.On("FindByNiks", []string{"1", "4", "5"}).Return([]model.Staff{{Nik_Staff: "4", DeletedAt: &deletedAt}}, nil)
+
+	result, err := suite.usecase.Import(testActor, staffImportRows, true)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 6, result.TotalRows)
+	assert.Equal(suite.T(), 2, result.ValidRows)
+	assert.Equal(suite.T(), []dto.ImportRowError{
+		{Row: 3, Message: "phone number must be between 10 and 15 characters"},
+		{Row: 4, Message: "birth date must be formatted as YYYY-MM-DD"},
+		{Row: 5, Message: "nik staff 1 is already in row 2"},
+		{Row: 6, Message: "staff 4 is deleted, restore it first"},
+	}, result.Errors)
+	suite.repo.AssertNotCalled(suite.T(), "Upsert", mock.Anything, mock.Anything)
+}
+
+func (suite *StaffUsecaseTestSuite) TestImport_Upsert() {
+	existing := model.Staff{Nik_Staff: "1", Name: "Budi Lama", Phone_number: "082284163929", Address: "Pekanbaru", Divisi: "IT"}
+	suite.repo.On("FindByNiks", []string{"1", "4", "5"}).Return([]model.Staff{existing}, nil)
+	suite.repo.On("Upsert", mock.MatchedBy(func(staffs []model.Staff) bool { return len(staffs) == 3 }),
+		[]model.AuditLog{testAudit, testAudit, testAudit}).Return(nil)
+
+	result, err := suite.usecase.Import(testActor, staffImportRows, false)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, result.Imported)
+	assert.Equal(suite.T(), 1, result.Updated)
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityStaff, "1", model.AuditActionUpdate, existing, mock.Anything)
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityStaff, "5", model.AuditActionCreate, nil, mock.Anything)
+}
+
+func (suite *StaffUsecaseTestSuite) TestImport_WithoutEmailColumn() {
+	existing := model.Staff{Nik_Staff: "1", Name: "Budi", Phone_number: "082284163929", Address: "Pekanbaru", Divisi: "IT", Email: "budi@mail.com"}
+	suite.repo.On("FindByNiks", []string{"1"}).Return([]model.Staff{existing}, nil)
+	suite.repo.On("Upsert", mock.Anything, mock.Anything).Return(nil)
+
+	result, err := suite.usecase.Import(testActor, [][]string{
+		{"nik_staff", "name", "phone_number", "address", "divisi"},
+		{"1", "Budi Santoso", "082284163929", "Pekanbaru", "IT"},
+	}, false)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, result.Updated)
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityStaff, "1", model.AuditActionUpdate, existing,
+		mock.MatchedBy(func(after model.Staff) bool { return after.Name == "Budi Santoso" && after.Email == "budi@mail.com" }))
+}
@@ -0,0 +1,278 @@
+package usecase
+
+import (
+	"errors"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/repository"
+	"final-project-enigma-clean/util/helper"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type StocktakeUsecase interface {
+	Open(actorId string, payload dto.StocktakeRequest) (model.Stocktake, error)
+	FindAll(status string) ([]model.Stocktake, error)
+	FindById(id string) (model.Stocktake, error)
+	RecordCount(actorId, id string, payload dto.StocktakeCountRequest) error
+	Report(id string) (dto.StocktakeReport, error)
+	Close(actorId, id string, payload dto.StocktakeCloseRequest) (dto.StocktakeReport, error)
+}
+
+type stocktakeUsecase struct {
+	repo       repository.StocktakeRepository
+	assetUC    AssetUsecase
+	categoryUC CategoryUsecase
+	auditUC    AuditUsecase
+}
+
+// stocktakeAdjustment is the after state of an adjust entry in the audit log
+type stocktakeAdjustment struct {
+	AssetId    string `json:"asset_id"`
+	Reason     string `json:"reason"`
+	Expected   int    `json:"expected"`
+	Counted    int    `json:"counted"`
+	Difference int    `json:"difference"`
+}
+
+// Open implements StocktakeUsecase.
+func (s *stocktakeUsecase) Open(actorId string, payload dto.StocktakeRequest) (model.Stocktake, error) {
+	payload.Name = strings.TrimSpace(payload.Name)
+	if payload.Name == "" {
+		return model.Stocktake{}, exception.BadRequestErr("name cannot empty")
+	}
+	if payload.CategoryId != "" {
+		if _, err := s.categoryUC.FindById(payload.CategoryId); err != nil {
+			return model.Stocktake{}, err
+		}
+	}
+
+	stocktake := model.Stocktake{
+		Id:         helper.GenerateUUID(),
+		Name:       payload.Name,
+		CategoryId: payload.CategoryId,
+		Status:     model.StocktakeOpen,
+		Note:       payload.Note,
+		OpenedBy:   actorId,
+		OpenedAt:   time.Now(),
+	}
+	audit, err := s.auditUC.Entry(actorId, model.AuditEntityStocktake, stocktake.Id, model.AuditActionCreate, nil, stocktake)
+	if err != nil {
+		return model.Stocktake{}, err
+	}
+	if err = s.repo.Save(stocktake, audit); err != nil {
+		return model.Stocktake{}, fmt.Errorf("failed to open stocktake: %v", err)
+	}
+	return stocktake, nil
+}
+
+// FindAll implements StocktakeUsecase.
+func (s *stocktakeUsecase) FindAll(status string) ([]model.Stocktake, error) {
+	if status != "" && status != model.StocktakeOpen && status != model.StocktakeClosed {
+		return nil, exception.BadRequestErr("status must be open or closed")
+	}
+	stocktakes, err := s.repo.FindAll(status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stocktakes: %v", err)
+	}
+	return stocktakes, nil
+}
+
+// FindById implements StocktakeUsecase.
+// The session is returned with its counts
+func (s *stocktakeUsecase) FindById(id string) (model.Stocktake, error) {
+	stocktake, err := s.find(id)
+	if err != nil {
+		return model.Stocktake{}, err
+	}
+	if stocktake.Counts, err = s.repo.FindCounts(id); err != nil {
+		return model.Stocktake{}, fmt.Errorf("failed to get stocktake counts: %v", err)
+	}
+	return stocktake, nil
+}
+
+// RecordCount implements StocktakeUsecase.
+func (s *stocktakeUsecase) RecordCount(actorId, id string, payload dto.StocktakeCountRequest) error {
+	if payload.AssetId == "" {
+		return exception.BadRequestErr("asset id cannot empty")
+	}
+	if payload.Counted == nil {
+		return exception.BadRequestErr("counted is required")
+	}
+	if *payload.Counted < 0 {
+		return exception.BadRequestErr("counted cannot be negative")
+	}
+
+	stocktake, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	if stocktake.Status != model.StocktakeOpen {
+		return exception.BadRequestErr("stocktake is closed")
+	}
+	asset, err := s.assetUC.FindById(payload.AssetId)
+	if err != nil {
+		return err
+	}
+	if stocktake.CategoryId != "" && asset.Category.Id != stocktake.CategoryId {
+		return exception.BadRequestErr(fmt.Sprintf("%s is not in the category of this stocktake", asset.Name))
+	}
+
+	err = s.repo.SaveCount(model.StocktakeCount{
+		StocktakeId: id,
+		AssetId:     asset.Id,
+		Location:    strings.TrimSpace(payload.Location),
+		Counted:     *payload.Counted,
+		CountedBy:   actorId,
+		CountedAt:   time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record count: %v", err)
+	}
+	return nil
+}
+
+// Report implements StocktakeUsecase.
+func (s *stocktakeUsecase) Report(id string) (dto.StocktakeReport, error) {
+	stocktake, err := s.find(id)
+	if err != nil {
+		return dto.StocktakeReport{}, err
+	}
+	return s.report(stocktake)
+}
+
+// Close implements StocktakeUsecase.
+// With adjust the total of every counted asset is set to the count plus the item on loan, and its
+// available to the count less the item reserved by an approved loan request. The session is closed with
+// the adjustments in one transaction so the differences are never posted twice nor lost, assets tracked
+// per unit are left to their units
+func (s *stocktakeUsecase) Close(actorId, id string, payload dto.StocktakeCloseRequest) (dto.StocktakeReport, error) {
+	payload.Reason = strings.TrimSpace(payload.Reason)
+	if payload.Adjust && payload.Reason == "" {
+		return dto.StocktakeReport{}, exception.BadRequestErr("reason is required to adjust the assets")
+	}
+
+	stocktake, err := s.find(id)
+	if err != nil {
+		return dto.StocktakeReport{}, err
+	}
+	if stocktake.Status != model.StocktakeOpen {
+		return dto.StocktakeReport{}, exception.BadRequestErr("stocktake is already closed")
+	}
+	report, err := s.report(stocktake)
+	if err != nil {
+		return dto.StocktakeReport{}, err
+	}
+
+	closedAt := time.Now()
+	closed := stocktake
+	closed.Status, closed.ClosedBy, closed.ClosedAt = model.StocktakeClosed, actorId, &closedAt
+	audit, err := s.auditUC.Entry(actorId, model.AuditEntityStocktake, id, model.AuditActionClose, stocktake, closed)
+	if err != nil {
+		return dto.StocktakeReport{}, err
+	}
+
+	var adjustments []model.StocktakeAdjustment
+	var adjusted []string
+	for _, line := range report.Lines {
+		if !payload.Adjust || line.Counted == nil || line.TrackedByUnit || (*line.Difference == 0 && line.AvailableDrift == 0) {
+			continue
+		}
+		adjustment, err := s.adjustment(actorId, id, payload.Reason, line)
+		if err != nil {
+			return dto.StocktakeReport{}, fmt.Errorf("failed to adjust %s: %v", line.AssetName, err)
+		}
+		adjustments = append(adjustments, adjustment)
+		adjusted = append(adjusted, line.AssetId)
+	}
+
+	if err = s.repo.Close(id, actorId, closedAt, audit, adjustments); err != nil {
+		return dto.StocktakeReport{}, stocktakeErr(err)
+	}
+	report.Stocktake = closed
+	report.Adjusted = adjusted
+	return report, nil
+}
+
+// adjustment build the posting of the difference of a counted asset with the update entry of the asset
+// and the adjust entry of the stocktake, the repository write them together with the close
+func (s *stocktakeUsecase) adjustment(actorId, id, reason string, line model.StocktakeLine) (model.StocktakeAdjustment, error) {
+	asset, err := s.assetUC.FindById(line.AssetId)
+	if err != nil {
+		return model.StocktakeAdjustment{}, err
+	}
+	assetAudit, err := s.auditUC.Entry(actorId, model.AuditEntityAsset, asset.Id, model.AuditActionUpdate, asset, nil)
+	if err != nil {
+		return model.StocktakeAdjustment{}, err
+	}
+	audit, err := s.auditUC.Entry(actorId, model.AuditEntityStocktake, id, model.AuditActionAdjust, line, stocktakeAdjustment{
+		AssetId:    line.AssetId,
+		Reason:     reason,
+		Expected:   line.Expected,
+		Counted:    *line.Counted,
+		Difference: *line.Difference,
+	})
+	if err != nil {
+		return model.StocktakeAdjustment{}, err
+	}
+	return model.StocktakeAdjustment{Line: line, AssetAudit: assetAudit, Audit: audit}, nil
+}
+
+func (s *stocktakeUsecase) find(id string) (model.Stocktake, error) {
+	stocktake, err := s.repo.FindById(id)
+	if err != nil {
+		return model.Stocktake{}, stocktakeErr(err)
+	}
+	return stocktake, nil
+}
+
+func (s *stocktakeUsecase) report(stocktake model.Stocktake) (dto.StocktakeReport, error) {
+	lines, err := s.repo.Lines(stocktake)
+	if err != nil {
+		return dto.StocktakeReport{}, fmt.Errorf("failed to get stocktake report: %v", err)
+	}
+
+	report := dto.StocktakeReport{Stocktake: stocktake, Lines: lines}
+	for i := range report.Lines {
+		line := &report.Lines[i]
+		line.Expected = line.Total - line.OnLoan
+		line.AvailableDrift = line.Available - (line.Expected - line.Reserved)
+		report.Summary.Assets++
+		if line.Counted == nil {
+			report.Summary.Uncounted++
+			continue
+		}
+		difference := *line.Counted - line.Expected
+		line.Difference = &difference
+		report.Summary.Counted++
+		if difference == 0 {
+			report.Summary.Matched++
+		} else {
+			report.Summary.Discrepancies++
+		}
+	}
+	return report, nil
+}
+
+func stocktakeErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrStocktakeNotFound):
+		return exception.NotFoundErr("stocktake not found")
+	case errors.Is(err, repository.ErrStocktakeClosed):
+		return exception.BadRequestErr("stocktake is already closed")
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return exception.BadRequestErr(fmt.Sprintf("failed to adjust, stock changed meanwhile: %v", err))
+	}
+	return err
+}
+
+func NewStocktakeUsecase(repo repository.StocktakeRepository, assetUC AssetUsecase, categoryUC CategoryUsecase, auditUC AuditUsecase) StocktakeUsecase {
+	return &stocktakeUsecase{
+		repo:       repo,
+		assetUC:    assetUC,
+		categoryUC: categoryUC,
+		auditUC:    auditUC,
+	}
+}
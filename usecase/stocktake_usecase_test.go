@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/__mock__/usecasemock"
+	"final-project-enigma-clean/exception"
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/model/dto"
+	"final-project-enigma-clean/repository"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type StocktakeUsecaseSuite struct {
+	suite.Suite
+	repo       *repomock.StocktakeRepoMock
+	assetUC    *usecasemock.AssetUsecaseMock
+	categoryUC *usecasemock.CategoryUsecaseMock
+	auditUC    *usecasemock.AuditUsecaseMock
+	usecase    StocktakeUsecase
+}
+
+var openStocktake = model.Stocktake{Id: "s1", Name: "Q3 count", CategoryId: "c1", Status: model.StocktakeOpen}
+
+func (suite *StocktakeUsecaseSuite) SetupTest() {
+	suite.repo = new(repomock.StocktakeRepoMock)
+	suite.assetUC = new(usecasemock.AssetUsecaseMock)
+	suite.categoryUC = new(usecasemock.CategoryUsecaseMock)
+	suite.auditUC = new(usecasemock.AuditUsecaseMock)
+	suite.auditUC.On("Entry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testAudit, nil).Maybe()
+	suite.usecase = NewStocktakeUsecase(suite.repo, suite.assetUC, suite.categoryUC, suite.auditUC)
+}
+
+func TestStocktakeUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(StocktakeUsecaseSuite))
+}
+
+func counted(n int) *int {
+	return &n
+}
+
+func (suite *StocktakeUsecaseSuite) TestOpen_Success() {
+	suite.categoryUC.On("FindById", "c1").Return(model.Category{Id: "c1"}, nil)
+	suite.repo.On("Save", mock.MatchedBy(func(stocktake model.Stocktake) bool {
+		return stocktake.Id != "" && stocktake.Name == "Q3 count" && stocktake.Status == model.StocktakeOpen && stocktake.OpenedBy == testActor
+	}), testAudit).Return(nil)
+
+	stocktake, err := suite.usecase.Open(testActor, dto.StocktakeRequest{Name: " Q3 count ", CategoryId: "c1"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "c1", stocktake.CategoryId)
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityStocktake, stocktake.Id, model.AuditActionCreate, nil, stocktake)
+}
+
+func (suite *StocktakeUsecaseSuite) TestRecordCount_Invalid() {
+	for _, payload := range []dto.StocktakeCountRequest{
+		{Counted: counted(1)},
+		{AssetId: "a1"},
+		{AssetId: "a1", Counted: counted(-1)},
+	} {
+		err := suite.usecase.RecordCount(testActor, "s1", payload)
+		assert.IsType(suite.T(), &exception.Http{}, err)
+	}
+	suite.repo.AssertNotCalled(suite.T(), "SaveCount", mock.Anything)
+}
+
+func (suite *StocktakeUsecaseSuite) TestRecordCount_OutOfScope() {
+	suite.repo.On("FindById", "s1").Return(openStocktake, nil)
+	suite.assetUC.On("FindById", "a1").Return(model.Asset{Id: "a1", Name: "Kursi", Category: model.Category{Id: "c2"}}, nil)
+
+	err := suite.usecase.RecordCount(testActor, "s1", dto.StocktakeCountRequest{AssetId: "a1", Counted: counted(3)})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+	suite.repo.AssertNotCalled(suite.T(), "SaveCount", mock.Anything)
+}
+
+func (suite *StocktakeUsecaseSuite) TestRecordCount_Closed() {
+	closed := openStocktake
+	closed.Status = model.StocktakeClosed
+	suite.repo.On("FindById", "s1").Return(closed, nil)
+
+	err := suite.usecase.RecordCount(testActor, "s1", dto.StocktakeCountRequest{AssetId: "a1", Counted: counted(3)})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+}
+
+func (suite *StocktakeUsecaseSuite) TestRecordCount_Success() {
+	suite.repo.On("FindById", "s1").Return(openStocktake, nil)
+	suite.assetUC.On("FindById", "a1").Return(model.Asset{Id: "a1", Category: model.Category{Id: "c1"}}, nil)
+	suite.repo.On("SaveCount", mock.MatchedBy(func(count model.StocktakeCount) bool {
+		return count.StocktakeId == "s1" && count.AssetId == "a1" && count.Location == "Gudang" && count.Counted == 3 && count.CountedBy == testActor
+	})).Return(nil)
+
+	err := suite.usecase.RecordCount(testActor, "s1", dto.StocktakeCountRequest{AssetId: "a1", Location: " Gudang ", Counted: counted(3)})
+	assert.NoError(suite.T(), err)
+}
+
+func (suite *StocktakeUsecaseSuite) TestReport_NotFound() {
+	suite.repo.On("FindById", "x").Return(model.Stocktake{}, repository.ErrStocktakeNotFound)
+
+	_, err := suite.usecase.Report("x")
+	assert.Equal(suite.T(), http.StatusNotFound, err.(*exception.Http).StatusCode)
+}
+
+func (suite *StocktakeUsecaseSuite) TestReport_Success() {
+	suite.repo.On("FindById", "s1").Return(openStocktake, nil)
+	suite.repo.On("Lines", openStocktake).Return([]model.StocktakeLine{
+		{AssetId: "a1", Total: 10, Available: 8, OnLoan: 2, Counted: counted(7)},
+		{AssetId: "a2", Total: 5, Available: 5, Counted: counted(5)},
+		{AssetId: "a3", Total: 4, Available: 4},
+	}, nil)
+
+	report, err := suite.usecase.Report("s1")
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), dto.StocktakeSummary{Assets: 3, Counted: 2, Uncounted: 1, Matched: 1, Discrepancies: 1}, report.Summary)
+	assert.Equal(suite.T(), 8, report.Lines[0].Expected)
+	assert.Equal(suite.T(), -1, *report.Lines[0].Difference)
+	assert.Nil(suite.T(), report.Lines[2].Difference)
+}
+
+func (suite *StocktakeUsecaseSuite) TestReport_Reserved() {
+	suite.repo.On("FindById", "s1").Return(openStocktake, nil)
+	suite.repo.On("Lines", openStocktake).Return([]model.StocktakeLine{
+		{AssetId: "a1", Total: 10, Available: 5, OnLoan: 2, Reserved: 3, Counted: counted(8)},
+	}, nil)
+
+	report, err := suite.usecase.Report("s1")
+	assert.NoError(suite.T(), err)
+	//the reserved item are on the shelf but not available
+	assert.Equal(suite.T(), 8, report.Lines[0].Expected)
+	assert.Equal(suite.T(), 0, *report.Lines[0].Difference)
+	assert.Equal(suite.T(), 0, report.Lines[0].AvailableDrift)
+}
+
+func (suite *StocktakeUsecaseSuite) TestClose_AdjustWithoutReason() {
+	_, err := suite.usecase.Close(testActor, "s1", dto.StocktakeCloseRequest{Adjust: true, Reason: " "})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+	suite.repo.AssertNotCalled(suite.T(), "Close", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *StocktakeUsecaseSuite) TestClose_Adjust() {
+	asset := model.Asset{Id: "a1", Name: "Kursi", Category: model.Category{Id: "c1"}, AssetType: model.TypeAsset{Id: "t1"},
+		Status: "Ready", Total: 10, Available: 8}
+	suite.repo.On("FindById", "s1").Return(openStocktake, nil)
+	suite.repo.On("Lines", openStocktake).Return([]model.StocktakeLine{
+		{AssetId: "a1", Total: 10, Available: 7, OnLoan: 2, Counted: counted(6)},
+		{AssetId: "a2", Total: 3, Available: 3, TrackedByUnit: true, Counted: counted(2)},
+		{AssetId: "a3", Total: 4, Available: 4},
+	}, nil)
+	suite.assetUC.On("FindById", "a1").Return(asset, nil)
+	suite.repo.On("Close", "s1", testActor, mock.Anything, testAudit, mock.MatchedBy(func(adjustments []model.StocktakeAdjustment) bool {
+		return len(adjustments) == 1 && adjustments[0].Line.AssetId == "a1" && *adjustments[0].Line.Difference == -2 &&
+			adjustments[0].Line.AvailableDrift == -1
+	})).Return(nil)
+
+	report, err := suite.usecase.Close(testActor, "s1", dto.StocktakeCloseRequest{Adjust: true, Reason: "yearly count"})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"a1"}, report.Adjusted)
+	assert.Equal(suite.T(), model.StocktakeClosed, report.Stocktake.Status)
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityStocktake, "s1", model.AuditActionAdjust,
+		report.Lines[0], stocktakeAdjustment{AssetId: "a1", Reason: "yearly count", Expected: 8, Counted: 6, Difference: -2})
+	suite.auditUC.AssertCalled(suite.T(), "Entry", testActor, model.AuditEntityAsset, "a1", model.AuditActionUpdate, asset, nil)
+}
+
+func (suite *StocktakeUsecaseSuite) TestClose_AlreadyClosed() {
+	suite.repo.On("FindById", "s1").Return(openStocktake, nil)
+	suite.repo.On("Lines", openStocktake).Return([]model.StocktakeLine{}, nil)
+	suite.repo.On("Close", "s1", testActor, mock.Anything, testAudit, []model.StocktakeAdjustment(nil)).Return(repository.ErrStocktakeClosed)
+
+	_, err := suite.usecase.Close(testActor, "s1", dto.StocktakeCloseRequest{})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+}
+
+func (suite *StocktakeUsecaseSuite) TestClose_AdjustFailed() {
+	suite.repo.On("FindById", "s1").Return(openStocktake, nil)
+	suite.repo.On("Lines", openStocktake).Return([]model.StocktakeLine{
+		{AssetId: "a1", Total: 10, Available: 7, OnLoan: 2, Counted: counted(6)},
+	}, nil)
+	suite.assetUC.On("FindById", "a1").Return(model.Asset{Id: "a1"}, nil)
+	suite.repo.On("Close", "s1", testActor, mock.Anything, testAudit, mock.Anything).
+		Return(fmt.Errorf("%w: asset a1", repository.ErrInsufficientStock))
+
+	_, err := suite.usecase.Close(testActor, "s1", dto.StocktakeCloseRequest{Adjust: true, Reason: "yearly count"})
+	assert.Equal(suite.T(), http.StatusBadRequest, err.(*exception.Http).StatusCode)
+}
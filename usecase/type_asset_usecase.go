@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"errors"
 	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
@@ -10,17 +11,20 @@ import (
 )
 
 type TypeAssetUseCase interface {
-	CreateNew(payload model.TypeAsset) error
+	CreateNew(actorId string, payload model.TypeAsset) error
 	FindByName(name string) ([]model.TypeAsset, error)
 	FindById(id string) (model.TypeAsset, error)
 	FindAll() ([]model.TypeAsset, error)
-	Update(payload model.TypeAsset) error
-	Delete(id string) error
+	Update(actorId string, payload model.TypeAsset) error
+	Delete(actorId, id string) error
+	Restore(actorId, id string) error
 	Paging(payload dto.PageRequest) ([]model.TypeAsset, dto.Paging, error)
+	PagingCursor(payload dto.CursorRequest) ([]model.TypeAsset, dto.CursorPaging, error)
 }
 
 type typeAssetUseCase struct {
-	repo repository.TypeAssetRepository
+	repo    repository.TypeAssetRepository
+	auditUC AuditUsecase
 }
 
 // FindById implements TypeAssetUseCase.
@@ -34,12 +38,16 @@ func (t *typeAssetUseCase) FindById(id string) (model.TypeAsset, error) {
 }
 
 // CreateNew implements TypeAssetUseCase.
-func (t *typeAssetUseCase) CreateNew(payload model.TypeAsset) error {
+func (t *typeAssetUseCase) CreateNew(actorId string, payload model.TypeAsset) error {
 	if payload.Name == "" {
 		return exception.BadRequestErr("name cannot Empty")
 	}
 	payload.Id = helper.GenerateUUID()
-	err := t.repo.Save(payload)
+	audit, err := t.auditUC.Entry(actorId, model.AuditEntityTypeAsset, payload.Id, model.AuditActionCreate, nil, payload)
+	if err != nil {
+		return err
+	}
+	err = t.repo.Save(payload, audit)
 	if err != nil {
 		return fmt.Errorf("failed to create new type asset: %v", err)
 	}
@@ -47,12 +55,16 @@ func (t *typeAssetUseCase) CreateNew(payload model.TypeAsset) error {
 }
 
 // Delete implements TypeAssetUseCase.
-func (t *typeAssetUseCase) Delete(id string) error {
+func (t *typeAssetUseCase) Delete(actorId, id string) error {
 	typeAsset, err := t.FindById(id)
 	if err != nil {
 		return err
 	}
-	err = t.repo.Delete(typeAsset.Id)
+	audit, err := t.auditUC.Entry(actorId, model.AuditEntityTypeAsset, typeAsset.Id, model.AuditActionDelete, typeAsset, nil)
+	if err != nil {
+		return err
+	}
+	err = t.repo.Delete(typeAsset.Id, audit)
 	if err != nil {
 		return fmt.Errorf("failed to delete type asset: %v", err)
 	}
@@ -78,29 +90,60 @@ func (t *typeAssetUseCase) FindByName(name string) ([]model.TypeAsset, error) {
 
 }
 
+// PagingCursor implements TypeAssetUseCase.
+func (t *typeAssetUseCase) PagingCursor(payload dto.CursorRequest) ([]model.TypeAsset, dto.CursorPaging, error) {
+	payload, err := cursorRequest(payload)
+	if err != nil {
+		return nil, dto.CursorPaging{}, err
+	}
+	return t.repo.PagingCursor(payload)
+}
+
 // Paging implements TypeAssetUseCase.
 func (t *typeAssetUseCase) Paging(payload dto.PageRequest) ([]model.TypeAsset, dto.Paging, error) {
 	return t.repo.Paging(payload)
 }
 
 // Update implements TypeAssetUseCase.
-func (t *typeAssetUseCase) Update(payload model.TypeAsset) error {
+func (t *typeAssetUseCase) Update(actorId string, payload model.TypeAsset) error {
 	if payload.Name == "" {
 		return exception.BadRequestErr("name cannot Empty")
 	}
-	_, err := t.FindById(payload.Id)
+	before, err := t.FindById(payload.Id)
+	if err != nil {
+		return err
+	}
+	audit, err := t.auditUC.Entry(actorId, model.AuditEntityTypeAsset, payload.Id, model.AuditActionUpdate, before, payload)
 	if err != nil {
 		return err
 	}
-	err = t.repo.Update(payload)
+	err = t.repo.Update(payload, audit)
 	if err != nil {
 		return fmt.Errorf("failed to update type asset: %v", err)
 	}
 	return nil
 }
 
-func NewTypeAssetUseCase(repo repository.TypeAssetRepository) TypeAssetUseCase {
+// Restore implements TypeAssetUseCase.
+// it bring back a deleted type asset, the repository add the restored row to the audit entry
+func (t *typeAssetUseCase) Restore(actorId, id string) error {
+	audit, err := t.auditUC.Entry(actorId, model.AuditEntityTypeAsset, id, model.AuditActionRestore, nil, nil)
+	if err != nil {
+		return err
+	}
+	err = t.repo.Restore(id, audit)
+	if errors.Is(err, repository.ErrNotDeleted) {
+		return exception.NotFoundErr("deleted type asset not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore type asset: %v", err)
+	}
+	return nil
+}
+
+func NewTypeAssetUseCase(repo repository.TypeAssetRepository, auditUC AuditUsecase) TypeAssetUseCase {
 	return &typeAssetUseCase{
-		repo: repo,
+		repo:    repo,
+		auditUC: auditUC,
 	}
 }
@@ -3,23 +3,28 @@ package usecase
 import (
 	"errors"
 	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/__mock__/usecasemock"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/model/dto"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
 type TypeAssetUsecaseTestSuite struct {
 	suite.Suite
 	repo    *repomock.TypeAssetRepoMock
+	auditUC *usecasemock.AuditUsecaseMock
 	usecase TypeAssetUseCase
 }
 
 func (suite *TypeAssetUsecaseTestSuite) SetupTest() {
 	suite.repo = new(repomock.TypeAssetRepoMock)
-	suite.usecase = NewTypeAssetUseCase(suite.repo)
+	suite.auditUC = new(usecasemock.AuditUsecaseMock)
+	suite.auditUC.On("Entry", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(testAudit, nil).Maybe()
+	suite.usecase = NewTypeAssetUseCase(suite.repo, suite.auditUC)
 }
 
 func TestTypeAssetUsecaseTestSuite(t *testing.T) {
@@ -31,8 +36,8 @@ func (suite *TypeAssetUsecaseTestSuite) TestCreate_Success() {
 		Id:   "1",
 		Name: "Product A",
 	}
-	suite.repo.On("Save", mockData).Return(nil)
-	err := suite.usecase.CreateNew(mockData)
+	suite.repo.On("Save", mockData, testAudit).Return(nil)
+	err := suite.usecase.CreateNew(testActor, mockData)
 	assert.Nil(suite.T(), err)
 	assert.NoError(suite.T(), err)
 
@@ -40,7 +45,7 @@ func (suite *TypeAssetUsecaseTestSuite) TestCreate_Success() {
 
 func (suite *TypeAssetUsecaseTestSuite) TestCreate_EmptyField() {
 
-	gotErr := suite.usecase.CreateNew(model.TypeAsset{
+	gotErr := suite.usecase.CreateNew(testActor, model.TypeAsset{
 		Id:   "1",
 		Name: "",
 	})
@@ -53,8 +58,8 @@ func (suite *TypeAssetUsecaseTestSuite) TestCreate_Failed() {
 		Name: "Bergerak",
 	}
 
-	suite.repo.On("Save", mockData).Return(errors.New("failed save type asset"))
-	gotErr := suite.usecase.CreateNew(mockData)
+	suite.repo.On("Save", mockData, testAudit).Return(errors.New("failed save type asset"))
+	gotErr := suite.usecase.CreateNew(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -124,14 +129,14 @@ func (suite *TypeAssetUsecaseTestSuite) TestUpdate_Success() {
 		Name: "Bergerak",
 	}
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Update", mockData).Return(nil)
-	gotErr := suite.usecase.Update(mockData)
+	suite.repo.On("Update", mockData, testAudit).Return(nil)
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *TypeAssetUsecaseTestSuite) TestUpdate_EmptyField() {
 
-	gotErr := suite.usecase.Update(model.TypeAsset{
+	gotErr := suite.usecase.Update(testActor, model.TypeAsset{
 		Id:   "1",
 		Name: "",
 	})
@@ -144,7 +149,7 @@ func (suite *TypeAssetUsecaseTestSuite) TestUpdate_InvalidId() {
 		Name: "Bergerak",
 	}
 	suite.repo.On("FindById", "1").Return(model.TypeAsset{}, errors.New("failed get typeAsset"))
-	gotErr := suite.usecase.Update(mockData)
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -155,8 +160,8 @@ func (suite *TypeAssetUsecaseTestSuite) TestUpdate_Failed() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Update", mockData).Return(errors.New("failed update typeAsset"))
-	gotErr := suite.usecase.Update(mockData)
+	suite.repo.On("Update", mockData, testAudit).Return(errors.New("failed update typeAsset"))
+	gotErr := suite.usecase.Update(testActor, mockData)
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -167,14 +172,14 @@ func (suite *TypeAssetUsecaseTestSuite) TestDelete_Success() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Delete", "1").Return(nil)
-	gotErr := suite.usecase.Delete("1")
+	suite.repo.On("Delete", "1", testAudit).Return(nil)
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.NoError(suite.T(), gotErr)
 }
 
 func (suite *TypeAssetUsecaseTestSuite) TestDelete_InvalidId() {
 	suite.repo.On("FindById", "1").Return(model.TypeAsset{}, errors.New("failed get typeAsset"))
-	gotErr := suite.usecase.Delete("1")
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.Error(suite.T(), gotErr)
 }
 
@@ -185,8 +190,8 @@ func (suite *TypeAssetUsecaseTestSuite) TestDelete_Failed() {
 	}
 
 	suite.repo.On("FindById", "1").Return(mockData, nil)
-	suite.repo.On("Delete", "1").Return(errors.New("failed delete"))
-	gotErr := suite.usecase.Delete("1")
+	suite.repo.On("Delete", "1", testAudit).Return(errors.New("failed delete"))
+	gotErr := suite.usecase.Delete(testActor, "1")
 	assert.Error(suite.T(), gotErr)
 }
 
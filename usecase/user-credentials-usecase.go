@@ -2,12 +2,15 @@ package usecase
 
 import (
 	"errors"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
 	"final-project-enigma-clean/repository"
+	"final-project-enigma-clean/util/emailtemplate"
 	"final-project-enigma-clean/util/helper"
 	"fmt"
 	"regexp"
 	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gookit/slog"
@@ -24,10 +27,29 @@ type UserCredentialUsecase interface {
 	EmailExist(email string) bool
 	ForgotPass(email string) error
 	ForgotPassRequest(email, newPassword, confirmPassword string) error
+	ResetPassword(email, newPassword string) error
+	IssueToken(email string) (model.TokenPair, error)
+	RefreshToken(refreshToken string) (model.TokenPair, error)
+	Logout(refreshToken string) error
+	CheckSession(userID, familyID string) error
+	UpdateRole(payload model.UpdateRoleRequest) error
+	UpdateStatus(payload model.UpdateStatusRequest) error
+	UpdateLocale(userID, locale string) error
+	VerifyOTP(purpose, email string, otp int) error
 }
 
+const (
+	// OTPTTL is how long an emailed otp stay valid
+	OTPTTL = 30 * time.Minute
+	// RefreshTokenTTL is how long a login can be renewed without entering otp again
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 type userDetailUsecase struct {
 	udetailsRepo repository.UserCredentialsRepository
+	otpStore     repository.OTPStore
+	tokenRepo    repository.TokenRepository
+	outboxRepo   repository.EmailOutboxRepository
 }
 
 func (u *userDetailUsecase) FindingUserEmailPass(email string) (userlogin model.ChangePasswordRequest, err error) {
@@ -45,9 +67,13 @@ func (u *userDetailUsecase) LoginUserChangePass(user model.ChangePasswordRequest
 	}
 
 	//logic otp
-	otp, _ := helper.GenerateOTP()
-	helper.SendOTPChangePassword(user.Email, strconv.Itoa(otp))
-	OTPMap[user.Email] = otp
+	otp, err := u.issueOTP(model.OTPPurposeChangePassword, user.Email)
+	if err != nil {
+		return "", err
+	}
+	if err = u.sendTemplate(user.Email, emailtemplate.EventChangePasswordOTP, u.localeOf(user.Email), otpData(otp)); err != nil {
+		return "", err
+	}
 	slog.Infof("Sending otp to %v", user.Email)
 
 	return user.NewPassword, nil
@@ -81,20 +107,18 @@ func (u *userDetailUsecase) RegisterUser(user model.UserRegisterRequest) error {
 		return errors.New("Invalid email")
 	}
 
-	//password requirement area
-	if len(user.Password) < 6 {
-		return fmt.Errorf("Password must contain at least six number")
-	}
-	if !helper.ContainsUppercase(user.Password) {
-		return fmt.Errorf("Password must contain at least one uppercase letter")
-	}
-
-	if !helper.ContainsSpecialChar(user.Password) {
-		return fmt.Errorf("Password must contain at least one special character")
+	if err = validatePassword(user.Password); err != nil {
+		return err
 	}
 
 	//generate uuid for user id
 	user.ID = helper.GenerateUUID()
+	//new user only get read access until admin grant another role
+	user.Role = model.RoleViewer
+	if user.Locale != "" && !emailtemplate.IsValidLocale(user.Locale) {
+		return fmt.Errorf("Locale must be id or en")
+	}
+	user.Locale = emailtemplate.NormalizeLocale(user.Locale)
 
 	//hash password using bcrypt
 	hashedPass, err := helper.HashPassword(user.Password)
@@ -103,18 +127,15 @@ func (u *userDetailUsecase) RegisterUser(user model.UserRegisterRequest) error {
 	}
 	user.Password = hashedPass
 
-	//save
-	if err = u.udetailsRepo.UserRegister(user); err != nil {
+	welcome, err := outboxMessage(user.Email, emailtemplate.EventWelcome, user.Locale, emailtemplate.WelcomeData{Name: user.Name})
+	if err != nil {
 		return err
 	}
 
-	//email
-	helper.SendEmailRegister(user.Email, user.Name)
-	return nil
+	//save, the welcome email is delivered by the outbox worker
+	return u.udetailsRepo.UserRegister(user, welcome)
 }
 
-var OTPMap = make(map[string]int)
-
 // login business logic
 func (u *userDetailUsecase) LoginUser(userlogin model.UserLoginRequest) (string, error) {
 	// TODO implement me
@@ -131,9 +152,13 @@ func (u *userDetailUsecase) LoginUser(userlogin model.UserLoginRequest) (string,
 	}
 
 	//logic otp
-	otp, _ := helper.GenerateOTP()
-	helper.SendEmailWithOTP(user.Email, strconv.Itoa(otp))
-	OTPMap[user.Email] = otp
+	otp, err := u.issueOTP(model.OTPPurposeLogin, user.Email)
+	if err != nil {
+		return "", err
+	}
+	if err = u.sendTemplate(user.Email, emailtemplate.EventLoginOTP, u.localeOf(user.Email), otpData(otp)); err != nil {
+		return "", err
+	}
 	slog.Infof("Sending otp to %v", user.Email)
 
 	// return id
@@ -182,9 +207,13 @@ func (u *userDetailUsecase) ForgotPass(email string) error {
 	}
 
 	//send otp to email
-	otp, _ := helper.GenerateOTP()
-	helper.SendOTPForgotPass(user.Email, strconv.Itoa(otp))
-	OTPMap[user.Email] = otp
+	otp, err := u.issueOTP(model.OTPPurposeForgotPassword, user.Email)
+	if err != nil {
+		return err
+	}
+	if err = u.sendTemplate(user.Email, emailtemplate.EventForgotPasswordOTP, u.localeOf(user.Email), otpData(otp)); err != nil {
+		return err
+	}
 	slog.Infof("user %v has forgot password, Sending otp ....", user.Email)
 
 	return nil
@@ -213,8 +242,261 @@ func (u *userDetailUsecase) ForgotPassRequest(email, newPassword, confirmPasswor
 	return nil
 }
 
-func NewUserCredentialUsecase(udetailsRepo repository.UserCredentialsRepository) UserCredentialUsecase {
+// ResetPassword set a new password without otp, it is run by operators from the cli,
+// every login of the user is revoked
+func (u *userDetailUsecase) ResetPassword(email, newPassword string) error {
+	user, err := u.udetailsRepo.FindUserByEmail(email)
+	if err != nil {
+		return exception.NotFoundErr("user not found")
+	}
+	if err = validatePassword(newPassword); err != nil {
+		return err
+	}
+
+	hashedPass, err := helper.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("Failed to generate password %v", err.Error())
+	}
+	if err = u.udetailsRepo.ChangePassword(user.Email, hashedPass); err != nil {
+		return err
+	}
+	if err = u.tokenRepo.RevokeAllForUser(user.ID); err != nil {
+		return fmt.Errorf("failed to revoke token: %v", err)
+	}
+	return nil
+}
+
+// issue access and refresh token of a new login family, called after otp is verified
+func (u *userDetailUsecase) IssueToken(email string) (model.TokenPair, error) {
+	user, err := u.udetailsRepo.FindUserByEmail(email)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	if !user.IsActive {
+		return model.TokenPair{}, fmt.Errorf("User is not active")
+	}
+
+	refreshToken, hash, err := helper.GenerateRefreshToken()
+	if err != nil {
+		return model.TokenPair{}, fmt.Errorf("failed to generate refresh token: %v", err)
+	}
+	familyID := helper.GenerateUUID()
+	err = u.tokenRepo.Save(model.RefreshToken{
+		ID:        helper.GenerateUUID(),
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	})
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+
+	return u.tokenPair(user, familyID, refreshToken)
+}
+
+// exchange a refresh token for a new pair, the used refresh token can not be used again
+func (u *userDetailUsecase) RefreshToken(refreshToken string) (model.TokenPair, error) {
+	if refreshToken == "" {
+		return model.TokenPair{}, exception.BadRequestErr("refresh token cannot empty")
+	}
+
+	nextToken, hash, err := helper.GenerateRefreshToken()
+	if err != nil {
+		return model.TokenPair{}, fmt.Errorf("failed to generate refresh token: %v", err)
+	}
+	rotated, err := u.tokenRepo.Rotate(helper.HashRefreshToken(refreshToken), model.RefreshToken{
+		ID:        helper.GenerateUUID(),
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTokenReused):
+			slog.Warnf("refresh token reuse detected, token family has been revoked")
+			return model.TokenPair{}, exception.UnauthorizedErr("Refresh token has been revoked")
+		case errors.Is(err, repository.ErrTokenNotFound), errors.Is(err, repository.ErrTokenRevoked):
+			return model.TokenPair{}, exception.UnauthorizedErr("Refresh token has been revoked")
+		case errors.Is(err, repository.ErrTokenExpired):
+			return model.TokenPair{}, exception.UnauthorizedErr("Refresh token expired")
+		}
+		return model.TokenPair{}, fmt.Errorf("failed to rotate refresh token: %v", err)
+	}
+
+	//role or status may have changed since the last token
+	user, err := u.udetailsRepo.FindUserByID(rotated.UserID)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	if !user.IsActive {
+		u.tokenRepo.RevokeAllForUser(user.ID)
+		return model.TokenPair{}, exception.UnauthorizedErr("User is not active")
+	}
+
+	return u.tokenPair(user, rotated.FamilyID, nextToken)
+}
+
+// logout revoke every refresh token of the login the given token belong to
+func (u *userDetailUsecase) Logout(refreshToken string) error {
+	if refreshToken == "" {
+		return exception.BadRequestErr("refresh token cannot empty")
+	}
+	if err := u.tokenRepo.RevokeByHash(helper.HashRefreshToken(refreshToken)); err != nil {
+		if errors.Is(err, repository.ErrTokenNotFound) {
+			return exception.UnauthorizedErr("Refresh token has been revoked")
+		}
+		return err
+	}
+	return nil
+}
+
+// check that the access token still belong to an active user and a live login
+func (u *userDetailUsecase) CheckSession(userID, familyID string) error {
+	if userID == "" || familyID == "" {
+		return exception.UnauthorizedErr("Token has been revoked")
+	}
+	active, err := u.tokenRepo.IsSessionActive(userID, familyID)
+	if err != nil {
+		return fmt.Errorf("failed to check session: %v", err)
+	}
+	if !active {
+		return exception.UnauthorizedErr("Token has been revoked")
+	}
+	return nil
+}
+
+func (u *userDetailUsecase) tokenPair(user model.UserCredentials, familyID, refreshToken string) (model.TokenPair, error) {
+	accessToken, err := helper.GenerateJWT(user, familyID)
+	if err != nil {
+		return model.TokenPair{}, err
+	}
+	return model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(helper.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (u *userDetailUsecase) UpdateRole(payload model.UpdateRoleRequest) error {
+	if payload.ID == "" {
+		return exception.BadRequestErr("id cannot empty")
+	}
+	if !model.IsValidRole(payload.Role) {
+		return exception.BadRequestErr(fmt.Sprintf("role %s is not valid", payload.Role))
+	}
+
+	if err := u.udetailsRepo.UpdateRole(payload.ID, payload.Role); err != nil {
+		return fmt.Errorf("failed to update role: %v", err)
+	}
+	//the role is carried by the tokens, the user log in again to get the new one
+	if err := u.tokenRepo.RevokeAllForUser(payload.ID); err != nil {
+		return fmt.Errorf("failed to revoke token: %v", err)
+	}
+	return nil
+}
+
+// deactivated user lose access at once, all of their login are revoked
+func (u *userDetailUsecase) UpdateStatus(payload model.UpdateStatusRequest) error {
+	if payload.ID == "" {
+		return exception.BadRequestErr("id cannot empty")
+	}
+
+	if err := u.udetailsRepo.UpdateStatus(payload.ID, payload.IsActive); err != nil {
+		return fmt.Errorf("failed to update status: %v", err)
+	}
+	if !payload.IsActive {
+		if err := u.tokenRepo.RevokeAllForUser(payload.ID); err != nil {
+			return fmt.Errorf("failed to revoke token: %v", err)
+		}
+	}
+	return nil
+}
+
+// render the event in the recipient locale and queue it in the outbox,
+// a slow mail provider no longer block the request
+func (u *userDetailUsecase) sendTemplate(to, event, locale string, data any) error {
+	email, err := outboxMessage(to, event, locale, data)
+	if err != nil {
+		return err
+	}
+	if err = u.outboxRepo.Enqueue(email); err != nil {
+		return fmt.Errorf("failed to queue email: %v", err)
+	}
+	return nil
+}
+
+// locale of the recipient, unknown user get the default locale
+func (u *userDetailUsecase) localeOf(email string) string {
+	user, err := u.udetailsRepo.FindUserByEmail(email)
+	if err != nil {
+		return emailtemplate.DefaultLocale
+	}
+	return emailtemplate.NormalizeLocale(user.Locale)
+}
+
+//password requirement area
+func validatePassword(password string) error {
+	if len(password) < 6 {
+		return fmt.Errorf("Password must contain at least six number")
+	}
+	if !helper.ContainsUppercase(password) {
+		return fmt.Errorf("Password must contain at least one uppercase letter")
+	}
+	if !helper.ContainsSpecialChar(password) {
+		return fmt.Errorf("Password must contain at least one special character")
+	}
+	return nil
+}
+
+func otpData(otp int) emailtemplate.OTPData {
+	return emailtemplate.OTPData{OTP: strconv.Itoa(otp), ValidMinutes: int(OTPTTL.Minutes())}
+}
+
+// user choose the language of the email they receive
+func (u *userDetailUsecase) UpdateLocale(userID, locale string) error {
+	if !emailtemplate.IsValidLocale(locale) {
+		return exception.BadRequestErr("locale must be id or en")
+	}
+	if err := u.udetailsRepo.UpdateLocale(userID, locale); err != nil {
+		return fmt.Errorf("failed to update locale: %v", err)
+	}
+	return nil
+}
+
+// generate a fresh otp for the purpose, replacing the previous one
+func (u *userDetailUsecase) issueOTP(purpose, email string) (int, error) {
+	otp, err := helper.GenerateOTP()
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate otp: %v", err)
+	}
+	if err = u.otpStore.Save(purpose, email, otp, time.Now().Add(OTPTTL)); err != nil {
+		return 0, fmt.Errorf("failed to save otp: %v", err)
+	}
+	return otp, nil
+}
+
+// verify and consume the otp, a code is only valid for the purpose it was sent for
+func (u *userDetailUsecase) VerifyOTP(purpose, email string, otp int) error {
+	err := u.otpStore.Verify(purpose, email, otp)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, repository.ErrOTPNotFound), errors.Is(err, repository.ErrOTPExpired):
+		return exception.UnauthorizedErr("OTP not found or expired")
+	case errors.Is(err, repository.ErrOTPTooManyAttempts):
+		return exception.UnauthorizedErr("Too many invalid OTP, please request a new one")
+	case errors.Is(err, repository.ErrOTPInvalid):
+		return exception.UnauthorizedErr("OTP is invalid")
+	default:
+		return fmt.Errorf("failed to verify otp: %v", err)
+	}
+}
+
+func NewUserCredentialUsecase(udetailsRepo repository.UserCredentialsRepository, otpStore repository.OTPStore, tokenRepo repository.TokenRepository, outboxRepo repository.EmailOutboxRepository) UserCredentialUsecase {
 	return &userDetailUsecase{
 		udetailsRepo: udetailsRepo,
+		otpStore:     otpStore,
+		tokenRepo:    tokenRepo,
+		outboxRepo:   outboxRepo,
 	}
 }
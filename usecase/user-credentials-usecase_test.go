@@ -3,8 +3,13 @@ package usecase_test
 import (
 	"errors"
 	"final-project-enigma-clean/__mock__/repomock"
+	"final-project-enigma-clean/exception"
 	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/repository"
 	"final-project-enigma-clean/usecase"
+	"final-project-enigma-clean/util/helper"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -20,18 +25,27 @@ type MockUserCredentialsRepository struct {
 type UserCredentialSuite struct {
 	suite.Suite
 	repo       *repomock.MockUserCredentialsRepository
+	otpStore   *repomock.OTPStoreMock
+	tokenRepo  *repomock.TokenRepoMock
+	outboxRepo *repomock.EmailOutboxRepoMock
 	usecase    usecase.UserCredentialUsecase
 	repository *MockUserCredentialsRepository
 }
 
 func (suite *UserCredentialSuite) SetupTest() {
 	suite.repo = new(repomock.MockUserCredentialsRepository)
-	suite.usecase = usecase.NewUserCredentialUsecase(suite.repo)
+	suite.otpStore = new(repomock.OTPStoreMock)
+	suite.tokenRepo = new(repomock.TokenRepoMock)
+	suite.outboxRepo = new(repomock.EmailOutboxRepoMock)
+	suite.usecase = usecase.NewUserCredentialUsecase(suite.repo, suite.otpStore, suite.tokenRepo, suite.outboxRepo)
 }
 
 func (suite *UserCredentialSuite) TestRegisterUser_Success() {
 	expectedInput := mock.AnythingOfType("model.UserRegisterRequest")
-	suite.repo.On("UserRegister", expectedInput).Return(nil)
+	//the welcome email is written with the user, not sent during the request
+	suite.repo.On("UserRegister", expectedInput, mock.MatchedBy(func(email model.EmailOutbox) bool {
+		return email.Recipient == "test@example.com" && email.Id != "" && email.Status == model.OutboxStatusPending
+	})).Return(nil)
 
 	// Create a user to register
 	userToRegister := model.UserRegisterRequest{
@@ -55,7 +69,7 @@ func (suite *UserCredentialSuite) TestRegisterUser_HashPasswordFailed() {
 		Name:     "John Doe",
 	}
 
-	suite.repo.On("UserRegister", mock.Anything).Return(errors.New("error from repository"))
+	suite.repo.On("UserRegister", mock.Anything, mock.Anything).Return(errors.New("error from repository"))
 
 	err := suite.usecase.RegisterUser(user)
 
@@ -72,7 +86,7 @@ func (suite *UserCredentialSuite) TestRegisterUser_RepositoryError() {
 		Name:     "John Doe",
 	}
 
-	suite.repo.On("UserRegister", mock.AnythingOfType("model.UserRegisterRequest")).Return(errors.New("error from repository"))
+	suite.repo.On("UserRegister", mock.AnythingOfType("model.UserRegisterRequest"), mock.AnythingOfType("model.EmailOutbox")).Return(errors.New("error from repository"))
 
 	err := suite.usecase.RegisterUser(user)
 
@@ -92,7 +106,7 @@ func (suite *UserCredentialSuite) TestRegisterUser_InvalidEmailFormat() {
 		IsActive: true,
 	}
 
-	suite.repo.On("UserRegister", user).
+	suite.repo.On("UserRegister", user, mock.Anything).
 		Return(errors.New("Invalid email format"))
 
 	err := suite.usecase.RegisterUser(user)
@@ -103,6 +117,275 @@ func (suite *UserCredentialSuite) TestRegisterUser_InvalidEmailFormat() {
 	suite.repo.AssertExpectations(suite.T())
 }
 
+func (suite *UserCredentialSuite) TestRegisterUser_DoesNotSendMail() {
+	suite.repo.On("UserRegister", mock.AnythingOfType("model.UserRegisterRequest"), mock.AnythingOfType("model.EmailOutbox")).Return(nil)
+
+	err := suite.usecase.RegisterUser(model.UserRegisterRequest{
+		Email:    "test@example.com",
+		Password: "Password123!",
+		Name:     "John Doe",
+	})
+
+	assert.NoError(suite.T(), err)
+	suite.outboxRepo.AssertNotCalled(suite.T(), "Enqueue", mock.Anything)
+}
+
+func (suite *UserCredentialSuite) TestForgotPass_SendOTP() {
+	suite.repo.On("FindUserEmail", "test@example.com").Return(model.UserLoginRequest{Email: "test@example.com"}, nil)
+	suite.repo.On("FindUserByEmail", "test@example.com").Return(model.UserCredentials{Email: "test@example.com", Locale: "id"}, nil)
+	suite.otpStore.On("Save", model.OTPPurposeForgotPassword, "test@example.com", mock.AnythingOfType("int"), mock.AnythingOfType("time.Time")).Return(nil)
+	//the mail is rendered in the user locale
+	suite.outboxRepo.On("Enqueue", mock.MatchedBy(func(email model.EmailOutbox) bool {
+		return email.Subject == "Instruksi reset password" && email.Recipient == "test@example.com" && email.Sensitive
+	})).Return(nil)
+
+	err := suite.usecase.ForgotPass("test@example.com")
+
+	assert.NoError(suite.T(), err)
+	suite.otpStore.AssertExpectations(suite.T())
+	suite.outboxRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestForgotPass_EnqueueFailed() {
+	suite.repo.On("FindUserEmail", "test@example.com").Return(model.UserLoginRequest{Email: "test@example.com"}, nil)
+	suite.repo.On("FindUserByEmail", "test@example.com").Return(model.UserCredentials{}, errors.New("Invalid Credentials"))
+	suite.otpStore.On("Save", model.OTPPurposeForgotPassword, "test@example.com", mock.AnythingOfType("int"), mock.AnythingOfType("time.Time")).Return(nil)
+	suite.outboxRepo.On("Enqueue", mock.AnythingOfType("model.EmailOutbox")).Return(errors.New("db down"))
+
+	err := suite.usecase.ForgotPass("test@example.com")
+
+	assert.Error(suite.T(), err)
+}
+
+func (suite *UserCredentialSuite) TestRegisterUser_InvalidLocale() {
+	err := suite.usecase.RegisterUser(model.UserRegisterRequest{
+		Email:    "test@example.com",
+		Password: "Password123!",
+		Name:     "John Doe",
+		Locale:   "fr",
+	})
+
+	assert.Error(suite.T(), err)
+	suite.repo.AssertNotCalled(suite.T(), "UserRegister", mock.Anything, mock.Anything)
+}
+
+func (suite *UserCredentialSuite) TestRegisterUser_WelcomeEscapeName() {
+	suite.repo.On("UserRegister", mock.MatchedBy(func(user model.UserRegisterRequest) bool {
+		return user.Locale == "id"
+	}), mock.MatchedBy(func(email model.EmailOutbox) bool {
+		return !strings.Contains(email.Html, "<script>") && strings.Contains(email.Html, "&lt;script&gt;")
+	})).Return(nil)
+
+	err := suite.usecase.RegisterUser(model.UserRegisterRequest{
+		Email:    "test@example.com",
+		Password: "Password123!",
+		Name:     "<script>alert(1)</script>",
+		Locale:   "id",
+	})
+
+	assert.NoError(suite.T(), err)
+	suite.repo.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestUpdateLocale() {
+	suite.repo.On("UpdateLocale", "1", "id").Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.UpdateLocale("1", "id"))
+	assert.Error(suite.T(), suite.usecase.UpdateLocale("1", "jp"))
+	suite.repo.AssertNumberOfCalls(suite.T(), "UpdateLocale", 1)
+}
+
+func (suite *UserCredentialSuite) TestIssueToken_Success() {
+	user := model.UserCredentials{ID: "1", Email: "test@example.com", IsActive: true, Role: model.RoleViewer}
+	suite.repo.On("FindUserByEmail", user.Email).Return(user, nil)
+	suite.tokenRepo.On("Save", mock.MatchedBy(func(token model.RefreshToken) bool {
+		return token.UserID == user.ID && token.FamilyID != "" && token.TokenHash != ""
+	})).Return(nil)
+
+	tokens, err := suite.usecase.IssueToken(user.Email)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), tokens.RefreshToken)
+
+	claims, err := helper.ParseJWT(tokens.AccessToken)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), user.ID, claims.UserID)
+	assert.Equal(suite.T(), model.RoleViewer, claims.Role)
+	assert.NotEmpty(suite.T(), claims.FamilyID)
+	suite.tokenRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestIssueToken_Inactive() {
+	user := model.UserCredentials{ID: "1", Email: "test@example.com", IsActive: false}
+	suite.repo.On("FindUserByEmail", user.Email).Return(user, nil)
+
+	tokens, err := suite.usecase.IssueToken(user.Email)
+	assert.Error(suite.T(), err)
+	assert.Empty(suite.T(), tokens.AccessToken)
+	suite.tokenRepo.AssertNotCalled(suite.T(), "Save", mock.Anything)
+}
+
+func (suite *UserCredentialSuite) TestRefreshToken_Success() {
+	user := model.UserCredentials{ID: "1", Email: "test@example.com", IsActive: true, Role: model.RoleAdmin}
+	suite.tokenRepo.On("Rotate", helper.HashRefreshToken("old-token"), mock.AnythingOfType("model.RefreshToken")).
+		Return(model.RefreshToken{UserID: user.ID, FamilyID: "family-1"}, nil)
+	suite.repo.On("FindUserByID", user.ID).Return(user, nil)
+
+	tokens, err := suite.usecase.RefreshToken("old-token")
+	assert.NoError(suite.T(), err)
+	assert.NotEqual(suite.T(), "old-token", tokens.RefreshToken)
+
+	claims, err := helper.ParseJWT(tokens.AccessToken)
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "family-1", claims.FamilyID)
+	assert.Equal(suite.T(), model.RoleAdmin, claims.Role)
+}
+
+func (suite *UserCredentialSuite) TestRefreshToken_Reused() {
+	suite.tokenRepo.On("Rotate", helper.HashRefreshToken("old-token"), mock.AnythingOfType("model.RefreshToken")).
+		Return(model.RefreshToken{}, repository.ErrTokenReused)
+
+	_, err := suite.usecase.RefreshToken("old-token")
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusUnauthorized, httpErr.StatusCode)
+	suite.repo.AssertNotCalled(suite.T(), "FindUserByID", mock.Anything)
+}
+
+func (suite *UserCredentialSuite) TestRefreshToken_InactiveUser() {
+	user := model.UserCredentials{ID: "1", Email: "test@example.com", IsActive: false}
+	suite.tokenRepo.On("Rotate", helper.HashRefreshToken("old-token"), mock.AnythingOfType("model.RefreshToken")).
+		Return(model.RefreshToken{UserID: user.ID, FamilyID: "family-1"}, nil)
+	suite.repo.On("FindUserByID", user.ID).Return(user, nil)
+	suite.tokenRepo.On("RevokeAllForUser", user.ID).Return(nil)
+
+	_, err := suite.usecase.RefreshToken("old-token")
+	assert.Error(suite.T(), err)
+	suite.tokenRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestLogout_Success() {
+	suite.tokenRepo.On("RevokeByHash", helper.HashRefreshToken("refresh")).Return(nil)
+
+	assert.NoError(suite.T(), suite.usecase.Logout("refresh"))
+	suite.tokenRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestLogout_Empty() {
+	assert.Error(suite.T(), suite.usecase.Logout(""))
+	suite.tokenRepo.AssertNotCalled(suite.T(), "RevokeByHash", mock.Anything)
+}
+
+func (suite *UserCredentialSuite) TestCheckSession() {
+	suite.tokenRepo.On("IsSessionActive", "1", "family-1").Return(true, nil)
+	suite.tokenRepo.On("IsSessionActive", "1", "family-2").Return(false, nil)
+
+	assert.NoError(suite.T(), suite.usecase.CheckSession("1", "family-1"))
+	assert.Error(suite.T(), suite.usecase.CheckSession("1", "family-2"))
+	//token issued before families existed is not accepted
+	assert.Error(suite.T(), suite.usecase.CheckSession("1", ""))
+}
+
+func (suite *UserCredentialSuite) TestUpdateStatus_DeactivateRevokeToken() {
+	suite.repo.On("UpdateStatus", "1", false).Return(nil)
+	suite.tokenRepo.On("RevokeAllForUser", "1").Return(nil)
+
+	err := suite.usecase.UpdateStatus(model.UpdateStatusRequest{ID: "1", IsActive: false})
+	assert.NoError(suite.T(), err)
+	suite.repo.AssertExpectations(suite.T())
+	suite.tokenRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestUpdateStatus_Activate() {
+	suite.repo.On("UpdateStatus", "1", true).Return(nil)
+
+	err := suite.usecase.UpdateStatus(model.UpdateStatusRequest{ID: "1", IsActive: true})
+	assert.NoError(suite.T(), err)
+	suite.tokenRepo.AssertNotCalled(suite.T(), "RevokeAllForUser", mock.Anything)
+}
+
+func (suite *UserCredentialSuite) TestUpdateRole_Success() {
+	suite.repo.On("UpdateRole", "1", model.RoleAssetManager).Return(nil)
+	//the old role is carried by the issued tokens
+	suite.tokenRepo.On("RevokeAllForUser", "1").Return(nil)
+
+	err := suite.usecase.UpdateRole(model.UpdateRoleRequest{ID: "1", Role: model.RoleAssetManager})
+	assert.NoError(suite.T(), err)
+	suite.repo.AssertExpectations(suite.T())
+	suite.tokenRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestUpdateRole_InvalidRole() {
+	err := suite.usecase.UpdateRole(model.UpdateRoleRequest{ID: "1", Role: "superuser"})
+	assert.Error(suite.T(), err)
+	suite.repo.AssertNotCalled(suite.T(), "UpdateRole", mock.Anything, mock.Anything)
+}
+
+func (suite *UserCredentialSuite) TestVerifyOTP_Success() {
+	suite.otpStore.On("Verify", model.OTPPurposeLogin, "test@example.com", 123456).Return(nil)
+
+	err := suite.usecase.VerifyOTP(model.OTPPurposeLogin, "test@example.com", 123456)
+	assert.NoError(suite.T(), err)
+	suite.otpStore.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestVerifyOTP_Invalid() {
+	suite.otpStore.On("Verify", model.OTPPurposeLogin, "test@example.com", 123456).Return(repository.ErrOTPInvalid)
+
+	err := suite.usecase.VerifyOTP(model.OTPPurposeLogin, "test@example.com", 123456)
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusUnauthorized, httpErr.StatusCode)
+}
+
+func (suite *UserCredentialSuite) TestVerifyOTP_Expired() {
+	suite.otpStore.On("Verify", model.OTPPurposeForgotPassword, "test@example.com", 123456).Return(repository.ErrOTPExpired)
+
+	err := suite.usecase.VerifyOTP(model.OTPPurposeForgotPassword, "test@example.com", 123456)
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusUnauthorized, httpErr.StatusCode)
+}
+
+func (suite *UserCredentialSuite) TestVerifyOTP_StoreFailed() {
+	suite.otpStore.On("Verify", model.OTPPurposeLogin, "test@example.com", 123456).Return(errors.New("connection refused"))
+
+	err := suite.usecase.VerifyOTP(model.OTPPurposeLogin, "test@example.com", 123456)
+	var httpErr *exception.Http
+	assert.Error(suite.T(), err)
+	assert.False(suite.T(), errors.As(err, &httpErr))
+}
+
 func TestUserCredentialSuite(t *testing.T) {
 	suite.Run(t, new(UserCredentialSuite))
 }
+
+func (suite *UserCredentialSuite) TestResetPassword_Success() {
+	user := model.UserCredentials{ID: "1", Email: "test@example.com"}
+	suite.repo.On("FindUserByEmail", user.Email).Return(user, nil)
+	suite.repo.On("ChangePassword", user.Email, mock.MatchedBy(func(hash string) bool {
+		return helper.ComparePassword(hash, "NewPass1!") == nil
+	})).Return(nil)
+	suite.tokenRepo.On("RevokeAllForUser", user.ID).Return(nil)
+
+	err := suite.usecase.ResetPassword(user.Email, "NewPass1!")
+	assert.NoError(suite.T(), err)
+	suite.repo.AssertExpectations(suite.T())
+	suite.tokenRepo.AssertExpectations(suite.T())
+}
+
+func (suite *UserCredentialSuite) TestResetPassword_WeakPassword() {
+	suite.repo.On("FindUserByEmail", "test@example.com").Return(model.UserCredentials{ID: "1", Email: "test@example.com"}, nil)
+
+	err := suite.usecase.ResetPassword("test@example.com", "weak")
+	assert.Error(suite.T(), err)
+	suite.repo.AssertNotCalled(suite.T(), "ChangePassword", mock.Anything, mock.Anything)
+}
+
+func (suite *UserCredentialSuite) TestResetPassword_UserNotFound() {
+	suite.repo.On("FindUserByEmail", "nobody@example.com").Return(model.UserCredentials{}, errors.New("Invalid Credentials"))
+
+	err := suite.usecase.ResetPassword("nobody@example.com", "NewPass1!")
+	var httpErr *exception.Http
+	assert.ErrorAs(suite.T(), err, &httpErr)
+	assert.Equal(suite.T(), http.StatusNotFound, httpErr.StatusCode)
+}
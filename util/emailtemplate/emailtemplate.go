@@ -0,0 +1,197 @@
+package emailtemplate
+
+import (
+	"bytes"
+	"embed"
+	"final-project-enigma-clean/util/mailer"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+	"time"
+)
+
+const (
+	EventWelcome           = "welcome"
+	EventLoginOTP          = "login_otp"
+	EventChangePasswordOTP = "change_password_otp"
+	EventForgotPasswordOTP = "forgot_password_otp"
+	EventOverdueReminder   = "overdue_reminder"
+	EventLoanRequest       = "loan_request"
+)
+
+const (
+	LocaleID = "id"
+	LocaleEN = "en"
+	// DefaultLocale is used for users without a locale, it match the language of the old emails
+	DefaultLocale = LocaleEN
+)
+
+var (
+	events  = []string{EventWelcome, EventLoginOTP, EventChangePasswordOTP, EventForgotPasswordOTP, EventOverdueReminder, EventLoanRequest}
+	locales = []string{LocaleID, LocaleEN}
+)
+
+type WelcomeData struct {
+	Name string
+}
+
+type OTPData struct {
+	OTP          string
+	ValidMinutes int
+}
+
+type OverdueData struct {
+	Name          string
+	TransactionID string
+	ReturnDate    time.Time
+	DaysOverdue   int
+	Items         []OverdueItem
+}
+
+type OverdueItem struct {
+	AssetName string
+	Remaining int
+}
+
+// LoanRequestData tell the recipient a loan request moved to Status, Comment is the one of the
+// approver and ReturnDate is only set once the request is issued
+type LoanRequestData struct {
+	Name       string
+	RequestID  string
+	Status     string
+	Requester  string
+	StaffName  string
+	Comment    string
+	ReturnDate time.Time
+	Items      []LoanRequestItem
+}
+
+type LoanRequestItem struct {
+	AssetName string
+	Quantity  int
+}
+
+//go:embed templates
+var files embed.FS
+
+var funcs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Format("02 Jan 2006") },
+}
+
+// every event of every locale is parsed once, a broken template fail at startup
+var parsed = func() map[string]*template.Template {
+	m := make(map[string]*template.Template)
+	for _, locale := range locales {
+		for _, event := range events {
+			m[key(event, locale)] = template.Must(template.New("layout.html").Funcs(funcs).ParseFS(files,
+				"templates/layout.html",
+				"templates/"+locale+"/common.html",
+				"templates/"+locale+"/"+event+".html",
+			))
+		}
+	}
+	return m
+}()
+
+func key(event, locale string) string {
+	return locale + "/" + event
+}
+
+// Events list the available email events
+func Events() []string {
+	return append([]string(nil), events...)
+}
+
+// IsSensitive tell whether the email carry a secret such as an otp, its body must not be kept
+// once it is delivered nor sent again later
+func IsSensitive(event string) bool {
+	switch event {
+	case EventLoginOTP, EventChangePasswordOTP, EventForgotPasswordOTP:
+		return true
+	}
+	return false
+}
+
+// IsValidLocale tell whether the locale has its own templates
+func IsValidLocale(locale string) bool {
+	for _, l := range locales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeLocale fall back to the default locale for empty or unknown value
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if IsValidLocale(locale) {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// Render execute the template of the event in the locale, user input in data is escaped
+func Render(event, locale string, data any) (subject string, body string, err error) {
+	tmpl, ok := parsed[key(event, NormalizeLocale(locale))]
+	if !ok {
+		return "", "", fmt.Errorf("emailtemplate: unknown event %q", event)
+	}
+
+	var buf bytes.Buffer
+	if err = tmpl.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", fmt.Errorf("emailtemplate: render subject of %s: %v", event, err)
+	}
+	//subject is a mail header, not html
+	subject = html.UnescapeString(strings.TrimSpace(buf.String()))
+
+	buf.Reset()
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("emailtemplate: render %s: %v", event, err)
+	}
+	return subject, buf.String(), nil
+}
+
+// Message render the event into a message ready for the mailer
+func Message(to, event, locale string, data any) (mailer.Message, error) {
+	subject, body, err := Render(event, locale, data)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	return mailer.Message{
+		To:      []string{to},
+		Subject: subject,
+		HTML:    body,
+	}, nil
+}
+
+// SampleData return placeholder data used by the preview endpoint
+func SampleData(event string) (any, error) {
+	switch event {
+	case EventWelcome:
+		return WelcomeData{Name: "Stephanie"}, nil
+	case EventLoginOTP, EventChangePasswordOTP, EventForgotPasswordOTP:
+		return OTPData{OTP: "123456", ValidMinutes: 30}, nil
+	case EventOverdueReminder:
+		return OverdueData{
+			Name:          "Stephanie",
+			TransactionID: "00000000-0000-0000-0000-000000000000",
+			ReturnDate:    time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+			DaysOverdue:   3,
+			Items:         []OverdueItem{{AssetName: "Laptop", Remaining: 1}},
+		}, nil
+	case EventLoanRequest:
+		return LoanRequestData{
+			Name:       "Stephanie",
+			RequestID:  "00000000-0000-0000-0000-000000000000",
+			Status:     "approved",
+			Requester:  "Stephanie",
+			StaffName:  "Budi",
+			Comment:    "Pick it up at the GA desk",
+			ReturnDate: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC),
+			Items:      []LoanRequestItem{{AssetName: "Laptop", Quantity: 1}},
+		}, nil
+	}
+	return nil, fmt.Errorf("emailtemplate: unknown event %q", event)
+}
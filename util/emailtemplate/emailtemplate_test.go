@@ -0,0 +1,81 @@
+package emailtemplate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender_AllEventsAndLocales(t *testing.T) {
+	for _, locale := range locales {
+		for _, event := range events {
+			data, err := SampleData(event)
+			require.NoError(t, err)
+
+			subject, body, err := Render(event, locale, data)
+			require.NoError(t, err, "%s/%s", locale, event)
+			assert.NotEmpty(t, subject, "%s/%s", locale, event)
+			assert.Contains(t, body, "<html", "%s/%s", locale, event)
+		}
+	}
+}
+
+func TestRender_EscapeUserInput(t *testing.T) {
+	_, body, err := Render(EventWelcome, LocaleEN, WelcomeData{Name: `<img src=x onerror="alert(1)">`})
+
+	require.NoError(t, err)
+	assert.NotContains(t, body, "<img src=x")
+	assert.Contains(t, body, "&lt;img src=x")
+}
+
+func TestRender_LocaleSelection(t *testing.T) {
+	subjectID, bodyID, err := Render(EventLoginOTP, LocaleID, OTPData{OTP: "123456", ValidMinutes: 30})
+	require.NoError(t, err)
+	subjectEN, _, err := Render(EventLoginOTP, "", OTPData{OTP: "123456", ValidMinutes: 30})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Masuk ke Stephanie Project", subjectID)
+	assert.Equal(t, "Log in to Stephanie Project", subjectEN)
+	assert.True(t, strings.Contains(bodyID, "123456"))
+	assert.True(t, strings.Contains(bodyID, "30 menit"))
+}
+
+func TestRender_SubjectIsPlainText(t *testing.T) {
+	subject, _, err := Render(EventWelcome, LocaleEN, WelcomeData{Name: "x"})
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome on aboard 🎉", subject)
+}
+
+func TestRender_UnknownEvent(t *testing.T) {
+	_, _, err := Render("birthday", LocaleEN, nil)
+	assert.Error(t, err)
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	assert.Equal(t, LocaleID, NormalizeLocale(" ID "))
+	assert.Equal(t, DefaultLocale, NormalizeLocale("fr"))
+	assert.Equal(t, DefaultLocale, NormalizeLocale(""))
+}
+
+func TestRender_LoanRequestStatus(t *testing.T) {
+	data := LoanRequestData{Name: "Budi", RequestID: "r1", Status: "rejected", StaffName: "Budi", Comment: "out of stock"}
+
+	subject, body, err := Render(EventLoanRequest, LocaleEN, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Loan request rejected", subject)
+	assert.Contains(t, body, "was rejected")
+	assert.Contains(t, body, "out of stock")
+
+	subject, _, err = Render(EventLoanRequest, LocaleID, data)
+	require.NoError(t, err)
+	assert.Equal(t, "Permintaan peminjaman ditolak", subject)
+}
+
+func TestIsSensitive(t *testing.T) {
+	assert.True(t, IsSensitive(EventLoginOTP))
+	assert.True(t, IsSensitive(EventForgotPasswordOTP))
+	assert.False(t, IsSensitive(EventWelcome))
+	assert.False(t, IsSensitive(EventOverdueReminder))
+}
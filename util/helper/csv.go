@@ -5,43 +5,77 @@ import (
 	"encoding/csv"
 	"final-project-enigma-clean/model"
 	"fmt"
+	"strconv"
+	"time"
 )
 
-func ConvertToCSVForStaff(staffs []model.Staff) ([]byte, error) {
-	var csvData [][]string
-
-	// Header nya
-	header := []string{"Nik_Staff", "Name", "Phone Number", "Address", "Birth Date", "Img_URL", "Divisi"}
+// StaffColumns are the header of a staff export, the same names are read by the staff import
+var StaffColumns = []string{"nik_staff", "name", "phone_number", "address", "birth_date", "img_url", "divisi", "email"}
 
-	//SET HEADERRRR !!!!!
-	csvData = append(csvData, header)
-
-	// convert to csv
+// StaffRows turn the staff into a header row and one row per staff
+func StaffRows(staffs []model.Staff) [][]string {
+	rows := [][]string{StaffColumns}
 	for _, staff := range staffs {
-		birthDatestr := staff.Birth_date.Format("2006-01-02")
-		row := []string{
+		birthDate := ""
+		if !staff.Birth_date.IsZero() {
+			birthDate = staff.Birth_date.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
 			staff.Nik_Staff,
 			staff.Name,
 			staff.Phone_number,
 			staff.Address,
-			birthDatestr,
+			birthDate,
 			staff.Img_url,
 			staff.Divisi,
-		}
-		csvData = append(csvData, row)
+			staff.Email,
+		})
 	}
+	return rows
+}
 
+func ConvertToCSVForStaff(staffs []model.Staff) ([]byte, error) {
 	var csvBuffer bytes.Buffer
 	writer := csv.NewWriter(&csvBuffer)
 
 	// write data to csv
-	err := writer.WriteAll(csvData)
+	err := writer.WriteAll(StaffRows(staffs))
 	if err != nil {
 		return nil, err
 	}
 	return csvBuffer.Bytes(), nil
 }
 
+// TransactionDetailColumns are the header of a loan export, one row per detail line
+var TransactionDetailColumns = []string{"transaction_id", "submission_date", "return_date", "actual_return_date", "nik_staff", "staff_name",
+	"user_name", "asset_id", "asset_name", "total_item", "total_returned", "status", "returned_at"}
+
+// TransactionDetailRecord turn a detail line into the cells of TransactionDetailColumns
+func TransactionDetailRecord(row model.TransactionDetailRow) []string {
+	return []string{
+		row.TransactionId,
+		row.SubmissionDate.Format("2006-01-02"),
+		row.ReturnDate.Format("2006-01-02"),
+		formatDate(row.ActualReturnDate),
+		row.NikStaff,
+		row.StaffName,
+		row.UserName,
+		row.AssetId,
+		row.AssetName,
+		strconv.Itoa(row.TotalItem),
+		strconv.Itoa(row.TotalReturned),
+		row.Status,
+		formatDate(row.ReturnedAt),
+	}
+}
+
+func formatDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
 // for assets endpoint
 func ConvertToCSVForAssets(assets []model.ManageAsset) ([]byte, error) {
 	var csvData [][]string
@@ -0,0 +1,30 @@
+package helper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+type cursor struct {
+	After string `json:"after"`
+}
+
+// EncodeCursor hide the last key of a page behind an opaque token
+func EncodeCursor(key string) string {
+	raw, _ := json.Marshal(cursor{After: key})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor return the key of a token made by EncodeCursor
+func DecodeCursor(token string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", errors.New("invalid cursor")
+	}
+	var c cursor
+	if err = json.Unmarshal(raw, &c); err != nil || c.After == "" {
+		return "", errors.New("invalid cursor")
+	}
+	return c.After, nil
+}
@@ -1,35 +1,82 @@
 package helper
 
 import (
-	"github.com/golang-jwt/jwt/v4"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"final-project-enigma-clean/model"
+	"fmt"
 	"os"
 	"time"
+
+	"github.com/golang-jwt/jwt/v4"
 )
 
-var secret = []byte(os.Getenv("JWT_SECRET"))
+// AccessTokenTTL is kept short, clients renew it with the refresh token
+const AccessTokenTTL = 15 * time.Minute
 
 type JWTClaims struct {
-	UserID string `json:"email"`
+	UserID   string `json:"user_id"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	FamilyID string `json:"fid,omitempty"`
 	jwt.StandardClaims
 }
 
-// init jwt in here
-func GenerateJWT(Email string) (string, error) {
+// read secret on every call, .env is loaded after package init
+func jwtSecret() []byte {
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// init jwt in here, familyID tie the access token to the refresh token family of the login
+func GenerateJWT(user model.UserCredentials, familyID string) (string, error) {
 	claims := JWTClaims{
-		UserID: Email,
+		UserID:   user.ID,
+		Email:    user.Email,
+		Role:     user.Role,
+		FamilyID: familyID,
 		StandardClaims: jwt.StandardClaims{
+			Subject:   user.ID,
 			IssuedAt:  time.Now().Unix(),
-			ExpiresAt: time.Now().Add(6 * time.Hour).Unix(),
+			ExpiresAt: time.Now().Add(AccessTokenTTL).Unix(),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	return token.SignedString(jwtSecret())
 }
 
 // parse jwt
-func ParseJWT(tokenHeader string) (*jwt.Token, error) {
-	return jwt.Parse(tokenHeader, func(token *jwt.Token) (interface{}, error) {
-		return secret, nil
+func ParseJWT(tokenHeader string) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	token, err := jwt.ParseWithClaims(tokenHeader, claims, func(token *jwt.Token) (interface{}, error) {
+		//only accept the algorithm we sign with
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return jwtSecret(), nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
+
+// GenerateRefreshToken return the opaque token given to the client and the hash we store
+func GenerateRefreshToken() (token string, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err = rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashRefreshToken(token), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
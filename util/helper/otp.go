@@ -1,35 +1,16 @@
 package helper
 
 import (
-	"encoding/base32"
-	"github.com/pquerna/otp"
-	"github.com/pquerna/otp/totp"
-	"os"
-	"strconv"
-	"time"
+	"crypto/rand"
+	"math/big"
 )
 
-// init otp for email in here
+// init otp for email in here, every call return an independent random 6 digit code
 func GenerateOTP() (int, error) {
-	passcode := GeneratePassCode(os.Getenv("jwt_secret"))
-	otpInt, err := strconv.Atoi(passcode)
+	n, err := rand.Int(rand.Reader, big.NewInt(900000))
 	if err != nil {
 		return 0, err
 	}
 
-	return otpInt, nil
-}
-
-func GeneratePassCode(utf8string string) string {
-	secret := base32.StdEncoding.EncodeToString([]byte(utf8string))
-	passcode, err := totp.GenerateCodeCustom(secret, time.Now(), totp.ValidateOpts{
-		Period:    30,
-		Skew:      1,
-		Digits:    otp.DigitsSix,
-		Algorithm: otp.AlgorithmSHA512,
-	})
-	if err != nil {
-		panic(err)
-	}
-	return passcode
+	return int(n.Int64()) + 100000, nil
 }
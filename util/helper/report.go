@@ -0,0 +1,122 @@
+package helper
+
+import (
+	"final-project-enigma-clean/model"
+	"final-project-enigma-clean/util/report"
+)
+
+// AssetSheet put the assets on a typed sheet
+func AssetSheet(assets []model.Asset) report.Sheet {
+	sheet := report.Sheet{Name: "assets", Columns: []report.Column{
+		{Header: "id", Width: 38},
+		{Header: "name", Width: 30},
+		{Header: "category"},
+		{Header: "asset_type"},
+		{Header: "total", Type: report.Number, Width: 10},
+		{Header: "available", Type: report.Number, Width: 10},
+		{Header: "status"},
+		{Header: "entry_date", Type: report.Date},
+		{Header: "deleted_at", Type: report.DateTime},
+	}}
+	for _, asset := range assets {
+		sheet.Add(asset.Id, asset.Name, asset.Category.Name, asset.AssetType.Name, asset.Total, asset.Available,
+			asset.Status, asset.EntryDate, asset.DeletedAt)
+	}
+	return sheet
+}
+
+// StaffSheet put the staff on a typed sheet with the columns of the staff export
+func StaffSheet(staffs []model.Staff) report.Sheet {
+	sheet := report.Sheet{Name: "staff", Columns: []report.Column{
+		{Header: "nik_staff"},
+		{Header: "name", Width: 30},
+		{Header: "phone_number"},
+		{Header: "address", Width: 40},
+		{Header: "birth_date", Type: report.Date},
+		{Header: "img_url", Width: 30},
+		{Header: "divisi"},
+		{Header: "email", Width: 30},
+		{Header: "deleted_at", Type: report.DateTime},
+	}}
+	for _, staff := range staffs {
+		sheet.Add(staff.Nik_Staff, staff.Name, staff.Phone_number, staff.Address, staff.Birth_date, staff.Img_url,
+			staff.Divisi, staff.Email, staff.DeletedAt)
+	}
+	return sheet
+}
+
+// CategorySheet put the categories on a typed sheet
+func CategorySheet(categories []model.Category) report.Sheet {
+	sheet := report.Sheet{Name: "categories", Columns: []report.Column{
+		{Header: "id", Width: 38},
+		{Header: "name", Width: 30},
+		{Header: "deleted_at", Type: report.DateTime},
+	}}
+	for _, category := range categories {
+		sheet.Add(category.Id, category.Name, category.DeletedAt)
+	}
+	return sheet
+}
+
+// TransactionSheets put the loans on a transactions sheet and their detail lines
+// on a details sheet, the two are joined by transaction_id
+func TransactionSheets(transactions []model.ManageAsset) []report.Sheet {
+	header := report.Sheet{Name: "transactions", Columns: []report.Column{
+		{Header: "transaction_id", Width: 38},
+		{Header: "submission_date", Type: report.Date},
+		{Header: "return_date", Type: report.Date},
+		{Header: "actual_return_date", Type: report.Date},
+		{Header: "nik_staff"},
+		{Header: "staff_name", Width: 30},
+		{Header: "user_name", Width: 30},
+		{Header: "total_item", Type: report.Number, Width: 10},
+	}}
+	details := report.Sheet{Name: "details", Columns: []report.Column{
+		{Header: "transaction_id", Width: 38},
+		{Header: "asset_id", Width: 38},
+		{Header: "asset_name", Width: 30},
+		{Header: "total_item", Type: report.Number, Width: 10},
+		{Header: "total_returned", Type: report.Number, Width: 10},
+		{Header: "status"},
+		{Header: "returned_at", Type: report.DateTime},
+	}}
+	for _, transaction := range transactions {
+		total := 0
+		for _, detail := range transaction.Detail {
+			total += detail.TotalItem
+			details.Add(transaction.Id, detail.Asset.Id, detail.Asset.Name, detail.TotalItem, detail.TotalReturned,
+				detail.Status, detail.ReturnedAt)
+		}
+		header.Add(transaction.Id, transaction.SubmissionDate, transaction.ReturnDate, transaction.ActualReturnDate,
+			transaction.Staff.Nik_Staff, transaction.Staff.Name, transaction.User.Name, total)
+	}
+	return []report.Sheet{header, details}
+}
+
+// StocktakeSheet put the lines of a stocktake report on a typed sheet, an uncounted asset
+// has its counted and difference left blank
+func StocktakeSheet(lines []model.StocktakeLine) report.Sheet {
+	sheet := report.Sheet{Name: "stocktake", Columns: []report.Column{
+		{Header: "asset_id", Width: 38},
+		{Header: "asset_name", Width: 30},
+		{Header: "category"},
+		{Header: "tracked_by_unit"},
+		{Header: "total", Type: report.Number, Width: 10},
+		{Header: "available", Type: report.Number, Width: 10},
+		{Header: "on_loan", Type: report.Number, Width: 10},
+		{Header: "reserved", Type: report.Number, Width: 10},
+		{Header: "expected", Type: report.Number, Width: 10},
+		{Header: "counted", Type: report.Number, Width: 10},
+		{Header: "difference", Type: report.Number, Width: 10},
+		{Header: "available_drift", Type: report.Number, Width: 10},
+	}}
+	for _, line := range lines {
+		var counted, difference any
+		if line.Counted != nil {
+			counted, difference = *line.Counted, *line.Difference
+		}
+		sheet.Add(line.AssetId, line.AssetName, line.Category, line.TrackedByUnit, line.Total, line.Available,
+			line.OnLoan, line.Reserved, line.Expected, counted, difference, line.AvailableDrift)
+	}
+	return sheet
+}
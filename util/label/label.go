@@ -0,0 +1,188 @@
+package label
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/go-pdf/fpdf"
+)
+
+// the symbologies a label can be printed with
+const (
+	QR      = "qr"
+	Code128 = "code128"
+)
+
+// the image formats of a single label
+const (
+	PNG = "png"
+	SVG = "svg"
+)
+
+var (
+	ErrUnsupportedKind   = errors.New("type must be qr or code128")
+	ErrUnsupportedFormat = errors.New("format must be png or svg")
+)
+
+// quietZone is the blank border around a code, in modules, a scanner need it to find the edges
+var quietZone = map[string]int{QR: 4, Code128: 10}
+
+// Write render content as a qr code or a code128 barcode in the format, size is the width in pixel of a png
+func Write(w io.Writer, kind, format, content string, size int) error {
+	code, err := encode(kind, content)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case PNG:
+		return writePNG(w, kind, code, size)
+	case SVG:
+		return writeSVG(w, kind, code)
+	}
+	return ErrUnsupportedFormat
+}
+
+func encode(kind, content string) (barcode.Barcode, error) {
+	switch kind {
+	case QR:
+		return qr.Encode(content, qr.M, qr.Auto)
+	case Code128:
+		return code128.Encode(content)
+	}
+	return nil, ErrUnsupportedKind
+}
+
+// modules give the width and the height of the code in modules, a barcode is a single row
+// drawn as high as a quarter of its width
+func modules(kind string, code barcode.Barcode) (int, int) {
+	bounds := code.Bounds()
+	if kind == Code128 {
+		return bounds.Dx(), bounds.Dx() / 4
+	}
+	return bounds.Dx(), bounds.Dy()
+}
+
+func dark(kind string, code barcode.Barcode, x, y int) bool {
+	if kind == Code128 {
+		y = 0
+	}
+	r, _, _, _ := code.At(x, y).RGBA()
+	return r == 0
+}
+
+// writePNG scale every module to the same whole number of pixels, so no bar is drawn wider than the others
+func writePNG(w io.Writer, kind string, code barcode.Barcode, size int) error {
+	width, height := modules(kind, code)
+	zone := quietZone[kind]
+	scale := size / (width + 2*zone)
+	if scale < 1 {
+		scale = 1
+	}
+
+	img := image.NewGray(image.Rect(0, 0, (width+2*zone)*scale, (height+2*zone)*scale))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !dark(kind, code, x, y) {
+				continue
+			}
+			module := image.Rect((x+zone)*scale, (y+zone)*scale, (x+zone+1)*scale, (y+zone+1)*scale)
+			draw.Draw(img, module, &image.Uniform{C: color.Black}, image.Point{}, draw.Src)
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// writeSVG draw a run of dark modules on a row as one rect, one unit of the view box is a module
+func writeSVG(w io.Writer, kind string, code barcode.Barcode) error {
+	width, height := modules(kind, code)
+	zone := quietZone[kind]
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		width+2*zone, height+2*zone)
+	svg.WriteString(`<rect width="100%" height="100%" fill="#fff"/>`)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; {
+			if !dark(kind, code, x, y) {
+				x++
+				continue
+			}
+			run := x
+			for run < width && dark(kind, code, run, y) {
+				run++
+			}
+			fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%d" height="1"/>`, x+zone, y+zone, run-x)
+			x = run
+		}
+	}
+	svg.WriteString("</svg>")
+	_, err := io.WriteString(w, svg.String())
+	return err
+}
+
+// Label is one cell of a label sheet, the qr code hold the Code and the text is printed next to it
+type Label struct {
+	Code  string
+	Title string
+	Text  string
+}
+
+// the grid of a common A4 sheet of 3 by 8 labels, in millimeter
+const (
+	sheetColumns = 3
+	sheetRows    = 8
+	labelWidth   = 70.0
+	labelHeight  = 37.0
+	sheetTop     = 0.5
+	qrSize       = 27.0
+)
+
+// Sheet lay the labels out on as many A4 pages of 3 by 8 labels as needed
+func Sheet(w io.Writer, labels []Label) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetCatalogSort(true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for i, label := range labels {
+		cell := i % (sheetColumns * sheetRows)
+		if cell == 0 {
+			pdf.AddPage()
+		}
+		x := float64(cell%sheetColumns) * labelWidth
+		y := sheetTop + float64(cell/sheetColumns)*labelHeight
+
+		var buf bytes.Buffer
+		if err := Write(&buf, QR, PNG, label.Code, 200); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("label-%d", i)
+		pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, &buf)
+		pdf.ImageOptions(name, x+3, y+(labelHeight-qrSize)/2, qrSize, qrSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+		textX := x + qrSize + 5
+		textWidth := labelWidth - qrSize - 8
+		pdf.SetXY(textX, y+6)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.MultiCell(textWidth, 4.5, tr(label.Title), "", "L", false)
+		pdf.SetX(textX)
+		pdf.SetFont("Helvetica", "", 6)
+		pdf.MultiCell(textWidth, 3, tr(label.Text), "", "L", false)
+	}
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+	return pdf.Output(w)
+}
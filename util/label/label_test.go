@@ -0,0 +1,56 @@
+package label
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const assetId = "7f9c2ba4-e88f-4a2c-9d1b-1c8e2f3a4b5c"
+
+func TestWrite_QrPng(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, QR, PNG, assetId, 300))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	bounds := img.Bounds()
+	assert.Equal(t, bounds.Dx(), bounds.Dy())
+	assert.LessOrEqual(t, bounds.Dx(), 300)
+	//the corner is in the quiet zone
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.NotZero(t, r)
+}
+
+func TestWrite_Code128Svg(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, Code128, SVG, assetId, 0))
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 `))
+	assert.True(t, strings.HasSuffix(svg, "</svg>"))
+	assert.Contains(t, svg, `<rect x="10" y="10" width="2" height="1"/>`)
+}
+
+func TestWrite_Unsupported(t *testing.T) {
+	var buf bytes.Buffer
+	assert.ErrorIs(t, Write(&buf, "ean13", PNG, assetId, 100), ErrUnsupportedKind)
+	assert.ErrorIs(t, Write(&buf, QR, "gif", assetId, 100), ErrUnsupportedFormat)
+}
+
+func TestSheet_Pages(t *testing.T) {
+	labels := make([]Label, 30)
+	for i := range labels {
+		labels[i] = Label{Code: fmt.Sprintf("asset-%d", i), Title: "Laptop", Text: "Elektronik"}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Sheet(&buf, labels))
+
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("/Type /Page\n")))
+}
@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultBrevoURL = "https://api.brevo.com/v3/smtp/email"
+
+type brevoAddress struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email"`
+}
+
+type brevoPayload struct {
+	Sender      brevoAddress   `json:"sender"`
+	To          []brevoAddress `json:"to"`
+	Subject     string         `json:"subject"`
+	HtmlContent string         `json:"htmlContent"`
+	TextContent string         `json:"textContent,omitempty"`
+}
+
+type brevoMailer struct {
+	url    string
+	apiKey string
+	from   sender
+	client *http.Client
+}
+
+func (b *brevoMailer) Send(ctx context.Context, msg Message) error {
+	if err := validate(msg); err != nil {
+		return err
+	}
+
+	payload := brevoPayload{
+		Sender:      brevoAddress{Name: b.from.name, Email: b.from.email},
+		Subject:     msg.Subject,
+		HtmlContent: msg.HTML,
+		TextContent: msg.Text,
+	}
+	for _, to := range msg.To {
+		payload.To = append(payload.To, brevoAddress{Email: to})
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("mailer: failed to encode brevo payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("mailer: failed to build brevo request: %v", err)
+	}
+	req.Header.Add("accept", "application/json")
+	req.Header.Add("content-type", "application/json")
+	req.Header.Add("api-key", b.apiKey)
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mailer: brevo request failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("mailer: brevo responded %d: %s", res.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func NewBrevoMailer(url, apiKey, fromName, fromEmail string) Mailer {
+	if url == "" {
+		url = defaultBrevoURL
+	}
+	return &brevoMailer{
+		url:    url,
+		apiKey: apiKey,
+		from:   sender{name: fromName, email: fromEmail},
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
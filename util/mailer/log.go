@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gookit/slog"
+)
+
+// logMailer never deliver anything, it is meant for local development and tests
+type logMailer struct {
+	dir  string
+	from sender
+	now  func() time.Time
+}
+
+func (l *logMailer) Send(ctx context.Context, msg Message) error {
+	if err := validate(msg); err != nil {
+		return err
+	}
+
+	slog.Infof("mailer(log): to=%v subject=%q", strings.Join(msg.To, ","), msg.Subject)
+	if l.dir == "" {
+		return nil
+	}
+
+	now := l.now()
+	body, err := encode(l.from, msg, now)
+	if err != nil {
+		return fmt.Errorf("mailer: failed to encode message: %v", err)
+	}
+	if err = os.MkdirAll(l.dir, 0o755); err != nil {
+		return fmt.Errorf("mailer: failed to create log dir: %v", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), sanitizeFileName(msg.To[0]))
+	if err = os.WriteFile(filepath.Join(l.dir, name), body, 0o644); err != nil {
+		return fmt.Errorf("mailer: failed to write message: %v", err)
+	}
+	return nil
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func NewLogMailer(dir, fromName, fromEmail string) Mailer {
+	return &logMailer{
+		dir:  dir,
+		from: sender{name: fromName, email: fromEmail},
+		now:  time.Now,
+	}
+}
@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message is a single email, HTML is required and Text is an optional plain text alternative
+type Message struct {
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer deliver a message, an error means the message was not accepted by the provider
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+const (
+	DriverBrevo = "brevo"
+	DriverSMTP  = "smtp"
+	DriverLog   = "log"
+)
+
+type Config struct {
+	Driver    string
+	FromName  string
+	FromEmail string
+
+	BrevoAPIKey string
+	BrevoURL    string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+
+	//log driver write every message as .eml into this directory, empty only log the envelope
+	LogDir string
+}
+
+// New build the mailer selected by cfg.Driver
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverBrevo:
+		if cfg.BrevoAPIKey == "" {
+			return nil, fmt.Errorf("mailer: brevo driver require MAILER_API_KEY")
+		}
+		return NewBrevoMailer(cfg.BrevoURL, cfg.BrevoAPIKey, cfg.FromName, cfg.FromEmail), nil
+	case DriverSMTP:
+		if cfg.SMTPHost == "" || cfg.SMTPPort == "" {
+			return nil, fmt.Errorf("mailer: smtp driver require SMTP_HOST and SMTP_PORT")
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUser, cfg.SMTPPassword, cfg.FromName, cfg.FromEmail), nil
+	case DriverLog, "":
+		return NewLogMailer(cfg.LogDir, cfg.FromName, cfg.FromEmail), nil
+	default:
+		return nil, fmt.Errorf("mailer: unknown driver %q", cfg.Driver)
+	}
+}
+
+type sender struct {
+	name  string
+	email string
+}
+
+func validate(msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mailer: message has no recipient")
+	}
+	if msg.Subject == "" {
+		return fmt.Errorf("mailer: message has no subject")
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testMessage() Message {
+	return Message{
+		To:      []string{"staff@mail.com"},
+		Subject: "Verification code",
+		HTML:    "<p>123456</p>",
+	}
+}
+
+func TestNew_SelectDriver(t *testing.T) {
+	m, err := New(Config{Driver: DriverLog})
+	assert.NoError(t, err)
+	assert.IsType(t, &logMailer{}, m)
+
+	m, err = New(Config{Driver: DriverBrevo, BrevoAPIKey: "key"})
+	assert.NoError(t, err)
+	assert.IsType(t, &brevoMailer{}, m)
+
+	m, err = New(Config{Driver: DriverSMTP, SMTPHost: "localhost", SMTPPort: "1025"})
+	assert.NoError(t, err)
+	assert.IsType(t, &smtpMailer{}, m)
+
+	_, err = New(Config{Driver: DriverBrevo})
+	assert.Error(t, err)
+
+	_, err = New(Config{Driver: "pigeon"})
+	assert.Error(t, err)
+}
+
+func TestBrevoMailer_Send(t *testing.T) {
+	var payload brevoPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("api-key"))
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	m := NewBrevoMailer(server.URL, "secret", "Stephanie Project", "noreply@mail.com")
+	err := m.Send(context.Background(), testMessage())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "noreply@mail.com", payload.Sender.Email)
+	assert.Equal(t, "staff@mail.com", payload.To[0].Email)
+	assert.Equal(t, "<p>123456</p>", payload.HtmlContent)
+}
+
+func TestBrevoMailer_ProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"code":"unauthorized"}`))
+	}))
+	defer server.Close()
+
+	m := NewBrevoMailer(server.URL, "wrong", "", "noreply@mail.com")
+	err := m.Send(context.Background(), testMessage())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestLogMailer_WriteEml(t *testing.T) {
+	dir := t.TempDir()
+	m := NewLogMailer(dir, "Stephanie Project", "noreply@mail.com")
+
+	msg := testMessage()
+	msg.Text = "123456"
+	require.NoError(t, m.Send(context.Background(), msg))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	body, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "To: staff@mail.com")
+	assert.Contains(t, string(body), "multipart/alternative")
+}
+
+// a server that accept the connection but never greet must not hold the sender past its context
+func TestSMTPMailer_StalledServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	m := NewSMTPMailer(host, port, "", "", "", "noreply@mail.com")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = m.Send(ctx, testMessage())
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestSend_RejectMessageWithoutRecipient(t *testing.T) {
+	m := NewLogMailer("", "", "noreply@mail.com")
+	err := m.Send(context.Background(), Message{Subject: "hello"})
+	assert.Error(t, err)
+}
+
+func TestEncode_SubjectIsEncoded(t *testing.T) {
+	msg := testMessage()
+	msg.Subject = "Welcome on aboard 🎉"
+	body, err := encode(sender{email: "noreply@mail.com"}, msg, time.Now())
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), "Subject: =?utf-8?q?")
+	assert.True(t, strings.Contains(string(body), "Content-Type: text/html"))
+}
@@ -0,0 +1,112 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// smtpTimeout bound a send when the context has no earlier deadline, a server that stop
+// answering would otherwise hold the caller forever
+const smtpTimeout = 10 * time.Second
+
+type smtpMailer struct {
+	host    string
+	addr    string
+	auth    smtp.Auth
+	from    sender
+	timeout time.Duration
+}
+
+func (s *smtpMailer) Send(ctx context.Context, msg Message) error {
+	if err := validate(msg); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := encode(s.from, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("mailer: failed to encode message: %v", err)
+	}
+	if err = s.send(ctx, msg.To, body); err != nil {
+		return fmt.Errorf("mailer: smtp send failed: %v", err)
+	}
+	return nil
+}
+
+// send follow smtp.SendMail on a connection dialed with a timeout, the whole exchange
+// must end before the deadline of the context or the timeout
+func (s *smtpMailer) send(ctx context.Context, to []string, body []byte) error {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err = conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	//upgrade to STARTTLS when the server offer it, as smtp.SendMail does
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err = client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := client.Extension("AUTH"); ok {
+			if err = client.Auth(s.auth); err != nil {
+				return err
+			}
+		}
+	}
+	if err = client.Mail(s.from.email); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err = client.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(body); err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func NewSMTPMailer(host, port, user, password, fromName, fromEmail string) Mailer {
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, password, host)
+	}
+	return &smtpMailer{
+		host:    host,
+		addr:    net.JoinHostPort(host, port),
+		auth:    auth,
+		from:    sender{name: fromName, email: fromEmail},
+		timeout: smtpTimeout,
+	}
+}
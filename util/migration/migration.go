@@ -0,0 +1,310 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gookit/slog"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// every migration is a pair of files, 000001_create_table.up.sql and 000001_create_table.down.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lock key shared by every instance, only one of them migrate at a time
+const lockKey = 7340021
+
+var (
+	ErrChecksumMismatch = errors.New("applied migration was changed")
+	ErrUnknownVersion   = errors.New("applied migration is missing from the binary")
+)
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load read the migrations from fsys ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+			sum := sha256.Sum256(body)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+type applied struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	now        func() time.Time
+}
+
+// New build a migrator over the migrations embedded in the binary
+func New(db *sql.DB) (*Migrator, error) {
+	fsys, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return NewWithFS(db, fsys)
+}
+
+func NewWithFS(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		now:        time.Now,
+	}, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`create table if not exists schema_migrations (
+		version bigint primary key,
+		name varchar(255) not null,
+		checksum varchar(64) not null,
+		applied_at timestamp not null)`)
+	return err
+}
+
+func (m *Migrator) applied() (map[int64]applied, error) {
+	rows, err := m.db.Query("select version, checksum, applied_at from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]applied)
+	for rows.Next() {
+		var version int64
+		var a applied
+		if err = rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = a
+	}
+	return versions, rows.Err()
+}
+
+// verify refuse to go on when an applied migration was edited or removed,
+// the database would no longer match the files
+func (m *Migrator) verify(versions map[int64]applied) error {
+	known := make(map[int64]Migration)
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	for version, a := range versions {
+		mig, ok := known[version]
+		if !ok {
+			return fmt.Errorf("%w: version %d", ErrUnknownVersion, version)
+		}
+		if mig.Checksum != a.checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
+
+// Up apply every pending migration, each one in its own transaction
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	versions, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	if err = m.verify(versions); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range m.migrations {
+		if _, ok := versions[mig.Version]; ok {
+			continue
+		}
+		ok, err := m.apply(mig)
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %d_%s: %v", mig.Version, mig.Name, err)
+		}
+		if ok {
+			slog.Infof("applied migration %d_%s", mig.Version, mig.Name)
+			done = append(done, mig)
+		}
+	}
+	return done, nil
+}
+
+func (m *Migrator) apply(mig Migration) (bool, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	//another instance may have applied it while we waited for the lock
+	if _, err = tx.Exec("select pg_advisory_xact_lock($1)", lockKey); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	var exists bool
+	if err = tx.QueryRow("select exists(select 1 from schema_migrations where version = $1)", mig.Version).Scan(&exists); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if exists {
+		return false, tx.Rollback()
+	}
+
+	if _, err = tx.Exec(mig.Up); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	_, err = tx.Exec("insert into schema_migrations (version, name, checksum, applied_at) values ($1, $2, $3, $4)",
+		mig.Version, mig.Name, mig.Checksum, m.now())
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Down revert the last steps applied migrations, newest first
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("steps must be greater than 0")
+	}
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	versions, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	if err = m.verify(versions); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.migrations[i]
+		if _, ok := versions[mig.Version]; !ok {
+			continue
+		}
+		if err = m.revert(mig); err != nil {
+			return done, fmt.Errorf("failed to revert migration %d_%s: %v", mig.Version, mig.Name, err)
+		}
+		slog.Infof("reverted migration %d_%s", mig.Version, mig.Name)
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+func (m *Migrator) revert(mig Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec("select pg_advisory_xact_lock($1)", lockKey); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(mig.Down); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec("delete from schema_migrations where version = $1", mig.Version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Status list every known migration with the time it was applied, nil when pending
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+	versions, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := Status{Migration: mig}
+		if a, ok := versions[mig.Version]; ok {
+			appliedAt := a.appliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
@@ -0,0 +1,187 @@
+package migration
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+var testFS = fstest.MapFS{
+	"000001_create_category.up.sql":   {Data: []byte("create table category (id varchar(100) primary key)")},
+	"000001_create_category.down.sql": {Data: []byte("drop table category")},
+	"000002_create_asset.up.sql":      {Data: []byte("create table asset (id varchar(100) primary key)")},
+	"000002_create_asset.down.sql":    {Data: []byte("drop table asset")},
+	"README.md":                       {Data: []byte("not a migration")},
+}
+
+type MigrationSuite struct {
+	suite.Suite
+	db       *sql.DB
+	mock     sqlmock.Sqlmock
+	migrator *Migrator
+}
+
+func (suite *MigrationSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	assert.NoError(suite.T(), err)
+	suite.db = db
+	suite.mock = mock
+	suite.migrator, err = NewWithFS(db, testFS)
+	assert.NoError(suite.T(), err)
+	suite.migrator.now = func() time.Time { return time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC) }
+}
+
+func (suite *MigrationSuite) TearDownTest() {
+	suite.db.Close()
+}
+
+func TestMigrationSuite(t *testing.T) {
+	suite.Run(t, new(MigrationSuite))
+}
+
+func (suite *MigrationSuite) expectApplied(versions ...int64) {
+	suite.mock.ExpectExec("create table if not exists schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "checksum", "applied_at"})
+	for _, version := range versions {
+		mig := suite.migrator.migrations[version-1]
+		rows.AddRow(version, mig.Checksum, time.Now())
+	}
+	suite.mock.ExpectQuery("select version, checksum, applied_at from schema_migrations").WillReturnRows(rows)
+}
+
+func (suite *MigrationSuite) TestLoad() {
+	migrations, err := Load(testFS)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), migrations, 2)
+	assert.Equal(suite.T(), int64(1), migrations[0].Version)
+	assert.Equal(suite.T(), "create_category", migrations[0].Name)
+	assert.Equal(suite.T(), "drop table asset", migrations[1].Down)
+	assert.Len(suite.T(), migrations[0].Checksum, 64)
+}
+
+func (suite *MigrationSuite) TestLoad_MissingDown() {
+	_, err := Load(fstest.MapFS{
+		"000001_create_category.up.sql": {Data: []byte("create table category (id varchar(100))")},
+	})
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MigrationSuite) TestLoad_Embedded() {
+	migrator, err := New(nil)
+	assert.NoError(suite.T(), err)
+	assert.NotEmpty(suite.T(), migrator.migrations)
+	for i, mig := range migrator.migrations {
+		assert.Equal(suite.T(), int64(i+1), mig.Version)
+	}
+}
+
+func (suite *MigrationSuite) TestUp_ApplyPending() {
+	suite.expectApplied(1)
+
+	mig := suite.migrator.migrations[1]
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("select pg_advisory_xact_lock").WithArgs(lockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery("select exists").WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	suite.mock.ExpectExec("create table asset").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectExec("insert into schema_migrations").
+		WithArgs(int64(2), "create_asset", mig.Checksum, suite.migrator.now()).WillReturnResult(sqlmock.NewResult(1, 1))
+	suite.mock.ExpectCommit()
+
+	done, err := suite.migrator.Up()
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), done, 1)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *MigrationSuite) TestUp_AppliedByAnotherInstance() {
+	suite.expectApplied(1)
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("select pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery("select exists").WithArgs(int64(2)).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	suite.mock.ExpectRollback()
+
+	done, err := suite.migrator.Up()
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), done)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *MigrationSuite) TestUp_ChecksumMismatch() {
+	suite.mock.ExpectExec("create table if not exists schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery("select version, checksum, applied_at from schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).AddRow(1, "edited", time.Now()))
+
+	_, err := suite.migrator.Up()
+	assert.ErrorIs(suite.T(), err, ErrChecksumMismatch)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *MigrationSuite) TestUp_UnknownVersion() {
+	suite.mock.ExpectExec("create table if not exists schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery("select version, checksum, applied_at from schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).AddRow(9, "x", time.Now()))
+
+	_, err := suite.migrator.Up()
+	assert.ErrorIs(suite.T(), err, ErrUnknownVersion)
+}
+
+func (suite *MigrationSuite) TestUp_StatementFailed() {
+	suite.expectApplied()
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("select pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectQuery("select exists").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	suite.mock.ExpectExec("create table category").WillReturnError(sql.ErrConnDone)
+	suite.mock.ExpectRollback()
+
+	done, err := suite.migrator.Up()
+	assert.Error(suite.T(), err)
+	assert.Empty(suite.T(), done)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *MigrationSuite) TestDown_NewestFirst() {
+	suite.expectApplied(1, 2)
+
+	suite.mock.ExpectBegin()
+	suite.mock.ExpectExec("select pg_advisory_xact_lock").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectExec("drop table asset").WillReturnResult(sqlmock.NewResult(0, 0))
+	suite.mock.ExpectExec("delete from schema_migrations").WithArgs(int64(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+	suite.mock.ExpectCommit()
+
+	done, err := suite.migrator.Down(1)
+	assert.NoError(suite.T(), err)
+	assert.Len(suite.T(), done, 1)
+	assert.Equal(suite.T(), int64(2), done[0].Version)
+	assert.NoError(suite.T(), suite.mock.ExpectationsWereMet())
+}
+
+func (suite *MigrationSuite) TestDown_InvalidSteps() {
+	_, err := suite.migrator.Down(0)
+	assert.Error(suite.T(), err)
+}
+
+func (suite *MigrationSuite) TestRun_Status() {
+	suite.expectApplied(1)
+
+	var out bytes.Buffer
+	err := suite.migrator.Run([]string{"status"}, &out)
+	assert.NoError(suite.T(), err)
+	assert.Contains(suite.T(), out.String(), "000001_create_category")
+	assert.Contains(suite.T(), out.String(), "000002_create_asset")
+	assert.Contains(suite.T(), out.String(), "pending")
+}
+
+func (suite *MigrationSuite) TestRun_Unknown() {
+	err := suite.migrator.Run([]string{"sideways"}, &bytes.Buffer{})
+	assert.Error(suite.T(), err)
+	err = suite.migrator.Run(nil, &bytes.Buffer{})
+	assert.Error(suite.T(), err)
+}
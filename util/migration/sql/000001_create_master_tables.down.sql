@@ -0,0 +1,5 @@
+drop table if exists user_credential;
+drop table if exists staff;
+drop table if exists asset;
+drop table if exists asset_type;
+drop table if exists category;
@@ -0,0 +1,45 @@
+create table if not exists category (
+	id varchar(100) primary key,
+	name varchar(100) not null
+);
+
+create table if not exists asset_type (
+	id varchar(100) primary key,
+	name varchar(100) not null
+);
+
+create table if not exists asset (
+	id varchar(100) primary key,
+	id_category varchar(100) references category(id),
+	id_asset_type varchar(100) references asset_type(id),
+	name varchar(100) not null,
+	available int not null default 0 check (available >= 0),
+	total int not null default 0,
+	status varchar(100) not null default '',
+	entry_date timestamp not null default now(),
+	img_url text not null default ''
+);
+
+create index if not exists idx_asset_id_category on asset(id_category);
+create index if not exists idx_asset_id_asset_type on asset(id_asset_type);
+
+create table if not exists staff (
+	nik_staff varchar(100) primary key,
+	name varchar(100) not null,
+	phone_number varchar(20) not null default '',
+	address text not null default '',
+	birth_date date,
+	img_url text not null default '',
+	divisi varchar(100) not null default '',
+	email varchar(100) not null default ''
+);
+
+create table if not exists user_credential (
+	id varchar(100) primary key,
+	email varchar(100) not null unique,
+	password varchar(100) not null,
+	name varchar(100) not null,
+	is_active boolean not null default false,
+	role varchar(20) not null default 'viewer',
+	locale varchar(10) not null default ''
+);
@@ -0,0 +1,2 @@
+drop table if exists detail_manage_asset;
+drop table if exists manage_asset;
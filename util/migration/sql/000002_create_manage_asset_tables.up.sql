@@ -0,0 +1,28 @@
+create table if not exists manage_asset (
+	id varchar(100) primary key,
+	id_user varchar(100) not null references user_credential(id),
+	nik_staff varchar(100) not null references staff(nik_staff),
+	submission_date timestamp not null,
+	return_date timestamp not null,
+	actual_return_date timestamp,
+	overdue_at timestamp,
+	reminded_at timestamp
+);
+
+create index if not exists idx_manage_asset_id_user on manage_asset(id_user);
+create index if not exists idx_manage_asset_nik_staff on manage_asset(nik_staff);
+-- open loans looked up by the overdue reminder
+create index if not exists idx_manage_asset_open_return_date on manage_asset(return_date) where actual_return_date is null;
+
+create table if not exists detail_manage_asset (
+	id varchar(100) primary key,
+	id_asset varchar(100) not null references asset(id),
+	id_manage_asset varchar(100) not null references manage_asset(id) on delete cascade,
+	total_item int not null check (total_item >= 0),
+	total_returned int not null default 0 check (total_returned >= 0 and total_returned <= total_item),
+	status varchar(100) not null default '',
+	returned_at timestamp
+);
+
+create index if not exists idx_detail_manage_asset_id_manage_asset on detail_manage_asset(id_manage_asset);
+create index if not exists idx_detail_manage_asset_id_asset on detail_manage_asset(id_asset);
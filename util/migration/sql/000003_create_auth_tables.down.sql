@@ -0,0 +1,2 @@
+drop table if exists refresh_token;
+drop table if exists user_otp;
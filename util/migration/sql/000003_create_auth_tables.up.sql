@@ -0,0 +1,22 @@
+create table if not exists user_otp (
+	email varchar(100) not null,
+	purpose varchar(50) not null,
+	code_hash varchar(100) not null,
+	expires_at timestamp not null,
+	attempts int not null default 0,
+	primary key (email, purpose)
+);
+
+create table if not exists refresh_token (
+	id varchar(100) primary key,
+	user_id varchar(100) not null references user_credential(id) on delete cascade,
+	family_id varchar(100) not null,
+	token_hash varchar(100) not null unique,
+	expires_at timestamp not null,
+	used_at timestamp,
+	revoked_at timestamp,
+	created_at timestamp not null default now()
+);
+
+create index if not exists idx_refresh_token_family_id on refresh_token(family_id);
+create index if not exists idx_refresh_token_user_id on refresh_token(user_id);
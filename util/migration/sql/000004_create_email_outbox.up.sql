@@ -0,0 +1,15 @@
+create table if not exists email_outbox (
+	id varchar(100) primary key,
+	recipient varchar(100) not null,
+	subject text not null,
+	html text not null,
+	status varchar(20) not null,
+	attempts int not null default 0,
+	last_error text,
+	next_attempt_at timestamp not null,
+	created_at timestamp not null,
+	sent_at timestamp
+);
+
+-- the worker claim pending emails by due time, admins list the failed ones
+create index if not exists idx_email_outbox_status_next_attempt_at on email_outbox(status, next_attempt_at);
@@ -0,0 +1,2 @@
+drop table if exists detail_manage_asset_unit;
+drop table if exists asset_unit;
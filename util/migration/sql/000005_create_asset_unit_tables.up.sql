@@ -0,0 +1,20 @@
+create table if not exists asset_unit (
+	id varchar(100) primary key,
+	id_asset varchar(100) not null references asset(id),
+	serial_number varchar(100) not null unique,
+	condition varchar(20) not null,
+	status varchar(20) not null,
+	purchase_date date,
+	holder_nik varchar(100) references staff(nik_staff)
+);
+
+create index if not exists idx_asset_unit_id_asset on asset_unit(id_asset);
+
+create table if not exists detail_manage_asset_unit (
+	id_detail varchar(100) not null references detail_manage_asset(id) on delete cascade,
+	id_unit varchar(100) not null references asset_unit(id),
+	returned_at timestamp,
+	primary key (id_detail, id_unit)
+);
+
+create index if not exists idx_detail_manage_asset_unit_id_unit on detail_manage_asset_unit(id_unit);
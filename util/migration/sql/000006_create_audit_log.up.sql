@@ -0,0 +1,24 @@
+create table if not exists audit_log (
+	id varchar(100) primary key,
+	actor_id varchar(100) not null,
+	entity varchar(30) not null,
+	entity_id varchar(100) not null,
+	action varchar(20) not null,
+	before jsonb,
+	after jsonb,
+	created_at timestamp not null
+);
+
+-- the history of one entity is read newest first
+create index if not exists idx_audit_log_entity on audit_log(entity, entity_id, created_at desc);
+
+-- the log is append only, an entry can not be changed or removed once written
+create or replace function audit_log_append_only() returns trigger as $$
+begin
+	raise exception 'audit_log is append only';
+end;
+$$ language plpgsql;
+
+drop trigger if exists trg_audit_log_append_only on audit_log;
+create trigger trg_audit_log_append_only before update or delete on audit_log
+	for each row execute function audit_log_append_only();
@@ -0,0 +1,7 @@
+drop index if exists idx_staff_active;
+drop index if exists idx_asset_active;
+
+alter table asset drop column if exists deleted_at;
+alter table staff drop column if exists deleted_at;
+alter table asset_type drop column if exists deleted_at;
+alter table category drop column if exists deleted_at;
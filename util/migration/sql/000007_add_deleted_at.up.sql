@@ -0,0 +1,9 @@
+-- master data is soft deleted, loans keep pointing to a deleted asset or staff
+alter table category add column if not exists deleted_at timestamp;
+alter table asset_type add column if not exists deleted_at timestamp;
+alter table staff add column if not exists deleted_at timestamp;
+alter table asset add column if not exists deleted_at timestamp;
+
+-- every listing skip the deleted rows
+create index if not exists idx_asset_active on asset(name) where deleted_at is null;
+create index if not exists idx_staff_active on staff(name) where deleted_at is null;
@@ -0,0 +1,2 @@
+drop table if exists stocktake_count;
+drop table if exists stocktake;
@@ -0,0 +1,23 @@
+create table if not exists stocktake (
+	id varchar(100) primary key,
+	name varchar(100) not null,
+	-- a session limited to one category only count and report its assets
+	id_category varchar(100) references category(id),
+	status varchar(20) not null,
+	note text not null default '',
+	opened_by varchar(100) not null,
+	opened_at timestamp not null,
+	closed_by varchar(100),
+	closed_at timestamp
+);
+
+-- an asset is counted once per location, counting it again replace the number
+create table if not exists stocktake_count (
+	id_stocktake varchar(100) not null references stocktake(id) on delete cascade,
+	id_asset varchar(100) not null references asset(id),
+	location varchar(100) not null default '',
+	counted int not null check (counted >= 0),
+	counted_by varchar(100) not null,
+	counted_at timestamp not null,
+	primary key (id_stocktake, id_asset, location)
+);
@@ -0,0 +1,2 @@
+drop table if exists loan_request_detail;
+drop table if exists loan_request;
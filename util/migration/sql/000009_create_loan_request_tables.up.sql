@@ -0,0 +1,30 @@
+create table if not exists loan_request (
+	id varchar(100) primary key,
+	id_user varchar(100) not null references user_credential(id),
+	nik_staff varchar(100) not null references staff(nik_staff),
+	duration int not null check (duration >= 0),
+	status varchar(20) not null,
+	note text not null default '',
+	-- the comment of the approver, or of whoever closed the request
+	comment text not null default '',
+	decided_by varchar(100),
+	decided_at timestamp,
+	issued_by varchar(100),
+	issued_at timestamp,
+	-- the loan created when the request is issued
+	id_manage_asset varchar(100) references manage_asset(id),
+	created_at timestamp not null,
+	updated_at timestamp not null
+);
+
+create index if not exists idx_loan_request_status on loan_request(status, created_at desc);
+create index if not exists idx_loan_request_id_user on loan_request(id_user);
+
+create table if not exists loan_request_detail (
+	id varchar(100) primary key,
+	id_loan_request varchar(100) not null references loan_request(id) on delete cascade,
+	id_asset varchar(100) not null references asset(id),
+	total_item int not null check (total_item > 0)
+);
+
+create index if not exists idx_loan_request_detail_id_loan_request on loan_request_detail(id_loan_request);
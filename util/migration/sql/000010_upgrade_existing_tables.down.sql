@@ -0,0 +1,2 @@
+-- the columns belong to the tables of 000001 and 000002 on a new database, so they are left in place
+drop index if exists idx_manage_asset_open_return_date;
@@ -0,0 +1,21 @@
+-- a database set up by hand already has the tables of 000001 and 000002, so their create table is skipped
+-- and the columns added since are created here. On a new database every column already exist
+alter table user_credential add column if not exists role varchar(20);
+-- every user could change the master data and the loans before the roles, they keep it as asset-manager
+update user_credential set role = 'asset-manager' where role is null;
+alter table user_credential alter column role set default 'viewer';
+alter table user_credential alter column role set not null;
+alter table user_credential add column if not exists locale varchar(10) not null default '';
+
+alter table staff add column if not exists email varchar(100) not null default '';
+
+alter table manage_asset add column if not exists actual_return_date timestamp;
+alter table manage_asset add column if not exists overdue_at timestamp;
+alter table manage_asset add column if not exists reminded_at timestamp;
+
+alter table detail_manage_asset add column if not exists total_returned int not null default 0
+	check (total_returned >= 0 and total_returned <= total_item);
+alter table detail_manage_asset add column if not exists returned_at timestamp;
+
+-- open loans looked up by the overdue reminder
+create index if not exists idx_manage_asset_open_return_date on manage_asset(return_date) where actual_return_date is null;
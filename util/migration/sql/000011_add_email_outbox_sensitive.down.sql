@@ -0,0 +1 @@
+alter table email_outbox drop column if exists sensitive;
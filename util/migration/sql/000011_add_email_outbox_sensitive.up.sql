@@ -0,0 +1,2 @@
+-- an email carrying an otp has its body cleared once it is delivered or dead-lettered, and is never resent
+alter table email_outbox add column if not exists sensitive boolean not null default false;
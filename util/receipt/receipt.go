@@ -0,0 +1,186 @@
+package receipt
+
+import (
+	"final-project-enigma-clean/model"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	margin          = 15.0
+	lineHeight      = 6.0
+	signatureHeight = 45.0
+	dateLayout      = "02 January 2006"
+)
+
+// the widths of the item columns, they add up to the printable width of an A4 page
+var itemColumns = []struct {
+	header string
+	width  float64
+	align  string
+}{
+	{"No", 10, "C"},
+	{"Asset", 58, "L"},
+	{"Serial number", 52, "L"},
+	{"Qty", 16, "C"},
+	{"Returned", 20, "C"},
+	{"Status", 24, "C"},
+}
+
+// Write render the handover form of a loan as an A4 pdf, printedAt is stamped on the
+// form and used as the creation date so the same loan always give the same file
+func Write(w io.Writer, transaction model.ManageAsset, printedAt time.Time) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(true, margin)
+	pdf.SetCreationDate(printedAt)
+	pdf.SetModificationDate(printedAt)
+	//sort the resources written from maps, the output would change on every run otherwise
+	pdf.SetCatalogSort(true)
+	pdf.SetTitle("Asset handover receipt "+transaction.Id, true)
+	//the core fonts are cp1252, the names are translated so an accent is not printed as two bytes
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-margin + 5)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 4, tr(fmt.Sprintf("Receipt %s - page %d", transaction.Id, pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "ASSET HANDOVER RECEIPT", "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 5, tr("Printed "+printedAt.Format(dateLayout+" 15:04")), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	field := func(label, value string) {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(45, lineHeight, label, "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, lineHeight, tr(": "+value), "", 1, "L", false, 0, "")
+	}
+	field("Transaction", transaction.Id)
+	field("Submission date", formatDate(transaction.SubmissionDate))
+	field("Return date", formatDate(transaction.ReturnDate))
+	if transaction.ActualReturnDate != nil {
+		field("Returned on", formatDate(*transaction.ActualReturnDate))
+	}
+	pdf.Ln(2)
+	field("Handed over by", transaction.User.Name)
+	if transaction.User.Email != "" {
+		field("Email", transaction.User.Email)
+	}
+	pdf.Ln(2)
+	field("Received by", transaction.Staff.Name)
+	field("NIK", transaction.Staff.Nik_Staff)
+	if transaction.Staff.Divisi != "" {
+		field("Division", transaction.Staff.Divisi)
+	}
+	if transaction.Staff.Phone_number != "" {
+		field("Phone", transaction.Staff.Phone_number)
+	}
+	pdf.Ln(4)
+
+	itemHeader := func() {
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.SetFillColor(230, 230, 230)
+		for _, column := range itemColumns {
+			pdf.CellFormat(column.width, lineHeight+1, column.header, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", 10)
+	}
+	itemHeader()
+	_, pageHeight := pdf.GetPageSize()
+	total := 0
+	for i, detail := range transaction.Detail {
+		total += detail.TotalItem
+		cells := []string{
+			strconv.Itoa(i + 1),
+			detail.Asset.Name,
+			serialNumbers(detail.Units),
+			strconv.Itoa(detail.TotalItem),
+			strconv.Itoa(detail.TotalReturned),
+			detail.Status,
+		}
+		//every cell of a row get as many lines as the longest one
+		lines := 1
+		for j, cell := range cells {
+			if n := len(pdf.SplitText(tr(cell), itemColumns[j].width-2)); n > lines {
+				lines = n
+			}
+		}
+		height := float64(lines) * lineHeight
+		if pdf.GetY()+height > pageHeight-margin {
+			pdf.AddPage()
+			itemHeader()
+		}
+		x, y := pdf.GetXY()
+		for j, cell := range cells {
+			pdf.Rect(x, y, itemColumns[j].width, height, "D")
+			pdf.MultiCell(itemColumns[j].width, lineHeight, tr(cell), "", itemColumns[j].align, false)
+			x += itemColumns[j].width
+			pdf.SetXY(x, y)
+		}
+		pdf.SetXY(margin, y+height)
+	}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(itemColumns[0].width+itemColumns[1].width+itemColumns[2].width, lineHeight+1, "Total", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(itemColumns[3].width, lineHeight+1, strconv.Itoa(total), "1", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.MultiCell(0, 5, "The receiver confirms the items above were handed over in good condition and agrees to return "+
+		"them by the return date. Lost or damaged items are the responsibility of the receiver.", "", "L", false)
+	pdf.Ln(6)
+
+	if pdf.GetY()+signatureHeight > pageHeight-margin {
+		pdf.AddPage()
+	}
+	signatures := []struct{ role, name string }{
+		{"Handed over by", transaction.User.Name},
+		{"Received by", transaction.Staff.Name},
+		{"Acknowledged by (GA)", ""},
+	}
+	width := (210 - 2*margin) / float64(len(signatures))
+	y := pdf.GetY()
+	for i, signature := range signatures {
+		x := margin + float64(i)*width
+		pdf.SetXY(x, y)
+		pdf.SetFont("Helvetica", "B", 10)
+		pdf.CellFormat(width, lineHeight, signature.role, "", 2, "C", false, 0, "")
+		pdf.Line(x+8, y+30, x+width-8, y+30)
+		pdf.SetXY(x, y+31)
+		pdf.SetFont("Helvetica", "", 9)
+		name := signature.name
+		if name == "" {
+			name = "(name and date)"
+		}
+		pdf.CellFormat(width, 5, tr(name), "", 2, "C", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return "-"
+	}
+	return date.Format(dateLayout)
+}
+
+func serialNumbers(units []model.AssetUnit) string {
+	if len(units) == 0 {
+		return "-"
+	}
+	serials := make([]string, len(units))
+	for i, unit := range units {
+		serials[i] = unit.SerialNumber
+	}
+	return strings.Join(serials, ", ")
+}
@@ -0,0 +1,48 @@
+package receipt
+
+import (
+	"bytes"
+	"final-project-enigma-clean/model"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transaction(details int) model.ManageAsset {
+	submitted := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	loan := model.ManageAsset{
+		Id:             "trx-1",
+		User:           model.UserCredentials{Name: "Admin GA", Email: "ga@example.com"},
+		Staff:          model.Staff{Nik_Staff: "1001", Name: "José Budi", Divisi: "Finance"},
+		SubmissionDate: submitted,
+		ReturnDate:     submitted.AddDate(0, 0, 7),
+	}
+	for i := 0; i < details; i++ {
+		loan.Detail = append(loan.Detail, model.ManageDetailAsset{
+			Asset:     model.Asset{Name: fmt.Sprintf("Laptop %d", i)},
+			TotalItem: 2,
+			Status:    "ready",
+			Units:     []model.AssetUnit{{SerialNumber: fmt.Sprintf("SN-%d-A", i)}, {SerialNumber: fmt.Sprintf("SN-%d-B", i)}},
+		})
+	}
+	return loan
+}
+
+func TestWrite_SameLoanSameFile(t *testing.T) {
+	printed := time.Date(2023, 9, 1, 10, 0, 0, 0, time.UTC)
+	var first, second bytes.Buffer
+	require.NoError(t, Write(&first, transaction(2), printed))
+	require.NoError(t, Write(&second, transaction(2), printed))
+
+	assert.True(t, bytes.HasPrefix(first.Bytes(), []byte("%PDF-")))
+	assert.Equal(t, first.Bytes(), second.Bytes())
+}
+
+func TestWrite_LongLoanSpanPages(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, transaction(60), time.Now()))
+	assert.Greater(t, bytes.Count(buf.Bytes(), []byte("/Type /Page\n")), 1)
+}
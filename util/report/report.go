@@ -0,0 +1,167 @@
+package report
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ColumnType tell how the cells of a column are written and shown
+type ColumnType int
+
+const (
+	Text ColumnType = iota
+	Number
+	Date
+	DateTime
+)
+
+// Column is the header and the type of a column, a zero width keep the default width
+type Column struct {
+	Header string
+	Type   ColumnType
+	Width  float64
+}
+
+// Sheet is a named table of a workbook, every row hold one cell per column
+type Sheet struct {
+	Name    string
+	Columns []Column
+	Rows    [][]any
+}
+
+// Add append a row, a nil or zero time is written as an empty cell
+func (s *Sheet) Add(cells ...any) {
+	s.Rows = append(s.Rows, cells)
+}
+
+const defaultWidth = 18
+
+var headerStyle = &excelize.Style{
+	Font:   &excelize.Font{Bold: true, Color: "FFFFFF"},
+	Fill:   excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"305496"}},
+	Border: []excelize.Border{{Type: "bottom", Color: "000000", Style: 1}},
+}
+
+var numFormats = map[ColumnType]string{
+	Number:   "#,##0",
+	Date:     "yyyy-mm-dd",
+	DateTime: "yyyy-mm-dd hh:mm",
+}
+
+// Write render the sheets as one xlsx workbook on w, every sheet get a styled
+// and frozen header row with a filter on it
+func Write(w io.Writer, sheets ...Sheet) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	header, err := file.NewStyle(headerStyle)
+	if err != nil {
+		return err
+	}
+	styles := make(map[ColumnType]int)
+	for columnType, format := range numFormats {
+		format := format
+		if styles[columnType], err = file.NewStyle(&excelize.Style{CustomNumFmt: &format}); err != nil {
+			return err
+		}
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			err = file.SetSheetName(file.GetSheetName(0), sheet.Name)
+		} else {
+			_, err = file.NewSheet(sheet.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if err = writeSheet(file, sheet, header, styles); err != nil {
+			return err
+		}
+	}
+	return file.Write(w)
+}
+
+func writeSheet(file *excelize.File, sheet Sheet, header int, styles map[ColumnType]int) error {
+	headers := make([]any, len(sheet.Columns))
+	for i, column := range sheet.Columns {
+		headers[i] = column.Header
+	}
+	if err := file.SetSheetRow(sheet.Name, "A1", &headers); err != nil {
+		return err
+	}
+	for i, row := range sheet.Rows {
+		cells := make([]any, len(row))
+		for j, value := range row {
+			cells[j] = cellValue(value)
+		}
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		if err = file.SetSheetRow(sheet.Name, cell, &cells); err != nil {
+			return err
+		}
+	}
+	if len(sheet.Columns) == 0 {
+		return nil
+	}
+
+	last, err := excelize.ColumnNumberToName(len(sheet.Columns))
+	if err != nil {
+		return err
+	}
+	if err = file.SetCellStyle(sheet.Name, "A1", last+"1", header); err != nil {
+		return err
+	}
+	for i, column := range sheet.Columns {
+		name, err := excelize.ColumnNumberToName(i + 1)
+		if err != nil {
+			return err
+		}
+		width := column.Width
+		if width == 0 {
+			width = defaultWidth
+		}
+		if err = file.SetColWidth(sheet.Name, name, name, width); err != nil {
+			return err
+		}
+		//the style is set on the written cells, a column style would not override the default date format
+		if style, ok := styles[column.Type]; ok && len(sheet.Rows) > 0 {
+			bottom := name + strconv.Itoa(len(sheet.Rows)+1)
+			if err = file.SetCellStyle(sheet.Name, name+"2", bottom, style); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = file.SetPanes(sheet.Name, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+	return file.AutoFilter(sheet.Name, "A1:"+last+strconv.Itoa(len(sheet.Rows)+1), nil)
+}
+
+// cellValue unwrap a time pointer and blank a missing time so it is not shown as 1900-01-00
+func cellValue(value any) any {
+	switch v := value.(type) {
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return nil
+		}
+		return *v
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v
+	}
+	return value
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWrite_TypedSheets(t *testing.T) {
+	entry := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	assets := Sheet{Name: "assets", Columns: []Column{
+		{Header: "name", Type: Text},
+		{Header: "total", Type: Number},
+		{Header: "entry_date", Type: Date},
+		{Header: "deleted_at", Type: DateTime},
+	}}
+	assets.Add("Laptop", 1200, entry, (*time.Time)(nil))
+	categories := Sheet{Name: "categories", Columns: []Column{{Header: "name"}}}
+	categories.Add("Elektronik")
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, assets, categories))
+
+	file, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer file.Close()
+	assert.Equal(t, []string{"assets", "categories"}, file.GetSheetList())
+
+	rows, err := file.GetRows("assets")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "total", "entry_date", "deleted_at"}, rows[0])
+	assert.Equal(t, []string{"Laptop", "1,200", "2023-05-01"}, rows[1])
+
+	total, err := file.GetCellType("assets", "B2")
+	require.NoError(t, err)
+	assert.NotEqual(t, excelize.CellTypeSharedString, total)
+	raw, err := file.GetCellValue("assets", "C2", excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	assert.Equal(t, "45047", raw)
+
+	panes, err := file.GetPanes("assets")
+	require.NoError(t, err)
+	assert.True(t, panes.Freeze)
+	assert.Equal(t, 1, panes.YSplit)
+}
+
+func TestWrite_InvalidSheetName(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, Sheet{Name: "bad/name"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gookit/slog"
+)
+
+// Job is a background task run once on start then every Interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler run every job in its own goroutine until the context is cancelled,
+// a run never overlap with the previous run of the same job
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start launch the jobs and return immediately
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Wait block until every job stopped after the context is cancelled
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	//the first run is not delayed by a whole interval, a restart would otherwise skip it
+	s.run(ctx, job)
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.run(ctx, job)
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) {
+	if ctx.Err() != nil {
+		return
+	}
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		slog.Errorf("job %s failed: %v", job.Name, err)
+	}
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
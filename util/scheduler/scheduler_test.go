@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_RunUntilCancelled(t *testing.T) {
+	var runs, failed int32
+	s := New()
+	s.Add(Job{Name: "ok", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+	s.Add(Job{Name: "failing", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&failed, 1)
+		return errors.New("boom")
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	s.Wait()
+
+	stopped := atomic.LoadInt32(&runs)
+	assert.Greater(t, stopped, int32(1))
+	//a failing job keep being scheduled
+	assert.Greater(t, atomic.LoadInt32(&failed), int32(1))
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, atomic.LoadInt32(&runs))
+}
+
+func TestScheduler_RunOnStart(t *testing.T) {
+	ran := make(chan struct{}, 1)
+	s := New()
+	s.Add(Job{Name: "hourly", Interval: time.Hour, Run: func(ctx context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job did not run on start")
+	}
+	cancel()
+	s.Wait()
+}
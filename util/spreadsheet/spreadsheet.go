@@ -0,0 +1,184 @@
+package spreadsheet
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrUnsupportedFormat is returned for a file that is neither csv nor xlsx
+var ErrUnsupportedFormat = errors.New("file must be a .csv or .xlsx")
+
+// ReadRows read every row of a csv file or of the first sheet of a xlsx file,
+// the format is chosen by the extension of the file name
+func ReadRows(name string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		//a row may leave its trailing cells out
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case ".xlsx":
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, nil
+		}
+		return file.GetRows(sheets[0])
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// RowWriter write the rows of an export one at a time, Close must be called to finish the file.
+// Discard release the file without writing it, it is deferred so a failed export does not leak it
+// and does nothing after Close
+type RowWriter interface {
+	Write(row []string) error
+	Close() error
+	Discard()
+}
+
+// NewRowWriter start a csv or a single sheet xlsx file on w
+func NewRowWriter(w io.Writer, format, sheet string) (RowWriter, error) {
+	switch format {
+	case "csv":
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case "xlsx":
+		file := excelize.NewFile()
+		if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+			return nil, err
+		}
+		//the stream writer spill the rows to a temporary file instead of holding every cell
+		stream, err := file.NewStreamWriter(sheet)
+		if err != nil {
+			return nil, err
+		}
+		return &xlsxWriter{file: file, stream: stream, out: w}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// WriteRows write every row at once
+func WriteRows(w io.Writer, format, sheet string, rows [][]string) error {
+	writer, err := NewRowWriter(w, format, sheet)
+	if err != nil {
+		return err
+	}
+	defer writer.Discard()
+	for _, row := range rows {
+		if err = writer.Write(row); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) Write(row []string) error {
+	return c.writer.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvWriter) Discard() {}
+
+type xlsxWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	out    io.Writer
+	line   int
+	closed bool
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	x.line++
+	cells := make([]any, len(row))
+	for i, value := range row {
+		cells[i] = value
+	}
+	cell, err := excelize.CoordinatesToCellName(1, x.line)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.Discard()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	return x.file.Write(x.out)
+}
+
+func (x *xlsxWriter) Discard() {
+	if x.closed {
+		return
+	}
+	x.closed = true
+	//the rows spilled by the stream writer are removed with the file
+	x.file.Close()
+}
+
+// Table is a header row and the data rows under it, the header names are matched case insensitive
+type Table struct {
+	columns map[string]int
+	Rows    [][]string
+}
+
+// NewTable take the first row as the header, a blank data row is kept as nil
+// so the index of a row still give its line in the file
+func NewTable(rows [][]string) Table {
+	table := Table{columns: make(map[string]int)}
+	if len(rows) == 0 {
+		return table
+	}
+	for i, name := range rows[0] {
+		//excel save a csv with a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		table.columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, row := range rows[1:] {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			table.Rows = append(table.Rows, nil)
+			continue
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// Line give the line in the file of the data row at index i
+func (t Table) Line(i int) int {
+	return i + 2
+}
+
+// Has tell whether the header has the column
+func (t Table) Has(column string) bool {
+	_, ok := t.columns[column]
+	return ok
+}
+
+// Cell return the trimmed value of a column in a row, empty when the row is too short
+func (t Table) Cell(row []string, column string) string {
+	i, ok := t.columns[column]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
@@ -0,0 +1,74 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestReadRows_Csv(t *testing.T) {
+	rows, err := ReadRows("assets.CSV", strings.NewReader("\ufeffName,Total\nLaptop, 5\nMeja\n"))
+	require.NoError(t, err)
+	table := NewTable(rows)
+	assert.True(t, table.Has("name"))
+	assert.Equal(t, "5", table.Cell(table.Rows[0], "total"))
+	assert.Equal(t, "", table.Cell(table.Rows[1], "total"))
+}
+
+func TestReadRows_Xlsx(t *testing.T) {
+	file := excelize.NewFile()
+	file.SetSheetRow("Sheet1", "A1", &[]string{"name", "total"})
+	file.SetSheetRow("Sheet1", "A3", &[]any{"Laptop", 5})
+	var buf bytes.Buffer
+	require.NoError(t, file.Write(&buf))
+
+	rows, err := ReadRows("assets.xlsx", &buf)
+	require.NoError(t, err)
+	table := NewTable(rows)
+	//the blank second line is kept so the line numbers match the sheet
+	require.Len(t, table.Rows, 2)
+	assert.Nil(t, table.Rows[0])
+	assert.Equal(t, "Laptop", table.Cell(table.Rows[1], "name"))
+	assert.Equal(t, 3, table.Line(1))
+}
+
+func TestReadRows_Unsupported(t *testing.T) {
+	_, err := ReadRows("assets.pdf", strings.NewReader(""))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestWriteRows_XlsxRoundTrip(t *testing.T) {
+	rows := [][]string{{"nik_staff", "name"}, {"1", "Budi"}}
+	var buf bytes.Buffer
+	require.NoError(t, WriteRows(&buf, "xlsx", "staff", rows))
+
+	read, err := ReadRows("staff.xlsx", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, rows, read)
+}
+
+func TestRowWriter_DiscardWriteNothing(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewRowWriter(&buf, "xlsx", "transactions")
+	require.NoError(t, err)
+	require.NoError(t, writer.Write([]string{"id"}))
+	writer.Discard()
+	assert.Zero(t, buf.Len())
+}
+
+func TestRowWriter_DiscardAfterClose(t *testing.T) {
+	var buf bytes.Buffer
+	writer, err := NewRowWriter(&buf, "xlsx", "transactions")
+	require.NoError(t, err)
+	require.NoError(t, writer.Write([]string{"id"}))
+	require.NoError(t, writer.Close())
+	writer.Discard()
+
+	read, err := ReadRows("transactions.xlsx", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"id"}}, read)
+}